@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// fakeOpenAI returns the URL of a chat completions API answering every
+// request with answer.
+func fakeOpenAI(t *testing.T, answer string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": answer}}},
+			"usage":   map[string]int{"prompt_tokens": 1, "completion_tokens": 1, "total_tokens": 2},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/v1"
+}
+
+func TestRunAskOnly(t *testing.T) {
+	// No namespace is needed, and the node can't be reached, so asking
+	// fails if it is connected to.
+	args := []string{"-ask-only", "-node", "ws://127.0.0.1:1", "-openai-base-url", fakeOpenAI(t, "pong"), "-prompt", "ping"}
+	opts, err := parse(t, args, map[string]string{"OPENAI_KEY": "test-key"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := run(context.Background(), opts, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "pong") {
+		t.Errorf("output = %q, want the model's response", out.String())
+	}
+}
+
+func TestParseFlagsAskOnlyConflicts(t *testing.T) {
+	for _, flag := range []string{"-dry-run", "-random-namespace"} {
+		if _, err := parse(t, []string{"-ask-only", flag, "-prompt", "hi"}, nil, nil); err == nil {
+			t.Errorf("-ask-only was accepted with %s", flag)
+		}
+	}
+}
+
+func TestRunAskOnlyFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": {"message": "bad request"}}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+	opts, err := parse(t, []string{"-ask-only", "-openai-base-url", server.URL + "/v1", "-prompt", "ping"}, map[string]string{"OPENAI_KEY": "test-key"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = run(context.Background(), opts, io.Discard)
+	if !errors.Is(err, scavenger.ErrCompletion) {
+		t.Fatalf("error = %v, want a completion error", err)
+	}
+	if code := exitCode(context.Background(), err); code != exitCompletion {
+		t.Errorf("exit code = %d, want %d like a full run", code, exitCompletion)
+	}
+}
+
+func TestAskOnlyClientRateLimit(t *testing.T) {
+	opts, err := parse(t, []string{"-ask-only", "-rate-limit", "30", "-prompt", "ping"}, map[string]string{"OPENAI_KEY": "test-key"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := askOnlyClient(opts.config, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.RateLimiter == nil {
+		t.Error("the ask-only client has no rate limiter")
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// runBatch runs every prompt in the prompts file and writes the results to
+// the results file as JSON lines, in the order of the prompts. With -pack,
+// the prompts are submitted together in a single blob.
+func runBatch(ctx context.Context, client *scavenger.Client, opts *options) error {
+	prompts, err := readPromptsFile(opts.promptsFile)
+	if err != nil {
+		return err
+	}
+	slog.Info("Running prompts", "prompts", len(prompts), "concurrency", opts.config.Concurrency, "pack", opts.pack)
+
+	var results []scavenger.BatchResult
+	if opts.pack {
+		results, err = client.RunPack(ctx, prompts)
+	} else {
+		results, err = client.RunBatch(ctx, prompts)
+	}
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if opts.resultsFile != "" {
+		f, err := os.Create(opts.resultsFile)
+		if err != nil {
+			return fmt.Errorf("error creating results file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	return writeBatchResults(w, results)
+}
+
+// writeBatchResults writes results to w as JSON lines. It fails if any of
+// the prompts did.
+func writeBatchResults(w io.Writer, results []scavenger.BatchResult) error {
+	failed := 0
+	enc := json.NewEncoder(w)
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+		if err := enc.Encode(result); err != nil {
+			return fmt.Errorf("error writing results: %w", err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d prompts failed", failed, len(results))
+	}
+	return nil
+}
+
+// readPromptsFile reads the prompts from the file at path, one per line.
+// Blank lines are skipped.
+func readPromptsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening prompts file: %w", err)
+	}
+	defer f.Close()
+
+	var prompts []string
+	scanner := bufio.NewScanner(f)
+	// Prompts can be long, so we allow lines of up to a few megabytes.
+	scanner.Buffer(nil, 8<<20)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			prompts = append(prompts, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading prompts file: %w", err)
+	}
+	if len(prompts) == 0 {
+		return nil, fmt.Errorf("prompts file %s is empty", path)
+	}
+	return prompts, nil
+}
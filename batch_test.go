@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+func TestReadPromptsFile(t *testing.T) {
+	prompts, err := readPromptsFile(writeFile(t, "prompts.txt", "first\n\n  second  \n\t\nthird"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(prompts, "|") != "first|second|third" {
+		t.Errorf("prompts = %q, want the non-blank lines trimmed", prompts)
+	}
+	if _, err := readPromptsFile(writeFile(t, "prompts.txt", "\n \n")); err == nil || !strings.Contains(err.Error(), "is empty") {
+		t.Errorf("error = %v, want an empty prompts file", err)
+	}
+	if _, err := readPromptsFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("reading a missing prompts file succeeded")
+	}
+}
+
+func TestWriteBatchResults(t *testing.T) {
+	results := []scavenger.BatchResult{
+		{Index: 0, Prompt: "a", Result: &scavenger.RunResult{Response: "A"}},
+		{Index: 1, Prompt: "b", Error: "refused"},
+	}
+	var out bytes.Buffer
+	err := writeBatchResults(&out, results)
+	if err == nil || err.Error() != "1 of 2 prompts failed" {
+		t.Errorf("error = %v, want the failed prompt counted", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("wrote %d lines, want one per result", len(lines))
+	}
+	var got scavenger.BatchResult
+	if err := json.Unmarshal([]byte(lines[1]), &got); err != nil || got.Error != "refused" {
+		t.Errorf("second line = %s, want the failed result", lines[1])
+	}
+}
+
+func TestRunBatchCommand(t *testing.T) {
+	client, _, completer := newTestClient(t)
+	results := filepath.Join(t.TempDir(), "results.jsonl")
+	opts := &options{
+		config:      client.Config,
+		promptsFile: writeFile(t, "prompts.txt", "one\ntwo\n"),
+		resultsFile: results,
+	}
+	if err := runBatch(context.Background(), client, opts); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 2 {
+		t.Errorf("results file has %d lines, want 2", n)
+	}
+	if len(completer.asked()) != 2 {
+		t.Errorf("model was asked %d times, want 2", len(completer.asked()))
+	}
+}
+
+func TestRunBatchCommandPack(t *testing.T) {
+	client, _, completer := newTestClient(t)
+	results := filepath.Join(t.TempDir(), "results.jsonl")
+	opts := &options{
+		config:      client.Config,
+		promptsFile: writeFile(t, "prompts.txt", "one\ntwo\nthree\n"),
+		resultsFile: results,
+		pack:        true,
+	}
+	if err := runBatch(context.Background(), client, opts); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("results file has %d lines, want one per packed prompt", len(lines))
+	}
+	for _, line := range lines {
+		var result scavenger.BatchResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Result == nil || result.Result.Height != 1 {
+			t.Errorf("result %s isn't from the pack at height 1", line)
+		}
+	}
+	if len(completer.asked()) != 3 {
+		t.Errorf("model was asked %d times, want every packed prompt asked", len(completer.asked()))
+	}
+}
+
+func TestParseFlagsPack(t *testing.T) {
+	prompts := writeFile(t, "prompts.txt", "one\ntwo\n")
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-prompts-file", prompts, "-pack"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.pack {
+		t.Error("-pack wasn't set")
+	}
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no prompts file", []string{"-pack", "-prompt", "hi"}, "-pack requires -prompts-file"},
+		{"store response", []string{"-pack", "-prompts-file", prompts, "-store-response"}, "-pack can't be combined with -store-response"},
+		{"several namespaces", []string{"-pack", "-prompts-file", prompts, "-namespace", "00000000000000000001"}, "-pack can't be combined with several namespaces"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse(t, append([]string{"-namespace", testNamespace}, tt.args...), nil, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// benchCommand submits many blobs of random data and reports how fast the
+// node takes them, to tune concurrency and gas settings. The model isn't
+// asked.
+func benchCommand(ctx context.Context, args []string) error {
+	cfg, err := loadConfig(args, os.Getenv)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("bench", os.Stderr, cfg, nodeFlags, namespaceFlags, []string{"blob-version", "gas-price", "gas-price-multiplier", "max-blob-size", "submit-attempts", "submit-backoff", "concurrency"})
+	n := fs.Int("n", 10, "number of blobs to submit")
+	size := fs.Int("size", 1024, "size of every blob in bytes")
+	mock := fs.Bool("mock", false, "submit to an in-memory fake instead of a node, e.g. in CI")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: prompt-scavenger bench [-n <blobs>] [-size <bytes>] [-concurrency <n>] [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if *n < 1 {
+		return fmt.Errorf("-n must be at least 1, got %d", *n)
+	}
+	if *size < 1 {
+		return fmt.Errorf("-size must be at least 1, got %d", *size)
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	setupLogging(cfg)
+	warnHighGasPrice(cfg.GasPrice)
+
+	ctx, cancel := withTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	var client *scavenger.Client
+	if *mock {
+		client = &scavenger.Client{Config: cfg, Blobs: &scavenger.FakeBlobAPI{}}
+	} else {
+		client, err = connect(ctx, cfg)
+		if err != nil {
+			return scavenger.StageError("connect", err)
+		}
+		defer client.Close()
+	}
+
+	// Without a namespace, we benchmark in a fresh one so as not to clutter
+	// a real one.
+	if cfg.Namespace == "" {
+		cfg.Namespace, err = scavenger.RandomNamespaceID()
+		if err != nil {
+			return err
+		}
+	}
+	namespaceID, err := scavenger.CreateNamespaceID(cfg.Namespace, cfg.NamespaceVersion, cfg.PadNamespace)
+	if err != nil {
+		return scavenger.StageError("namespace", fmt.Errorf("Failed to decode namespace: %w", err))
+	}
+
+	// An interrupted benchmark still reports what it measured so far.
+	result, err := client.Bench(ctx, namespaceID, *n, *size)
+	if result == nil {
+		return err
+	}
+	if cfg.Output == scavenger.OutputJSON {
+		if jsonErr := writeJSON(os.Stdout, result); jsonErr != nil {
+			return jsonErr
+		}
+		return err
+	}
+	printBenchResult(result, cfg.Namespace)
+	return err
+}
+
+// printBenchResult prints result as a table.
+func printBenchResult(result *scavenger.BenchResult, namespace string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	rows := [][2]string{
+		{"namespace", namespace},
+		{"blobs", fmt.Sprintf("%d of %d bytes", result.Blobs, result.Size)},
+		{"concurrency", fmt.Sprint(result.Concurrency)},
+		{"succeeded", fmt.Sprint(result.Succeeded)},
+		{"failed", fmt.Sprintf("%d (%.1f%%)", result.Failed, result.ErrorRate*100)},
+		{"duration", result.Duration.Round(time.Microsecond).String()},
+		{"throughput", fmt.Sprintf("%.2f blobs/s, %.0f bytes/s", result.BlobsPerSecond, result.BytesPerSecond)},
+		{"latency p50", result.LatencyP50.Round(time.Microsecond).String()},
+		{"latency p90", result.LatencyP90.Round(time.Microsecond).String()},
+		{"latency p99", result.LatencyP99.Round(time.Microsecond).String()},
+		{"latency max", result.LatencyMax.Round(time.Microsecond).String()},
+	}
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s\t%s\n", row[0], row[1])
+	}
+	for _, err := range result.Errors {
+		fmt.Fprintf(w, "error\t%s\n", err)
+	}
+	w.Flush()
+}
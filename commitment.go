@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// commitmentCommand prints the commitments the prompt's blobs would have
+// when submitted, without connecting to a node.
+func commitmentCommand(_ context.Context, args []string) error {
+	opts, err := parseFlags("prompt-scavenger commitment", args, os.Stdin, os.Stderr, os.Getenv, [][]string{namespaceFlags, payloadFlags}, nil)
+	if err != nil {
+		return err
+	}
+	if opts.promptsFile != "" || opts.file != "" || opts.randomNamespace || opts.dryRun || opts.askOnly {
+		return fmt.Errorf("flags -prompts-file, -file, -random-namespace, -dry-run and -ask-only are not supported by commitment")
+	}
+	cfg := opts.config
+	setupLogging(cfg)
+
+	namespaceID, err := scavenger.CreateNamespaceID(cfg.Namespace, cfg.NamespaceVersion, cfg.PadNamespace)
+	if err != nil {
+		return scavenger.StageError("namespace", fmt.Errorf("Failed to decode namespace: %w", err))
+	}
+	commitments, err := scavenger.PromptCommitments(cfg, namespaceID, opts.prompt)
+	if err != nil {
+		return err
+	}
+
+	hexCommitments := make([]string, len(commitments))
+	for i, commitment := range commitments {
+		hexCommitments[i] = hex.EncodeToString(commitment)
+	}
+	if cfg.Output == scavenger.OutputJSON {
+		return writeJSON(os.Stdout, map[string]any{
+			"namespace":   scavenger.NamespaceHex(namespaceID),
+			"commitments": hexCommitments,
+		})
+	}
+	// Chunked prompts are printed in the form -commitment takes.
+	fmt.Println(strings.Join(hexCommitments, ","))
+	return nil
+}
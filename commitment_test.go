@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCommitmentCommandFlags(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	for _, args := range [][]string{
+		{"-random-namespace", "-raw", "-prompt", "hi"},
+		{"-namespace", testNamespace, "-raw", "-dry-run", "-prompt", "hi"},
+	} {
+		err := commitmentCommand(context.Background(), args)
+		if err == nil || !strings.Contains(err.Error(), "not supported by commitment") {
+			t.Errorf("commitment %q: error = %v, want the flags unsupported", args, err)
+		}
+	}
+}
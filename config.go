@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// defaultConfigFile is the name of the config file looked up in the
+// user's home directory when -config is not given.
+const defaultConfigFile = ".prompt-scavenger.yaml"
+
+// loadConfigFile loads the config file at path. If path is empty the
+// default file in the home directory is used, and it is fine for it to be
+// missing.
+func loadConfigFile(path string) (*scavenger.Config, error) {
+	if path != "" {
+		return scavenger.LoadConfig(path)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return scavenger.DefaultConfig(), nil
+	}
+	path = filepath.Join(home, defaultConfigFile)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return scavenger.DefaultConfig(), nil
+	}
+	return scavenger.LoadConfig(path)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// ErrNotConfirmed is returned when the user doesn't confirm a submission.
+var ErrNotConfirmed = errors.New("submission not confirmed")
+
+// confirmSubmission prints the fee estimate and, unless assumeYes is set,
+// asks the user to confirm on stdin. Without a terminal to ask on,
+// assumeYes is required.
+func confirmSubmission(est scavenger.FeeEstimate, assumeYes bool, stdin io.Reader, out io.Writer) error {
+	fmt.Fprintf(out, "Estimated cost: %s\n", est)
+
+	if assumeYes {
+		return nil
+	}
+	if !isTerminal(stdin) {
+		return fmt.Errorf("%w: pass -yes to submit without an interactive terminal", ErrNotConfirmed)
+	}
+
+	fmt.Fprint(out, "Submit? [y/N] ")
+	answer, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("error reading confirmation: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return ErrNotConfirmed
+	}
+}
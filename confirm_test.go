@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+func TestConfirmSubmission(t *testing.T) {
+	est := scavenger.FeeEstimate{Shares: 1, Gas: 80000, GasPrice: 0.002, Fee: 160}
+
+	var out bytes.Buffer
+	if err := confirmSubmission(est, true, strings.NewReader(""), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "Estimated cost: 1 blob share(s)") {
+		t.Errorf("output = %q, want the estimate", out.String())
+	}
+
+	// Without a terminal nobody can be asked, so -yes is required.
+	err := confirmSubmission(est, false, strings.NewReader("y\n"), &out)
+	if !errors.Is(err, ErrNotConfirmed) {
+		t.Errorf("error = %v, want ErrNotConfirmed", err)
+	}
+}
+
+func TestConfirmSubmissionDeclined(t *testing.T) {
+	// /dev/null is a character device, so it passes for a terminal, and
+	// gives an empty answer.
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer null.Close()
+	var out bytes.Buffer
+	if err := confirmSubmission(scavenger.FeeEstimate{}, false, null, &out); !errors.Is(err, ErrNotConfirmed) {
+		t.Errorf("error = %v, want ErrNotConfirmed", err)
+	}
+	if !strings.Contains(out.String(), "Submit? [y/N] ") {
+		t.Errorf("output = %q, want the question", out.String())
+	}
+}
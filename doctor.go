@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// doctorCommand checks the environment for common setup problems and
+// prints a checklist. It fails if any critical check does.
+func doctorCommand(ctx context.Context, args []string) error {
+	cfg, err := loadConfig(args, os.Getenv)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("doctor", os.Stderr, cfg, nodeFlags, namespaceFlags, providerFlags, []string{"store-response", "response-namespace"})
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: prompt-scavenger doctor [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	setupLogging(cfg)
+
+	ctx, cancel := withTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	report := scavenger.Doctor(ctx, cfg)
+	if cfg.Output == scavenger.OutputJSON {
+		if err := writeJSON(os.Stdout, report); err != nil {
+			return err
+		}
+	} else {
+		for _, c := range report.Checks {
+			status := "PASS"
+			switch {
+			case c.Passed:
+			case c.Critical:
+				status = "FAIL"
+			default:
+				status = "WARN"
+			}
+			fmt.Printf("%s %-10s %s%s\n", status, c.Name, c.Detail, c.Error)
+			if c.Hint != "" {
+				fmt.Printf("     %-10s hint: %s\n", "", c.Hint)
+			}
+		}
+	}
+
+	if !report.Passed {
+		return fmt.Errorf("doctor found problems, see the failed checks")
+	}
+	return nil
+}
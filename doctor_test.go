@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+func TestDoctorCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("OPENAI_KEY", "")
+	var err error
+	out := captureStdout(t, func() {
+		err = doctorCommand(context.Background(), []string{"-node", "ws://127.0.0.1:1", "-namespace", testNamespace})
+	})
+	if err == nil || !strings.Contains(err.Error(), "doctor found problems") {
+		t.Fatalf("error = %v, want the failed checks reported", err)
+	}
+	for _, want := range []string{"FAIL openai key", "hint: set $OPENAI_KEY", "FAIL node", "PASS namespace"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q doesn't contain %q", out, want)
+		}
+	}
+	if strings.Contains(out, "clock") {
+		t.Errorf("output %q has a clock check without a node", out)
+	}
+}
+
+func TestDoctorCommandJSON(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("OPENAI_KEY", "")
+	var err error
+	out := captureStdout(t, func() {
+		err = doctorCommand(context.Background(), []string{"-node", "ws://127.0.0.1:1", "-namespace", testNamespace, "-output", "json"})
+	})
+	if err == nil {
+		t.Fatal("doctor passed without a key or node")
+	}
+	var report scavenger.DoctorReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("output %q isn't a JSON report: %v", out, err)
+	}
+	if report.Passed || len(report.Checks) != 3 {
+		t.Errorf("report = %+v, want the three checks possible without a node, failed", report)
+	}
+}
+
+func TestDoctorCommandArgs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := doctorCommand(context.Background(), []string{"extra"}); err == nil || !strings.Contains(err.Error(), "unexpected arguments: extra") {
+		t.Errorf("error = %v, want the unexpected arguments", err)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	nodeclient "github.com/celestiaorg/celestia-openrpc"
+	"github.com/celestiaorg/celestia-openrpc/types/core"
+	"github.com/celestiaorg/celestia-openrpc/types/header"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+func TestRunDry(t *testing.T) {
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-dry-run", "-prompt", "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.dryRun {
+		t.Fatal("-dry-run wasn't set")
+	}
+	client, api, completer := newTestClient(t)
+	client.Config = opts.config
+	client.Node = &nodeclient.Client{}
+	client.Node.Header.LocalHead = func(context.Context) (*header.ExtendedHeader, error) {
+		return &header.ExtendedHeader{Commit: &core.Commit{Height: 9}}, nil
+	}
+	var out strings.Builder
+	if err := runDry(context.Background(), client, opts, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "Would submit 1 blob(s)") {
+		t.Errorf("printed %q, want what would be submitted", out.String())
+	}
+	if len(completer.asked()) != 0 {
+		t.Errorf("model was asked %q, want nothing", completer.asked())
+	}
+	if blobs, _ := api.GetAll(context.Background(), 1, []share.Namespace{testNS(t)}); len(blobs) != 0 {
+		t.Error("a blob was submitted")
+	}
+}
+
+func TestParseFlagsDryRunConflicts(t *testing.T) {
+	for _, args := range [][]string{
+		{"-namespace", testNamespace, "-dry-run", "-prompts-file", writeFile(t, "prompts.txt", "a\nb\n")},
+		{"-namespace", testNamespace, "-dry-run", "-ask-only", "-prompt", "hi"},
+	} {
+		if _, err := parse(t, args, nil, nil); err == nil {
+			t.Errorf("flags %q were accepted", args)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Encodings of -input-encoding and -output-encoding. Raw passes the bytes
+// through as they are.
+const (
+	encodingRaw    = "raw"
+	encodingBase64 = "base64"
+	encodingHex    = "hex"
+)
+
+// checkEncoding fails unless name is one of the encodings.
+func checkEncoding(flagName, name string) error {
+	switch name {
+	case encodingRaw, encodingBase64, encodingHex:
+		return nil
+	}
+	return fmt.Errorf("-%s must be raw, base64 or hex, got %q", flagName, name)
+}
+
+// decodeInput decodes s, a prompt given in encoding. Surrounding
+// whitespace, such as the newline of a piped in prompt, is ignored.
+func decodeInput(s, encoding string) (string, error) {
+	switch encoding {
+	case encodingBase64:
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+		var corrupt base64.CorruptInputError
+		if errors.As(err, &corrupt) {
+			return "", fmt.Errorf("invalid base64 prompt at byte %d", int64(corrupt))
+		}
+		return string(data), err
+	case encodingHex:
+		s = strings.TrimSpace(s)
+		data, err := hex.DecodeString(s)
+		if err == nil {
+			return string(data), nil
+		}
+		// The hex package doesn't say where the invalid byte is.
+		if i := strings.IndexFunc(s, func(r rune) bool { return !strings.ContainsRune("0123456789abcdefABCDEF", r) }); i >= 0 {
+			r, _ := utf8.DecodeRuneInString(s[i:])
+			return "", fmt.Errorf("invalid hex prompt at byte %d: %q is not a hex digit", i, r)
+		}
+		if errors.Is(err, hex.ErrLength) {
+			return "", fmt.Errorf("invalid hex prompt: odd number of digits (%d)", len(s))
+		}
+		return "", fmt.Errorf("invalid hex prompt: %w", err)
+	}
+	return s, nil
+}
+
+// encodeOutput encodes data in encoding for printing it.
+func encodeOutput(data []byte, encoding string) string {
+	switch encoding {
+	case encodingBase64:
+		return base64.StdEncoding.EncodeToString(data)
+	case encodingHex:
+		return hex.EncodeToString(data)
+	}
+	return string(data)
+}
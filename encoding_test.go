@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncodingRoundTrip(t *testing.T) {
+	data := []byte("bytes \x00\xff and text")
+	for _, encoding := range []string{encodingRaw, encodingBase64, encodingHex} {
+		decoded, err := decodeInput(encodeOutput(data, encoding)+"\n", encoding)
+		if err != nil {
+			t.Fatalf("%s: %v", encoding, err)
+		}
+		if encoding == encodingRaw {
+			// Raw prompts are left as they are, newline included.
+			decoded = strings.TrimSuffix(decoded, "\n")
+		}
+		if decoded != string(data) {
+			t.Errorf("%s: round trip gave %q, want %q", encoding, decoded, data)
+		}
+	}
+}
+
+func TestDecodeInputErrors(t *testing.T) {
+	tests := []struct {
+		s, encoding, want string
+	}{
+		{"aGVsbG8*", encodingBase64, "invalid base64 prompt at byte 7"},
+		{"68656c6c6g", encodingHex, `invalid hex prompt at byte 9: 'g' is not a hex digit`},
+		{"68656", encodingHex, "odd number of digits (5)"},
+	}
+	for _, tt := range tests {
+		_, err := decodeInput(tt.s, tt.encoding)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s %q: error = %v, want %q", tt.encoding, tt.s, err, tt.want)
+		}
+	}
+}
+
+func TestParseFlagsInputEncoding(t *testing.T) {
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-input-encoding", "base64", "aGVsbG8="}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.prompt != "hello" {
+		t.Errorf("prompt = %q, want the decoded one", opts.prompt)
+	}
+	for _, args := range [][]string{
+		{"-input-encoding", "rot13", "hi"},
+		// Invalid UTF-8 can't go in an envelope.
+		{"-input-encoding", "hex", "ff"},
+	} {
+		if _, err := parse(t, append([]string{"-namespace", testNamespace}, args...), nil, nil); err == nil {
+			t.Errorf("flags %q were accepted", args)
+		}
+	}
+	if _, err := parse(t, []string{"-namespace", testNamespace, "-raw", "-input-encoding", "hex", "ff"}, nil, nil); err != nil {
+		t.Errorf("binary prompt with -raw: %v", err)
+	}
+}
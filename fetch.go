@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// commitmentSize is the size of a blob commitment, which is a Merkle root.
+const commitmentSize = sha256.Size
+
+// fetchCommand fetches a blob that was submitted earlier and optionally
+// asks the model about it, without submitting anything.
+func fetchCommand(ctx context.Context, args []string) error {
+	cfg, err := loadConfig(args, os.Getenv)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("fetch", os.Stderr, cfg, nodeFlags, namespaceFlags, fetchFlags, providerFlags, samplingFlags, askFlags, []string{"n", "concurrency"})
+	height := fs.Uint64("height", 0, "height the blob was included at (required)")
+	commitmentHex := fs.String("commitment", "", "commitment of the blob as hex, or a comma-separated list of the commitments of a chunked prompt (required)")
+	ask := fs.Bool("ask", false, "send the fetched blob to the model")
+	outFile := fs.String("out", "", "write the fetched payload to this file, required for blobs holding a file unless printed with -output-encoding")
+	outputEncoding := fs.String("output-encoding", encodingRaw, "encoding the fetched payload is printed in, raw, base64 or hex, e.g. for binary data")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: prompt-scavenger fetch -height <height> -namespace <hex> -commitment <hex> [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if *height == 0 {
+		return fmt.Errorf("missing required flag -height")
+	}
+	if cfg.Namespace == "" {
+		return fmt.Errorf("missing required flag -namespace")
+	}
+	if *commitmentHex == "" {
+		return fmt.Errorf("missing required flag -commitment")
+	}
+	commitments, err := decodeCommitments(*commitmentHex)
+	if err != nil {
+		return err
+	}
+	if err := checkEncoding("output-encoding", *outputEncoding); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	setupLogging(cfg)
+	if *ask {
+		warnUnknownModel(cfg)
+		warnOpenAIKeyFile(cfg)
+		warnSeed(cfg)
+	}
+
+	ctx, cancel := withTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	client, err := connect(ctx, cfg)
+	if err != nil {
+		return scavenger.StageError("connect", err)
+	}
+	defer client.Close()
+
+	namespaceID, err := scavenger.CreateNamespaceID(cfg.Namespace, cfg.NamespaceVersion, cfg.PadNamespace)
+	if err != nil {
+		return scavenger.StageError("namespace", fmt.Errorf("Failed to decode namespace: %w", err))
+	}
+
+	// A single commitment is a plain blob, several are the chunks of one
+	// prompt.
+	fetched, err := client.FetchPrompt(ctx, *height, namespaceID, commitments)
+	if err != nil {
+		return scavenger.StageError("fetch", err)
+	}
+	out := &scavenger.RunResult{
+		Namespace:   scavenger.NamespaceHex(namespaceID),
+		Height:      *height,
+		Commitment:  hex.EncodeToString(commitments[0]),
+		ContentType: fetched.ContentType,
+		Metadata:    fetched.Metadata,
+		Signer:      fetched.Signer,
+	}
+	if out.Signer != "" {
+		slog.Info("Prompt signature verified", "signer", out.Signer)
+	}
+	if m := fetched.Metadata; m != nil && cfg.Output != scavenger.OutputJSON {
+		slog.Info("Prompt metadata",
+			"version", m.Version,
+			"submitted_at", time.Unix(m.Timestamp, 0).UTC().Format(time.RFC3339),
+			"model", m.Model)
+	}
+	if *outFile != "" {
+		if err := os.WriteFile(*outFile, fetched.Payload, 0o644); err != nil {
+			return fmt.Errorf("error writing payload: %w", err)
+		}
+		slog.Info("Payload written", "file", *outFile, "bytes", len(fetched.Payload))
+	}
+
+	// A pack of prompts is asked about prompt by prompt, with a JSON line
+	// of results per prompt like -prompts-file. Otherwise its payload is
+	// the prompts, one per line.
+	if len(fetched.Prompts) > 0 {
+		slog.Info("Blob holds a prompt pack", "prompts", len(fetched.Prompts))
+		if *ask {
+			if cfg.Stream {
+				return fmt.Errorf("the responses to a prompt pack can't be streamed")
+			}
+			return writeBatchResults(os.Stdout, client.AskPack(ctx, fetched.Prompts, out))
+		}
+	}
+
+	// Files could be anything but text, so they are only written out or
+	// printed encoded.
+	if fetched.ContentType != "" {
+		if *outFile == "" && *outputEncoding == encodingRaw {
+			return fmt.Errorf("blob holds a file of type %s, write it with -out or print it with -output-encoding", fetched.ContentType)
+		}
+		if *ask {
+			return fmt.Errorf("blob holds a file of type %s, which can't be sent to the model", fetched.ContentType)
+		}
+		if *outputEncoding != encodingRaw {
+			out.FetchedPayload = encodeOutput(fetched.Payload, *outputEncoding)
+		}
+	} else {
+		out.FetchedPayload = string(fetched.Payload)
+	}
+	if len(commitments) > 1 {
+		for _, c := range commitments {
+			out.Commitments = append(out.Commitments, hex.EncodeToString(c))
+		}
+	}
+
+	if *ask {
+		out.Model = cfg.Model
+		var usage scavenger.Usage
+		out.Response, usage, err = client.Ask(ctx, scavenger.WrapPrompt(cfg, out.FetchedPayload))
+		if err != nil {
+			return scavenger.StageError("completion", fmt.Errorf("Failed to process message with %s: %w", cfg.Model, err))
+		}
+		out.Structured = scavenger.StructuredResponse(cfg, out.Response)
+		out.SetUsage(cfg, usage)
+	}
+	// The model was asked about the payload as is, only printing it is
+	// encoded.
+	if fetched.ContentType == "" {
+		out.FetchedPayload = encodeOutput(fetched.Payload, *outputEncoding)
+	}
+
+	switch {
+	case cfg.Output == scavenger.OutputJSON:
+		return writeJSON(os.Stdout, out)
+	case !*ask && *outFile != "":
+		// The payload is in the file already.
+	case !*ask:
+		fmt.Println(out.FetchedPayload)
+	case cfg.Stream:
+		// A streamed response has already been printed as it arrived.
+		fmt.Println()
+	default:
+		slog.Info("Fetched blob",
+			"height", out.Height,
+			"namespace", out.Namespace,
+			"commitment", out.Commitment,
+			"payload", out.FetchedPayload)
+		fmt.Println(out.Response)
+	}
+	return nil
+}
+
+// decodeCommitments parses a comma-separated list of hex encoded blob
+// commitments.
+func decodeCommitments(s string) ([]blob.Commitment, error) {
+	var commitments []blob.Commitment
+	for _, part := range strings.Split(s, ",") {
+		commitment, err := decodeCommitment(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		commitments = append(commitments, commitment)
+	}
+	return commitments, nil
+}
+
+// decodeCommitment parses a hex encoded blob commitment.
+func decodeCommitment(s string) (blob.Commitment, error) {
+	commitment, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding commitment hex: %w", err)
+	}
+	if len(commitment) != commitmentSize {
+		return nil, fmt.Errorf("commitment must be %d bytes, got %d bytes", commitmentSize, len(commitment))
+	}
+	return commitment, nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeCommitments(t *testing.T) {
+	a, b := strings.Repeat("ab", commitmentSize), strings.Repeat("cd", commitmentSize)
+	commitments, err := decodeCommitments(a + ", " + b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commitments) != 2 || commitments[0][0] != 0xab || commitments[1][0] != 0xcd {
+		t.Errorf("commitments = %x, want %s and %s", commitments, a, b)
+	}
+
+	for _, s := range []string{"", "abcd", strings.Repeat("zz", commitmentSize), a + ","} {
+		if _, err := decodeCommitments(s); err == nil {
+			t.Errorf("decodeCommitments(%q) succeeded", s)
+		}
+	}
+}
@@ -0,0 +1,683 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// options holds everything the user configured for this run.
+type options struct {
+	config *scavenger.Config
+	prompt string
+
+	// randomNamespace generates a fresh namespace instead of using the
+	// configured one.
+	randomNamespace bool
+
+	// thread, if set, is the latest turn of the thread the prompt
+	// continues.
+	thread *scavenger.BlobRef
+
+	// promptsFile, if set, holds many prompts, one per line, that are run
+	// instead of a single prompt. Their results are written to
+	// resultsFile, or stdout.
+	promptsFile string
+	resultsFile string
+	// pack submits the prompts of promptsFile packed into a single blob.
+	pack bool
+
+	// repeat is the number of times the prompt is run, with delay between
+	// the runs.
+	repeat int
+	delay  time.Duration
+
+	// file, if set, is submitted as a blob instead of a prompt, with
+	// contentType as a hint of what it holds.
+	file        string
+	contentType string
+
+	// dryRun only prepares the submission and checks the node, without
+	// submitting or asking the model.
+	dryRun bool
+
+	// askOnly asks the model about the prompt without submitting it, for
+	// comparison with the full flow.
+	askOnly bool
+}
+
+// parseFlags parses the program arguments (without the program name) into
+// options, merging them with the environment and the config file as
+// described on scavenger.Config. The prompt can be given either with
+// -prompt or as a single trailing positional argument, which keeps older
+// invocations working. A prompt of "-", or no prompt at all when stdin is
+// not a terminal, reads the prompt from stdin instead. With -prompts-file,
+// the prompts are read from the file later instead.
+//
+// name is the command being run, groups are the groups of config flags it
+// takes, see newFlagSet, and register, if not nil, registers additional
+// flags that only this command takes.
+func parseFlags(
+	name string,
+	args []string,
+	stdin io.Reader,
+	output io.Writer,
+	getenv func(string) string,
+	groups [][]string,
+	register func(fs *flag.FlagSet),
+) (*options, error) {
+	cfg, err := loadConfig(args, getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	fs := newFlagSet(name, output, cfg, groups...)
+	prompt := fs.String("prompt", "", "prompt to submit, or - to read it from stdin (or pass it as the last argument)")
+	randomNamespace := fs.Bool("random-namespace", false, "submit to a newly generated random namespace")
+	promptsFile := fs.String("prompts-file", "", "run every line of this file as a prompt, writing the results as JSON lines")
+	resultsFile := fs.String("results-file", "", "file to write the results of -prompts-file to (default stdout)")
+	pack := fs.Bool("pack", false, "submit the prompts of -prompts-file packed into a single gzipped blob")
+	file := fs.String("file", "", "submit the raw bytes of this file instead of a prompt")
+	contentType := fs.String("content-type", "", "content type stored with -file (default: detected from the file)")
+	dryRun := fs.Bool("dry-run", false, "print what would be submitted and check the node, without submitting or asking the model")
+	templateFile := fs.String("template-file", "", "render the prompt from this text/template file, with variables from -var")
+	vars := make(map[string]string)
+	fs.Func("var", "template variable as key=value, can be repeated", varsFlag(vars))
+	askOnly := fs.Bool("ask-only", false, "only ask the model, without connecting to a node or submitting anything")
+	inputEncoding := fs.String("input-encoding", encodingRaw, "encoding of the prompt, raw, base64 or hex, decoded before submitting it")
+	if register != nil {
+		register(fs)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s -namespace <hex> [flags] [prompt]\n\nFlags:\n", name)
+		fs.PrintDefaults()
+		fmt.Fprint(fs.Output(), exitCodesHelp)
+	}
+
+	if err := parseFlagSet(fs, args); err != nil {
+		return nil, err
+	}
+	if (isFlagSet(fs, "namespace") || isFlagSet(fs, "namespace-label")) && *randomNamespace {
+		return nil, fmt.Errorf("flags -namespace, -namespace-label and -random-namespace are mutually exclusive")
+	}
+
+	opts := &options{
+		config:          cfg,
+		prompt:          *prompt,
+		randomNamespace: *randomNamespace,
+		promptsFile:     *promptsFile,
+		resultsFile:     *resultsFile,
+		pack:            *pack,
+		file:            *file,
+		contentType:     *contentType,
+		dryRun:          *dryRun,
+		askOnly:         *askOnly,
+	}
+
+	// A single trailing argument is treated as the prompt.
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		if opts.prompt != "" {
+			return nil, fmt.Errorf("prompt given both with -prompt and as an argument")
+		}
+		opts.prompt = fs.Arg(0)
+	default:
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args()[1:], " "))
+	}
+
+	// A template renders the prompt instead.
+	if *templateFile != "" {
+		if opts.prompt != "" || opts.promptsFile != "" || opts.file != "" {
+			return nil, fmt.Errorf("-template-file can't be combined with a prompt, -prompts-file or -file")
+		}
+		opts.prompt, err = renderTemplate(*templateFile, vars)
+		if err != nil {
+			return nil, err
+		}
+	} else if len(vars) > 0 {
+		return nil, fmt.Errorf("flag -var requires -template-file")
+	}
+
+	// Fall back to stdin when a prompt is being piped in.
+	if opts.prompt == "" && opts.promptsFile == "" && opts.file == "" && !isTerminal(stdin) {
+		opts.prompt = "-"
+	}
+	if opts.prompt == "-" {
+		prompt, err := readPrompt(stdin)
+		if err != nil {
+			return nil, err
+		}
+		opts.prompt = prompt
+	}
+	if err := checkEncoding("input-encoding", *inputEncoding); err != nil {
+		return nil, err
+	}
+	if *inputEncoding != encodingRaw {
+		if opts.promptsFile != "" || opts.file != "" || *templateFile != "" {
+			return nil, fmt.Errorf("-input-encoding can't be combined with -prompts-file, -file or -template-file")
+		}
+		opts.prompt, err = decodeInput(opts.prompt, *inputEncoding)
+		if err != nil {
+			return nil, err
+		}
+		// The envelope is JSON, which would replace invalid bytes.
+		if !cfg.Raw && !utf8.ValidString(opts.prompt) {
+			return nil, fmt.Errorf("decoded prompt is not valid UTF-8, submit binary data with -raw or -file")
+		}
+	}
+
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// loadConfig loads the config file named by -config in args, or the
+// default one, and applies the environment on top of it. Flags are applied
+// last, when the flag set created by newFlagSet is parsed.
+func loadConfig(args []string, getenv func(string) string) (*scavenger.Config, error) {
+	cfg, err := loadConfigFile(configPathFromArgs(args))
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.ApplyEnv(getenv); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// configPathFromArgs finds the value of -config in args. It has to be
+// known before the remaining flags are parsed, since they are applied on
+// top of the file.
+func configPathFromArgs(args []string) string {
+	for i, arg := range args {
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name != "config" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// The config flags are grouped by what they control, so that every command
+// only takes the flags it uses. The groups only name the flags, they are
+// defined by configFlags.
+var (
+	// commonFlags are taken by every command.
+	commonFlags = []string{"config", "output", "log-level", "quiet", "verbose", "log-format"}
+	// nodeFlags connect to the node, and bound the run.
+	nodeFlags = []string{"node", "jwt", "jwt-file", "timeout"}
+	// namespaceFlags select the namespace.
+	namespaceFlags = []string{"namespace", "namespace-label", "namespace-version", "pad-namespace"}
+	// payloadFlags turn a prompt into the payloads of its blobs.
+	payloadFlags = []string{"raw", "compress", "encrypt", "chunk-size", "redact", "redact-rules", "blob-version"}
+	// promptFlags sign and moderate a prompt, and see its submission
+	// through.
+	promptFlags = []string{"sign-key", "moderate", "moderation-threshold", "journal", "journal-dir", "wait-confirmations", "confirmations-timeout"}
+	// submitFlags submit blobs and pay for them.
+	submitFlags = []string{
+		"mirror-node", "mirror-required", "network", "explorer-url", "gas-price", "gas-price-multiplier",
+		"max-blob-size", "size-warn", "size-limit", "estimate", "yes", "check-balance", "ledger", "tx-hash",
+		"memo", "submit-attempts", "submit-backoff",
+	}
+	// fetchFlags fetch blobs back and verify them.
+	fetchFlags = []string{"wait", "verify-sig", "verify-proof", "fetch-concurrency", "fetch-keep-going"}
+	// providerFlags reach the model provider.
+	providerFlags = []string{"provider", "openai-key-file", "openai-base-url", "openai-org", "azure-deployment", "azure-api-version", "openai-proxy"}
+	// samplingFlags pick the model and how it samples, which submit records
+	// in its receipts.
+	samplingFlags = []string{"model", "system", "system-file", "temperature", "max-tokens", "top-p", "seed"}
+	// askFlags control the rest of asking the model.
+	askFlags = []string{"wrap-prompt", "schema-file", "truncate", "stream", "openai-attempts", "openai-timeout", "rate-limit", "rate-burst"}
+	// cacheFlags control the response cache.
+	cacheFlags = []string{"no-cache", "cache-dir", "cache-ttl"}
+	// runFlags change the steps of a run.
+	runFlags = []string{"no-fetch", "store-response", "response-namespace"}
+
+	// mainFlags are the groups of the main command, which runs the whole
+	// flow.
+	mainFlags = [][]string{
+		nodeFlags, namespaceFlags, payloadFlags, promptFlags, submitFlags, fetchFlags,
+		providerFlags, samplingFlags, askFlags, cacheFlags, runFlags, {"n", "concurrency"},
+	}
+)
+
+// newFlagSet creates a flag set for the named command with the common
+// flags and the flags of groups. The flags write straight into cfg, so
+// parsing only overrides the settings that were explicitly given.
+func newFlagSet(name string, output io.Writer, cfg *scavenger.Config, groups ...[]string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	all := configFlags(cfg)
+	for _, group := range append([][]string{commonFlags}, groups...) {
+		for _, flagName := range group {
+			f := all.Lookup(flagName)
+			fs.Var(f.Value, f.Name, f.Usage)
+		}
+	}
+	return fs
+}
+
+// configFlags defines a flag for every scavenger.Config setting, writing
+// into cfg. newFlagSet picks the ones a command takes from them.
+func configFlags(cfg *scavenger.Config) *flag.FlagSet {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+
+	// -config has already been handled by loadConfig. It's registered so
+	// that it's accepted and shows up in the help output.
+	fs.String("config", "", "path to a YAML config file (default ~/"+defaultConfigFile+")")
+
+	fs.StringVar(&cfg.NodeIP, "node", cfg.NodeIP, "RPC address of the celestia node, or a comma separated list of nodes to fail over between")
+	fs.StringVar(&cfg.MirrorNode, "mirror-node", cfg.MirrorNode, "RPC address of a second node that every submission is also submitted to")
+	fs.BoolVar(&cfg.MirrorRequired, "mirror-required", cfg.MirrorRequired, "fail the run if mirroring to -mirror-node fails, instead of warning")
+	fs.StringVar(&cfg.Network, "network", cfg.Network, "network the node is on, arabica, mocha or mainnet, used for explorer links")
+	fs.StringVar(&cfg.ExplorerURL, "explorer-url", cfg.ExplorerURL, "base URL of the explorer to link to, overriding -network")
+	fs.StringVar(&cfg.AuthToken, "jwt", cfg.AuthToken, "JWT auth token for the node (default $CELESTIA_NODE_AUTH_TOKEN)")
+	// A token file given as a flag takes precedence over a token from the
+	// environment, which ResolveAuthToken would otherwise prefer.
+	fs.Func("jwt-file", "path to a file containing the node's JWT auth token", func(path string) error {
+		cfg.AuthToken, cfg.AuthTokenFile = "", path
+		return nil
+	})
+	fs.StringVar(&cfg.OpenAIKeyFile, "openai-key-file", cfg.OpenAIKeyFile, "path to a file containing the OpenAI key, used instead of $OPENAI_KEY")
+	fs.Var(&namespaceFlag{cfg: cfg}, "namespace", "namespace to use, as hex (required); repeat it to also submit to further namespaces in the same transaction")
+	fs.StringVar(&cfg.NamespaceLabel, "namespace-label", cfg.NamespaceLabel, "derive the namespace from this label instead of giving it as hex")
+	fs.Func("namespace-version", "namespace version, only 0 is defined for user namespaces so far (default 0)", func(s string) error {
+		v, err := strconv.ParseUint(s, 10, 8)
+		if err != nil || v != 0 {
+			return fmt.Errorf("must be 0, the only version defined for user namespaces")
+		}
+		cfg.NamespaceVersion = uint8(v)
+		return nil
+	})
+	fs.Func("blob-version", "share version of submitted blobs, one of the client's built-in supported versions (default 0)", func(s string) error {
+		v, err := strconv.ParseUint(s, 10, 8)
+		if err != nil {
+			return fmt.Errorf("must be a share version, e.g. 0")
+		}
+		cfg.BlobVersion = uint8(v)
+		return nil
+	})
+	fs.BoolVar(&cfg.PadNamespace, "pad-namespace", cfg.PadNamespace, "left-pad short namespace IDs with zeros")
+
+	fs.Func("gas-price", "gas price in utia per gas unit, or auto (alias min) for the minimum gas price times -gas-price-multiplier; the network isn't queried over a node connection, so this is the app's default minimum (default: the node's default)", cfg.SetGasPrice)
+	fs.Float64Var(&cfg.GasPriceMultiplier, "gas-price-multiplier", cfg.GasPriceMultiplier, "multiplier over the minimum gas price with -gas-price auto")
+	fs.StringVar(&cfg.Compress, "compress", cfg.Compress, "compress the prompt before submitting it, none or gzip")
+	fs.BoolVar(&cfg.Raw, "raw", cfg.Raw, "submit the prompt as is, without the JSON envelope holding the submission time and model")
+	fs.Var(modeFlag{&cfg.Redact, []string{scavenger.RedactAll, scavenger.RedactChainOnly}}, "redact", "replace secrets and personal data in the prompt with placeholders, or only on chain with -redact=chain-only")
+	fs.StringVar(&cfg.RedactRulesFile, "redact-rules", cfg.RedactRulesFile, "file of additional redaction rules, one name and regular expression per line")
+	fs.Var(modeFlag{&cfg.Moderate, []string{scavenger.ModerateBlock, scavenger.ModerateWarn}}, "moderate", "check the prompt with OpenAI's moderation endpoint and refuse to submit it if flagged, or only warn with -moderate=warn")
+	fs.Float64Var(&cfg.ModerationThreshold, "moderation-threshold", cfg.ModerationThreshold, "flag categories scoring at least this, between 0 and 1 (default: OpenAI's verdict)")
+	fs.StringVar(&cfg.SignKeyFile, "sign-key", cfg.SignKeyFile, "sign the prompt with the Ed25519 key in this file, a hex encoded 32 byte seed")
+	fs.BoolVar(&cfg.VerifySignature, "verify-sig", cfg.VerifySignature, "require fetched prompts to carry a valid signature")
+	fs.BoolVar(&cfg.Encrypt, "encrypt", cfg.Encrypt, "encrypt the prompt with AES-GCM using the hex key in PROMPT_SCAVENGER_KEY")
+	fs.IntVar(&cfg.MaxBlobSize, "max-blob-size", cfg.MaxBlobSize, "largest payload in bytes submitted in one transaction")
+	fs.IntVar(&cfg.SizeWarn, "size-warn", cfg.SizeWarn, "warn about submitting payloads larger than this many bytes (0 disables the warning)")
+	fs.IntVar(&cfg.SizeLimit, "size-limit", cfg.SizeLimit, "refuse to submit payloads larger than this many bytes without -yes (0 disables the limit)")
+	fs.IntVar(&cfg.ChunkSize, "chunk-size", cfg.ChunkSize, "split prompts larger than this many bytes across several blobs (0 disables chunking)")
+	fs.BoolVar(&cfg.Estimate, "estimate", cfg.Estimate, "print the estimated fee and ask for confirmation before submitting")
+	fs.BoolVar(&cfg.AssumeYes, "yes", cfg.AssumeYes, "submit without asking for confirmation, required with -estimate when not on a terminal and for payloads over -size-limit")
+	fs.BoolVar(&cfg.CheckBalance, "check-balance", cfg.CheckBalance, "check the node's account can pay the estimated fee before submitting")
+	fs.BoolVar(&cfg.Journal, "journal", cfg.Journal, "record submissions and reuse them when the same payload is submitted again, e.g. after a crash")
+	fs.StringVar(&cfg.JournalDir, "journal-dir", cfg.JournalDir, "directory of the submission journal (default: next to the response cache)")
+	fs.StringVar(&cfg.LedgerFile, "ledger", cfg.LedgerFile, "append a record of every submission and its estimated fee to this JSON lines file")
+	fs.BoolVar(&cfg.TxHash, "tx-hash", cfg.TxHash, "submit through the state API, which reports the transaction hash")
+	fs.StringVar(&cfg.Memo, "memo", cfg.Memo, fmt.Sprintf("memo to attach to the submission's transaction, at most %d bytes", scavenger.MaxMemoLength))
+	fs.IntVar(&cfg.SubmitAttempts, "submit-attempts", cfg.SubmitAttempts, "number of attempts for submitting the blob")
+	fs.DurationVar(&cfg.SubmitBackoff, "submit-backoff", cfg.SubmitBackoff, "delay before the first submit retry, doubled on every further retry")
+	fs.DurationVar(&cfg.Wait, "wait", cfg.Wait, "how long to poll for the submitted blob until the node serves it (0 fetches right away)")
+	fs.BoolVar(&cfg.NoFetch, "no-fetch", cfg.NoFetch, "don't fetch the submitted blob back, ask the model about the local prompt without verifying the blob")
+	fs.IntVar(&cfg.Confirmations, "wait-confirmations", cfg.Confirmations, "wait until the submission is this many blocks deep before going on")
+	fs.DurationVar(&cfg.ConfirmationsTimeout, "confirmations-timeout", cfg.ConfirmationsTimeout, "how long to wait for -wait-confirmations")
+	fs.BoolVar(&cfg.VerifyProof, "verify-proof", cfg.VerifyProof, "verify the blob's inclusion proof after fetching it")
+	fs.BoolVar(&cfg.StoreResponse, "store-response", cfg.StoreResponse, "submit the response as a blob linked to the prompt")
+	fs.StringVar(&cfg.ResponseNamespace, "response-namespace", cfg.ResponseNamespace, "namespace to store the response in with -store-response (default the prompt's namespace)")
+	fs.IntVar(&cfg.Choices, "n", cfg.Choices, "number of alternative responses to ask the model for")
+
+	fs.StringVar(&cfg.Provider, "provider", cfg.Provider, "model provider used to answer the prompt")
+	fs.StringVar(&cfg.Model, "model", cfg.Model, "model used to answer the prompt")
+	fs.StringVar(&cfg.OpenAIBaseURL, "openai-base-url", cfg.OpenAIBaseURL, "base URL of an OpenAI compatible API, e.g. Azure or a local proxy (default $OPENAI_BASE_URL)")
+	fs.StringVar(&cfg.OpenAIOrg, "openai-org", cfg.OpenAIOrg, "OpenAI organization ID")
+	fs.StringVar(&cfg.AzureDeployment, "azure-deployment", cfg.AzureDeployment, "Azure OpenAI deployment to send requests to, required with -provider azure")
+	fs.StringVar(&cfg.AzureAPIVersion, "azure-api-version", cfg.AzureAPIVersion, "Azure OpenAI API version requested with -provider azure")
+	fs.StringVar(&cfg.OpenAIProxy, "openai-proxy", cfg.OpenAIProxy, "HTTP or HTTPS proxy for OpenAI requests, with user:password@ for basic auth (default $HTTPS_PROXY)")
+	fs.IntVar(&cfg.OpenAIAttempts, "openai-attempts", cfg.OpenAIAttempts, "number of attempts for rate limited or failed completions")
+	fs.StringVar(&cfg.SystemPrompt, "system", cfg.SystemPrompt, "system prompt sent before the user prompt")
+	fs.Func("system-file", "path to a file containing the system prompt", func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		cfg.SystemPrompt = string(data)
+		return nil
+	})
+	fs.Var(wrapFlag{&cfg.WrapPrompt}, "wrap-prompt", "wrap the fetched payload in a fenced code block before asking the model, or in the template given as the value, with "+scavenger.WrapPlaceholder+" for the payload")
+	fs.StringVar(&cfg.SchemaFile, "schema-file", cfg.SchemaFile, "JSON schema of an object the response has to match, for structured output instead of prose")
+	fs.BoolVar(&cfg.Truncate, "truncate", cfg.Truncate, "truncate prompts that don't fit into the model's context instead of failing")
+	fs.BoolVar(&cfg.Stream, "stream", cfg.Stream, "stream the response to stdout as it is generated")
+	fs.Func("temperature", "sampling temperature between 0 and 2 (default: OpenAI's default)", float32Setter(&cfg.Temperature))
+	fs.Func("max-tokens", "maximum number of tokens to generate (default: OpenAI's default)", intSetter(&cfg.MaxTokens))
+	fs.Func("top-p", "nucleus sampling probability between 0 and 1 (default: OpenAI's default)", float32Setter(&cfg.TopP))
+	fs.Func("seed", "seed for sampling reproducibly, on a best-effort basis (default: random)", intSetter(&cfg.Seed))
+
+	fs.BoolVar(&cfg.NoCache, "no-cache", cfg.NoCache, "always submit and ask, ignoring and not updating the response cache")
+	fs.StringVar(&cfg.CacheDir, "cache-dir", cfg.CacheDir, "directory of the response cache (default: the user's cache directory)")
+	fs.DurationVar(&cfg.CacheTTL, "cache-ttl", cfg.CacheTTL, "how long cached responses stay valid (0 means forever)")
+	fs.IntVar(&cfg.Concurrency, "concurrency", cfg.Concurrency, "number of prompts from -prompts-file, or blobs in bench, run at the same time")
+	fs.IntVar(&cfg.FetchConcurrency, "fetch-concurrency", cfg.FetchConcurrency, "number of blobs, such as the chunks of a prompt, fetched at the same time")
+	fs.BoolVar(&cfg.FetchKeepGoing, "fetch-keep-going", cfg.FetchKeepGoing, "keep fetching the other blobs when one fails, reporting every failure")
+	fs.DurationVar(&cfg.Timeout, "timeout", cfg.Timeout, "timeout for the whole run, e.g. 30s (0 means no timeout)")
+	fs.DurationVar(&cfg.OpenAITimeout, "openai-timeout", cfg.OpenAITimeout, "timeout for asking the model, within -timeout (0 means only -timeout applies)")
+	fs.Float64Var(&cfg.RateLimit, "rate-limit", cfg.RateLimit, "maximum requests to the model a minute, shared by concurrent runs (0 means no limit)")
+	fs.IntVar(&cfg.RateBurst, "rate-burst", cfg.RateBurst, "number of requests -rate-limit lets through at once after a pause")
+	fs.StringVar(&cfg.Output, "output", cfg.Output, "output format, text or json")
+	fs.StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "minimum level logged: debug, info, warn or error")
+	fs.BoolFunc("quiet", "only log errors, short for -log-level error", logLevelSetter(&cfg.LogLevel, "error"))
+	fs.BoolFunc("verbose", "also log debug details such as request parameters and stage timings, short for -log-level debug", logLevelSetter(&cfg.LogLevel, "debug"))
+	fs.StringVar(&cfg.LogFormat, "log-format", cfg.LogFormat, "log format, text or json")
+	return fs
+}
+
+// parseFlagSet parses args with fs and checks the combinations of config
+// flags that are not allowed.
+func parseFlagSet(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if isFlagSet(fs, "jwt") && isFlagSet(fs, "jwt-file") {
+		return fmt.Errorf("flags -jwt and -jwt-file are mutually exclusive")
+	}
+	if isFlagSet(fs, "system") && isFlagSet(fs, "system-file") {
+		return fmt.Errorf("flags -system and -system-file are mutually exclusive")
+	}
+	if isFlagSet(fs, "quiet") && isFlagSet(fs, "verbose") {
+		return fmt.Errorf("flags -quiet and -verbose are mutually exclusive")
+	}
+	if isFlagSet(fs, "log-level") && (isFlagSet(fs, "quiet") || isFlagSet(fs, "verbose")) {
+		return fmt.Errorf("flags -quiet and -verbose can't be combined with -log-level")
+	}
+	if isFlagSet(fs, "namespace") && isFlagSet(fs, "namespace-label") {
+		return fmt.Errorf("flags -namespace and -namespace-label are mutually exclusive")
+	}
+
+	// A label, even one from the config file, wins over a namespace from
+	// the config file or the environment. We print the namespace, so it
+	// can be used without the label too.
+	if f := fs.Lookup("namespace-label"); f != nil && f.Value.String() != "" {
+		label := f.Value.String()
+		ns := fs.Lookup("namespace")
+		if err := ns.Value.Set(scavenger.LabelNamespaceID(label)); err != nil {
+			return err
+		}
+		fmt.Fprintf(fs.Output(), "Namespace for label %q: %s\n", label, ns.Value.String())
+	}
+	return nil
+}
+
+// isFlagSet reports whether the named flag was given on the command line.
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	found := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			found = true
+		}
+	})
+	return found
+}
+
+// namespaceFlag sets Namespace the first time it is given, and
+// ExtraNamespaces every time after that. Extra namespaces on the command
+// line replace those from the config file.
+type namespaceFlag struct {
+	cfg *scavenger.Config
+	n   int
+}
+
+func (f *namespaceFlag) String() string {
+	if f.cfg == nil {
+		return ""
+	}
+	return f.cfg.Namespace
+}
+
+func (f *namespaceFlag) Set(s string) error {
+	switch f.n {
+	case 0:
+		f.cfg.Namespace = s
+	case 1:
+		f.cfg.ExtraNamespaces = []string{s}
+	default:
+		f.cfg.ExtraNamespaces = append(f.cfg.ExtraNamespaces, s)
+	}
+	f.n++
+	return nil
+}
+
+// wrapFlag sets the prompt wrapping template. Given on its own like a
+// boolean flag it selects scavenger.DefaultPromptWrap, false turns
+// wrapping off, and anything else is the template.
+type wrapFlag struct {
+	template *string
+}
+
+func (f wrapFlag) IsBoolFlag() bool { return true }
+
+func (f wrapFlag) String() string {
+	if f.template == nil {
+		return ""
+	}
+	return *f.template
+}
+
+func (f wrapFlag) Set(s string) error {
+	switch s {
+	case "true":
+		*f.template = scavenger.DefaultPromptWrap
+	case "false":
+		*f.template = ""
+	default:
+		*f.template = s
+	}
+	return nil
+}
+
+// modeFlag is a flag selecting a mode, which can be given on its own like
+// a boolean flag to select the first of modes, as in -moderate, or with
+// the mode as its value, as in -moderate=warn. False turns it off.
+type modeFlag struct {
+	mode  *string
+	modes []string
+}
+
+func (f modeFlag) IsBoolFlag() bool { return true }
+
+func (f modeFlag) String() string {
+	if f.mode == nil {
+		return ""
+	}
+	return *f.mode
+}
+
+func (f modeFlag) Set(s string) error {
+	switch s {
+	case "true":
+		*f.mode = f.modes[0]
+		return nil
+	case "false":
+		*f.mode = ""
+		return nil
+	}
+	for _, mode := range f.modes {
+		if s == mode {
+			*f.mode = mode
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of %s", strings.Join(f.modes, ", "))
+}
+
+// float32Setter returns a flag.Func setter storing the parsed value in
+// *dst, so that unset flags stay nil.
+func float32Setter(dst **float32) func(string) error {
+	return func(s string) error {
+		v, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return err
+		}
+		f := float32(v)
+		*dst = &f
+		return nil
+	}
+}
+
+// logLevelSetter returns a flag.BoolFunc setter setting *dst to level,
+// for flags that are shorthands for a log level. -flag=false leaves it.
+func logLevelSetter(dst *string, level string) func(string) error {
+	return func(s string) error {
+		on, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		if on {
+			*dst = level
+		}
+		return nil
+	}
+}
+
+// intSetter is like float32Setter, for ints.
+func intSetter(dst **int) func(string) error {
+	return func(s string) error {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		*dst = &v
+		return nil
+	}
+}
+
+// validatePack checks -pack against the other flags. Packed prompts share
+// one blob in one namespace and have no envelopes, so nothing can be
+// signed or linked to a single prompt.
+func (o *options) validatePack() error {
+	if len(o.config.ExtraNamespaces) > 0 {
+		return fmt.Errorf("-pack can't be combined with several namespaces")
+	}
+	if o.config.SignKeyFile != "" {
+		return fmt.Errorf("-pack can't be combined with -sign-key")
+	}
+	if o.config.StoreResponse {
+		return fmt.Errorf("-pack can't be combined with -store-response")
+	}
+	if o.config.NoFetch {
+		return fmt.Errorf("-pack can't be combined with -no-fetch")
+	}
+	return nil
+}
+
+// validate checks that all required options are present.
+func (o *options) validate() error {
+	if o.askOnly && (o.promptsFile != "" || o.file != "" || o.dryRun || o.randomNamespace) {
+		return fmt.Errorf("-ask-only can't be combined with -prompts-file, -file, -dry-run or -random-namespace")
+	}
+	// Nothing is submitted with -ask-only, so no namespace is needed.
+	if o.config.Namespace == "" && !o.randomNamespace && !o.askOnly {
+		return fmt.Errorf("missing required flag -namespace (or use -random-namespace)")
+	}
+	if len(o.config.ExtraNamespaces) > 0 && (o.file != "" || o.dryRun || o.randomNamespace) {
+		return fmt.Errorf("several namespaces can't be combined with -file, -dry-run or -random-namespace")
+	}
+	if err := o.config.Validate(); err != nil {
+		return err
+	}
+	if o.promptsFile != "" {
+		if o.file != "" {
+			return fmt.Errorf("-file can't be combined with -prompts-file")
+		}
+		if o.dryRun {
+			return fmt.Errorf("-dry-run can't be combined with -prompts-file")
+		}
+		if o.prompt != "" {
+			return fmt.Errorf("a prompt can't be combined with -prompts-file")
+		}
+		if o.config.Stream {
+			return fmt.Errorf("streaming can't be combined with -prompts-file")
+		}
+		if o.config.Estimate {
+			return fmt.Errorf("-estimate can't be combined with -prompts-file")
+		}
+		if o.pack {
+			return o.validatePack()
+		}
+		return nil
+	}
+	if o.resultsFile != "" {
+		return fmt.Errorf("flag -results-file requires -prompts-file")
+	}
+	if o.pack {
+		return fmt.Errorf("flag -pack requires -prompts-file")
+	}
+	if o.contentType != "" && o.file == "" {
+		return fmt.Errorf("flag -content-type requires -file")
+	}
+	if o.file != "" {
+		if o.prompt != "" {
+			return fmt.Errorf("a prompt can't be combined with -file")
+		}
+		if o.dryRun {
+			return fmt.Errorf("-dry-run can't be combined with -file")
+		}
+		return nil
+	}
+	if o.prompt == "" {
+		return fmt.Errorf("missing required flag -prompt (or pass the prompt as the last argument)")
+	}
+	return nil
+}
+
+// readPrompt reads the whole prompt from r. A single trailing newline is
+// trimmed, everything else is kept as-is since it becomes the blob payload.
+func readPrompt(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("error reading prompt from stdin: %w", err)
+	}
+
+	prompt := strings.TrimSuffix(string(data), "\n")
+	if prompt == "" {
+		return "", fmt.Errorf("prompt read from stdin is empty")
+	}
+	return prompt, nil
+}
+
+// isTerminal reports whether r is an interactive terminal. Readers that are
+// not files, such as in-memory buffers, are never terminals.
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
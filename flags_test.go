@@ -0,0 +1,575 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// testNamespace is a valid namespace ID used throughout the tests.
+const testNamespace = "000000000000706f6e67"
+
+// parse runs parseFlags for the main command with args, env as the
+// environment and stdin as the input. The home directory is pointed at an
+// empty directory, so that no config file of the user is picked up.
+func parse(t *testing.T, args []string, env map[string]string, stdin io.Reader) (*options, error) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	if stdin == nil {
+		stdin = strings.NewReader("")
+	}
+	getenv := func(key string) string { return env[key] }
+	return parseFlags("prompt-scavenger", args, stdin, io.Discard, getenv, mainFlags, nil)
+}
+
+func TestParseFlags(t *testing.T) {
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-node", "ws://node:26658", "-prompt", "hello"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.prompt != "hello" {
+		t.Errorf("prompt = %q, want %q", opts.prompt, "hello")
+	}
+	if opts.config.Namespace != testNamespace {
+		t.Errorf("namespace = %q, want %q", opts.config.Namespace, testNamespace)
+	}
+	if opts.config.NodeIP != "ws://node:26658" {
+		t.Errorf("node = %q, want %q", opts.config.NodeIP, "ws://node:26658")
+	}
+}
+
+func TestParseFlagsPositionalPrompt(t *testing.T) {
+	opts, err := parse(t, []string{"-namespace", testNamespace, "hello there"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.prompt != "hello there" {
+		t.Errorf("prompt = %q, want %q", opts.prompt, "hello there")
+	}
+}
+
+func TestParseFlagsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"missing namespace", []string{"hello"}, "missing required flag -namespace"},
+		{"prompt twice", []string{"-namespace", testNamespace, "-prompt", "a", "b"}, "prompt given both"},
+		{"extra arguments", []string{"-namespace", testNamespace, "a", "b", "c"}, "unexpected arguments: b c"},
+		{"unknown flag", []string{"-namespace", testNamespace, "-nope", "a"}, "flag provided but not defined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse(t, tt.args, nil, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFlagsStdin(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"dash", []string{"-namespace", testNamespace, "-prompt", "-"}},
+		{"dash argument", []string{"-namespace", testNamespace, "-"}},
+		{"piped", []string{"-namespace", testNamespace}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parse(t, tt.args, nil, strings.NewReader("from stdin\n\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Only a single trailing newline is trimmed.
+			if want := "from stdin\n"; opts.prompt != want {
+				t.Errorf("prompt = %q, want %q", opts.prompt, want)
+			}
+		})
+	}
+}
+
+func TestReadPromptEmpty(t *testing.T) {
+	if _, err := readPrompt(strings.NewReader("\n")); err == nil {
+		t.Fatal("reading an empty prompt succeeded")
+	}
+}
+
+// writeFile writes data to name in a new temporary directory and returns
+// the file's path.
+func writeFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseFlagsPrecedence(t *testing.T) {
+	config := writeFile(t, "config.yaml", "model: from-file\nnetwork: mocha\nsubmit_attempts: 7\n")
+	env := map[string]string{
+		"PROMPT_SCAVENGER_MODEL":   "from-env",
+		"PROMPT_SCAVENGER_NETWORK": "mainnet",
+	}
+	opts, err := parse(t, []string{"-config", config, "-namespace", testNamespace, "-model", "from-flag", "hi"}, env, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := opts.config
+	if cfg.Model != "from-flag" {
+		t.Errorf("model = %q, want the flag's", cfg.Model)
+	}
+	if cfg.Network != "mainnet" {
+		t.Errorf("network = %q, want the environment's", cfg.Network)
+	}
+	if cfg.SubmitAttempts != 7 {
+		t.Errorf("submit attempts = %d, want the file's", cfg.SubmitAttempts)
+	}
+	if cfg.FetchConcurrency != scavenger.DefaultConfig().FetchConcurrency {
+		t.Errorf("fetch concurrency = %d, want the default", cfg.FetchConcurrency)
+	}
+}
+
+func TestParseFlagsDefaultConfigFile(t *testing.T) {
+	home := t.TempDir()
+	if err := os.WriteFile(filepath.Join(home, defaultConfigFile), []byte("model: from-home\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	opts, err := parseFlags("prompt-scavenger", []string{"-namespace", testNamespace, "hi"}, strings.NewReader(""), io.Discard, func(string) string { return "" }, mainFlags, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.config.Model != "from-home" {
+		t.Errorf("model = %q, want the one from the default config file", opts.config.Model)
+	}
+}
+
+func TestConfigPathFromArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"-config", "a.yaml"}, "a.yaml"},
+		{[]string{"--config=b.yaml", "hi"}, "b.yaml"},
+		{[]string{"-model", "x", "-config=c.yaml"}, "c.yaml"},
+		{[]string{"config", "d.yaml"}, ""},
+	}
+	for _, tt := range tests {
+		if got := configPathFromArgs(tt.args); got != tt.want {
+			t.Errorf("configPathFromArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestParseFlagsSystemFile(t *testing.T) {
+	path := writeFile(t, "system.txt", "you are terse\n")
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-system-file", path, "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.config.SystemPrompt != "you are terse\n" {
+		t.Errorf("system prompt = %q, want the file's contents", opts.config.SystemPrompt)
+	}
+
+	_, err = parse(t, []string{"-namespace", testNamespace, "-system", "x", "-system-file", path, "hi"}, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("error = %v, want -system and -system-file to be mutually exclusive", err)
+	}
+}
+
+func TestParseFlagsSampling(t *testing.T) {
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-temperature", "0", "-max-tokens", "100", "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := opts.config
+	// An explicit zero is told apart from not setting the flag.
+	if cfg.Temperature == nil || *cfg.Temperature != 0 {
+		t.Errorf("temperature = %v, want an explicit 0", cfg.Temperature)
+	}
+	if cfg.MaxTokens == nil || *cfg.MaxTokens != 100 {
+		t.Errorf("max tokens = %v, want 100", cfg.MaxTokens)
+	}
+	if cfg.TopP != nil {
+		t.Errorf("top-p = %v, want it unset", *cfg.TopP)
+	}
+
+	if _, err := parse(t, []string{"-namespace", testNamespace, "-top-p", "1.5", "hi"}, nil, nil); err == nil {
+		t.Error("top-p above 1 was accepted")
+	}
+}
+
+func TestParseFlagsTimeout(t *testing.T) {
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-timeout", "45s", "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.config.Timeout != 45*time.Second {
+		t.Errorf("timeout = %s, want 45s", opts.config.Timeout)
+	}
+	if _, err := parse(t, []string{"-namespace", testNamespace, "-timeout", "-1s", "hi"}, nil, nil); err == nil {
+		t.Error("negative timeout was accepted")
+	}
+}
+
+func TestParseFlagsNamespaceVersion(t *testing.T) {
+	if _, err := parse(t, []string{"-namespace", testNamespace, "-namespace-version", "0", "hi"}, nil, nil); err != nil {
+		t.Errorf("version 0: %v", err)
+	}
+	if _, err := parse(t, []string{"-namespace", testNamespace, "-namespace-version", "1", "hi"}, nil, nil); err == nil {
+		t.Error("version 1 was accepted")
+	}
+}
+
+func TestParseFlagsRandomNamespace(t *testing.T) {
+	opts, err := parse(t, []string{"-random-namespace", "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.randomNamespace {
+		t.Error("random namespace not selected")
+	}
+	if _, err := parse(t, []string{"-random-namespace", "-namespace", testNamespace, "hi"}, nil, nil); err == nil {
+		t.Error("-random-namespace was accepted with -namespace")
+	}
+}
+
+func TestParseFlagsOutput(t *testing.T) {
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-output", "json", "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.config.Output != scavenger.OutputJSON {
+		t.Errorf("output = %q, want json", opts.config.Output)
+	}
+	if _, err := parse(t, []string{"-namespace", testNamespace, "-output", "yaml", "hi"}, nil, nil); err == nil {
+		t.Error("unknown output format was accepted")
+	}
+	if _, err := parse(t, []string{"-namespace", testNamespace, "-output", "json", "-stream", "hi"}, nil, nil); err == nil {
+		t.Error("JSON output was accepted with streaming")
+	}
+}
+
+// TestFlagGroups checks that every config flag is in exactly one group, so
+// that each is taken by the commands it belongs to.
+func TestFlagGroups(t *testing.T) {
+	groups := append([][]string{commonFlags}, mainFlags...)
+	count := make(map[string]int)
+	for _, group := range groups {
+		for _, name := range group {
+			count[name]++
+		}
+	}
+	configFlags(scavenger.DefaultConfig()).VisitAll(func(f *flag.Flag) {
+		if count[f.Name] != 1 {
+			t.Errorf("flag -%s is in %d groups, want 1", f.Name, count[f.Name])
+		}
+		delete(count, f.Name)
+	})
+	for name := range count {
+		t.Errorf("group flag -%s isn't defined", name)
+	}
+}
+
+func TestNewFlagSetGroups(t *testing.T) {
+	fs := newFlagSet("test", io.Discard, scavenger.DefaultConfig(), nodeFlags)
+	for _, name := range []string{"config", "quiet", "node", "timeout"} {
+		if fs.Lookup(name) == nil {
+			t.Errorf("flag -%s is missing", name)
+		}
+	}
+	if fs.Lookup("model") != nil {
+		t.Error("flag -model of another group was registered")
+	}
+}
+
+func TestParseFlagsJWT(t *testing.T) {
+	env := map[string]string{"CELESTIA_NODE_AUTH_TOKEN": "from-env"}
+	opts, err := parse(t, []string{"-namespace", testNamespace, "hi"}, env, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, _ := opts.config.ResolveAuthToken(); token != "from-env" {
+		t.Errorf("token = %q, want the environment's", token)
+	}
+
+	opts, err = parse(t, []string{"-namespace", testNamespace, "-jwt", "from-flag", "hi"}, env, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, _ := opts.config.ResolveAuthToken(); token != "from-flag" {
+		t.Errorf("token = %q, want the flag's", token)
+	}
+
+	path := writeFile(t, "token", "from-file\n")
+	opts, err = parse(t, []string{"-namespace", testNamespace, "-jwt-file", path, "hi"}, env, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, err := opts.config.ResolveAuthToken(); err != nil || token != "from-file" {
+		t.Errorf("token = %q (error %v), want the file's over the environment's", token, err)
+	}
+
+	_, err = parse(t, []string{"-namespace", testNamespace, "-jwt", "x", "-jwt-file", path, "hi"}, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("error = %v, want -jwt and -jwt-file to be mutually exclusive", err)
+	}
+}
+
+func TestParseFlagsGasPrice(t *testing.T) {
+	env := map[string]string{"PROMPT_SCAVENGER_GAS_PRICE": "0.1"}
+	opts, err := parse(t, []string{"-namespace", testNamespace, "hi"}, env, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.config.GasPrice != 0.1 {
+		t.Errorf("gas price = %v, want the environment's", opts.config.GasPrice)
+	}
+	opts, err = parse(t, []string{"-namespace", testNamespace, "-gas-price", "0.2", "hi"}, env, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.config.GasPrice != 0.2 {
+		t.Errorf("gas price = %v, want the flag's", opts.config.GasPrice)
+	}
+	opts, err = parse(t, []string{"-namespace", testNamespace, "-gas-price", "auto", "hi"}, env, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.config.GasPriceMin {
+		t.Error("-gas-price auto didn't select the minimum gas price")
+	}
+	for _, price := range []string{"-0.5", "-1", "NaN"} {
+		if _, err := parse(t, []string{"-namespace", testNamespace, "-gas-price", price, "hi"}, nil, nil); err == nil {
+			t.Errorf("gas price %s was accepted", price)
+		}
+	}
+}
+
+func TestParseFlagsExplorer(t *testing.T) {
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-network", "mocha", "-prompt", "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := opts.config.ExplorerBlockURL(3); got != "https://mocha.celenium.io/block/3" {
+		t.Errorf("explorer link = %q, want mocha's", got)
+	}
+	opts, err = parse(t, []string{"-namespace", testNamespace, "-network", "mocha", "-explorer-url", "https://explorer.example", "-prompt", "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := opts.config.ExplorerBlockURL(3); got != "https://explorer.example/block/3" {
+		t.Errorf("explorer link = %q, want the -explorer-url one", got)
+	}
+}
+
+func TestParseFlagsNamespaceLabel(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var out strings.Builder
+	getenv := func(string) string { return "" }
+	opts, err := parseFlags("prompt-scavenger", []string{"-namespace-label", "my prompts", "-prompt", "hi"}, strings.NewReader(""), &out, getenv, mainFlags, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := scavenger.LabelNamespaceID("my prompts")
+	if opts.config.Namespace != want {
+		t.Errorf("namespace = %q, want the label's %q", opts.config.Namespace, want)
+	}
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("printed %q, want the derived namespace", out.String())
+	}
+
+	// A label wins over a namespace from the environment.
+	opts, err = parse(t, []string{"-namespace-label", "my prompts", "-prompt", "hi"}, map[string]string{"CELESTIA_NAMESPACE": testNamespace}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.config.Namespace != want {
+		t.Errorf("namespace = %q, want the label's over the environment's", opts.config.Namespace)
+	}
+
+	if _, err := parse(t, []string{"-namespace", testNamespace, "-namespace-label", "my prompts", "-prompt", "hi"}, nil, nil); err == nil {
+		t.Error("-namespace and -namespace-label were accepted together")
+	}
+}
+
+func TestParseFlagsModerate(t *testing.T) {
+	tests := []struct {
+		flag string
+		want string
+	}{
+		{"-moderate", scavenger.ModerateBlock},
+		{"-moderate=warn", scavenger.ModerateWarn},
+		{"-moderate=false", scavenger.ModerateOff},
+	}
+	for _, tt := range tests {
+		opts, err := parse(t, []string{"-namespace", testNamespace, tt.flag, "-prompt", "hi"}, nil, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.flag, err)
+		}
+		if opts.config.Moderate != tt.want {
+			t.Errorf("%s: moderation = %q, want %q", tt.flag, opts.config.Moderate, tt.want)
+		}
+	}
+	if _, err := parse(t, []string{"-namespace", testNamespace, "-moderate=maybe", "-prompt", "hi"}, nil, nil); err == nil {
+		t.Error("unknown moderation mode was accepted")
+	}
+}
+
+func TestParseFlagsNamespaces(t *testing.T) {
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-namespace", "aaaa", "-namespace", "bbbb", "-prompt", "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.config.Namespace != testNamespace || strings.Join(opts.config.ExtraNamespaces, ",") != "aaaa,bbbb" {
+		t.Errorf("namespace %q with extra %v, want the first flag and then the others", opts.config.Namespace, opts.config.ExtraNamespaces)
+	}
+	if _, err := parse(t, []string{"-namespace", testNamespace, "-namespace", "aaaa", "-dry-run", "-prompt", "hi"}, nil, nil); err == nil {
+		t.Error("several namespaces were accepted with -dry-run")
+	}
+}
+
+func TestParseFlagsWrapPrompt(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"-wrap-prompt"}, scavenger.DefaultPromptWrap},
+		{[]string{"-wrap-prompt=<data>{prompt}</data>"}, "<data>{prompt}</data>"},
+	}
+	for _, tt := range tests {
+		opts, err := parse(t, append([]string{"-namespace", testNamespace}, append(tt.args, "hi")...), nil, nil)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.args, err)
+		}
+		if opts.config.WrapPrompt != tt.want {
+			t.Errorf("%q: template = %q, want %q", tt.args, opts.config.WrapPrompt, tt.want)
+		}
+	}
+}
+
+func TestParseFlagsSeed(t *testing.T) {
+	opts, err := parse(t, []string{"-namespace", testNamespace, "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.config.Seed != nil {
+		t.Errorf("seed = %d, want none without -seed", *opts.config.Seed)
+	}
+	opts, err = parse(t, []string{"-namespace", testNamespace, "-seed", "7", "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.config.Seed == nil || *opts.config.Seed != 7 {
+		t.Errorf("seed = %v, want 7", opts.config.Seed)
+	}
+	if _, err := parse(t, []string{"-namespace", testNamespace, "-seed", "lucky", "hi"}, nil, nil); err == nil {
+		t.Error("seed that isn't a number was accepted")
+	}
+}
+
+func TestParseFlagsResponseNamespace(t *testing.T) {
+	const answers = "00000000616e73776572"
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-store-response", "-response-namespace", answers, "-prompt", "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.config.ResponseNamespace != answers {
+		t.Errorf("response namespace = %q, want %q", opts.config.ResponseNamespace, answers)
+	}
+	if _, err := parse(t, []string{"-namespace", testNamespace, "-response-namespace", answers, "-prompt", "hi"}, nil, nil); err == nil {
+		t.Error("-response-namespace without -store-response was accepted")
+	}
+}
+
+func TestParseFlagsRateLimit(t *testing.T) {
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-rate-limit", "30", "-rate-burst", "5", "-prompt", "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.config.RateLimit != 30 || opts.config.RateBurst != 5 {
+		t.Errorf("rate limit = %v with burst %d, want 30 with burst 5", opts.config.RateLimit, opts.config.RateBurst)
+	}
+	opts, err = parse(t, []string{"-namespace", testNamespace, "-prompt", "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.config.RateLimit != 0 {
+		t.Errorf("rate limit = %v by default, want none", opts.config.RateLimit)
+	}
+	if _, err := parse(t, []string{"-namespace", testNamespace, "-rate-limit", "-1", "-prompt", "hi"}, nil, nil); err == nil {
+		t.Error("a negative rate limit was accepted")
+	}
+}
+
+func TestParseFlagsNoFetch(t *testing.T) {
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-no-fetch", "-prompt", "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.config.NoFetch {
+		t.Error("-no-fetch wasn't set")
+	}
+	for _, args := range [][]string{
+		{"-no-fetch", "-wait", "5s", "-prompt", "hi"},
+		{"-no-fetch", "-pack", "-prompts-file", writeFile(t, "prompts.txt", "one\n")},
+	} {
+		if _, err := parse(t, append([]string{"-namespace", testNamespace}, args...), nil, nil); err == nil {
+			t.Errorf("flags %q were accepted", args)
+		}
+	}
+	if _, err := parse(t, []string{"-namespace", testNamespace, "-pack", "-prompts-file", writeFile(t, "prompts.txt", "one\n")}, nil, nil); err != nil {
+		t.Errorf("-pack without -no-fetch: %v", err)
+	}
+}
+
+func TestParseFlagsBlobVersion(t *testing.T) {
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-blob-version", "0", "-prompt", "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.config.BlobVersion != 0 {
+		t.Errorf("blob version = %d, want 0", opts.config.BlobVersion)
+	}
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"1", "unsupported blob version 1"},
+		{"v0", "must be a share version"},
+		{"256", "must be a share version"},
+	}
+	for _, tt := range tests {
+		_, err := parse(t, []string{"-namespace", testNamespace, "-blob-version", tt.version, "-prompt", "hi"}, nil, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("-blob-version %s: error = %v, want one containing %q", tt.version, err, tt.want)
+		}
+	}
+}
+
+func TestParseFlagsMemo(t *testing.T) {
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-memo", "invoice 42", "-prompt", "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.config.Memo != "invoice 42" {
+		t.Errorf("memo = %q, want %q", opts.config.Memo, "invoice 42")
+	}
+	memo := strings.Repeat("m", scavenger.MaxMemoLength+1)
+	if _, err := parse(t, []string{"-namespace", testNamespace, "-memo", memo, "-prompt", "hi"}, nil, nil); err == nil {
+		t.Error("a memo over the limit was accepted")
+	}
+}
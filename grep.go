@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// wideScan is the number of heights from which grep warns that scanning
+// them takes a while, as each is a request of its own.
+const wideScan = 100
+
+// grepCommand prints the blobs in the namespace within a range of heights
+// whose payload contains a substring or matches a regular expression.
+func grepCommand(ctx context.Context, args []string) error {
+	cfg, err := loadConfig(args, os.Getenv)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("grep", os.Stderr, cfg, nodeFlags, namespaceFlags)
+	height := fs.Uint64("height", 0, "height to search the blobs of")
+	heights := fs.String("heights", "", "range of heights to search the blobs of, e.g. 100-110")
+	isRegexp := fs.Bool("regexp", false, "treat the pattern as a regular expression instead of a substring")
+	ignoreCase := fs.Bool("i", false, "match case-insensitively")
+	maxResults := fs.Int("max-results", 0, "stop after this many matching blobs (0 means no limit)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: prompt-scavenger grep -namespace <hex> (-height <height> | -heights <from>-<to>) [flags] <pattern>\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	switch fs.NArg() {
+	case 0:
+		return fmt.Errorf("missing pattern to search for")
+	case 1:
+	default:
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args()[1:], " "))
+	}
+	if cfg.Namespace == "" {
+		return fmt.Errorf("missing required flag -namespace")
+	}
+	if *maxResults < 0 {
+		return fmt.Errorf("-max-results must not be negative, got %d", *maxResults)
+	}
+	from, to := *height, *height
+	switch {
+	case *height != 0 && *heights != "":
+		return fmt.Errorf("flags -height and -heights are mutually exclusive")
+	case *heights != "":
+		from, to, err = parseHeightRange(*heights)
+		if err != nil {
+			return err
+		}
+	case *height == 0:
+		return fmt.Errorf("missing required flag -height or -heights")
+	}
+	match, err := grepMatcher(fs.Arg(0), *isRegexp, *ignoreCase)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	setupLogging(cfg)
+	if to-from+1 >= wideScan {
+		slog.Warn("Searching many heights, one request each, this can take a while", "heights", to-from+1)
+	}
+
+	ctx, cancel := withTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	client, err := connect(ctx, cfg)
+	if err != nil {
+		return scavenger.StageError("connect", err)
+	}
+	defer client.Close()
+
+	namespaceID, err := scavenger.CreateNamespaceID(cfg.Namespace, cfg.NamespaceVersion, cfg.PadNamespace)
+	if err != nil {
+		return scavenger.StageError("namespace", fmt.Errorf("Failed to decode namespace: %w", err))
+	}
+	found, err := client.SearchBlobs(ctx, namespaceID, from, to, match, *maxResults)
+	if err != nil {
+		return scavenger.StageError("fetch", err)
+	}
+
+	if cfg.Output == scavenger.OutputJSON {
+		if found == nil {
+			found = []scavenger.ListedBlob{}
+		}
+		return writeJSON(os.Stdout, found)
+	}
+	if len(found) == 0 {
+		fmt.Fprintf(os.Stderr, "No matching blobs in namespace %s at heights %d to %d\n", scavenger.NamespaceHex(namespaceID), from, to)
+		return nil
+	}
+	for _, b := range found {
+		fmt.Printf("%d\t%s\t%s\n", b.Height, b.Commitment, b.Preview)
+	}
+	return nil
+}
+
+// grepMatcher returns the function matching a blob's text against pattern.
+func grepMatcher(pattern string, isRegexp, ignoreCase bool) (func(string) bool, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern must not be empty")
+	}
+	if !isRegexp {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return re.MatchString, nil
+}
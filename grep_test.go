@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGrepMatcher(t *testing.T) {
+	tests := []struct {
+		pattern              string
+		isRegexp, ignoreCase bool
+		text                 string
+		want                 bool
+	}{
+		{"a.c", false, false, "a.c", true},
+		{"a.c", false, false, "abc", false},
+		{"a.c", true, false, "abc", true},
+		{"BLOB", false, true, "a blob", true},
+		{"BLOB", false, false, "a blob", false},
+		{"^blob$", true, true, "Blob", true},
+	}
+	for _, tt := range tests {
+		match, err := grepMatcher(tt.pattern, tt.isRegexp, tt.ignoreCase)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.pattern, err)
+		}
+		if got := match(tt.text); got != tt.want {
+			t.Errorf("%q (regexp %v, ignore case %v) matches %q = %v, want %v", tt.pattern, tt.isRegexp, tt.ignoreCase, tt.text, got, tt.want)
+		}
+	}
+	if _, err := grepMatcher("(", true, false); err == nil {
+		t.Error("invalid regexp was accepted")
+	}
+	if _, err := grepMatcher("", false, false); err == nil {
+		t.Error("empty pattern was accepted")
+	}
+}
+
+func TestGrepCommandErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-namespace", testNamespace, "-height", "1"}, "missing pattern"},
+		{[]string{"-height", "1", "blob"}, "missing required flag -namespace"},
+		{[]string{"-namespace", testNamespace, "blob"}, "missing required flag -height or -heights"},
+		{[]string{"-namespace", testNamespace, "-height", "1", "-heights", "1-2", "blob"}, "mutually exclusive"},
+		{[]string{"-namespace", testNamespace, "-height", "1", "-max-results", "-1", "blob"}, "-max-results must not be negative"},
+	}
+	for _, tt := range tests {
+		err := grepCommand(context.Background(), tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// ledgerCommand totals the submissions recorded in the ledger written with
+// -ledger, without connecting to a node.
+func ledgerCommand(_ context.Context, args []string) error {
+	cfg, err := loadConfig(args, os.Getenv)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("ledger", os.Stderr, cfg, []string{"ledger"})
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: prompt-scavenger ledger -ledger <file> [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if cfg.LedgerFile == "" {
+		return fmt.Errorf("missing required flag -ledger")
+	}
+	setupLogging(cfg)
+
+	summary, err := scavenger.NewLedger(cfg.LedgerFile).Summarize()
+	if err != nil {
+		return err
+	}
+	if cfg.Output == scavenger.OutputJSON {
+		return writeJSON(os.Stdout, summary)
+	}
+	if summary.Submissions == 0 {
+		fmt.Fprintf(os.Stderr, "No submissions recorded in %s\n", cfg.LedgerFile)
+		return nil
+	}
+	fmt.Printf("%d submission(s) of %d bytes from %s to %s, ~%.6f TIA\n",
+		summary.Submissions, summary.Size,
+		summary.First.Local().Format(time.DateTime), summary.Last.Local().Format(time.DateTime),
+		summary.Fee/1e6)
+	return nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLedgerCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := writeFile(t, "ledger.jsonl", strings.Join([]string{
+		`{"time": "2024-01-01T00:00:00Z", "height": 1, "size": 5, "fee": 1500000}`,
+		`{"time": "2024-01-01T01:00:00Z", "height": 2, "size": 10, "fee": 500000}`,
+	}, "\n"))
+	var err error
+	out := captureStdout(t, func() { err = ledgerCommand(context.Background(), []string{"-ledger", path}) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "2 submission(s) of 15 bytes") || !strings.Contains(out, "~2.000000 TIA") {
+		t.Errorf("printed %q, want the totals of both records", out)
+	}
+}
+
+func TestLedgerCommandErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := ledgerCommand(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "-ledger") {
+		t.Errorf("error = %v, want the missing ledger reported", err)
+	}
+	if err := ledgerCommand(context.Background(), []string{"-ledger", writeFile(t, "ledger.jsonl", "oops\n")}); err == nil {
+		t.Error("summarizing a corrupt ledger succeeded")
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// listCommand prints every blob in the namespace at a height, or at each
+// height of a range, with a preview of its payload.
+func listCommand(ctx context.Context, args []string) error {
+	cfg, err := loadConfig(args, os.Getenv)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("list", os.Stderr, cfg, nodeFlags, namespaceFlags)
+	height := fs.Uint64("height", 0, "height to list the blobs of")
+	heights := fs.String("heights", "", "range of heights to list the blobs of, e.g. 100-110")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: prompt-scavenger list -namespace <hex> (-height <height> | -heights <from>-<to>) [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if cfg.Namespace == "" {
+		return fmt.Errorf("missing required flag -namespace")
+	}
+	from, to := *height, *height
+	switch {
+	case *height != 0 && *heights != "":
+		return fmt.Errorf("flags -height and -heights are mutually exclusive")
+	case *heights != "":
+		from, to, err = parseHeightRange(*heights)
+		if err != nil {
+			return err
+		}
+	case *height == 0:
+		return fmt.Errorf("missing required flag -height or -heights")
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	setupLogging(cfg)
+
+	ctx, cancel := withTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	client, err := connect(ctx, cfg)
+	if err != nil {
+		return scavenger.StageError("connect", err)
+	}
+	defer client.Close()
+
+	namespaceID, err := scavenger.CreateNamespaceID(cfg.Namespace, cfg.NamespaceVersion, cfg.PadNamespace)
+	if err != nil {
+		return scavenger.StageError("namespace", fmt.Errorf("Failed to decode namespace: %w", err))
+	}
+	listed, err := client.ListBlobs(ctx, namespaceID, from, to)
+	if err != nil {
+		return scavenger.StageError("fetch", err)
+	}
+
+	if cfg.Output == scavenger.OutputJSON {
+		if listed == nil {
+			listed = []scavenger.ListedBlob{}
+		}
+		return writeJSON(os.Stdout, listed)
+	}
+	if len(listed) == 0 {
+		fmt.Fprintf(os.Stderr, "No blobs in namespace %s at heights %d to %d\n", scavenger.NamespaceHex(namespaceID), from, to)
+		return nil
+	}
+	for _, b := range listed {
+		fmt.Printf("%d\t%s\t%s\n", b.Height, b.Commitment, b.Preview)
+	}
+	return nil
+}
+
+// maxHeightRange bounds how many heights -heights scans, one request each.
+const maxHeightRange = 10000
+
+// parseHeightRange parses a range of heights like 100-110, both included.
+func parseHeightRange(s string) (uint64, uint64, error) {
+	fromStr, toStr, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("heights must be a range like 100-110, got %q", s)
+	}
+	from, err := strconv.ParseUint(strings.TrimSpace(fromStr), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid start of height range: %w", err)
+	}
+	to, err := strconv.ParseUint(strings.TrimSpace(toStr), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid end of height range: %w", err)
+	}
+	if from == 0 || to < from {
+		return 0, 0, fmt.Errorf("height range must start above zero and not end before it starts, got %q", s)
+	}
+	if to-from >= maxHeightRange {
+		return 0, 0, fmt.Errorf("height range can span at most %d heights, got %d", maxHeightRange, to-from+1)
+	}
+	return from, to, nil
+}
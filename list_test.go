@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseHeightRange(t *testing.T) {
+	from, to, err := parseHeightRange("100 - 110")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != 100 || to != 110 {
+		t.Errorf("range = %d to %d, want 100 to 110", from, to)
+	}
+	if from, to, err := parseHeightRange("7-7"); err != nil || from != 7 || to != 7 {
+		t.Errorf("range = %d to %d (error %v), want only height 7", from, to, err)
+	}
+	for _, s := range []string{"100", "a-b", "1-x", "0-5", "5-4", fmt.Sprintf("1-%d", maxHeightRange+1)} {
+		if _, _, err := parseHeightRange(s); err == nil {
+			t.Errorf("range %q was accepted", s)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// newLogger creates a logger writing to w at the configured level and in
+// the configured format. The config has been validated, so both are known.
+func newLogger(cfg *scavenger.Config, w io.Writer) *slog.Logger {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(cfg.LogLevel))
+	opts := &slog.HandlerOptions{Level: level}
+	if cfg.LogFormat == scavenger.OutputJSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// setupLogging makes the configured logger, writing to stderr, the
+// default. Results are written to stdout, so they stay apart from the logs.
+func setupLogging(cfg *scavenger.Config) {
+	slog.SetDefault(newLogger(cfg, os.Stderr))
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+func TestNewLogger(t *testing.T) {
+	cfg := scavenger.DefaultConfig()
+	cfg.LogLevel = "warn"
+	var out bytes.Buffer
+	logger := newLogger(cfg, &out)
+	logger.Info("hidden")
+	logger.Warn("shown", "height", 3)
+	if strings.Contains(out.String(), "hidden") {
+		t.Errorf("info message logged at level warn: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "level=WARN msg=shown height=3") {
+		t.Errorf("output = %q, want the warning as text", out.String())
+	}
+}
+
+func TestNewLoggerJSON(t *testing.T) {
+	cfg := scavenger.DefaultConfig()
+	cfg.LogFormat = scavenger.OutputJSON
+	cfg.LogLevel = "debug"
+	var out bytes.Buffer
+	newLogger(cfg, &out).Debug("details", "attempt", 2)
+	var entry map[string]any
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("output isn't JSON: %v\n%s", err, out.String())
+	}
+	if entry["level"] != "DEBUG" || entry["msg"] != "details" || entry["attempt"] != float64(2) {
+		t.Errorf("entry = %v, want the debug message and its attributes", entry)
+	}
+}
+
+func TestParseFlagsLogging(t *testing.T) {
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-log-level", "error", "-log-format", "json", "hi"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.config.LogLevel != "error" || opts.config.LogFormat != scavenger.OutputJSON {
+		t.Errorf("logging = %s as %s, want error as json", opts.config.LogLevel, opts.config.LogFormat)
+	}
+	for _, args := range [][]string{
+		{"-log-level", "loud"},
+		{"-log-format", "xml"},
+	} {
+		if _, err := parse(t, append([]string{"-namespace", testNamespace}, append(args, "hi")...), nil, nil); err == nil {
+			t.Errorf("flags %q were accepted", args)
+		}
+	}
+}
+
+func TestParseFlagsQuietVerbose(t *testing.T) {
+	tests := []struct {
+		flag  string
+		level string
+		lines int
+	}{
+		{"-quiet", "error", 1},
+		{"", scavenger.DefaultConfig().LogLevel, 2},
+		{"-verbose", "debug", 3},
+	}
+	for _, tt := range tests {
+		args := []string{"-namespace", testNamespace, "hi"}
+		if tt.flag != "" {
+			args = append([]string{tt.flag}, args...)
+		}
+		opts, err := parse(t, args, nil, nil)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.flag, err)
+		}
+		if opts.config.LogLevel != tt.level {
+			t.Errorf("%q: log level = %s, want %s", tt.flag, opts.config.LogLevel, tt.level)
+		}
+		var out bytes.Buffer
+		logger := newLogger(opts.config, &out)
+		logger.Debug("request parameters")
+		logger.Info("blob submitted")
+		logger.Error("fetch failed")
+		if lines := strings.Count(out.String(), "\n"); lines != tt.lines {
+			t.Errorf("%q: logged %d lines, want %d:\n%s", tt.flag, lines, tt.lines, out.String())
+		}
+	}
+}
+
+func TestParseFlagsQuietVerboseErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"-quiet", "-verbose"},
+		{"-quiet", "-log-level", "warn"},
+		{"-log-level", "warn", "-verbose"},
+	} {
+		if _, err := parse(t, append([]string{"-namespace", testNamespace}, append(args, "hi")...), nil, nil); err == nil {
+			t.Errorf("flags %q were accepted", args)
+		}
+	}
+}
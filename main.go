@@ -3,124 +3,510 @@ package main
 import (
 	"context"
 	"encoding/hex"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
 
-	nodeclient "github.com/celestiaorg/celestia-openrpc"
-	"github.com/celestiaorg/celestia-openrpc/types/blob"
-	"github.com/celestiaorg/celestia-openrpc/types/share"
-	openai "github.com/sashabaranov/go-openai"
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
 )
 
+// commands are the subcommands, selected by the first argument. Without
+// one, the default flow of submitting, fetching and asking runs.
+var commands = map[string]func(ctx context.Context, args []string) error{
+	"watch":      watchCommand,
+	"fetch":      fetchCommand,
+	"submit":     submitCommand,
+	"commitment": commitmentCommand,
+	"selftest":   selftestCommand,
+	"doctor":     doctorCommand,
+	"list":       listCommand,
+	"grep":       grepCommand,
+	"repl":       replCommand,
+	"bench":      benchCommand,
+	"replay":     replayCommand,
+	"ledger":     ledgerCommand,
+	"version":    versionCommand,
+}
+
+// Exit codes, so that scripts can tell why a run failed. Anything not
+// listed, like invalid flags, exits with exitFailure.
+const (
+	exitFailure    = 1
+	exitNamespace  = 3
+	exitConnect    = 4
+	exitSubmit     = 5
+	exitFetch      = 6
+	exitVerify     = 7
+	exitCompletion = 8
+	exitConfirm    = 9
+	// exitInterrupted is the exit code after an interrupt, as shells use
+	// for SIGINT.
+	exitInterrupted = 130
+)
+
+// exitCodes maps the errors of the stages of a run to exit codes.
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{scavenger.ErrNamespace, exitNamespace},
+	{scavenger.ErrConnect, exitConnect},
+	{scavenger.ErrSubmit, exitSubmit},
+	{scavenger.ErrFetch, exitFetch},
+	{scavenger.ErrVerify, exitVerify},
+	{scavenger.ErrCompletion, exitCompletion},
+	{scavenger.ErrConfirmations, exitConfirm},
+}
+
+// exitCodesHelp documents the exit codes in the usage output.
+const exitCodesHelp = `
+Exit codes:
+  0    success
+  1    other errors, like invalid flags
+  3    invalid namespace
+  4    connecting to the node failed
+  5    submitting failed
+  6    fetching failed
+  7    verifying the fetched blob or its inclusion failed
+  8    the model failed to respond, or responded with nothing
+  9    the blobs weren't confirmed by enough blocks in time
+  130  interrupted
+`
+
 func main() {
-	ctx, cancel := context.WithCancel(context.Background())
+	// An interrupt cancels whatever is in flight, so that the deferred
+	// cleanup still runs. A second interrupt kills the process as usual.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, func() {
+		stop()
+		slog.Warn("Interrupted, shutting down...")
+	})
+
+	args := os.Args[1:]
+	if len(args) > 0 {
+		if cmd, ok := commands[args[0]]; ok {
+			exitOnError(ctx, cmd(ctx, args[1:]))
+			return
+		}
+	}
+
+	// Get IP, namespace, and prompt from the command line flags
+	var (
+		clearCache   bool
+		thread       string
+		threadHeight uint64
+		outPath      string
+		repeat       int
+		delay        time.Duration
+		noAsk        bool
+	)
+	opts, err := parseFlags("prompt-scavenger", args, os.Stdin, os.Stderr, os.Getenv, mainFlags, func(fs *flag.FlagSet) {
+		fs.BoolVar(&clearCache, "cache-clear", false, "clear the response cache before running")
+		fs.StringVar(&thread, "thread", "", "commitment of the latest turn of a thread to continue, e.g. a stored response")
+		fs.Uint64Var(&threadHeight, "thread-height", 0, "height of the turn given with -thread")
+		fs.StringVar(&outPath, "out", "", "write the response, or the JSON result with -output json, to this file instead of stdout")
+		fs.IntVar(&repeat, "repeat", 1, "run the prompt this many times, printing a summary of the runs")
+		fs.DurationVar(&delay, "delay", 0, "time to wait between the runs of -repeat")
+		fs.BoolVar(&noAsk, "no-ask", false, "only submit and fetch the prompt, without asking the model")
+	})
+	exitOnError(ctx, err)
+	setupLogging(opts.config)
+	opts.thread, err = parseThread(thread, threadHeight)
+	exitOnError(ctx, err)
+	if opts.thread != nil && opts.promptsFile != "" {
+		exitOnError(ctx, fmt.Errorf("flag -thread can't be combined with -prompts-file"))
+	}
+	if opts.file != "" {
+		exitOnError(ctx, fmt.Errorf("flag -file is only supported by the submit subcommand, fetch the file with fetch -out"))
+	}
+	if outPath != "" && opts.promptsFile != "" {
+		exitOnError(ctx, fmt.Errorf("flag -out can't be combined with -prompts-file, use -results-file"))
+	}
+	if opts.thread != nil && (opts.dryRun || opts.askOnly) {
+		exitOnError(ctx, fmt.Errorf("flag -thread can't be combined with -dry-run or -ask-only"))
+	}
+	if opts.thread != nil && len(opts.config.ExtraNamespaces) > 0 {
+		exitOnError(ctx, fmt.Errorf("flag -thread can't be combined with several namespaces"))
+	}
+	// A thread's turns all live in its namespace.
+	if opts.thread != nil && opts.config.ResponseNamespace != "" {
+		exitOnError(ctx, fmt.Errorf("flag -thread can't be combined with -response-namespace"))
+	}
+	opts.repeat, opts.delay = repeat, delay
+	opts.config.NoAsk = noAsk
+	exitOnError(ctx, opts.validateRepeat())
+	if noAsk && (opts.dryRun || opts.askOnly || opts.thread != nil || opts.promptsFile != "" || opts.config.StoreResponse) {
+		exitOnError(ctx, fmt.Errorf("flag -no-ask can't be combined with -dry-run, -ask-only, -thread, -prompts-file or -store-response"))
+	}
+	if clearCache {
+		exitOnError(ctx, clearResponseCache(opts.config))
+	}
+	warnUnknownModel(opts.config)
+	warnOpenAIKeyFile(opts.config)
+	warnSeed(opts.config)
+	warnHighGasPrice(opts.config.GasPrice)
+
+	out, err := openOutput(outPath)
+	exitOnError(ctx, err)
+	exitOnError(ctx, out.finish(run(ctx, opts, out)))
+}
+
+// exitOnError exits the program if err is not nil, with the exit code of
+// the stage that failed. Asking for help is not an error.
+func exitOnError(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, flag.ErrHelp) {
+		slog.Error(err.Error())
+	}
+	os.Exit(exitCode(ctx, err))
+}
+
+// exitCode returns the exit code for err. Errors after an interrupt, which
+// are most likely caused by it, exit with exitInterrupted instead of the
+// code of their stage.
+func exitCode(ctx context.Context, err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if ctx.Err() != nil {
+		return exitInterrupted
+	}
+	for _, c := range exitCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return exitFailure
+}
+
+// warnUnknownModel logs a warning if the configured OpenAI model is not
+// one we know about. Other providers' models aren't checked.
+func warnUnknownModel(cfg *scavenger.Config) {
+	if cfg.Provider == scavenger.ProviderOpenAI && !scavenger.IsKnownModel(cfg.Model) {
+		slog.Warn("Unrecognized model, passing it through unchanged", "model", cfg.Model)
+	}
+}
+
+// warnSeed logs a warning that a seed doesn't guarantee the same
+// responses, which OpenAI only promises to try.
+func warnSeed(cfg *scavenger.Config) {
+	if cfg.Seed != nil {
+		slog.Warn("Seeded completions are only reproducible on a best-effort basis, compare the system fingerprint of responses", "seed", *cfg.Seed)
+	}
+}
+
+// warnOpenAIKeyFile logs a warning if the OpenAI key file can be read by
+// any user.
+func warnOpenAIKeyFile(cfg *scavenger.Config) {
+	if cfg.OpenAIKeyFile == "" {
+		return
+	}
+	info, err := os.Stat(cfg.OpenAIKeyFile)
+	if err == nil && info.Mode().Perm()&0o004 != 0 {
+		slog.Warn("OpenAI key file is readable by all users, consider chmod 600", "file", cfg.OpenAIKeyFile)
+	}
+}
+
+// highGasPrice is the gas price above which we suspect a typo. It is well
+// above what the networks have needed so far.
+const highGasPrice = 1.0
+
+// warnHighGasPrice logs a warning if gasPrice is implausibly high.
+func warnHighGasPrice(gasPrice float64) {
+	if gasPrice > highGasPrice {
+		slog.Warn("Gas price is unusually high, double check -gas-price", "gas_price", gasPrice)
+	}
+}
+
+// run submits the prompt, fetches it back and asks the model about it. The
+// result is written to w.
+func run(ctx context.Context, opts *options, w io.Writer) error {
+	cfg := opts.config
+
+	// The timeout covers the whole run, from connecting to the node to the
+	// model's response.
+	ctx, cancel := withTimeout(ctx, cfg.Timeout)
 	defer cancel()
 
-	// Get IP, namespace, and prompt from program arguments
-	if len(os.Args) != 4 {
-		log.Fatal("Usage: go run main.go <nodeIP> <namespace> <prompt>")
+	// To compare, we can skip the node entirely and only ask the model.
+	if opts.askOnly {
+		return runAskOnly(ctx, cfg, opts.prompt, w)
 	}
-	nodeIP, namespaceHex, prompt := os.Args[1], os.Args[2], os.Args[3]
 
-	// We pass an empty string as the jwt token, since we
-	// disabled auth with the --rpc.skip-auth flag
-	client, err := nodeclient.NewClient(ctx, nodeIP, "")
+	client, err := connect(ctx, cfg)
 	if err != nil {
-		log.Fatalf("Failed to create client: %v", err)
+		return scavenger.StageError("connect", err)
 	}
 	defer client.Close()
+	client.StreamOutput = w
 
-	// Next, we convert the namespace hex string to the
-	// concrete NamespaceID type
-	namespaceID, err := createNamespaceID(namespaceHex)
-	if err != nil {
-		log.Fatalf("Failed to decode namespace: %v", err)
+	// For quick experiments we can make up a namespace. We print it, so
+	// the blob can still be found later.
+	if opts.randomNamespace {
+		cfg.Namespace, err = scavenger.RandomNamespaceID()
+		if err != nil {
+			return err
+		}
+		slog.Info("Using random namespace, reuse it with -namespace", "namespace", cfg.Namespace)
+	}
+
+	// A dry run stops before anything costs money.
+	if opts.dryRun {
+		return runDry(ctx, client, opts, w)
+	}
+
+	// Many prompts from a file are run as a batch.
+	if opts.promptsFile != "" {
+		return runBatch(ctx, client, opts)
 	}
 
-	// We can then create and submit a blob using the NamespaceID and our prompt.
-	createdBlob, height, err := createAndSubmitBlob(ctx, client, namespaceID, prompt)
+	// A thread continues a conversation on chain instead.
+	if opts.thread != nil {
+		result, err := client.RunThread(ctx, *opts.thread, opts.prompt)
+		if err != nil {
+			return err
+		}
+		return printThreadResult(w, cfg, result)
+	}
+
+	// Repeated runs are meant to submit every time, so the cache is left
+	// out.
+	if opts.repeat > 1 {
+		return runRepeat(ctx, client, opts, w)
+	}
+
+	if !cfg.NoCache {
+		client.Cache, err = scavenger.NewCache(cfg.CacheDir, cfg.CacheTTL)
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := client.Run(ctx, opts.prompt)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	return printRunResult(w, cfg, result)
+}
+
+// printRunResult prints the model's response from result to w, or all of
+// result in JSON mode.
+func printRunResult(w io.Writer, cfg *scavenger.Config, result *scavenger.RunResult) error {
+	// In JSON mode, stdout only gets the result. Logs go to stderr.
+	if cfg.Output == scavenger.OutputJSON {
+		return writeJSON(w, result)
+	}
+	logTimings(result.Timings)
+
+	// Without asking, what was submitted is the result.
+	if cfg.NoAsk {
+		_, err := fmt.Fprintf(w, "%d\t%s\n", result.Height, result.Commitment)
+		return err
+	}
+
+	if result.Cached {
+		slog.Info("Using cached response", "model", result.Model)
+		_, err := fmt.Fprintln(w, result.Response)
+		return err
 	}
 
-	// Now we will fetch the blob back from the network.
-	fetchedBlob, err := client.Blob.Get(ctx, height, namespaceID, createdBlob.Commitment)
+	// A streamed response has already been printed as it arrived.
+	if cfg.Stream {
+		_, err := fmt.Fprintln(w)
+		return err
+	}
+	if len(result.Choices) > 1 {
+		for i, choice := range result.Choices {
+			if _, err := fmt.Fprintf(w, "%d. %s\n", i+1, choice); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	_, err := fmt.Fprintln(w, result.Response)
+	return err
+}
+
+// logTimings logs how long each stage of a run took, at debug level.
+func logTimings(timings map[string]time.Duration) {
+	if len(timings) == 0 {
+		return
+	}
+	stages := make([]string, 0, len(timings))
+	for stage := range timings {
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+	attrs := make([]any, 0, 2*len(stages))
+	for _, stage := range stages {
+		attrs = append(attrs, stage, timings[stage])
+	}
+	slog.Debug("Stage timings", attrs...)
+}
+
+// runAskOnly asks the model about prompt without a node, printing the
+// response like a full run does.
+func runAskOnly(ctx context.Context, cfg *scavenger.Config, prompt string, w io.Writer) error {
+	client, err := askOnlyClient(cfg, w)
+	if err != nil {
+		return err
+	}
+	choices, usage, err := client.AskChoices(ctx, prompt, cfg.Choices)
 	if err != nil {
-		log.Fatalf("Failed to fetch blob: %v", err)
+		return scavenger.StageError("completion", fmt.Errorf("Failed to process message with %s: %w", cfg.Model, err))
+	}
+	result := &scavenger.RunResult{
+		SubmittedPayload: prompt,
+		Model:            cfg.Model,
+		Response:         choices[0],
+		Structured:       scavenger.StructuredResponse(cfg, choices[0]),
+	}
+	if len(choices) > 1 {
+		result.Choices = choices
 	}
+	result.SetUsage(cfg, usage)
+	return printRunResult(w, cfg, result)
+}
 
-	log.Printf("Fetched blob: %s\n", string(fetchedBlob.Data))
-	promptAnswer, err := gpt3(ctx, string(fetchedBlob.Data))
+// askOnlyClient creates a client for asking the model without a node,
+// streaming responses to w. No node is needed to ask, so the client only
+// has a completer, set up like those of connected clients.
+func askOnlyClient(cfg *scavenger.Config, w io.Writer) (*scavenger.Client, error) {
+	completer, err := scavenger.NewCompleter(cfg, w)
 	if err != nil {
-		log.Fatalf("Failed to process message with GPT-3: %v", err)
+		return nil, err
 	}
+	client := &scavenger.Client{Config: cfg, Completer: completer, StreamOutput: w}
+	setUpClient(client, cfg)
+	return client, nil
+}
 
-	log.Printf("GPT-3 response: %s\n", promptAnswer)
+// setUpClient applies the settings of cfg that don't depend on a node to
+// client: logging and the rate limit on requests to the model.
+func setUpClient(client *scavenger.Client, cfg *scavenger.Config) {
+	client.Logger = slog.Default()
+	if cfg.RateLimit > 0 {
+		client.RateLimiter = scavenger.NewRateLimiter(cfg.RateLimit, cfg.RateBurst)
+	}
 }
 
-// createNamespaceID converts a hex string to a NamespaceID
-func createNamespaceID(nIDString string) (share.Namespace, error) {
-	// First, we parse the passed hex string into a []byte slice
-	namespaceBytes, err := hex.DecodeString(nIDString)
+// runDry prints what would be submitted for the prompt to w.
+func runDry(ctx context.Context, client *scavenger.Client, opts *options, w io.Writer) error {
+	result, err := client.DryRun(ctx, opts.prompt)
 	if err != nil {
-		return nil, fmt.Errorf("error decoding hex string: %w", err)
+		return err
 	}
-
-	// Next, we create a new NamespaceID using the parsed bytes
-	return share.NewBlobNamespaceV0(namespaceBytes)
+	if opts.config.Output == scavenger.OutputJSON {
+		return writeJSON(w, result)
+	}
+	fmt.Fprintf(w, "Would submit %d blob(s) of %d bytes to namespace %s: %s\n", result.Blobs, result.Size, result.Namespace, result.Fee)
+	slog.Info("Dry run, nothing was submitted", "node_height", result.NodeHeight)
+	return nil
 }
 
-// createAndSubmitBlob creates a new blob and submits it to the network.
-func createAndSubmitBlob(
-	ctx context.Context,
-	client *nodeclient.Client,
-	ns share.Namespace,
-	payload string,
-) (*blob.Blob, uint64, error) {
-	// First we can create the blob using the namespace and payload.
-	createdBlob, err := blob.NewBlobV0(ns, []byte(payload))
+// parseThread parses the -thread and -thread-height flags. It returns nil
+// if no thread was given.
+func parseThread(commitmentHex string, height uint64) (*scavenger.BlobRef, error) {
+	if commitmentHex == "" && height == 0 {
+		return nil, nil
+	}
+	if commitmentHex == "" || height == 0 {
+		return nil, fmt.Errorf("flags -thread and -thread-height must be given together")
+	}
+	commitment, err := decodeCommitment(commitmentHex)
 	if err != nil {
-		return nil, 0, fmt.Errorf("Failed to create blob: %w", err)
+		return nil, err
 	}
+	return &scavenger.BlobRef{Height: height, Commitment: hex.EncodeToString(commitment)}, nil
+}
 
-	// After we've created the blob, we can submit it to the network.
-	// Here we use the default gas price.
-	height, err := client.Blob.Submit(ctx, []*blob.Blob{createdBlob}, blob.DefaultGasPrice())
+// printThreadResult prints the result of continuing a thread to w, and
+// logs how to continue it further.
+func printThreadResult(w io.Writer, cfg *scavenger.Config, result *scavenger.RunResult) error {
+	if cfg.Output == scavenger.OutputJSON {
+		return writeJSON(w, result)
+	}
+	var err error
+	if cfg.Stream {
+		_, err = fmt.Fprintln(w)
+	} else {
+		_, err = fmt.Fprintln(w, result.Response)
+	}
 	if err != nil {
-		return nil, 0, fmt.Errorf("Failed to submit blob: %v", err)
-	}
-
-	log.Printf("Blob submitted successfully at height: %d! \n", height)
-	log.Printf("Explorer link: https://arabica.celenium.io/block/%d \n", height)
-
-	return createdBlob, height, nil
-}
-
-// gpt3 processes a given message using GPT-3 and returns the response.
-func gpt3(ctx context.Context, msg string) (string, error) {
-	// Set the authentication header
-	openAIKey := os.Getenv("OPENAI_KEY")
-	if openAIKey == "" {
-		return "", fmt.Errorf("OPENAI_KEY environment variable not set")
-	}
-	client := openai.NewClient(openAIKey)
-	resp, err := client.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model: openai.GPT3Dot5Turbo,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: msg,
-				},
-			},
-		},
-	)
+		return err
+	}
+	slog.Info("Thread continued, continue it further with -thread and -thread-height",
+		"turns", result.ThreadTurns,
+		"height", result.ResponseHeight,
+		"commitment", result.ResponseCommitment)
+	return nil
+}
 
+// clearResponseCache removes all cached responses.
+func clearResponseCache(cfg *scavenger.Config) error {
+	cache, err := scavenger.NewCache(cfg.CacheDir, cfg.CacheTTL)
 	if err != nil {
-		return "", fmt.Errorf("ChatCompletion error: %w", err)
+		return err
 	}
+	if err := cache.Clear(); err != nil {
+		return err
+	}
+	slog.Info("Cleared response cache", "dir", cache.Dir)
+	return nil
+}
+
+// withTimeout bounds ctx by timeout, unless it is zero.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
 
-	return resp.Choices[0].Message.Content, nil
+// connect creates a client for the configured node, set up to log to
+// stderr and stream responses to stdout.
+func connect(ctx context.Context, cfg *scavenger.Config) (*scavenger.Client, error) {
+	// Without a token we can still talk to nodes started with the
+	// --rpc.skip-auth flag.
+	if cfg.AuthToken == "" && cfg.AuthTokenFile == "" {
+		slog.Warn("No node auth token given, assuming the node runs with --rpc.skip-auth")
+	}
+	client, err := scavenger.NewClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	client.StreamOutput = os.Stdout
+	setUpClient(client, cfg)
+	// Progress is only for someone watching, so it stays out of pipes and
+	// JSON output.
+	if cfg.Output != scavenger.OutputJSON && isTerminal(os.Stdout) && isTerminal(os.Stderr) {
+		client.Progress = scavenger.NewProgress(os.Stderr)
+	}
+	slog.Info("Connected to node", "node", client.NodeAddr, "height", client.NodeHeight)
+	if cfg.Journal {
+		client.Journal, err = scavenger.NewJournal(cfg.JournalDir)
+		if err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	if cfg.LedgerFile != "" {
+		client.Ledger = scavenger.NewLedger(cfg.LedgerFile)
+	}
+	if cfg.Estimate {
+		client.Confirm = func(est scavenger.FeeEstimate) error {
+			return confirmSubmission(est, cfg.AssumeYes, os.Stdin, os.Stderr)
+		}
+	}
+	return client, nil
 }
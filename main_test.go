@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+func TestWithTimeout(t *testing.T) {
+	ctx, cancel := withTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	if ctx.Err() != context.DeadlineExceeded {
+		t.Errorf("error = %v, want the deadline", ctx.Err())
+	}
+
+	ctx, cancel = withTimeout(context.Background(), 0)
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("zero timeout set a deadline")
+	}
+	cancel()
+	if ctx.Err() != context.Canceled {
+		t.Errorf("error = %v, want the cancellation", ctx.Err())
+	}
+}
+
+func TestPrintRunResultJSON(t *testing.T) {
+	cfg := scavenger.DefaultConfig()
+	cfg.Output = scavenger.OutputJSON
+	result := &scavenger.RunResult{Height: 12, Commitment: "abcd", FetchedPayload: "hi", Response: "hello"}
+	var out bytes.Buffer
+	if err := printRunResult(&out, cfg, result); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("output isn't JSON: %v\n%s", err, out.String())
+	}
+	if got["height"] != float64(12) || got["commitment"] != "abcd" || got["response"] != "hello" {
+		t.Errorf("output = %v, want the result's fields", got)
+	}
+}
+
+func TestPrintRunResultText(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    func(*scavenger.Config)
+		result scavenger.RunResult
+		want   string
+	}{
+		{"response", nil, scavenger.RunResult{Response: "hello"}, "hello\n"},
+		{"choices", nil, scavenger.RunResult{Response: "a", Choices: []string{"a", "b"}}, "1. a\n2. b\n"},
+		{"streamed", func(c *scavenger.Config) { c.Stream = true }, scavenger.RunResult{Response: "hello"}, "\n"},
+		{"no ask", func(c *scavenger.Config) { c.NoAsk = true }, scavenger.RunResult{Height: 3, Commitment: "ab"}, "3\tab\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := scavenger.DefaultConfig()
+			if tt.cfg != nil {
+				tt.cfg(cfg)
+			}
+			var out bytes.Buffer
+			if err := printRunResult(&out, cfg, &tt.result); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+// fakeCompleter answers every prompt with "answer to " and the prompt, or
+// fails with err. It records the prompts it is asked.
+type fakeCompleter struct {
+	err error
+
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (f *fakeCompleter) Complete(_ context.Context, messages []scavenger.Message) (string, scavenger.Usage, error) {
+	prompt := messages[len(messages)-1].Content
+	f.mu.Lock()
+	f.prompts = append(f.prompts, prompt)
+	f.mu.Unlock()
+	if f.err != nil {
+		return "", scavenger.Usage{}, f.err
+	}
+	return "answer to " + prompt, scavenger.Usage{TotalTokens: 1}, nil
+}
+
+// asked returns the prompts the completer was asked.
+func (f *fakeCompleter) asked() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.prompts...)
+}
+
+// newTestClient returns a client for the default config with
+// testNamespace, submitting to a FakeBlobAPI and asking a fakeCompleter.
+func newTestClient(t *testing.T) (*scavenger.Client, *scavenger.FakeBlobAPI, *fakeCompleter) {
+	t.Helper()
+	cfg := scavenger.DefaultConfig()
+	cfg.Namespace = testNamespace
+	api := &scavenger.FakeBlobAPI{}
+	completer := &fakeCompleter{}
+	return &scavenger.Client{Config: cfg, Blobs: api, Completer: completer}, api, completer
+}
+
+// testNS returns the namespace testNamespace.
+func testNS(t *testing.T) share.Namespace {
+	t.Helper()
+	ns, err := scavenger.CreateNamespaceID(testNamespace, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ns
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, 0},
+		{"help", flag.ErrHelp, 0},
+		{"other", errors.New("invalid flag"), exitFailure},
+		{"namespace", scavenger.StageError("namespace", errors.New("bad hex")), exitNamespace},
+		{"connect", scavenger.StageError("connect", errors.New("refused")), exitConnect},
+		{"submit", scavenger.StageError("submit", errors.New("out of funds")), exitSubmit},
+		{"fetch", scavenger.StageError("fetch", errors.New("not found")), exitFetch},
+		{"verify", scavenger.StageError("verification", errors.New("mismatch")), exitVerify},
+		{"proof", scavenger.StageError("proof verification", errors.New("not included")), exitVerify},
+		{"completion", scavenger.StageError("completion", errors.New("rate limited")), exitCompletion},
+		{"empty response", scavenger.StageError("completion", scavenger.ErrEmptyResponse), exitCompletion},
+		{"confirmations", scavenger.StageError("confirmations", errors.New("too slow")), exitConfirm},
+		{"wrapped", fmt.Errorf("run 2: %w", scavenger.StageError("submit", errors.New("x"))), exitSubmit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(context.Background(), tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCodeInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := scavenger.StageError("submit", context.Canceled)
+	if got := exitCode(ctx, err); got != exitInterrupted {
+		t.Errorf("exit code after an interrupt = %d, want %d", got, exitInterrupted)
+	}
+}
+
+func TestExitCodesHelp(t *testing.T) {
+	for _, c := range exitCodes {
+		if !strings.Contains(exitCodesHelp, fmt.Sprintf("\n  %d ", c.code)) {
+			t.Errorf("exit code %d for %v isn't documented", c.code, c.err)
+		}
+	}
+}
+
+// captureLogs sends the default logger's output, at any level, to the
+// returned buffer until the test ends.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(old) })
+	return &buf
+}
+
+func TestWarnOpenAIKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "openai-key")
+	if err := os.WriteFile(path, []byte("sk-secret\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := scavenger.DefaultConfig()
+	cfg.OpenAIKeyFile = path
+	logs := captureLogs(t)
+	warnOpenAIKeyFile(cfg)
+	if !strings.Contains(logs.String(), "readable by all users") {
+		t.Errorf("logged %q, want a warning about the world-readable file", logs.String())
+	}
+	if strings.Contains(logs.String(), "sk-secret") {
+		t.Errorf("logged %q, which has the key", logs.String())
+	}
+
+	logs.Reset()
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	warnOpenAIKeyFile(cfg)
+	if logs.Len() != 0 {
+		t.Errorf("logged %q for a private key file, want nothing", logs.String())
+	}
+}
+
+func TestParseFlagsOpenAIKeyFile(t *testing.T) {
+	path := writeFile(t, "openai-key", "sk-from-file\n")
+	env := map[string]string{"OPENAI_KEY": "sk-from-env"}
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-openai-key-file", path, "hi"}, env, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := opts.config.ResolveOpenAIKey(); err != nil || key != "sk-from-file" {
+		t.Errorf("key = %q (error %v), want the file's over the environment's", key, err)
+	}
+}
+
+func TestWarnSeed(t *testing.T) {
+	cfg := scavenger.DefaultConfig()
+	logs := captureLogs(t)
+	warnSeed(cfg)
+	if logs.Len() != 0 {
+		t.Errorf("logged %q without a seed, want nothing", logs.String())
+	}
+	seed := 7
+	cfg.Seed = &seed
+	warnSeed(cfg)
+	if !strings.Contains(logs.String(), "best-effort") {
+		t.Errorf("logged %q, want the seed's reproducibility warned about", logs.String())
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// metrics are the Prometheus metrics exposed by the watch command.
+type metrics struct {
+	registry *prometheus.Registry
+
+	blobsSubmitted prometheus.Counter
+	blobsFetched   prometheus.Counter
+	completions    prometheus.Counter
+	// errors counts failures by stage: submit, fetch or completion.
+	errors *prometheus.CounterVec
+	// latency observes the duration of every operation, by stage.
+	latency *prometheus.HistogramVec
+}
+
+// newMetrics creates the metrics, registered with a registry of their own.
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		blobsSubmitted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "prompt_scavenger_blobs_submitted_total",
+			Help: "Number of blobs submitted.",
+		}),
+		blobsFetched: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "prompt_scavenger_blobs_fetched_total",
+			Help: "Number of blobs fetched.",
+		}),
+		completions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "prompt_scavenger_completions_total",
+			Help: "Number of completions requested from the model.",
+		}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "prompt_scavenger_errors_total",
+			Help: "Number of failed operations, by stage.",
+		}, []string{"stage"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "prompt_scavenger_duration_seconds",
+			Help:    "Duration of operations, by stage.",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 12),
+		}, []string{"stage"}),
+	}
+	m.registry.MustRegister(m.blobsSubmitted, m.blobsFetched, m.completions, m.errors, m.latency)
+	return m
+}
+
+// observe records an operation of stage that started at start and
+// failed with err, if not nil.
+func (m *metrics) observe(stage string, start time.Time, err error) {
+	m.latency.WithLabelValues(stage).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(stage).Inc()
+	}
+}
+
+// instrumentBlobs returns api with its submissions and fetches counted.
+func (m *metrics) instrumentBlobs(api scavenger.BlobAPI) scavenger.BlobAPI {
+	return &metricsBlobAPI{BlobAPI: api, metrics: m}
+}
+
+// instrumentCompleter returns completer with its completions counted.
+func (m *metrics) instrumentCompleter(completer scavenger.Completer) scavenger.Completer {
+	return &metricsCompleter{Completer: completer, metrics: m}
+}
+
+// metricsBlobAPI counts the blobs submitted and fetched through BlobAPI.
+type metricsBlobAPI struct {
+	scavenger.BlobAPI
+	metrics *metrics
+}
+
+func (a *metricsBlobAPI) Submit(ctx context.Context, blobs []*blob.Blob, gasPrice float64) (uint64, error) {
+	start := time.Now()
+	height, err := a.BlobAPI.Submit(ctx, blobs, gasPrice)
+	a.metrics.observe("submit", start, err)
+	if err == nil {
+		a.metrics.blobsSubmitted.Add(float64(len(blobs)))
+	}
+	return height, err
+}
+
+func (a *metricsBlobAPI) Get(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (*blob.Blob, error) {
+	start := time.Now()
+	b, err := a.BlobAPI.Get(ctx, height, ns, commitment)
+	a.metrics.observe("fetch", start, fetchError(err))
+	if err == nil {
+		a.metrics.blobsFetched.Inc()
+	}
+	return b, err
+}
+
+func (a *metricsBlobAPI) GetAll(ctx context.Context, height uint64, namespaces []share.Namespace) ([]*blob.Blob, error) {
+	start := time.Now()
+	blobs, err := a.BlobAPI.GetAll(ctx, height, namespaces)
+	a.metrics.observe("fetch", start, fetchError(err))
+	a.metrics.blobsFetched.Add(float64(len(blobs)))
+	return blobs, err
+}
+
+// fetchError returns err, unless it only says there are no blobs, which
+// is the common case for heights without prompts and not a failure.
+func fetchError(err error) error {
+	if err != nil && scavenger.IsBlobNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// metricsCompleter counts the completions requested through Completer.
+type metricsCompleter struct {
+	scavenger.Completer
+	metrics *metrics
+}
+
+func (c *metricsCompleter) Complete(ctx context.Context, messages []scavenger.Message) (string, scavenger.Usage, error) {
+	start := time.Now()
+	answer, usage, err := c.Completer.Complete(ctx, messages)
+	c.metrics.observe("completion", start, err)
+	c.metrics.completions.Inc()
+	return answer, usage, err
+}
+
+// serveMetrics serves the metrics at /metrics on addr until ctx is done.
+// Listening happens right away, so a bad address fails before watching
+// starts.
+func serveMetrics(ctx context.Context, addr string, m *metrics) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("error listening for metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Metrics server failed", "error", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Failed to shut down metrics server", "error", err)
+		}
+	}()
+
+	slog.Info("Serving metrics", "addr", ln.Addr().String())
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+func TestMetricsBlobAPI(t *testing.T) {
+	ctx := context.Background()
+	m := newMetrics()
+	fake := &scavenger.FakeBlobAPI{}
+	api := m.instrumentBlobs(fake)
+	ns := testNS(t)
+	b, err := blob.NewBlobV0(ns, []byte("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.Submit(ctx, []*blob.Blob{b}, blob.DefaultGasPrice()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.Get(ctx, 1, ns, b.Commitment); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.GetAll(ctx, 1, []share.Namespace{ns}); err != nil {
+		t.Fatal(err)
+	}
+	// Heights without blobs aren't failures.
+	if _, err := api.GetAll(ctx, 2, []share.Namespace{ns}); err == nil {
+		t.Fatal("fetching an empty height succeeded")
+	}
+	if got := testutil.ToFloat64(m.blobsSubmitted); got != 1 {
+		t.Errorf("blobs submitted = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.blobsFetched); got != 2 {
+		t.Errorf("blobs fetched = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.errors.WithLabelValues("fetch")); got != 0 {
+		t.Errorf("fetch errors = %v, want none", got)
+	}
+
+	fake.SubmitErr = errors.New("out of funds")
+	if _, err := api.Submit(ctx, []*blob.Blob{b}, blob.DefaultGasPrice()); err == nil {
+		t.Fatal("failing submit succeeded")
+	}
+	if got := testutil.ToFloat64(m.errors.WithLabelValues("submit")); got != 1 {
+		t.Errorf("submit errors = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(m.latency); got != 2 {
+		t.Errorf("latency has %d stages, want submit and fetch", got)
+	}
+}
+
+func TestMetricsCompleter(t *testing.T) {
+	m := newMetrics()
+	fake := &fakeCompleter{}
+	completer := m.instrumentCompleter(fake)
+	if _, _, err := completer.Complete(context.Background(), scavenger.ChatMessages("", "hi")); err != nil {
+		t.Fatal(err)
+	}
+	fake.err = errors.New("rate limited")
+	if _, _, err := completer.Complete(context.Background(), scavenger.ChatMessages("", "hi")); err == nil {
+		t.Fatal("failing completion succeeded")
+	}
+	if got := testutil.ToFloat64(m.completions); got != 2 {
+		t.Errorf("completions = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.errors.WithLabelValues("completion")); got != 1 {
+		t.Errorf("completion errors = %v, want 1", got)
+	}
+}
+
+func TestServeMetrics(t *testing.T) {
+	// Find a free port to serve on.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := newMetrics()
+	m.completions.Inc()
+	if err := serveMetrics(ctx, addr, m); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(fmt.Sprintf("http://%s/metrics", addr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "prompt_scavenger_completions_total 1") {
+		t.Errorf("metrics = %s, want the completions counted", body)
+	}
+
+	// The address is taken now.
+	if err := serveMetrics(ctx, addr, newMetrics()); err == nil {
+		t.Error("serving twice on the same address succeeded")
+	}
+
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := http.Get(fmt.Sprintf("http://%s/metrics", addr)); err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("metrics still served after the context is done")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
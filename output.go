@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// writeJSON writes v as a single indented JSON object to w. It's how
+// results are printed to stdout with -output json.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("error writing JSON output: %w", err)
+	}
+	return nil
+}
+
+// outputFile is where the result of a run goes, stdout or a file given
+// with -out. A file is written to a temporary file next to it first, so it
+// only appears once the run succeeded.
+type outputFile struct {
+	io.Writer
+	tmp  *os.File
+	path string
+}
+
+// openOutput opens path for the result of a run. It creates the file's
+// directory and the temporary file right away, so that an unwritable path
+// fails before any work is done. An empty path or - selects stdout.
+func openOutput(path string) (*outputFile, error) {
+	if path == "" || path == "-" {
+		return &outputFile{Writer: os.Stdout}, nil
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating output directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return nil, fmt.Errorf("error creating output file: %w", err)
+	}
+	return &outputFile{Writer: tmp, tmp: tmp, path: path}, nil
+}
+
+// finish moves the output into place if err is nil and discards it
+// otherwise. It returns err, or the error finishing the output.
+func (o *outputFile) finish(err error) error {
+	if o.tmp == nil {
+		return err
+	}
+	if err != nil {
+		o.tmp.Close()
+		os.Remove(o.tmp.Name())
+		return err
+	}
+	if err := o.tmp.Close(); err != nil {
+		os.Remove(o.tmp.Name())
+		return fmt.Errorf("error writing output file: %w", err)
+	}
+	// CreateTemp makes the file private, but the output is no secret.
+	if err := os.Chmod(o.tmp.Name(), 0o644); err != nil {
+		os.Remove(o.tmp.Name())
+		return fmt.Errorf("error writing output file: %w", err)
+	}
+	if err := os.Rename(o.tmp.Name(), o.path); err != nil {
+		os.Remove(o.tmp.Name())
+		return fmt.Errorf("error writing output file: %w", err)
+	}
+	slog.Info("Output written", "file", o.path)
+	return nil
+}
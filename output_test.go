@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenOutputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results", "nested", "out.json")
+	out, err := openOutput(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(out, `{"response": "hi"}`)
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Error("output file appeared before the run finished")
+	}
+	if err := out.finish(nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"response": "hi"}` {
+		t.Errorf("output file holds %q, want what was written", data)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o644 {
+		t.Errorf("output file mode = %v, want 0644", info.Mode().Perm())
+	}
+}
+
+func TestOpenOutputFailedRun(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.txt")
+	out, err := openOutput(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(out, "half a result")
+	failed := errors.New("run failed")
+	if err := out.finish(failed); err != failed {
+		t.Errorf("finish returned %v, want the run's error", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("directory holds %v after a failed run, want nothing", entries)
+	}
+}
+
+func TestOpenOutputStdout(t *testing.T) {
+	for _, path := range []string{"", "-"} {
+		out, err := openOutput(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Writer != os.Stdout {
+			t.Errorf("output for %q isn't stdout", path)
+		}
+		if err := out.finish(nil); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestOpenOutputUnwritable(t *testing.T) {
+	// A file is in the way of the directory.
+	blocker := writeFile(t, "blocker", "")
+	if _, err := openOutput(filepath.Join(blocker, "out.txt")); err == nil {
+		t.Error("opening output under a file succeeded")
+	}
+}
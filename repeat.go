@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// validateRepeat checks -repeat and -delay against the other flags.
+func (o *options) validateRepeat() error {
+	if o.repeat < 1 {
+		return fmt.Errorf("-repeat must be at least 1, got %d", o.repeat)
+	}
+	if o.delay < 0 {
+		return fmt.Errorf("-delay must not be negative, got %s", o.delay)
+	}
+	if o.repeat == 1 {
+		if o.delay > 0 {
+			return fmt.Errorf("flag -delay requires -repeat")
+		}
+		return nil
+	}
+	if o.promptsFile != "" || o.thread != nil || o.dryRun || o.askOnly {
+		return fmt.Errorf("-repeat can't be combined with -prompts-file, -thread, -dry-run or -ask-only")
+	}
+	// The journal would hand back the first submission every time.
+	if o.config.Journal {
+		return fmt.Errorf("-repeat can't be combined with -journal")
+	}
+	if o.config.Stream {
+		return fmt.Errorf("-repeat can't be combined with -stream")
+	}
+	return nil
+}
+
+// runRepeat runs the prompt as often as -repeat asks and writes a summary
+// of the runs to w.
+func runRepeat(ctx context.Context, client *scavenger.Client, opts *options, w io.Writer) error {
+	slog.Info("Repeating prompt", "runs", opts.repeat, "delay", opts.delay)
+	summary, err := client.Repeat(ctx, opts.prompt, opts.repeat, opts.delay)
+	if werr := printRepeatSummary(w, opts.config, summary); werr != nil {
+		return werr
+	}
+	if err != nil {
+		return fmt.Errorf("stopped after %d of %d runs: %w", len(summary.Runs), opts.repeat, err)
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d of %d runs failed", summary.Failed, len(summary.Runs))
+	}
+	return nil
+}
+
+// printRepeatSummary prints a line per run to w, or the whole summary in
+// JSON mode.
+func printRepeatSummary(w io.Writer, cfg *scavenger.Config, summary *scavenger.RepeatSummary) error {
+	if cfg.Output == scavenger.OutputJSON {
+		return writeJSON(w, summary)
+	}
+	for _, run := range summary.Runs {
+		var err error
+		if run.Error != "" {
+			_, err = fmt.Fprintf(w, "%d\tfailed\t%s\n", run.Iteration, run.Error)
+		} else {
+			_, err = fmt.Fprintf(w, "%d\t%d\t%s\n", run.Iteration, run.Height, run.Commitment)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+func TestValidateRepeat(t *testing.T) {
+	valid := func() *options {
+		cfg := scavenger.DefaultConfig()
+		return &options{config: cfg, repeat: 3, delay: time.Second}
+	}
+	if err := valid().validateRepeat(); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]func(*options){
+		"no runs":               func(o *options) { o.repeat = 0 },
+		"negative delay":        func(o *options) { o.delay = -time.Second },
+		"delay without -repeat": func(o *options) { o.repeat = 1 },
+		"dry run":               func(o *options) { o.dryRun = true },
+		"prompts file":          func(o *options) { o.promptsFile = "prompts.txt" },
+		"journal":               func(o *options) { o.config.Journal = true },
+		"stream":                func(o *options) { o.config.Stream = true },
+	}
+	for name, modify := range tests {
+		o := valid()
+		modify(o)
+		if err := o.validateRepeat(); err == nil {
+			t.Errorf("%s was accepted", name)
+		}
+	}
+}
+
+func TestRunRepeat(t *testing.T) {
+	client, api, _ := newTestClient(t)
+	client.Config.NoAsk = true
+	opts := &options{config: client.Config, prompt: "hi", repeat: 2}
+	var out strings.Builder
+	if err := runRepeat(context.Background(), client, opts, &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "1\t1\t") || !strings.HasPrefix(lines[1], "2\t2\t") {
+		t.Errorf("printed %q, want a line with the height and commitment of each run", out.String())
+	}
+
+	api.SubmitErr = errors.New("mempool is full")
+	client.Config.SubmitAttempts = 1
+	out.Reset()
+	err := runRepeat(context.Background(), client, opts, &out)
+	if err == nil || !strings.Contains(err.Error(), "2 of 2 runs failed") {
+		t.Errorf("error = %v, want the failed runs counted", err)
+	}
+	if !strings.Contains(out.String(), "1\tfailed\t") {
+		t.Errorf("printed %q, want the failures", out.String())
+	}
+}
+
+func TestPrintRepeatSummaryJSON(t *testing.T) {
+	cfg := scavenger.DefaultConfig()
+	cfg.Output = scavenger.OutputJSON
+	var out strings.Builder
+	summary := &scavenger.RepeatSummary{Runs: []scavenger.RepeatedRun{{Iteration: 1, Height: 4}}, Succeeded: 1}
+	if err := printRepeatSummary(&out, cfg, summary); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"succeeded": 1`) || !strings.Contains(out.String(), `"height": 4`) {
+		t.Errorf("printed %s, want the summary as JSON", out.String())
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// replHelp describes the meta-commands of the REPL.
+const replHelp = `Every line is run as a prompt. Meta-commands:
+  :namespace <hex>  submit to another namespace
+  :model <name>     ask another model
+  :help             show this help
+  :quit             leave the REPL`
+
+// replCommand runs every line read from stdin as a prompt, reusing one
+// connection to the node and one model client for the whole session.
+func replCommand(ctx context.Context, args []string) error {
+	cfg, err := loadConfig(args, os.Getenv)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("repl", os.Stderr, cfg, nodeFlags, namespaceFlags, payloadFlags, promptFlags, submitFlags, fetchFlags, providerFlags, samplingFlags, askFlags, cacheFlags, runFlags, []string{"n"})
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: prompt-scavenger repl -namespace <hex> [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if cfg.Namespace == "" {
+		return fmt.Errorf("missing required flag -namespace")
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	setupLogging(cfg)
+	warnUnknownModel(cfg)
+	warnOpenAIKeyFile(cfg)
+	warnSeed(cfg)
+	warnHighGasPrice(cfg.GasPrice)
+
+	// The connection lasts as long as its context, so the timeout only
+	// applies to every prompt on its own.
+	client, err := connect(ctx, cfg)
+	if err != nil {
+		return scavenger.StageError("connect", err)
+	}
+	defer client.Close()
+	client.Completer, err = scavenger.NewCompleter(cfg, client.StreamOutput)
+	if err != nil {
+		return err
+	}
+	if !cfg.NoCache {
+		client.Cache, err = scavenger.NewCache(cfg.CacheDir, cfg.CacheTTL)
+		if err != nil {
+			return err
+		}
+	}
+
+	r := &repl{client: client, in: os.Stdin, prompt: os.Stderr}
+	return r.run(ctx)
+}
+
+// repl reads prompts and meta-commands from in, writing its prompt to
+// prompt and results to stdout.
+type repl struct {
+	client *scavenger.Client
+	in     io.Reader
+	prompt io.Writer
+}
+
+// run runs lines from r.in until :quit, the end of the input or ctx being
+// canceled. A failing prompt is logged, and the session goes on.
+func (r *repl) run(ctx context.Context) error {
+	// Reading blocks, so it happens on its own so that an interrupt isn't
+	// stuck behind it.
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		scanner := bufio.NewScanner(r.in)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-done:
+				return
+			}
+		}
+		readErr <- scanner.Err()
+		close(lines)
+	}()
+
+	for {
+		fmt.Fprint(r.prompt, "> ")
+		var line string
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(r.prompt)
+			return nil
+		case l, ok := <-lines:
+			if !ok {
+				fmt.Fprintln(r.prompt)
+				if err := <-readErr; err != nil {
+					return fmt.Errorf("error reading input: %w", err)
+				}
+				return nil
+			}
+			line = strings.TrimSpace(l)
+		}
+
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, ":"):
+			quit, err := r.meta(line)
+			if err != nil {
+				slog.Error(err.Error())
+			}
+			if quit {
+				return nil
+			}
+		default:
+			if err := r.ask(ctx, line); err != nil {
+				if ctx.Err() != nil {
+					return err
+				}
+				slog.Error(err.Error())
+			}
+		}
+	}
+}
+
+// meta runs a meta-command, and reports whether it ends the session.
+func (r *repl) meta(line string) (bool, error) {
+	cfg := r.client.Config
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case ":quit", ":q", ":exit":
+		return true, nil
+	case ":help":
+		fmt.Fprintln(r.prompt, replHelp)
+	case ":namespace":
+		if arg == "" {
+			fmt.Fprintln(r.prompt, cfg.Namespace)
+			return false, nil
+		}
+		namespaceID, err := scavenger.CreateNamespaceID(arg, cfg.NamespaceVersion, cfg.PadNamespace)
+		if err != nil {
+			return false, scavenger.StageError("namespace", fmt.Errorf("Failed to decode namespace: %w", err))
+		}
+		if err := scavenger.ValidateBlobNamespace(namespaceID); err != nil {
+			return false, scavenger.StageError("namespace", fmt.Errorf("namespace can't be used for blobs: %w", err))
+		}
+		cfg.Namespace = arg
+		slog.Info("Switched namespace", "namespace", scavenger.NamespaceHex(namespaceID))
+	case ":model":
+		if arg == "" {
+			fmt.Fprintln(r.prompt, cfg.Model)
+			return false, nil
+		}
+		// The model is read from the config on every completion, so the
+		// model client is kept.
+		cfg.Model = arg
+		warnUnknownModel(cfg)
+		slog.Info("Switched model", "model", cfg.Model)
+	default:
+		return false, fmt.Errorf("unknown meta-command %s, see :help", name)
+	}
+	return false, nil
+}
+
+// ask runs prompt through the whole flow and prints the result.
+func (r *repl) ask(ctx context.Context, prompt string) error {
+	ctx, cancel := withTimeout(ctx, r.client.Config.Timeout)
+	defer cancel()
+	result, err := r.client.Run(ctx, prompt)
+	if err != nil {
+		return err
+	}
+	return printRunResult(os.Stdout, r.client.Config, result)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+const otherNamespace = "0000000000000000aaaa"
+
+func TestREPL(t *testing.T) {
+	client, api, completer := newTestClient(t)
+	client.Config.NoCache = true
+	script := strings.Join([]string{
+		"first prompt",
+		"",
+		":namespace " + otherNamespace,
+		":namespace 00",
+		":model gpt-4o",
+		":bogus",
+		"second prompt",
+		":quit",
+		"after quitting",
+	}, "\n")
+	var prompt strings.Builder
+	r := &repl{client: client, in: strings.NewReader(script), prompt: &prompt}
+	if err := r.run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := completer.asked(); len(got) != 2 || got[0] != "first prompt" || got[1] != "second prompt" {
+		t.Errorf("model was asked %q, want the two prompts before :quit", got)
+	}
+	if client.Config.Namespace != otherNamespace || client.Config.Model != "gpt-4o" {
+		t.Errorf("session ended with namespace %s and model %s, want the switched ones", client.Config.Namespace, client.Config.Model)
+	}
+	other, err := scavenger.CreateNamespaceID(otherNamespace, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blobs, _ := api.GetAll(context.Background(), 1, []share.Namespace{testNS(t)}); len(blobs) != 1 {
+		t.Errorf("%d blobs in the first namespace at height 1, want the first prompt", len(blobs))
+	}
+	if blobs, _ := api.GetAll(context.Background(), 2, []share.Namespace{other}); len(blobs) != 1 {
+		t.Errorf("%d blobs in the switched namespace at height 2, want the second prompt", len(blobs))
+	}
+	if n := strings.Count(prompt.String(), "> "); n != 8 {
+		t.Errorf("prompted %d times, want once per line read", n)
+	}
+}
+
+func TestREPLEndOfInput(t *testing.T) {
+	client, _, completer := newTestClient(t)
+	client.Config.NoCache = true
+	var prompt strings.Builder
+	r := &repl{client: client, in: strings.NewReader(":model\nonly prompt"), prompt: &prompt}
+	if err := r.run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := completer.asked(); len(got) != 1 {
+		t.Errorf("model was asked %q, want the last line too", got)
+	}
+	if !strings.Contains(prompt.String(), client.Config.Model) {
+		t.Errorf("prompt output %q doesn't show the model", prompt.String())
+	}
+}
+
+func TestREPLCanceled(t *testing.T) {
+	client, _, _ := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// The input never ends, like a terminal.
+	in, w := io.Pipe()
+	defer w.Close()
+	r := &repl{client: client, in: in, prompt: &strings.Builder{}}
+	done := make(chan error, 1)
+	go func() { done <- r.run(ctx) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("error = %v, want the session to end quietly", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("session didn't end once canceled")
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// replayCommand fetches the prompt of a receipt written by submit and asks
+// the model recorded in it, to reproduce the completion step later.
+func replayCommand(ctx context.Context, args []string) error {
+	cfg, err := loadConfig(args, os.Getenv)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("replay", os.Stderr, cfg, nodeFlags, fetchFlags, providerFlags, samplingFlags, askFlags)
+	receiptFile := fs.String("receipt-file", "", "receipt written by submit -receipt-file (required)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: prompt-scavenger replay -receipt-file <file> [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if *receiptFile == "" {
+		return fmt.Errorf("missing required flag -receipt-file")
+	}
+	r, err := readReceipt(*receiptFile)
+	if err != nil {
+		return err
+	}
+	if r.Height == 0 || r.Namespace == "" {
+		return fmt.Errorf("receipt %s is missing the height or namespace", *receiptFile)
+	}
+
+	// The receipt decides what is fetched and how it is answered, so the
+	// replay matches the original run.
+	cfg.Namespace, cfg.ExtraNamespaces = r.Namespace, nil
+	// Receipts without a version didn't record how the prompt was
+	// answered, so they leave the configured model and parameters.
+	if r.Provider != "" {
+		cfg.Provider = r.Provider
+	}
+	if r.Model != "" {
+		cfg.Model = r.Model
+	}
+	if r.Version > 0 {
+		cfg.SystemPrompt, cfg.Temperature, cfg.MaxTokens, cfg.TopP = r.SystemPrompt, r.Temperature, r.MaxTokens, r.TopP
+	}
+	// Receipts from before seeds were recorded leave the one configured.
+	if r.Seed != nil {
+		cfg.Seed = r.Seed
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	setupLogging(cfg)
+	warnUnknownModel(cfg)
+	warnOpenAIKeyFile(cfg)
+	warnSeed(cfg)
+
+	commitments, err := replayCommitments(r)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	client, err := connect(ctx, cfg)
+	if err != nil {
+		return scavenger.StageError("connect", err)
+	}
+	defer client.Close()
+
+	namespaceID, err := scavenger.CreateNamespaceID(cfg.Namespace, cfg.NamespaceVersion, cfg.PadNamespace)
+	if err != nil {
+		return scavenger.StageError("namespace", fmt.Errorf("Failed to decode namespace: %w", err))
+	}
+	result, err := replay(ctx, client, r.Height, namespaceID, commitments)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Output == scavenger.OutputJSON {
+		return writeJSON(os.Stdout, result)
+	}
+	if cfg.Stream {
+		// A streamed response has already been printed as it arrived.
+		fmt.Println()
+		return nil
+	}
+	fmt.Println(result.Response)
+	return nil
+}
+
+// replay fetches the prompt with commitments, checks the commitments and
+// asks the configured model about it.
+func replay(ctx context.Context, client *scavenger.Client, height uint64, ns share.Namespace, commitments []blob.Commitment) (*scavenger.RunResult, error) {
+	cfg := client.Config
+	fetched, err := client.FetchPrompt(ctx, height, ns, commitments)
+	if err != nil {
+		return nil, scavenger.StageError("fetch", err)
+	}
+	for i, b := range fetched.Blobs {
+		if err := scavenger.VerifyCommitment(ns, b, commitments[i]); err != nil {
+			return nil, scavenger.StageError("verification", fmt.Errorf("Fetched blob failed verification: %w", err))
+		}
+	}
+	if fetched.ContentType != "" {
+		return nil, fmt.Errorf("blob holds a file of type %s, which can't be sent to the model", fetched.ContentType)
+	}
+	slog.Info("Fetched blob",
+		"height", height,
+		"commitment", hex.EncodeToString(commitments[0]),
+		"payload", string(fetched.Payload))
+
+	answer, usage, err := client.Ask(ctx, scavenger.WrapPrompt(cfg, string(fetched.Payload)))
+	if err != nil {
+		return nil, scavenger.StageError("completion", fmt.Errorf("Failed to process message with %s: %w", cfg.Model, err))
+	}
+	result := &scavenger.RunResult{
+		Namespace:      scavenger.NamespaceHex(fetched.Namespace),
+		Height:         height,
+		Commitment:     hex.EncodeToString(commitments[0]),
+		FetchedPayload: string(fetched.Payload),
+		Metadata:       fetched.Metadata,
+		Signer:         fetched.Signer,
+		Model:          cfg.Model,
+		Response:       answer,
+		Structured:     scavenger.StructuredResponse(cfg, answer),
+	}
+	result.SetUsage(cfg, usage)
+	if len(commitments) > 1 {
+		result.Commitments = scavenger.CommitmentsHex(fetched.Blobs)
+	}
+	return result, nil
+}
+
+// replayCommitments returns the commitments of the receipt's prompt, all
+// its chunks' for chunked prompts.
+func replayCommitments(r *receipt) ([]blob.Commitment, error) {
+	if len(r.Commitments) > 0 {
+		return decodeCommitments(strings.Join(r.Commitments, ","))
+	}
+	commitment, err := decodeCommitment(r.Commitment)
+	if err != nil {
+		return nil, err
+	}
+	return []blob.Commitment{commitment}, nil
+}
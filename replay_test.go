@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+func TestReplay(t *testing.T) {
+	client, _, completer := newTestClient(t)
+	sub, err := client.SubmitPrompt(context.Background(), testNS(t), "what is a blob?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeFile(t, "receipt.json", fmt.Sprintf(`{"version": %d, "namespace": %q, "height": %d, "commitment": %q, "model": "gpt-4o"}`,
+		receiptVersion, testNamespace, sub.Height, hex.EncodeToString(sub.Blobs[0].Commitment)))
+	r, err := readReceipt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitments, err := replayCommitments(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := replay(context.Background(), client, r.Height, testNS(t), commitments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FetchedPayload != "what is a blob?" || result.Response != "answer to what is a blob?" {
+		t.Errorf("fetched %q and got %q, want the submitted prompt answered", result.FetchedPayload, result.Response)
+	}
+	if got := completer.asked(); len(got) != 1 || got[0] != "what is a blob?" {
+		t.Errorf("model was asked %q, want the fetched prompt", got)
+	}
+}
+
+func TestReplayWrongCommitment(t *testing.T) {
+	client, _, completer := newTestClient(t)
+	sub, err := client.SubmitPrompt(context.Background(), testNS(t), "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = replay(context.Background(), client, sub.Height, testNS(t), []blob.Commitment{[]byte("not a commitment")})
+	if !errors.Is(err, scavenger.ErrFetch) || !errors.Is(err, blob.ErrBlobNotFound) {
+		t.Errorf("error = %v, want the unknown commitment failing the fetch", err)
+	}
+	if got := completer.asked(); len(got) != 0 {
+		t.Errorf("model was asked %q, want nothing", got)
+	}
+}
+
+func TestReplayCommitmentsChunked(t *testing.T) {
+	first, second := strings.Repeat("aa", 32), strings.Repeat("bb", 32)
+	r := &receipt{Commitment: first, Commitments: []string{first, second}}
+	commitments, err := replayCommitments(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commitments) != 2 || hex.EncodeToString(commitments[1]) != second {
+		t.Errorf("commitments = %x, want every chunk's", commitments)
+	}
+}
+
+func TestReadReceiptUnversioned(t *testing.T) {
+	r, err := readReceipt(writeFile(t, "receipt.json", `{"namespace": "`+testNamespace+`", "height": 3, "commitment": "aa"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Version != 0 || r.Height != 3 {
+		t.Errorf("read %+v, want the unversioned receipt", r)
+	}
+}
+
+func TestReplayCommandErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := replayCommand(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "-receipt-file") {
+		t.Errorf("error = %v, want the missing receipt file reported", err)
+	}
+	path := writeFile(t, "receipt.json", `{"version": 1, "commitment": "aa"}`)
+	if err := replayCommand(context.Background(), []string{"-receipt-file", path}); err == nil || !strings.Contains(err.Error(), "missing the height or namespace") {
+		t.Errorf("error = %v, want the incomplete receipt reported", err)
+	}
+}
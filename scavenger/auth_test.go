@@ -0,0 +1,56 @@
+package scavenger
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	nodeclient "github.com/celestiaorg/celestia-openrpc"
+)
+
+func TestResolveAuthToken(t *testing.T) {
+	cfg := DefaultConfig()
+	if token, err := cfg.ResolveAuthToken(); err != nil || token != "" {
+		t.Errorf("token = %q (error %v), want none", token, err)
+	}
+
+	cfg.AuthTokenFile = writeFile(t, "token", "  secret\n")
+	if token, err := cfg.ResolveAuthToken(); err != nil || token != "secret" {
+		t.Errorf("token = %q (error %v), want the file's, trimmed", token, err)
+	}
+
+	cfg.AuthTokenFile = filepath.Join(t.TempDir(), "missing")
+	if _, err := cfg.ResolveAuthToken(); err == nil {
+		t.Error("reading a missing token file succeeded")
+	}
+}
+
+func TestNewClientForwardsToken(t *testing.T) {
+	api := &FakeBlobAPI{}
+	var tokens []string
+	replaceNodeClient(t, func(_ context.Context, _, token string) (*nodeclient.Client, error) {
+		tokens = append(tokens, token)
+		return fakeNode(api), nil
+	})
+
+	path := writeFile(t, "token", "from-file\n")
+	for _, tt := range []struct {
+		token, file, want string
+	}{
+		{"", "", ""},
+		{"direct", "", "direct"},
+		{"", path, "from-file"},
+	} {
+		tokens = nil
+		cfg := testConfig()
+		cfg.AuthToken, cfg.AuthTokenFile = tt.token, tt.file
+		c, err := NewClient(context.Background(), cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Close()
+		if len(tokens) == 0 || tokens[0] != tt.want {
+			t.Errorf("node client got tokens %q, want %q", tokens, tt.want)
+		}
+	}
+}
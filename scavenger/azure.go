@@ -0,0 +1,61 @@
+package scavenger
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ProviderAzure answers with a model deployed to Azure OpenAI, at the
+// endpoint given as OpenAIBaseURL.
+const ProviderAzure = "azure"
+
+// DefaultAzureAPIVersion is the Azure OpenAI API version requested unless
+// another one is configured.
+const DefaultAzureAPIVersion = "2024-02-01"
+
+// newAzureCompleter creates an OpenAICompleter with a client for the
+// configured Azure deployment.
+func newAzureCompleter(cfg *Config, w io.Writer) (Completer, error) {
+	client, err := NewAzureClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newChatCompleter(cfg, client, w)
+}
+
+// NewAzureClient creates a client for the Azure OpenAI deployment in cfg,
+// authenticated with the configured OpenAI key.
+func NewAzureClient(cfg *Config) (ChatClient, error) {
+	key, err := cfg.ResolveOpenAIKey()
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return nil, fmt.Errorf("OPENAI_KEY environment variable not set, it holds the Azure OpenAI key")
+	}
+	config, err := azureConfig(cfg, key)
+	if err != nil {
+		return nil, err
+	}
+	return openAIClient{openai.NewClientWithConfig(config)}, nil
+}
+
+// azureConfig builds the client config for Azure from cfg. Azure routes
+// requests by deployment rather than model, so every model maps to the
+// configured deployment. The model is still what tokens and costs are
+// counted by.
+func azureConfig(cfg *Config, key string) (openai.ClientConfig, error) {
+	config := openai.DefaultAzureConfig(key, cfg.OpenAIBaseURL)
+	config.APIVersion = cfg.AzureAPIVersion
+	deployment := cfg.AzureDeployment
+	config.AzureModelMapperFunc = func(string) string { return deployment }
+	transport, err := openAITransport(cfg)
+	if err != nil {
+		return openai.ClientConfig{}, err
+	}
+	config.HTTPClient = &http.Client{Transport: retryAfterTransport{transport}}
+	return config, nil
+}
@@ -0,0 +1,82 @@
+package scavenger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// azureConfigFor returns a config for the Azure provider with deployment
+// at the endpoint base.
+func azureConfigFor(base, deployment string) *Config {
+	cfg := testConfig()
+	cfg.Provider = ProviderAzure
+	cfg.OpenAIKey = "azure-key"
+	cfg.OpenAIBaseURL = base
+	cfg.AzureDeployment = deployment
+	return cfg
+}
+
+func TestAzureDeployment(t *testing.T) {
+	var gotPath, gotVersion, gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotVersion, gotKey = r.URL.Path, r.URL.Query().Get("api-version"), r.Header.Get("api-key")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(chatResponse("from azure"))
+	}))
+	defer server.Close()
+
+	cfg := azureConfigFor(server.URL, "my-gpt")
+	cfg.AzureAPIVersion = "2024-06-01"
+	completer, err := NewCompleter(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer, _, err := completer.Complete(context.Background(), ChatMessages("", "hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "from azure" {
+		t.Errorf("answer = %q, want the deployment's", answer)
+	}
+	if gotPath != "/openai/deployments/my-gpt/chat/completions" || gotVersion != "2024-06-01" || gotKey != "azure-key" {
+		t.Errorf("server got %s with API version %q and key %q, want the deployment's path, the configured version and the key", gotPath, gotVersion, gotKey)
+	}
+}
+
+func TestNewAzureClientNoKey(t *testing.T) {
+	cfg := azureConfigFor("https://example.openai.azure.com", "my-gpt")
+	cfg.OpenAIKey = ""
+	if _, err := NewAzureClient(cfg); err == nil || !strings.Contains(err.Error(), "Azure OpenAI key") {
+		t.Errorf("error = %v, want the missing key reported", err)
+	}
+}
+
+func TestValidateAzure(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{"no deployment", func(c *Config) { c.AzureDeployment = "" }, "-azure-deployment"},
+		{"no endpoint", func(c *Config) { c.OpenAIBaseURL = "" }, "-openai-base-url"},
+		{"no API version", func(c *Config) { c.AzureAPIVersion = "" }, "API version"},
+	}
+	for _, tt := range tests {
+		cfg := azureConfigFor("https://example.openai.azure.com", "my-gpt")
+		tt.modify(cfg)
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+	if err := azureConfigFor("https://example.openai.azure.com", "my-gpt").Validate(); err != nil {
+		t.Errorf("complete Azure config: %v", err)
+	}
+	// Deployments are only needed for Azure.
+	if err := testConfig().Validate(); err != nil {
+		t.Errorf("OpenAI config: %v", err)
+	}
+}
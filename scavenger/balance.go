@@ -0,0 +1,27 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrInsufficientFunds is returned when the node's account can't pay for
+// a submission.
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// checkBalance fails if the node's account balance is below the
+// estimated fee.
+func (c *Client) checkBalance(ctx context.Context, est FeeEstimate) error {
+	balance, err := c.CurrentNode().State.Balance(ctx)
+	if err != nil {
+		return fmt.Errorf("error querying account balance: %w", err)
+	}
+
+	have, _ := new(big.Float).SetInt(balance.Amount.BigInt()).Float64()
+	if have < est.Fee {
+		return fmt.Errorf("%w, have %s%s need ~%.0f%s", ErrInsufficientFunds, balance.Amount, balance.Denom, est.Fee, balance.Denom)
+	}
+	return nil
+}
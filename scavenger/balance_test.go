@@ -0,0 +1,61 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/celestiaorg/celestia-openrpc/types/state"
+)
+
+// balanceClient returns a client whose node's account holds utia, or
+// whose balance query fails with err.
+func balanceClient(utia int64, err error) (*Client, *FakeBlobAPI) {
+	c, api, _ := newTestClient(testConfig())
+	c.Node = fakeNode(api)
+	c.Node.State.Balance = func(context.Context) (*state.Balance, error) {
+		if err != nil {
+			return nil, err
+		}
+		return &state.Balance{Denom: "utia", Amount: sdkmath.NewInt(utia)}, nil
+	}
+	return c, api
+}
+
+func TestCheckBalance(t *testing.T) {
+	est := FeeEstimate{Fee: 1000}
+	c, _ := balanceClient(1000, nil)
+	if err := c.checkBalance(context.Background(), est); err != nil {
+		t.Errorf("balance covering the fee: %v", err)
+	}
+
+	c, _ = balanceClient(999, nil)
+	err := c.checkBalance(context.Background(), est)
+	if !errors.Is(err, ErrInsufficientFunds) || !strings.Contains(err.Error(), "have 999utia need ~1000utia") {
+		t.Errorf("error = %v, want insufficient funds with the amounts", err)
+	}
+
+	c, _ = balanceClient(0, errors.New("no account"))
+	if err := c.checkBalance(context.Background(), est); err == nil || !strings.Contains(err.Error(), "error querying account balance") {
+		t.Errorf("error = %v, want the failed query", err)
+	}
+}
+
+func TestSubmitChecksBalance(t *testing.T) {
+	c, api := balanceClient(1, nil)
+	c.Config.CheckBalance = true
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi"); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("error = %v, want insufficient funds", err)
+	}
+	if fakeHeight(api) != 0 {
+		t.Error("the prompt was submitted without the funds")
+	}
+
+	// Without the check, the node is left to reject the submission.
+	c.Config.CheckBalance = false
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,80 @@
+package scavenger
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchResult is the outcome of running one prompt of a batch.
+type BatchResult struct {
+	// Index is the position of the prompt in the batch.
+	Index  int        `json:"index"`
+	Prompt string     `json:"prompt"`
+	Result *RunResult `json:"result,omitempty"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// RunBatch runs every prompt like Run, up to the configured concurrency at
+// once. The results are in the order of prompts. A failing prompt doesn't
+// stop the others, its error is recorded in its result instead.
+func (c *Client) RunBatch(ctx context.Context, prompts []string) ([]BatchResult, error) {
+	// The completer is shared by the workers, so it has to exist before
+	// they start.
+	if c.Completer == nil {
+		completer, err := NewCompleter(c.Config, c.StreamOutput)
+		if err != nil {
+			return nil, err
+		}
+		c.Completer = completer
+	}
+
+	results := make([]BatchResult, len(prompts))
+	for i, prompt := range prompts {
+		results[i] = BatchResult{Index: i, Prompt: prompt}
+	}
+
+	forEach(ctx, len(prompts), c.Config.Concurrency, func(i int) {
+		result, err := c.Run(ctx, prompts[i])
+		if err != nil {
+			results[i].Error = err.Error()
+			return
+		}
+		results[i].Result = result
+	}, func(i int, err error) {
+		results[i].Error = err.Error()
+	})
+	return results, nil
+}
+
+// forEach calls run for every index up to n, up to concurrency at once.
+// Indexes that weren't started before ctx was done are passed to skipped
+// with its error instead.
+func forEach(ctx context.Context, n, concurrency int, run func(i int), skipped func(i int, err error)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				run(i)
+			}
+		}()
+	}
+
+	next := 0
+	for ; next < n; next++ {
+		select {
+		case jobs <- next:
+			continue
+		case <-ctx.Done():
+		}
+		break
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i := next; i < n; i++ {
+		skipped(i, ctx.Err())
+	}
+}
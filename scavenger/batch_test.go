@@ -0,0 +1,103 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// refusingCompleter is a fakeCompleter failing for the prompt refuse.
+type refusingCompleter struct {
+	fakeCompleter
+	refuse string
+}
+
+func (f *refusingCompleter) Complete(ctx context.Context, messages []Message) (string, Usage, error) {
+	if messages[len(messages)-1].Content == f.refuse {
+		return "", Usage{}, errors.New("refused")
+	}
+	return f.fakeCompleter.Complete(ctx, messages)
+}
+
+func TestRunBatch(t *testing.T) {
+	cfg := testConfig()
+	cfg.Concurrency = 3
+	api := &FakeBlobAPI{}
+	c := &Client{Config: cfg, Blobs: api, Completer: &refusingCompleter{refuse: "b"}}
+	prompts := []string{"a", "b", "c", "d", "e"}
+	results, err := c.RunBatch(context.Background(), prompts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(prompts) {
+		t.Fatalf("got %d results, want %d", len(results), len(prompts))
+	}
+	for i, result := range results {
+		if result.Index != i || result.Prompt != prompts[i] {
+			t.Errorf("result %d is for prompt %d %q, want the results in order", i, result.Index, result.Prompt)
+		}
+		if prompts[i] == "b" {
+			if result.Error == "" || result.Result != nil {
+				t.Errorf("refused prompt has result %+v and error %q, want only the error", result.Result, result.Error)
+			}
+			continue
+		}
+		if result.Error != "" || result.Result.Response != "answer to "+prompts[i] {
+			t.Errorf("prompt %q failed with %q or was answered with the wrong response", prompts[i], result.Error)
+		}
+	}
+	// Every prompt is submitted, the refused one too.
+	if fakeHeight(api) != uint64(len(prompts)) {
+		t.Errorf("chain is at height %d, want one block per prompt", fakeHeight(api))
+	}
+}
+
+func TestForEachConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	var mu sync.Mutex
+	done := make(map[int]bool)
+	forEach(context.Background(), 20, 4, func(i int) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		mu.Lock()
+		done[i] = true
+		mu.Unlock()
+	}, func(i int, err error) {
+		t.Errorf("index %d skipped: %v", i, err)
+	})
+	if len(done) != 20 {
+		t.Errorf("ran %d indexes, want 20", len(done))
+	}
+	if peak.Load() > 4 {
+		t.Errorf("ran %d at once, want at most 4", peak.Load())
+	}
+}
+
+func TestForEachCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var ran, skipped atomic.Int32
+	forEach(ctx, 10, 1, func(i int) {
+		ran.Add(1)
+		if i == 2 {
+			cancel()
+		}
+	}, func(i int, err error) {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("index %d skipped with %v, want the cancellation", i, err)
+		}
+		skipped.Add(1)
+	})
+	if ran.Load()+skipped.Load() != 10 || skipped.Load() == 0 {
+		t.Errorf("ran %d and skipped %d, want the rest skipped after canceling", ran.Load(), skipped.Load())
+	}
+}
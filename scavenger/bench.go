@@ -0,0 +1,134 @@
+package scavenger
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// BenchResult summarizes a benchmark run by Bench.
+type BenchResult struct {
+	Blobs       int `json:"blobs"`
+	Size        int `json:"size"`
+	Concurrency int `json:"concurrency"`
+	Succeeded   int `json:"succeeded"`
+	Failed      int `json:"failed"`
+	// ErrorRate is the share of failed submissions, between 0 and 1.
+	ErrorRate float64       `json:"error_rate"`
+	Duration  time.Duration `json:"duration"`
+	// BlobsPerSecond and BytesPerSecond count successful submissions only.
+	BlobsPerSecond float64 `json:"blobs_per_second"`
+	BytesPerSecond float64 `json:"bytes_per_second"`
+	// The latency percentiles are those of successful submissions.
+	LatencyP50 time.Duration `json:"latency_p50"`
+	LatencyP90 time.Duration `json:"latency_p90"`
+	LatencyP99 time.Duration `json:"latency_p99"`
+	LatencyMax time.Duration `json:"latency_max"`
+	// Errors holds the distinct errors submissions failed with.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Bench submits n blobs of random data of size bytes to ns, up to the
+// configured concurrency at once, and measures how long the submissions
+// take. Submissions not started before ctx is done are left out.
+func (c *Client) Bench(ctx context.Context, ns share.Namespace, n, size int) (*BenchResult, error) {
+	if n < 1 || size < 1 {
+		return nil, fmt.Errorf("benchmark needs at least one blob of at least one byte")
+	}
+	cfg := c.Config
+	if err := checkBlobSize([][]byte{make([]byte, size)}, cfg.MaxBlobSize); err != nil {
+		return nil, err
+	}
+	policy := cfg.submitRetryPolicy()
+	submit := heightOnly(c.Blobs.Submit)
+	gasPrice := c.gasPrice(ctx)
+
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		errs      = make(map[string]bool)
+		failed    int
+	)
+	jobs := make(chan struct{})
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < cfg.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				// Random payloads keep the node from seeing duplicates.
+				payload := make([]byte, size)
+				_, err := rand.Read(payload)
+				began := time.Now()
+				if err == nil {
+					_, _, err = createAndSubmitBlobs(ctx, submit, []share.Namespace{ns}, [][]byte{payload}, cfg.BlobVersion, gasPrice, cfg.MaxBlobSize, policy, c.logger())
+				}
+				latency := time.Since(began)
+
+				mu.Lock()
+				if err != nil {
+					failed++
+					errs[err.Error()] = true
+				} else {
+					latencies = append(latencies, latency)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	started := 0
+	for ; started < n; started++ {
+		select {
+		case jobs <- struct{}{}:
+			continue
+		case <-ctx.Done():
+		}
+		break
+	}
+	close(jobs)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	result := &BenchResult{
+		Blobs:       started,
+		Size:        size,
+		Concurrency: cfg.Concurrency,
+		Succeeded:   len(latencies),
+		Failed:      failed,
+		Duration:    elapsed,
+	}
+	if started > 0 {
+		result.ErrorRate = float64(failed) / float64(started)
+	}
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		result.BlobsPerSecond = float64(len(latencies)) / seconds
+		result.BytesPerSecond = float64(len(latencies)*size) / seconds
+	}
+	slices.Sort(latencies)
+	result.LatencyP50 = percentile(latencies, 50)
+	result.LatencyP90 = percentile(latencies, 90)
+	result.LatencyP99 = percentile(latencies, 99)
+	result.LatencyMax = percentile(latencies, 100)
+	for err := range errs {
+		result.Errors = append(result.Errors, err)
+	}
+	slices.Sort(result.Errors)
+	return result, ctx.Err()
+}
+
+// percentile returns the p-th percentile of the sorted durations, by the
+// nearest rank.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
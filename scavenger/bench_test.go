@@ -0,0 +1,121 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+)
+
+// flakyBlobAPI fails every other submission.
+type flakyBlobAPI struct {
+	*FakeBlobAPI
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (a *flakyBlobAPI) Submit(ctx context.Context, blobs []*blob.Blob, gasPrice float64) (uint64, error) {
+	a.mu.Lock()
+	a.calls++
+	fail := a.calls%2 == 0
+	a.mu.Unlock()
+	if fail {
+		return 0, errors.New("insufficient fee")
+	}
+	return a.FakeBlobAPI.Submit(ctx, blobs, gasPrice)
+}
+
+func TestBench(t *testing.T) {
+	cfg := testConfig()
+	cfg.Concurrency = 3
+	c, api, completer := newTestClient(cfg)
+	result, err := c.Bench(context.Background(), testNS(t, testNamespace), 10, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Blobs != 10 || result.Succeeded != 10 || result.Failed != 0 || result.ErrorRate != 0 {
+		t.Errorf("result = %+v, want all 10 blobs submitted", result)
+	}
+	if fakeHeight(api) != 10 || len(completer.prompts()) != 0 {
+		t.Errorf("%d blocks and %d completions, want 10 blocks and no completions", fakeHeight(api), len(completer.prompts()))
+	}
+	if result.Size != 100 || result.Concurrency != 3 {
+		t.Errorf("result is for %d byte blobs at concurrency %d, want the requested ones", result.Size, result.Concurrency)
+	}
+	if result.BlobsPerSecond <= 0 || math.Abs(result.BytesPerSecond-result.BlobsPerSecond*100) > 1e-6*result.BytesPerSecond {
+		t.Errorf("throughput = %v blobs/s and %v bytes/s, want them to agree", result.BlobsPerSecond, result.BytesPerSecond)
+	}
+	if !(result.LatencyP50 <= result.LatencyP90 && result.LatencyP90 <= result.LatencyP99 && result.LatencyP99 <= result.LatencyMax) {
+		t.Errorf("latencies %v, %v, %v and %v aren't ordered", result.LatencyP50, result.LatencyP90, result.LatencyP99, result.LatencyMax)
+	}
+}
+
+func TestBenchErrors(t *testing.T) {
+	cfg := testConfig()
+	cfg.Concurrency = 1
+	c, api, _ := newTestClient(cfg)
+	c.Blobs = &flakyBlobAPI{FakeBlobAPI: api}
+	result, err := c.Bench(context.Background(), testNS(t, testNamespace), 4, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Succeeded != 2 || result.Failed != 2 || result.ErrorRate != 0.5 {
+		t.Errorf("result = %+v, want half of the submissions failed", result)
+	}
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "insufficient fee") {
+		t.Errorf("errors = %q, want the distinct error once", result.Errors)
+	}
+}
+
+func TestBenchCanceled(t *testing.T) {
+	c, api, _ := newTestClient(testConfig())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := c.Bench(ctx, testNS(t, testNamespace), 1000, 10)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want the cancellation", err)
+	}
+	if result.Blobs >= 1000 || uint64(result.Succeeded) != fakeHeight(api) {
+		t.Errorf("result = %+v, want the submissions stopped", result)
+	}
+}
+
+func TestBenchInvalid(t *testing.T) {
+	c, _, _ := newTestClient(testConfig())
+	for _, args := range [][2]int{{0, 10}, {1, 0}, {1, DefaultMaxBlobSize + 1}} {
+		if _, err := c.Bench(context.Background(), testNS(t, testNamespace), args[0], args[1]); err == nil {
+			t.Errorf("benchmark of %d blobs of %d bytes was run", args[0], args[1])
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 10; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, time.Millisecond},
+		{50, 5 * time.Millisecond},
+		{90, 9 * time.Millisecond},
+		{99, 10 * time.Millisecond},
+		{100, 10 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("p%v = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if percentile(nil, 50) != 0 {
+		t.Error("percentile of nothing isn't 0")
+	}
+}
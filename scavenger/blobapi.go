@@ -0,0 +1,103 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	sdkmath "cosmossdk.io/math"
+	nodeclient "github.com/celestiaorg/celestia-openrpc"
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+	"github.com/celestiaorg/celestia-openrpc/types/state"
+)
+
+// BlobAPI is the part of the node's blob API the client uses. It lets the
+// node be replaced, for example by a FakeBlobAPI in tests.
+type BlobAPI interface {
+	Submit(ctx context.Context, blobs []*blob.Blob, gasPrice float64) (uint64, error)
+	Get(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (*blob.Blob, error)
+	GetAll(ctx context.Context, height uint64, namespaces []share.Namespace) ([]*blob.Blob, error)
+	GetProof(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (*blob.Proof, error)
+	Included(ctx context.Context, height uint64, ns share.Namespace, proof *blob.Proof, commitment blob.Commitment) (bool, error)
+}
+
+// SubmitResult is what the network reports about a submission.
+type SubmitResult struct {
+	Height uint64
+	TxHash string
+}
+
+// TxSubmitter is implemented by blob APIs that can report the hash of the
+// transaction a submission was made in, which BlobAPI.Submit doesn't.
+type TxSubmitter interface {
+	SubmitWithResult(ctx context.Context, blobs []*blob.Blob, gasPrice float64) (*SubmitResult, error)
+}
+
+// MaxMemoLength is the longest memo a transaction can carry, in bytes.
+const MaxMemoLength = 256
+
+// ErrMemoUnsupported is returned when submitting with a memo through a blob
+// API that can't attach one, such as that of current node versions.
+var ErrMemoUnsupported = errors.New("transaction memos are not supported by this node version")
+
+// MemoSubmitter is implemented by blob APIs that can attach a memo to the
+// submission's transaction. Neither blob.Submit nor the state API of the
+// node take one yet.
+type MemoSubmitter interface {
+	SubmitWithMemo(ctx context.Context, blobs []*blob.Blob, gasPrice float64, memo string) (*SubmitResult, error)
+}
+
+// NodeBlobAPI returns the blob API of a node client as a BlobAPI. It is
+// also a TxSubmitter.
+func NodeBlobAPI(node *nodeclient.Client) BlobAPI {
+	return nodeBlobAPI{&node.Blob, &node.State}
+}
+
+// nodeBlobAPI adapts blob.API, a struct of RPC functions, to BlobAPI.
+type nodeBlobAPI struct {
+	api   *blob.API
+	state *state.API
+}
+
+func (n nodeBlobAPI) Submit(ctx context.Context, blobs []*blob.Blob, gasPrice float64) (uint64, error) {
+	return n.api.Submit(ctx, blobs, gasPrice)
+}
+
+func (n nodeBlobAPI) Get(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (*blob.Blob, error) {
+	return n.api.Get(ctx, height, ns, commitment)
+}
+
+func (n nodeBlobAPI) GetAll(ctx context.Context, height uint64, namespaces []share.Namespace) ([]*blob.Blob, error) {
+	return n.api.GetAll(ctx, height, namespaces)
+}
+
+func (n nodeBlobAPI) GetProof(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (*blob.Proof, error) {
+	return n.api.GetProof(ctx, height, ns, commitment)
+}
+
+func (n nodeBlobAPI) Included(ctx context.Context, height uint64, ns share.Namespace, proof *blob.Proof, commitment blob.Commitment) (bool, error) {
+	return n.api.Included(ctx, height, ns, proof, commitment)
+}
+
+// SubmitWithResult submits blobs in a PayForBlobs transaction through the
+// state API, which reports the transaction. The gas limit and fee are
+// estimated like EstimateFee does.
+func (n nodeBlobAPI) SubmitWithResult(ctx context.Context, blobs []*blob.Blob, gasPrice float64) (*SubmitResult, error) {
+	sizes := make([]int, len(blobs))
+	for i, b := range blobs {
+		sizes[i] = len(b.Data)
+	}
+	est := EstimateFee(sizes, gasPrice)
+	fee := sdkmath.NewInt(int64(math.Ceil(est.Fee)))
+
+	resp, err := n.state.SubmitPayForBlob(ctx, fee, est.Gas, blobs)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code != 0 {
+		return nil, fmt.Errorf("transaction %s failed with code %d: %s", resp.TxHash, resp.Code, resp.RawLog)
+	}
+	return &SubmitResult{Height: uint64(resp.Height), TxHash: resp.TxHash}, nil
+}
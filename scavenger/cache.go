@@ -0,0 +1,144 @@
+package scavenger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CacheEntry is a cached response to a prompt.
+type CacheEntry struct {
+	Model     string    `json:"model"`
+	Response  string    `json:"response"`
+	Usage     Usage     `json:"usage"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Cache stores responses on disk, one JSON file per prompt, so rerunning
+// a prompt costs neither fees nor completions.
+type Cache struct {
+	Dir string
+	// TTL is how long entries stay valid. Zero means forever.
+	TTL time.Duration
+}
+
+// NewCache creates a cache in dir. An empty dir selects the user's cache
+// directory.
+func NewCache(dir string, ttl time.Duration) (*Cache, error) {
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("error finding cache directory: %w", err)
+		}
+		dir = filepath.Join(base, "prompt-scavenger")
+	}
+	return &Cache{Dir: dir, TTL: ttl}, nil
+}
+
+// CacheKey derives the cache key of a prompt from everything that
+// influences the response: where the request goes, the model, the system
+// prompt, the sampling parameters and the prompt itself.
+func CacheKey(cfg *Config, prompt string) string {
+	fields := []string{
+		cfg.Provider,
+		cfg.OpenAIBaseURL,
+		cfg.AzureDeployment,
+		cfg.Model,
+		cfg.SystemPrompt,
+		optionalField(cfg.Temperature),
+		optionalField(cfg.TopP),
+		optionalField(cfg.MaxTokens),
+		optionalField(cfg.Seed),
+		prompt,
+	}
+	h := sha256.New()
+	for _, s := range fields {
+		// The lengths keep the fields from running into each other.
+		fmt.Fprintf(h, "%d:%s", len(s), s)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// optionalField formats an optional parameter for CacheKey. Unset ones are
+// empty, which no set one is.
+func optionalField[T any](v *T) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(*v)
+}
+
+// path returns the file the entry for key is stored in.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
+
+// Get returns the entry for key. Missing and expired entries are reported
+// as not found.
+func (c *Cache) Get(key string) (*CacheEntry, bool, error) {
+	data, err := os.ReadFile(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading cache entry: %w", err)
+	}
+
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false, fmt.Errorf("error decoding cache entry: %w", err)
+	}
+	if c.TTL > 0 && time.Since(entry.CreatedAt) > c.TTL {
+		// Expired entries are removed so they don't pile up.
+		os.Remove(c.path(key))
+		return nil, false, nil
+	}
+	return &entry, true, nil
+}
+
+// Put stores entry under key, stamped with the current time.
+func (c *Cache) Put(key string, entry *CacheEntry) error {
+	entry.CreatedAt = time.Now()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error encoding cache entry: %w", err)
+	}
+	if err := os.MkdirAll(c.Dir, 0o700); err != nil {
+		return fmt.Errorf("error creating cache directory: %w", err)
+	}
+	if err := os.WriteFile(c.path(key), data, 0o600); err != nil {
+		return fmt.Errorf("error writing cache entry: %w", err)
+	}
+	return nil
+}
+
+// Clear removes all entries. Dir may be any directory the user chose, so
+// only files named like entries are removed, and Dir itself is kept.
+func (c *Cache) Clear() error {
+	paths, err := filepath.Glob(filepath.Join(c.Dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("error clearing cache: %w", err)
+	}
+	for _, path := range paths {
+		if !isCacheKey(strings.TrimSuffix(filepath.Base(path), ".json")) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error clearing cache: %w", err)
+		}
+	}
+	return nil
+}
+
+// isCacheKey reports whether name is a key made by CacheKey, a hex
+// encoded SHA-256 hash.
+func isCacheKey(name string) bool {
+	b, err := hex.DecodeString(name)
+	return err == nil && len(b) == sha256.Size
+}
@@ -0,0 +1,136 @@
+package scavenger
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCachePutGet(t *testing.T) {
+	cache := &Cache{Dir: t.TempDir()}
+	if _, ok, err := cache.Get("missing"); ok || err != nil {
+		t.Fatalf("missing entry found (error %v)", err)
+	}
+	entry := &CacheEntry{Model: "m", Response: "cached answer", Usage: Usage{TotalTokens: 3}}
+	if err := cache.Put("key", entry); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := cache.Get("key")
+	if err != nil || !ok {
+		t.Fatalf("entry not found (error %v)", err)
+	}
+	if got.Response != "cached answer" || got.Model != "m" || got.Usage.TotalTokens != 3 || got.CreatedAt.IsZero() {
+		t.Errorf("got %+v, want the stored entry with its time", got)
+	}
+
+}
+
+func TestCacheClear(t *testing.T) {
+	dir := t.TempDir()
+	cache := &Cache{Dir: dir}
+	key := CacheKey(testConfig(), "hi")
+	if err := cache.Put(key, &CacheEntry{Response: "cached answer"}); err != nil {
+		t.Fatal(err)
+	}
+	// The cache directory may be shared with files of anything else.
+	for _, name := range []string{"notes.txt", "config.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("keep me"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cache.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := cache.Get(key); ok {
+		t.Error("entry found after clearing the cache")
+	}
+	for _, name := range []string{"notes.txt", "config.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("clearing the cache removed %s: %v", name, err)
+		}
+	}
+
+	// Clearing a cache that was never written to is fine.
+	if err := (&Cache{Dir: filepath.Join(dir, "missing")}).Clear(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	cache := &Cache{Dir: t.TempDir(), TTL: time.Hour}
+	data, err := json.Marshal(CacheEntry{Response: "old", CreatedAt: time.Now().Add(-2 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cache.path("old"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := cache.Get("old"); ok || err != nil {
+		t.Fatalf("expired entry found (error %v)", err)
+	}
+	if _, err := os.Stat(cache.path("old")); !os.IsNotExist(err) {
+		t.Error("expired entry wasn't removed")
+	}
+}
+
+func TestCacheCorrupt(t *testing.T) {
+	cache := &Cache{Dir: t.TempDir()}
+	if err := os.WriteFile(cache.path("bad"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := cache.Get("bad"); err == nil {
+		t.Error("reading a corrupt entry succeeded")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	base := DefaultConfig()
+	key := CacheKey(base, "hi")
+	if CacheKey(DefaultConfig(), "hi") != key {
+		t.Error("same prompt and config give different keys")
+	}
+	changes := map[string]func(*Config){
+		"model":    func(c *Config) { c.Model = "other" },
+		"system":   func(c *Config) { c.SystemPrompt = "be brief" },
+		"provider": func(c *Config) { c.Provider = ProviderAzure },
+		"base URL": func(c *Config) { c.OpenAIBaseURL = "http://localhost" },
+	}
+	for name, change := range changes {
+		cfg := DefaultConfig()
+		change(cfg)
+		if CacheKey(cfg, "hi") == key {
+			t.Errorf("changing the %s keeps the key", name)
+		}
+	}
+	if CacheKey(base, "hello") == key {
+		t.Error("another prompt has the same key")
+	}
+	// Fields can't run into each other.
+	a, b := DefaultConfig(), DefaultConfig()
+	a.SystemPrompt, b.SystemPrompt = "ab", "a"
+	if CacheKey(a, "c") == CacheKey(b, "bc") {
+		t.Error("moving text between the system prompt and the prompt keeps the key")
+	}
+}
+
+func TestRunCached(t *testing.T) {
+	c, api, completer := newTestClient(testConfig())
+	c.Cache = &Cache{Dir: t.TempDir()}
+	first, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Cached || !second.Cached || second.Response != first.Response {
+		t.Errorf("runs cached %t and %t with responses %q and %q, want the second from the cache", first.Cached, second.Cached, first.Response, second.Response)
+	}
+	if fakeHeight(api) != 1 || len(completer.prompts()) != 1 {
+		t.Errorf("submitted %d times and asked %d times, want once each", fakeHeight(api), len(completer.prompts()))
+	}
+}
@@ -0,0 +1,148 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// hangingStream is a ChatStream sending deltas and then hanging until it
+// is closed, after which Recv reports io.EOF as if the stream had ended.
+type hangingStream struct {
+	deltas []openai.ChatCompletionStreamResponse
+	// sent is closed once all deltas were received.
+	sent chan struct{}
+
+	once   sync.Once
+	closed chan struct{}
+}
+
+func newHangingStream(deltas ...string) *hangingStream {
+	return &hangingStream{
+		deltas: streamDeltas(deltas...)[:len(deltas)],
+		sent:   make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+}
+
+func (s *hangingStream) Recv() (openai.ChatCompletionStreamResponse, error) {
+	if len(s.deltas) > 0 {
+		resp := s.deltas[0]
+		s.deltas = s.deltas[1:]
+		return resp, nil
+	}
+	close(s.sent)
+	<-s.closed
+	return openai.ChatCompletionStreamResponse{}, io.EOF
+}
+
+func (s *hangingStream) Close() error {
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+// isClosed reports whether the stream was closed.
+func (s *hangingStream) isClosed() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// hangingClient is a ChatClient streaming with stream.
+type hangingClient struct {
+	fakeChatClient
+	stream *hangingStream
+}
+
+func (c *hangingClient) CreateChatCompletionStream(context.Context, openai.ChatCompletionRequest) (ChatStream, error) {
+	return c.stream, nil
+}
+
+// cancelMidStream calls complete with a context it cancels once stream
+// sent all its deltas, and returns what complete did.
+func cancelMidStream(t *testing.T, stream *hangingStream, complete func(ctx context.Context) (string, error)) (string, error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stream.sent
+		cancel()
+	}()
+	type outcome struct {
+		answer string
+		err    error
+	}
+	done := make(chan outcome)
+	go func() {
+		answer, err := complete(ctx)
+		done <- outcome{answer, err}
+	}()
+	select {
+	case o := <-done:
+		return o.answer, o.err
+	case <-time.After(5 * time.Second):
+		t.Fatal("the completion kept waiting on the stream after it was canceled")
+		return "", nil
+	}
+}
+
+func TestStreamCanceled(t *testing.T) {
+	before := runtime.NumGoroutine()
+	cfg := DefaultConfig()
+	cfg.Stream = true
+	stream := newHangingStream("Hel", "lo")
+	var out strings.Builder
+	completer := testCompleter(t, cfg, &hangingClient{stream: stream}, &out)
+	answer, err := cancelMidStream(t, stream, func(ctx context.Context) (string, error) {
+		answer, _, err := completer.Complete(ctx, ChatMessages("", "hi"))
+		return answer, err
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want the cancellation rather than the stream's end", err)
+	}
+	if answer != "Hello" || out.String() != "Hello" {
+		t.Errorf("answer = %q and streamed %q, want the partial text for both", answer, out.String())
+	}
+	if !strings.Contains(err.Error(), "after 5 bytes") {
+		t.Errorf("error %q doesn't say how much was received", err)
+	}
+	if !stream.isClosed() {
+		t.Error("the stream wasn't closed")
+	}
+
+	// Nothing is left waiting on the stream or the context.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines are running, %d were before the completion", n, before)
+	}
+}
+
+func TestConversePartialStream(t *testing.T) {
+	cfg := testConfig()
+	cfg.Stream = true
+	stream := newHangingStream("partial ", "answer")
+	c := &Client{Config: cfg, Blobs: &FakeBlobAPI{}, Completer: testCompleter(t, cfg, &hangingClient{stream: stream}, io.Discard)}
+	answer, err := cancelMidStream(t, stream, func(ctx context.Context) (string, error) {
+		answer, _, err := c.Converse(ctx, ChatMessages("", "hi"))
+		return answer, err
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want the cancellation", err)
+	}
+	if answer != "partial answer" {
+		t.Errorf("answer = %q, want the text streamed before the cancellation", answer)
+	}
+}
@@ -0,0 +1,103 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// choicesResponse is a response with a choice answering each of contents.
+func choicesResponse(contents ...string) openai.ChatCompletionResponse {
+	resp := chatResponse("")
+	resp.Choices = nil
+	for i, content := range contents {
+		resp.Choices = append(resp.Choices, openai.ChatCompletionChoice{
+			Index:   i,
+			Message: openai.ChatCompletionMessage{Role: RoleAssistant, Content: content},
+		})
+	}
+	return resp
+}
+
+func TestOpenAICompleterChoices(t *testing.T) {
+	client := &fakeChatClient{resp: choicesResponse("one", "two", "three")}
+	choices, _, err := testCompleter(t, DefaultConfig(), client, nil).CompleteChoices(context.Background(), ChatMessages("", "hi"), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(choices, ",") != "one,two,three" {
+		t.Errorf("choices = %q, want all three", choices)
+	}
+	if n := client.lastRequest(t).N; n != 3 {
+		t.Errorf("requested %d choices, want 3", n)
+	}
+}
+
+func TestOpenAICompleterChoicesBlank(t *testing.T) {
+	client := &fakeChatClient{resp: choicesResponse("one", " ", "three")}
+	choices, _, err := testCompleter(t, DefaultConfig(), client, nil).CompleteChoices(context.Background(), ChatMessages("", "hi"), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(choices, ",") != "one,three" {
+		t.Errorf("choices = %q, want the blank one left out", choices)
+	}
+
+	client.resp = choicesResponse("", "\n")
+	if _, _, err := testCompleter(t, DefaultConfig(), client, nil).CompleteChoices(context.Background(), ChatMessages("", "hi"), 2); !errors.Is(err, ErrEmptyResponse) {
+		t.Errorf("error = %v, want ErrEmptyResponse", err)
+	}
+}
+
+func TestClientRunChoices(t *testing.T) {
+	cfg := testConfig()
+	cfg.Choices = 3
+	cfg.StoreResponse = true
+	c, api, _ := newTestClient(cfg)
+	result, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Choices) != 3 || result.Response != result.Choices[0] {
+		t.Fatalf("choices = %q with response %q, want three with the first as the response", result.Choices, result.Response)
+	}
+	if len(result.ResponseCommitments) != 3 {
+		t.Fatalf("stored %d responses, want one per choice", len(result.ResponseCommitments))
+	}
+	for i, commitment := range result.ResponseCommitments {
+		envelope := fetchResponse(t, api, result.ResponseHeight, testNamespace, commitment)
+		if envelope.Response != result.Choices[i] || envelope.PromptCommitment != result.Commitment {
+			t.Errorf("stored response %d = %+v, want choice %q linked to the prompt", i, envelope, result.Choices[i])
+		}
+	}
+}
+
+// singleCompleter is a Completer that can't return several choices.
+type singleCompleter struct{ Completer }
+
+func TestAskChoicesUnsupported(t *testing.T) {
+	c, _, completer := newTestClient(testConfig())
+	c.Completer = singleCompleter{completer}
+	if _, _, err := c.AskChoices(context.Background(), "hi", 2); err == nil || !strings.Contains(err.Error(), "can't return several choices") {
+		t.Errorf("error = %v, want the provider unable to return choices", err)
+	}
+	// A single choice is just a completion.
+	if choices, _, err := c.AskChoices(context.Background(), "hi", 1); err != nil || len(choices) != 1 {
+		t.Errorf("choices = %q (error %v), want one", choices, err)
+	}
+}
+
+func TestValidateChoices(t *testing.T) {
+	cfg := testConfig()
+	cfg.Choices = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("zero choices were accepted")
+	}
+	cfg.Choices, cfg.Stream = 2, true
+	if err := cfg.Validate(); err == nil {
+		t.Error("several choices were accepted with streaming")
+	}
+}
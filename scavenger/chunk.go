@@ -0,0 +1,117 @@
+package scavenger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+)
+
+// Every chunk of a chunked prompt starts with a header made of
+// chunkMagic, a version byte, and the chunk's index and the total number
+// of chunks as big endian uint32s.
+const (
+	chunkMagic      = "PSCK"
+	chunkVersion    = 1
+	chunkHeaderSize = len(chunkMagic) + 1 + 4 + 4
+)
+
+// chunk is a decoded chunk of a prompt.
+type chunk struct {
+	index uint32
+	total uint32
+	data  []byte
+}
+
+// splitChunks splits payload into chunks of at most size bytes, each
+// prefixed with its header.
+func splitChunks(payload []byte, size int) [][]byte {
+	total := (len(payload) + size - 1) / size
+	chunks := make([][]byte, 0, total)
+	for i := 0; i < total; i++ {
+		end := min((i+1)*size, len(payload))
+		chunks = append(chunks, encodeChunk(uint32(i), uint32(total), payload[i*size:end]))
+	}
+	return chunks
+}
+
+// encodeChunk prefixes data with a chunk header.
+func encodeChunk(index, total uint32, data []byte) []byte {
+	buf := make([]byte, 0, chunkHeaderSize+len(data))
+	buf = append(buf, chunkMagic...)
+	buf = append(buf, chunkVersion)
+	buf = binary.BigEndian.AppendUint32(buf, index)
+	buf = binary.BigEndian.AppendUint32(buf, total)
+	return append(buf, data...)
+}
+
+// decodeChunk parses a chunk header from data.
+func decodeChunk(data []byte) (*chunk, error) {
+	if len(data) < chunkHeaderSize || !bytes.HasPrefix(data, []byte(chunkMagic)) {
+		return nil, fmt.Errorf("blob is not a prompt chunk")
+	}
+	if v := data[len(chunkMagic)]; v != chunkVersion {
+		return nil, fmt.Errorf("unsupported chunk version %d", v)
+	}
+
+	header := data[len(chunkMagic)+1:]
+	c := &chunk{
+		index: binary.BigEndian.Uint32(header[0:4]),
+		total: binary.BigEndian.Uint32(header[4:8]),
+		data:  data[chunkHeaderSize:],
+	}
+	if c.total == 0 || c.index >= c.total {
+		return nil, fmt.Errorf("invalid chunk header: index %d of %d", c.index, c.total)
+	}
+	return c, nil
+}
+
+// IsChunk reports whether the data of a blob is a chunk of a chunked
+// prompt. Chunks only make sense together, fetched by all their
+// commitments.
+func IsChunk(data []byte) bool {
+	_, err := decodeChunk(data)
+	return err == nil
+}
+
+// reassembleChunks decodes the given chunks, which may be in any order,
+// and concatenates their data back into the original payload. Every index
+// has to be present exactly once.
+func reassembleChunks(chunks [][]byte) ([]byte, error) {
+	// The chunk count comes from the blobs, so it is checked against the
+	// chunks we have before anything is allocated for it.
+	ordered := make([]*chunk, len(chunks))
+	for i, data := range chunks {
+		c, err := decodeChunk(data)
+		if err != nil {
+			return nil, fmt.Errorf("blob %d: %w", i, err)
+		}
+		if int64(c.total) != int64(len(chunks)) {
+			return nil, fmt.Errorf("blob %d: chunk %d is one of %d, but %d chunks were fetched", i, c.index, c.total, len(chunks))
+		}
+		if ordered[c.index] != nil {
+			return nil, fmt.Errorf("blob %d: duplicate chunk %d", i, c.index)
+		}
+		ordered[c.index] = c
+	}
+
+	var payload []byte
+	for i, c := range ordered {
+		if c == nil {
+			return nil, fmt.Errorf("missing chunk %d of %d", i, len(ordered))
+		}
+		payload = append(payload, c.data...)
+	}
+	return payload, nil
+}
+
+// CommitmentsHex returns the hex encoded commitments of blobs.
+func CommitmentsHex(blobs []*blob.Blob) []string {
+	commitments := make([]string, len(blobs))
+	for i, b := range blobs {
+		commitments[i] = hex.EncodeToString(b.Commitment)
+	}
+	return commitments
+}
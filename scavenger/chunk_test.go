@@ -0,0 +1,138 @@
+package scavenger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSplitChunks(t *testing.T) {
+	payload := []byte("0123456789")
+	chunks := splitChunks(payload, 4)
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(chunks))
+	}
+	var joined []byte
+	for i, data := range chunks {
+		if !IsChunk(data) {
+			t.Fatalf("chunk %d isn't recognized as one", i)
+		}
+		c, err := decodeChunk(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.index != uint32(i) || c.total != 3 {
+			t.Errorf("chunk %d has header %d of %d, want %d of 3", i, c.index, c.total, i)
+		}
+		joined = append(joined, c.data...)
+	}
+	if !bytes.Equal(joined, payload) || len(chunks[2]) != chunkHeaderSize+2 {
+		t.Errorf("chunks hold %q, want %q with 2 bytes in the last", joined, payload)
+	}
+}
+
+func TestDecodeChunkInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"not a chunk", []byte("hello, this is a plain prompt")},
+		{"short", []byte(chunkMagic)},
+		{"version", append([]byte(chunkMagic), 9, 0, 0, 0, 0, 0, 0, 0, 1)},
+		{"index past total", encodeChunk(2, 2, []byte("x"))},
+		{"no chunks", encodeChunk(0, 0, []byte("x"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if IsChunk(tt.data) {
+				t.Error("data is recognized as a chunk")
+			}
+		})
+	}
+}
+
+func TestSubmitPromptChunked(t *testing.T) {
+	cfg := testConfig()
+	cfg.Raw = true
+	cfg.ChunkSize = 8
+	c, api, _ := newTestClient(cfg)
+	prompt := strings.Repeat("chunk me ", 4)
+	sub, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (len(prompt) + 7) / 8; len(sub.Blobs) != want {
+		t.Fatalf("submitted %d blobs, want %d chunks", len(sub.Blobs), want)
+	}
+	// All chunks go in a single transaction.
+	if fakeHeight(api) != 1 {
+		t.Errorf("chain is at height %d, want the chunks in one block", fakeHeight(api))
+	}
+	for i, b := range sub.Blobs {
+		if !IsChunk(b.Data) {
+			t.Errorf("blob %d isn't a chunk", i)
+		}
+	}
+}
+
+func TestReassembleChunks(t *testing.T) {
+	payload := []byte("reassemble this payload")
+	chunks := splitChunks(payload, 5)
+	// Chunks may be fetched in any order.
+	chunks[0], chunks[3] = chunks[3], chunks[0]
+	got, err := reassembleChunks(chunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Errorf("reassembled %q, want %q", got, payload)
+	}
+}
+
+func TestReassembleChunksInvalid(t *testing.T) {
+	chunks := splitChunks([]byte("0123456789"), 4)
+	tests := []struct {
+		name   string
+		chunks [][]byte
+		want   string
+	}{
+		{"missing chunk", chunks[:2], "is one of 3, but 2 chunks were fetched"},
+		{"duplicate chunk", [][]byte{chunks[0], chunks[1], chunks[1]}, "duplicate chunk 1"},
+		{"not a chunk", [][]byte{chunks[0], []byte("plain"), chunks[2]}, "blob 1: blob is not a prompt chunk"},
+		// A forged header can't make the total allocate more than the
+		// chunks fetched.
+		{"huge total", [][]byte{encodeChunk(0, 1<<31, []byte("x"))}, "is one of 2147483648"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := reassembleChunks(tt.chunks)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchPromptChunked(t *testing.T) {
+	cfg := testConfig()
+	cfg.ChunkSize = 16
+	c, _, _ := newTestClient(cfg)
+	prompt := strings.Repeat("a long prompt ", 10)
+	sub, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched, err := c.FetchPrompt(context.Background(), sub.Height, sub.Namespace, sub.Commitments())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fetched.Payload) != prompt {
+		t.Errorf("fetched %q, want %q", fetched.Payload, prompt)
+	}
+
+	// A single chunk can't be fetched on its own.
+	if _, err := c.FetchPrompt(context.Background(), sub.Height, sub.Namespace, sub.Commitments()[:1]); err == nil {
+		t.Error("fetching one chunk of several succeeded")
+	}
+}
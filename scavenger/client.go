@@ -0,0 +1,873 @@
+// Package scavenger submits prompts to Celestia as blobs, fetches them
+// back and asks a model about them. It is the library behind the
+// prompt-scavenger command.
+package scavenger
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	nodeclient "github.com/celestiaorg/celestia-openrpc"
+	"github.com/celestiaorg/celestia-openrpc/types/appconsts"
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// newNodeClient creates the node client. It's a variable so it can be
+// replaced in tests.
+var newNodeClient = nodeclient.NewClient
+
+// nodeHeight returns the height of the node's chain head. It is a cheap
+// call that shows the node is reachable and accepts our token. Like
+// newNodeClient, it can be replaced in tests.
+var nodeHeight = func(ctx context.Context, node *nodeclient.Client) (uint64, error) {
+	head, err := node.Header.LocalHead(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return head.Height(), nil
+}
+
+// Client runs the steps of the prompt flow against a celestia node and a
+// chat model.
+type Client struct {
+	Config *Config
+	// Node is the node first connected to. Use CurrentNode, which follows
+	// reconnects.
+	Node *nodeclient.Client
+	// Blobs submits and fetches blobs. NewClient sets it to the node's
+	// blob API.
+	Blobs BlobAPI
+	// Completer answers prompts. If nil, one for the configured provider
+	// is created on the first call to Ask.
+	Completer Completer
+	// Moderation checks prompts before submitting them, when enabled in
+	// the config. If nil, an OpenAI client is created when needed.
+	Moderation ModerationClient
+
+	// StreamOutput receives the response as it arrives when streaming is
+	// enabled. If nil, the streamed text is discarded.
+	StreamOutput io.Writer
+	// Confirm, if set, is called with the estimated fee before every
+	// submission. Returning an error aborts the submission.
+	Confirm func(FeeEstimate) error
+	// Cache, if set, is consulted by Run before submitting a prompt.
+	Cache *Cache
+	// Journal, if set, records every submission, and payloads already
+	// submitted to a namespace are reused instead of submitted again.
+	Journal *Journal
+	// Ledger, if set, records every submitted transaction and its
+	// estimated fee.
+	Ledger *Ledger
+	// RateLimiter, if set, spaces out the requests to the model.
+	RateLimiter *RateLimiter
+	// Mirror, if set, is sent every submission again after it succeeded.
+	// NewClient sets it to the blob API of the configured mirror node.
+	Mirror BlobAPI
+	// Logger, if set, receives progress messages such as submit retries.
+	Logger *slog.Logger
+	// Progress, if set, reports the bytes submitted and fetched by each
+	// stage of a run.
+	Progress *Progress
+	// NodeAddr is the address of Node, and NodeHeight the height of its
+	// chain head when NewClient checked it.
+	NodeAddr   string
+	NodeHeight uint64
+
+	// conns are the connections to all configured nodes, Node's first.
+	// Lost connections are dialed again, so Node may be replaced.
+	conns     []*nodeConn
+	closeOnce sync.Once
+	// mirrorConn is the connection to the mirror node, and mirrorErr why
+	// there is none although one is configured.
+	mirrorConn *nodeConn
+	mirrorErr  error
+}
+
+// NewClient connects to the nodes configured in cfg and checks that they
+// serve requests. Nodes that can't be reached are left out, as long as one
+// can. With several nodes, Blobs fails over between them, and Node is the
+// first one reached. Blobs reconnects to a node it lost the connection to.
+func NewClient(ctx context.Context, cfg *Config) (*Client, error) {
+	token, err := cfg.ResolveAuthToken()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{Config: cfg}
+	var (
+		endpoints []*endpoint
+		errs      []error
+	)
+	addrs := cfg.NodeAddrs()
+	for _, addr := range addrs {
+		node, height, err := dialNode(ctx, addr, token)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot reach node at %s: %w", addr, err))
+			continue
+		}
+		if len(c.conns) == 0 {
+			c.NodeAddr, c.NodeHeight = addr, height
+		}
+		conn := &nodeConn{addr: addr, token: token, logger: c.logger, node: node}
+		c.conns = append(c.conns, conn)
+		endpoints = append(endpoints, &endpoint{addr: addr, api: reconnectingBlobAPI{conn}})
+	}
+	if len(c.conns) == 0 {
+		return nil, fmt.Errorf("Failed to create client: %w", errors.Join(errs...))
+	}
+
+	c.Node = c.conns[0].current()
+	c.Blobs = endpoints[0].api
+	if len(addrs) > 1 {
+		c.Blobs = newFailoverBlobAPI(endpoints, c.logger)
+	}
+
+	// An unreachable mirror is only fatal if it's required. Otherwise the
+	// submissions report why they weren't mirrored.
+	if addr := cfg.MirrorNode; addr != "" {
+		node, _, err := dialNode(ctx, addr, token)
+		switch {
+		case err == nil:
+			c.mirrorConn = &nodeConn{addr: addr, token: token, logger: c.logger, node: node}
+			c.Mirror = reconnectingBlobAPI{c.mirrorConn}
+		case cfg.MirrorRequired:
+			c.Close()
+			return nil, fmt.Errorf("cannot reach mirror node at %s: %w", addr, err)
+		default:
+			c.mirrorErr = fmt.Errorf("cannot reach mirror node at %s: %w", addr, err)
+		}
+	}
+	return c, nil
+}
+
+// Close closes the connections to the nodes. Only the first call does
+// anything.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		if len(c.conns) == 0 && c.Node != nil {
+			c.Node.Close()
+		}
+		for _, conn := range c.conns {
+			conn.Close()
+		}
+		if c.mirrorConn != nil {
+			c.mirrorConn.Close()
+		}
+	})
+}
+
+// CurrentNode returns the client of the first node. It is Node until the
+// connection to it is lost and dialed again.
+func (c *Client) CurrentNode() *nodeclient.Client {
+	if len(c.conns) == 0 {
+		return c.Node
+	}
+	return c.conns[0].current()
+}
+
+// Reconnect dials the first node again if err shows the connection to it
+// was lost. It reports whether it did, and fails if the node can't be
+// reached again.
+func (c *Client) Reconnect(ctx context.Context, err error) (bool, error) {
+	if len(c.conns) == 0 || !isConnectionLost(err) {
+		return false, nil
+	}
+	conn := c.conns[0]
+	if _, err := conn.reconnect(ctx, conn.current()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// discardLogger drops everything logged to it.
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// logger returns Logger, or a logger discarding everything if it isn't
+// set.
+func (c *Client) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return discardLogger
+}
+
+// Submission describes blobs submitted together in one transaction.
+type Submission struct {
+	Namespace share.Namespace
+	Height    uint64
+	// TxHash is the hash of the transaction, if the blob API reported it.
+	TxHash string
+	// Blobs are the submitted blobs. Chunked prompts have one per chunk,
+	// in chunk order.
+	Blobs []*blob.Blob
+	// MirrorHeight is the height the blobs were included at on the mirror
+	// node, if they were mirrored. Their commitments are the same.
+	MirrorHeight uint64
+}
+
+// Commitments returns the commitments of the submitted blobs.
+func (s *Submission) Commitments() []blob.Commitment {
+	commitments := make([]blob.Commitment, len(s.Blobs))
+	for i, b := range s.Blobs {
+		commitments[i] = b.Commitment
+	}
+	return commitments
+}
+
+// SubmitPrompt redacts and moderates prompt if enabled, wraps it in a
+// PromptEnvelope unless Raw is set, encodes it as configured and submits
+// it to ns as a single blob, or as several chunks if it is larger than
+// the configured chunk size.
+func (c *Client) SubmitPrompt(ctx context.Context, ns share.Namespace, prompt string) (*Submission, error) {
+	payloads, err := c.preparePrompt(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return c.submit(ctx, ns, []byte(prompt), payloads)
+}
+
+// SubmitPromptTo is like SubmitPrompt, but submits the prompt to each of
+// namespaces, all in a single transaction. The submissions are in the
+// order of namespaces and share a height. The journal is only used for a
+// single namespace.
+func (c *Client) SubmitPromptTo(ctx context.Context, namespaces []share.Namespace, prompt string) ([]*Submission, error) {
+	if len(namespaces) == 1 {
+		sub, err := c.SubmitPrompt(ctx, namespaces[0], prompt)
+		if err != nil {
+			return nil, err
+		}
+		return []*Submission{sub}, nil
+	}
+	payloads, err := c.preparePrompt(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return c.submitMulti(ctx, namespaces, payloads)
+}
+
+// preparePrompt redacts and moderates prompt and turns it into the
+// payloads to submit.
+func (c *Client) preparePrompt(ctx context.Context, prompt string) ([][]byte, error) {
+	c.Progress.Report(ProgressBuilding, 0, len(prompt))
+	prompt, err := c.redact(prompt)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.moderate(ctx, prompt); err != nil {
+		return nil, err
+	}
+	data, err := promptData(c.Config, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return encodePayloads(c.Config, data)
+}
+
+// encodePayloads encodes data as configured, and splits it into chunks if
+// it is larger than the configured chunk size.
+func encodePayloads(cfg *Config, data []byte) ([][]byte, error) {
+	payload, err := EncodePayload(cfg, data)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ChunkSize > 0 && len(payload) > cfg.ChunkSize {
+		return splitChunks(payload, cfg.ChunkSize), nil
+	}
+	return [][]byte{payload}, nil
+}
+
+// submit submits payloads as blobs, unless the journal has a record of
+// them being submitted already. The journal knows them by content, what
+// they encode before any envelope, encryption or compression, since those
+// make the payloads differ between runs, and by whether they are
+// encrypted and compressed.
+func (c *Client) submit(ctx context.Context, ns share.Namespace, content []byte, payloads [][]byte) (*Submission, error) {
+	if c.Journal == nil {
+		return c.submitNew(ctx, ns, payloads)
+	}
+
+	key := JournalKey(c.Config, ns, content)
+	record, ok, err := c.Journal.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if ok && record.Status == JournalDone {
+		sub, err := c.reuseSubmission(ctx, ns, record)
+		if err == nil {
+			c.logger().Info("Reusing earlier submission from the journal",
+				"height", sub.Height,
+				"namespace", NamespaceHex(ns),
+				"commitment", hex.EncodeToString(sub.Blobs[0].Commitment))
+			return sub, nil
+		}
+		if !IsBlobNotFound(err) {
+			return nil, err
+		}
+		c.logger().Warn("Blobs of the journal record are missing, submitting again", "height", record.Height, "namespace", NamespaceHex(ns))
+	}
+	if ok && record.Status == JournalPending {
+		// We can't tell whether the earlier submission landed, since we
+		// only learn the height once it did.
+		c.logger().Warn("An earlier submission of this payload was interrupted and may have landed, submitting again", "namespace", NamespaceHex(ns))
+	}
+
+	if err := c.Journal.Put(key, &JournalRecord{Status: JournalPending, Namespace: NamespaceHex(ns)}); err != nil {
+		return nil, err
+	}
+	sub, err := c.submitNew(ctx, ns, payloads)
+	if err != nil {
+		return nil, err
+	}
+	record = &JournalRecord{
+		Status:      JournalDone,
+		Namespace:   NamespaceHex(ns),
+		Height:      sub.Height,
+		TxHash:      sub.TxHash,
+		Commitments: CommitmentsHex(sub.Blobs),
+	}
+	if err := c.Journal.Put(key, record); err != nil {
+		c.logger().Warn("Failed to record submission in the journal", "error", err)
+	}
+	return sub, nil
+}
+
+// reuseSubmission fetches the blobs of a journal record, checking that
+// the submission it records exists.
+func (c *Client) reuseSubmission(ctx context.Context, ns share.Namespace, record *JournalRecord) (*Submission, error) {
+	if len(record.Commitments) == 0 {
+		return nil, fmt.Errorf("journal record has no commitments")
+	}
+	commitments := make([]blob.Commitment, len(record.Commitments))
+	for i, commitmentHex := range record.Commitments {
+		commitment, err := hex.DecodeString(commitmentHex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commitment in journal record: %w", err)
+		}
+		commitments[i] = commitment
+	}
+	blobs, err := fetchBlobs(ctx, c.Blobs, record.Height, ns, commitments, c.Config.FetchConcurrency, false)
+	if err != nil {
+		return nil, err
+	}
+	return &Submission{Namespace: ns, Height: record.Height, TxHash: record.TxHash, Blobs: blobs}, nil
+}
+
+// submitNew confirms the fee and checks the balance if needed, and submits
+// payloads as blobs.
+func (c *Client) submitNew(ctx context.Context, ns share.Namespace, payloads [][]byte) (*Submission, error) {
+	subs, err := c.submitMulti(ctx, []share.Namespace{ns}, payloads)
+	if err != nil {
+		return nil, err
+	}
+	return subs[0], nil
+}
+
+// submitMulti is like submitNew, but submits payloads to each of
+// namespaces in one transaction, returning a submission per namespace.
+func (c *Client) submitMulti(ctx context.Context, namespaces []share.Namespace, payloads [][]byte) ([]*Submission, error) {
+	// We'd rather fail than drop a memo the user asked for.
+	memoSubmitter, ok := c.Blobs.(MemoSubmitter)
+	if c.Config.Memo != "" && !ok {
+		return nil, ErrMemoUnsupported
+	}
+	// Oversized payloads are rejected before asking to confirm the fee.
+	all := repeatPayloads(payloads, len(namespaces))
+	if err := checkBlobSize(all, c.Config.MaxBlobSize); err != nil {
+		return nil, err
+	}
+	sizes := make([]int, len(all))
+	for i, payload := range all {
+		sizes[i] = len(payload)
+	}
+	gasPrice := c.gasPrice(ctx)
+	est := EstimateFee(sizes, gasPrice)
+	total := 0
+	for _, size := range sizes {
+		total += size
+	}
+	if err := c.checkSizeGuard(total, est); err != nil {
+		return nil, err
+	}
+
+	// Submit only fails for a lack of funds after doing all the work, so
+	// we can check up front.
+	if c.Config.CheckBalance {
+		if err := c.checkBalance(ctx, est); err != nil {
+			return nil, err
+		}
+	}
+	if c.Confirm != nil {
+		start := time.Now()
+		err := c.Confirm(est)
+		addConfirmTime(ctx, time.Since(start))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Only the state API reports the transaction hash, so we go through it
+	// if asked to.
+	submit := heightOnly(c.Blobs.Submit)
+	if c.Config.TxHash {
+		txSubmitter, ok := c.Blobs.(TxSubmitter)
+		if !ok {
+			return nil, fmt.Errorf("the blob API doesn't report transaction hashes")
+		}
+		submit = txSubmitter.SubmitWithResult
+	}
+	if memo := c.Config.Memo; memo != "" {
+		submit = func(ctx context.Context, blobs []*blob.Blob, gasPrice float64) (*SubmitResult, error) {
+			return memoSubmitter.SubmitWithMemo(ctx, blobs, gasPrice, memo)
+		}
+	}
+
+	c.Progress.Report(ProgressSubmitting, 0, total)
+	blobs, result, err := createAndSubmitBlobs(ctx, submit, namespaces, payloads, c.Config.BlobVersion, gasPrice, c.Config.MaxBlobSize, c.Config.submitRetryPolicy(), c.logger())
+	if err != nil {
+		return nil, err
+	}
+	c.Progress.Report(ProgressSubmitting, total, total)
+
+	explorer, ok := c.Config.ExplorerBlockURL(result.Height)
+	if !ok {
+		explorer = "no explorer configured"
+	}
+	txHash := result.TxHash
+	if txHash == "" {
+		txHash = "not reported by blob.Submit, use -tx-hash"
+	}
+	c.recordLedger(namespaces, blobs, result, total, est, false)
+
+	subs := make([]*Submission, len(namespaces))
+	for i, ns := range namespaces {
+		nsBlobs := blobs[i*len(payloads) : (i+1)*len(payloads)]
+		subs[i] = &Submission{Namespace: ns, Height: result.Height, TxHash: result.TxHash, Blobs: nsBlobs}
+		c.logger().Info("Blob submitted successfully",
+			"height", result.Height,
+			"namespace", NamespaceHex(ns),
+			"commitment", hex.EncodeToString(nsBlobs[0].Commitment),
+			"tx_hash", txHash,
+			"explorer", explorer)
+	}
+
+	mirrorHeight, err := c.mirror(ctx, namespaces, payloads, gasPrice, total, est)
+	if err != nil {
+		if c.Config.MirrorRequired {
+			return nil, fmt.Errorf("blobs were submitted at height %d, but not mirrored: %w", result.Height, err)
+		}
+		c.logger().Warn("Failed to mirror blobs", "height", result.Height, "error", err)
+	}
+	for _, sub := range subs {
+		sub.MirrorHeight = mirrorHeight
+	}
+	return subs, nil
+}
+
+// recordLedger adds the submitted transaction to the ledger, if there is
+// one. The blobs are paid for by now, so failing to record them is only
+// warned about.
+func (c *Client) recordLedger(namespaces []share.Namespace, blobs []*blob.Blob, result *SubmitResult, size int, est FeeEstimate, mirror bool) {
+	if c.Ledger == nil {
+		return
+	}
+	record := &LedgerRecord{
+		Time:        time.Now().UTC(),
+		Height:      result.Height,
+		TxHash:      result.TxHash,
+		Commitments: CommitmentsHex(blobs),
+		Size:        size,
+		GasPrice:    est.GasPrice,
+		Fee:         est.Fee,
+		Mirror:      mirror,
+	}
+	for _, ns := range namespaces {
+		record.Namespaces = append(record.Namespaces, NamespaceHex(ns))
+	}
+	if err := c.Ledger.Append(record); err != nil {
+		c.logger().Warn("Failed to record submission in the ledger", "ledger", c.Ledger.Path, "error", err)
+	}
+}
+
+// mirror submits payloads to namespaces on the mirror node, as they were
+// submitted to the node, and returns the height they were included at.
+// Without a mirror node, nothing is submitted.
+func (c *Client) mirror(ctx context.Context, namespaces []share.Namespace, payloads [][]byte, gasPrice float64, size int, est FeeEstimate) (uint64, error) {
+	if c.mirrorErr != nil {
+		return 0, c.mirrorErr
+	}
+	if c.Mirror == nil {
+		return 0, nil
+	}
+	blobs, result, err := createAndSubmitBlobs(ctx, heightOnly(c.Mirror.Submit), namespaces, payloads, c.Config.BlobVersion, gasPrice, c.Config.MaxBlobSize, c.Config.submitRetryPolicy(), c.logger())
+	if err != nil {
+		return 0, err
+	}
+	c.recordLedger(namespaces, blobs, result, size, est, true)
+	c.logger().Info("Blobs mirrored",
+		"mirror", c.Config.MirrorNode,
+		"height", result.Height,
+		"commitment", hex.EncodeToString(blobs[0].Commitment))
+	return result.Height, nil
+}
+
+// repeatPayloads returns payloads n times over, as submitted to n
+// namespaces.
+func repeatPayloads(payloads [][]byte, n int) [][]byte {
+	all := make([][]byte, 0, n*len(payloads))
+	for i := 0; i < n; i++ {
+		all = append(all, payloads...)
+	}
+	return all
+}
+
+// FetchedPrompt is a prompt fetched back from the network.
+type FetchedPrompt struct {
+	Namespace share.Namespace
+	Height    uint64
+	// Blobs are the fetched blobs, in the order of the commitments they
+	// were fetched by.
+	Blobs []*blob.Blob
+	// Payload is the decoded prompt, or the bytes of a file submitted
+	// with SubmitFile.
+	Payload []byte
+	// ContentType is the content type of a file. It is empty for prompts.
+	ContentType string
+	// Prompts are the prompts of a pack submitted with SubmitPack, in
+	// which case Payload holds them one per line.
+	Prompts []string
+	// Metadata is set for prompts submitted in a PromptEnvelope.
+	Metadata *PromptMetadata
+	// Signer is the hex encoded public key that signed the prompt, set
+	// when the signature was verified.
+	Signer string
+}
+
+// FetchPrompt fetches the prompt with the given commitments at height. A
+// single commitment is a plain blob, several are the chunks of one prompt.
+func (c *Client) FetchPrompt(
+	ctx context.Context,
+	height uint64,
+	ns share.Namespace,
+	commitments []blob.Commitment,
+) (*FetchedPrompt, error) {
+	blobs, err := fetchBlobs(ctx, c.Blobs, height, ns, commitments, c.Config.FetchConcurrency, c.Config.FetchKeepGoing)
+	if err != nil {
+		return nil, err
+	}
+	fetched := &FetchedPrompt{Namespace: ns, Height: height, Blobs: blobs}
+	data := make([][]byte, len(blobs))
+	for i, b := range blobs {
+		data[i] = b.Data
+	}
+
+	// A single chunk needs the others too, which reassembly reports.
+	payload := data[0]
+	if len(data) > 1 || IsChunk(payload) {
+		payload, err = reassembleChunks(data)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to reassemble chunked prompt: %w", err)
+		}
+	}
+	payload, err = DecodePayload(c.Config, payload)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode fetched blob: %w", err)
+	}
+	contentType, file, isFile, err := decodeFile(payload)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode fetched file: %w", err)
+	}
+	prompts, isPack, err := DecodePack(payload)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode fetched prompt pack: %w", err)
+	}
+	// Files and packs aren't put into envelopes, so they can't be signed.
+	if (isFile || isPack) && c.Config.VerifySignature {
+		return nil, ErrUnsigned
+	}
+	switch {
+	case isFile:
+		fetched.ContentType, payload = contentType, file
+	case isPack:
+		fetched.Prompts, payload = prompts, []byte(strings.Join(prompts, "\n"))
+	default:
+		if c.Config.VerifySignature {
+			signer, err := VerifyPrompt(payload)
+			if err != nil {
+				return nil, err
+			}
+			fetched.Signer = hex.EncodeToString(signer)
+		}
+		payload, fetched.Metadata, err = DecodePrompt(payload)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode fetched prompt: %w", err)
+		}
+	}
+	fetched.Payload = payload
+	return fetched, nil
+}
+
+// VerifyBlobs checks that the fetched blobs are the submitted ones.
+func (c *Client) VerifyBlobs(sub *Submission, fetched *FetchedPrompt) error {
+	if len(fetched.Blobs) != len(sub.Blobs) {
+		return fmt.Errorf("submitted %d blobs, fetched %d", len(sub.Blobs), len(fetched.Blobs))
+	}
+	for i, submitted := range sub.Blobs {
+		if err := VerifyBlob(sub.Namespace, submitted, fetched.Blobs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyInclusion checks the inclusion proofs of all submitted blobs.
+func (c *Client) VerifyInclusion(ctx context.Context, sub *Submission) error {
+	for _, b := range sub.Blobs {
+		if err := VerifyInclusion(ctx, c.Blobs, sub.Height, sub.Namespace, b.Commitment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ask sends prompt to the configured model and returns its response and
+// the tokens it used.
+func (c *Client) Ask(ctx context.Context, prompt string) (string, Usage, error) {
+	return c.Converse(ctx, ChatMessages(c.Config.SystemPrompt, prompt))
+}
+
+// Converse is like Ask, but sends a whole conversation. If a streamed
+// response is cut off because ctx is done, the text received so far is
+// returned along with the error.
+func (c *Client) Converse(ctx context.Context, messages []Message) (string, Usage, error) {
+	completer, err := c.completer()
+	if err != nil {
+		return "", Usage{}, err
+	}
+	c.logRequest(messages, 1)
+	if err := c.waitRateLimit(ctx); err != nil {
+		return "", Usage{}, err
+	}
+	completionCtx, cancel := c.completionContext(ctx)
+	defer cancel()
+	answer, usage, err := completer.Complete(completionCtx, messages)
+	if err != nil {
+		// An empty response still used up tokens.
+		if errors.Is(err, ErrEmptyResponse) {
+			c.logUsage(usage)
+		}
+		return answer, Usage{}, c.completionError(ctx, err)
+	}
+	c.logUsage(usage)
+	return answer, usage, nil
+}
+
+// AskChoices is like Ask, but asks for n alternative responses. The model
+// may return fewer, at least one.
+func (c *Client) AskChoices(ctx context.Context, prompt string, n int) ([]string, Usage, error) {
+	if n <= 1 {
+		answer, usage, err := c.Ask(ctx, prompt)
+		if err != nil {
+			return nil, Usage{}, err
+		}
+		return []string{answer}, usage, nil
+	}
+	completer, err := c.completer()
+	if err != nil {
+		return nil, Usage{}, err
+	}
+	choicesCompleter, ok := completer.(ChoicesCompleter)
+	if !ok {
+		return nil, Usage{}, fmt.Errorf("provider %s can't return several choices", c.Config.Provider)
+	}
+	messages := ChatMessages(c.Config.SystemPrompt, prompt)
+	c.logRequest(messages, n)
+	if err := c.waitRateLimit(ctx); err != nil {
+		return nil, Usage{}, err
+	}
+	completionCtx, cancel := c.completionContext(ctx)
+	defer cancel()
+	choices, usage, err := choicesCompleter.CompleteChoices(completionCtx, messages, n)
+	if err != nil {
+		return nil, Usage{}, c.completionError(ctx, err)
+	}
+	if len(choices) < n {
+		c.logger().Warn("Model returned fewer choices than requested", "requested", n, "returned", len(choices))
+	}
+	c.logUsage(usage)
+	return choices, usage, nil
+}
+
+// completionContext returns the context for a completion, bounded by
+// OpenAITimeout if set.
+func (c *Client) completionContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.Config.OpenAITimeout > 0 {
+		return context.WithTimeout(ctx, c.Config.OpenAITimeout)
+	}
+	return ctx, func() {}
+}
+
+// waitRateLimit waits for RateLimiter to let a request to the model
+// through. The wait doesn't count towards OpenAITimeout.
+func (c *Client) waitRateLimit(ctx context.Context) error {
+	if c.RateLimiter == nil {
+		return nil
+	}
+	start := time.Now()
+	if err := c.RateLimiter.Wait(ctx); err != nil {
+		return fmt.Errorf("waiting for the rate limit: %w", err)
+	}
+	if waited := time.Since(start); waited >= time.Second {
+		c.logger().Debug("Waited for the rate limit", "waited", waited.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// completionError tells a completion that ran out of OpenAITimeout apart
+// from the run running out of time, in which case ctx, the context the
+// completion's was derived from, is done too.
+func (c *Client) completionError(ctx context.Context, err error) error {
+	if c.Config.OpenAITimeout > 0 && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("model didn't respond within the OpenAI timeout of %s: %w", c.Config.OpenAITimeout, err)
+	}
+	return err
+}
+
+// completer returns Completer, creating one for the configured provider
+// if it isn't set.
+func (c *Client) completer() (Completer, error) {
+	if c.Completer == nil {
+		completer, err := NewCompleter(c.Config, c.StreamOutput)
+		if err != nil {
+			return nil, err
+		}
+		c.Completer = completer
+	}
+	return c.Completer, nil
+}
+
+// logRequest logs the parameters a completion is asked for with, at debug
+// level. The messages themselves are left out, only their number is
+// logged.
+func (c *Client) logRequest(messages []Message, n int) {
+	cfg := c.Config
+	attrs := []any{
+		"provider", cfg.Provider,
+		"model", cfg.Model,
+		"messages", len(messages),
+		"choices", n,
+		"stream", cfg.Stream,
+	}
+	if cfg.Temperature != nil {
+		attrs = append(attrs, "temperature", *cfg.Temperature)
+	}
+	if cfg.MaxTokens != nil {
+		attrs = append(attrs, "max_tokens", *cfg.MaxTokens)
+	}
+	if cfg.TopP != nil {
+		attrs = append(attrs, "top_p", *cfg.TopP)
+	}
+	if cfg.Seed != nil {
+		attrs = append(attrs, "seed", *cfg.Seed)
+	}
+	if cfg.SchemaFile != "" {
+		attrs = append(attrs, "schema_file", cfg.SchemaFile)
+	}
+	c.logger().Debug("Asking model", attrs...)
+}
+
+// logUsage logs the tokens a completion used and their cost.
+func (c *Client) logUsage(usage Usage) {
+	attrs := []any{
+		"model", c.Config.Model,
+		"prompt_tokens", usage.PromptTokens,
+		"completion_tokens", usage.CompletionTokens,
+		"total_tokens", usage.TotalTokens,
+	}
+	if cost, ok := c.Config.EstimateCost(usage); ok {
+		attrs = append(attrs, "cost_usd", cost)
+	}
+	if usage.SystemFingerprint != "" {
+		attrs = append(attrs, "system_fingerprint", usage.SystemFingerprint)
+	}
+	c.logger().Info("Tokens used", attrs...)
+}
+
+// CreateAndSubmitBlob creates a new blob with payload and submits it to
+// ns, retrying transient failures according to policy.
+func CreateAndSubmitBlob(
+	ctx context.Context,
+	api BlobAPI,
+	ns share.Namespace,
+	payload []byte,
+	gasPrice float64,
+	policy RetryPolicy,
+) (*blob.Blob, uint64, error) {
+	createdBlobs, result, err := createAndSubmitBlobs(ctx, heightOnly(api.Submit), []share.Namespace{ns}, [][]byte{payload}, appconsts.ShareVersionZero, gasPrice, DefaultMaxBlobSize, policy, discardLogger)
+	if err != nil {
+		return nil, 0, err
+	}
+	return createdBlobs[0], result.Height, nil
+}
+
+// createAndSubmitBlobs creates a blob of version for each payload in each
+// of namespaces and submits them all to the network in a single transaction,
+// so they share a height. The blobs are returned grouped by namespace.
+// Payloads larger than maxSize together are rejected up front.
+func createAndSubmitBlobs(
+	ctx context.Context,
+	submit submitFunc,
+	namespaces []share.Namespace,
+	payloads [][]byte,
+	version uint8,
+	gasPrice float64,
+	maxSize int,
+	policy RetryPolicy,
+	logger *slog.Logger,
+) ([]*blob.Blob, *SubmitResult, error) {
+	// The node would only reject an oversized submission after a round
+	// trip, so we check the size first.
+	if err := checkBlobSize(repeatPayloads(payloads, len(namespaces)), maxSize); err != nil {
+		return nil, nil, err
+	}
+
+	// First we can create the blobs using the namespaces and payloads.
+	createdBlobs := make([]*blob.Blob, 0, len(namespaces)*len(payloads))
+	for _, ns := range namespaces {
+		for _, payload := range payloads {
+			createdBlob, err := newBlob(version, ns, payload)
+			if err != nil {
+				return nil, nil, err
+			}
+			createdBlobs = append(createdBlobs, createdBlob)
+		}
+	}
+
+	// After we've created the blobs, we can submit them to the network.
+	// Unless set with -gas-price, this is the default gas price.
+	// Transient failures are retried with backoff.
+	result, err := submitWithRetry(ctx, submit, createdBlobs, gasPrice, policy, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to submit blob: %w", err)
+	}
+	return createdBlobs, result, nil
+}
+
+// heightOnly adapts a Submit method, which only reports the height, to a
+// submitFunc.
+func heightOnly(submit func(context.Context, []*blob.Blob, float64) (uint64, error)) submitFunc {
+	return func(ctx context.Context, blobs []*blob.Blob, gasPrice float64) (*SubmitResult, error) {
+		height, err := submit(ctx, blobs, gasPrice)
+		if err != nil {
+			return nil, err
+		}
+		return &SubmitResult{Height: height}, nil
+	}
+}
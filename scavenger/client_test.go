@@ -0,0 +1,227 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	nodeclient "github.com/celestiaorg/celestia-openrpc"
+	"github.com/celestiaorg/celestia-openrpc/types/core"
+	"github.com/celestiaorg/celestia-openrpc/types/header"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// testNamespace is a valid namespace ID used throughout the tests.
+const testNamespace = "000000000000706f6e67"
+
+// testConfig returns the default config with testNamespace, retrying
+// submissions without waiting.
+func testConfig() *Config {
+	cfg := DefaultConfig()
+	cfg.Namespace = testNamespace
+	cfg.SubmitBackoff = time.Millisecond
+	return cfg
+}
+
+// testNS returns the namespace of hex, failing the test if it is invalid.
+func testNS(t *testing.T, hex string) share.Namespace {
+	t.Helper()
+	ns, err := CreateNamespaceID(hex, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ns
+}
+
+// fakeCompleter is a Completer answering every prompt with answer, or
+// with "answer to " and the prompt if answer is empty. It records the
+// conversations it is asked.
+type fakeCompleter struct {
+	answer string
+	usage  Usage
+	err    error
+	// block makes Complete wait for its context to be done.
+	block bool
+
+	mu    sync.Mutex
+	calls [][]Message
+}
+
+func (f *fakeCompleter) Complete(ctx context.Context, messages []Message) (string, Usage, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, messages)
+	f.mu.Unlock()
+	if f.block {
+		<-ctx.Done()
+		return "", Usage{}, ctx.Err()
+	}
+	if f.err != nil {
+		return "", f.usage, f.err
+	}
+	if f.answer != "" {
+		return f.answer, f.usage, nil
+	}
+	return "answer to " + messages[len(messages)-1].Content, f.usage, nil
+}
+
+// CompleteChoices answers with n numbered answers.
+func (f *fakeCompleter) CompleteChoices(ctx context.Context, messages []Message, n int) ([]string, Usage, error) {
+	answer, usage, err := f.Complete(ctx, messages)
+	if err != nil {
+		return nil, usage, err
+	}
+	choices := make([]string, n)
+	for i := range choices {
+		choices[i] = fmt.Sprintf("%d: %s", i+1, answer)
+	}
+	return choices, usage, nil
+}
+
+// prompts returns the last message of every conversation asked.
+func (f *fakeCompleter) prompts() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	prompts := make([]string, len(f.calls))
+	for i, messages := range f.calls {
+		prompts[i] = messages[len(messages)-1].Content
+	}
+	return prompts
+}
+
+// newTestClient returns a client for cfg submitting to a FakeBlobAPI and
+// asking a fakeCompleter, which it returns too.
+func newTestClient(cfg *Config) (*Client, *FakeBlobAPI, *fakeCompleter) {
+	api := &FakeBlobAPI{}
+	completer := &fakeCompleter{}
+	return &Client{Config: cfg, Blobs: api, Completer: completer}, api, completer
+}
+
+// fakeHeight returns the height of the last block of api, 0 if nothing
+// was submitted yet.
+func fakeHeight(api *FakeBlobAPI) uint64 {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return uint64(len(api.heights))
+}
+
+// testHeader returns a header at height.
+func testHeader(height uint64) *header.ExtendedHeader {
+	return &header.ExtendedHeader{Commit: &core.Commit{Height: int64(height)}}
+}
+
+// fakeNode returns a node client serving the blobs of api, whose chain
+// head is the last block of api.
+func fakeNode(api *FakeBlobAPI) *nodeclient.Client {
+	node := &nodeclient.Client{}
+	node.Blob.Submit = api.Submit
+	node.Blob.Get = api.Get
+	node.Blob.GetAll = api.GetAll
+	node.Blob.GetProof = api.GetProof
+	node.Blob.Included = api.Included
+	node.Header.LocalHead = func(context.Context) (*header.ExtendedHeader, error) {
+		return testHeader(fakeHeight(api)), nil
+	}
+	return node
+}
+
+// useFakeNodes makes NewClient connect to the nodes of apis, by address,
+// until the test ends. Other addresses refuse the connection.
+func useFakeNodes(t *testing.T, apis map[string]*FakeBlobAPI) {
+	t.Helper()
+	replaceNodeClient(t, func(_ context.Context, addr, _ string) (*nodeclient.Client, error) {
+		api, ok := apis[addr]
+		if !ok {
+			return nil, fmt.Errorf("dial %s: %w", addr, syscall.ECONNREFUSED)
+		}
+		return fakeNode(api), nil
+	})
+}
+
+// replaceNodeClient replaces newNodeClient until the test ends.
+func replaceNodeClient(t *testing.T, fn func(ctx context.Context, addr, token string) (*nodeclient.Client, error)) {
+	t.Helper()
+	old := newNodeClient
+	newNodeClient = fn
+	t.Cleanup(func() { newNodeClient = old })
+}
+
+func TestRunTimeout(t *testing.T) {
+	replaceNodeClient(t, func(ctx context.Context, _, _ string) (*nodeclient.Client, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	cfg := testConfig()
+	cfg.Timeout = 10 * time.Millisecond
+	_, err := Run(context.Background(), cfg, "hi")
+	if !errors.Is(err, ErrConnect) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want a connect error from the timeout", err)
+	}
+}
+
+func TestClientRunCanceled(t *testing.T) {
+	c, _, completer := newTestClient(testConfig())
+	completer.block = true
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.Run(ctx, "hi")
+	if !errors.Is(err, ErrCompletion) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want a completion error from the deadline", err)
+	}
+}
+
+func TestRunWithFakeNode(t *testing.T) {
+	api := &FakeBlobAPI{}
+	useFakeNodes(t, map[string]*FakeBlobAPI{DefaultNodeIP: api})
+	cfg := testConfig()
+	cfg.NoAsk = true
+	result, err := Run(context.Background(), cfg, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Height != 1 || result.FetchedPayload != "hi" {
+		t.Errorf("got height %d and payload %q, want height 1 and the prompt", result.Height, result.FetchedPayload)
+	}
+	if !strings.HasSuffix(result.Namespace, testNamespace) {
+		t.Errorf("namespace = %s, want %s", result.Namespace, testNamespace)
+	}
+}
+
+func TestNewClientHead(t *testing.T) {
+	api := &FakeBlobAPI{}
+	submitBlobs(t, api, "first")
+	submitBlobs(t, api, "second")
+	useFakeNodes(t, map[string]*FakeBlobAPI{DefaultNodeIP: api})
+	c, err := NewClient(context.Background(), testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if c.NodeAddr != DefaultNodeIP || c.NodeHeight != 2 {
+		t.Errorf("connected to %s at height %d, want %s at the head's height 2", c.NodeAddr, c.NodeHeight, DefaultNodeIP)
+	}
+}
+
+func TestNewClientHeadFails(t *testing.T) {
+	unauthorized := errors.New("401 unauthorized")
+	replaceNodeClient(t, func(context.Context, string, string) (*nodeclient.Client, error) {
+		node := fakeNode(&FakeBlobAPI{})
+		node.Header.LocalHead = func(context.Context) (*header.ExtendedHeader, error) {
+			return nil, unauthorized
+		}
+		return node, nil
+	})
+	cfg := testConfig()
+	cfg.NodeIP = "ws://wrong:26658"
+	_, err := NewClient(context.Background(), cfg)
+	if !errors.Is(err, unauthorized) {
+		t.Fatalf("error = %v, want the head query's", err)
+	}
+	if !strings.Contains(err.Error(), "cannot reach node at ws://wrong:26658") {
+		t.Errorf("error %q doesn't name the dialed address", err)
+	}
+}
@@ -0,0 +1,82 @@
+package scavenger
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/celestiaorg/celestia-openrpc/types/appconsts"
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// ErrUnsupportedBlobVersion is returned for blob versions other than
+// those in appconsts.SupportedShareVersions, the client's built-in table of
+// the versions it can construct. The node can't be asked which versions
+// the network accepts, so the table stands in for it.
+var ErrUnsupportedBlobVersion = errors.New("unsupported blob version")
+
+// checkBlobVersion fails with ErrUnsupportedBlobVersion unless blobs of
+// version can be submitted.
+func checkBlobVersion(version uint8) error {
+	if !slices.Contains(appconsts.SupportedShareVersions, version) {
+		return fmt.Errorf("%w %d, this client's built-in table of share versions only supports %v", ErrUnsupportedBlobVersion, version, appconsts.SupportedShareVersions)
+	}
+	return nil
+}
+
+// newBlob creates a blob of the given share version with payload in ns.
+func newBlob(version uint8, ns share.Namespace, payload []byte) (*blob.Blob, error) {
+	if err := checkBlobVersion(version); err != nil {
+		return nil, err
+	}
+	b, err := blob.NewBlob(version, ns, payload)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create blob: %w", err)
+	}
+	return b, nil
+}
+
+// ComputeCommitment computes the commitment of a version 0 blob with
+// payload in ns, the same way the network does, without submitting it.
+func ComputeCommitment(ns share.Namespace, payload []byte) (blob.Commitment, error) {
+	return computeCommitment(appconsts.ShareVersionZero, ns, payload)
+}
+
+// computeCommitment is like ComputeCommitment for blobs of any version.
+func computeCommitment(version uint8, ns share.Namespace, payload []byte) (blob.Commitment, error) {
+	b, err := newBlob(version, ns, payload)
+	if err != nil {
+		return nil, err
+	}
+	return b.Commitment, nil
+}
+
+// PromptCommitments computes the commitments of the blobs SubmitPrompt
+// would submit for prompt, one per chunk. Encrypted payloads and prompt
+// envelopes differ on every submission, so their commitments can't be
+// computed ahead.
+func PromptCommitments(cfg *Config, ns share.Namespace, prompt string) ([]blob.Commitment, error) {
+	if cfg.Encrypt {
+		return nil, fmt.Errorf("commitments of encrypted prompts can't be computed, they change with every submission")
+	}
+	if !cfg.Raw {
+		return nil, fmt.Errorf("commitments of prompt envelopes can't be computed, they hold the submission time, use -raw")
+	}
+	prompt, _, err := redactPrompt(cfg, prompt)
+	if err != nil {
+		return nil, err
+	}
+	payloads, err := encodePayloads(cfg, []byte(prompt))
+	if err != nil {
+		return nil, err
+	}
+	commitments := make([]blob.Commitment, len(payloads))
+	for i, payload := range payloads {
+		commitments[i], err = computeCommitment(cfg.BlobVersion, ns, payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return commitments, nil
+}
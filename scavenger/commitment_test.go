@@ -0,0 +1,106 @@
+package scavenger
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestComputeCommitment(t *testing.T) {
+	ns := testNS(t, testNamespace)
+	commitment, err := ComputeCommitment(ns, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The commitment of the blob NewBlobV0 creates for "hello" in
+	// testNamespace.
+	const want = "fe00053fef43b7af46e026cd73def402cf9860b71de560c98c1613afc8159f28"
+	if got := hex.EncodeToString(commitment); got != want {
+		t.Errorf("commitment = %s, want %s", got, want)
+	}
+	if _, err := ComputeCommitment(ns, nil); err == nil {
+		t.Error("computing the commitment of an empty blob succeeded")
+	}
+}
+
+func TestPromptCommitments(t *testing.T) {
+	cfg := testConfig()
+	cfg.Raw = true
+	cfg.ChunkSize = 4
+	ns := testNS(t, testNamespace)
+	commitments, err := PromptCommitments(cfg, ns, "a prompt of several chunks")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// They match what is submitted.
+	c, _, _ := newTestClient(cfg)
+	sub, err := c.SubmitPrompt(context.Background(), ns, "a prompt of several chunks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commitments) != len(sub.Blobs) {
+		t.Fatalf("computed %d commitments for %d blobs", len(commitments), len(sub.Blobs))
+	}
+	for i, b := range sub.Blobs {
+		if !bytes.Equal(commitments[i], b.Commitment) {
+			t.Errorf("commitment %d = %x, want the submitted blob's %x", i, commitments[i], b.Commitment)
+		}
+	}
+}
+
+func TestPromptCommitmentsUnpredictable(t *testing.T) {
+	ns := testNS(t, testNamespace)
+	if _, err := PromptCommitments(testConfig(), ns, "hi"); err == nil {
+		t.Error("computing the commitments of an envelope succeeded")
+	}
+	cfg := testConfig()
+	cfg.Raw = true
+	cfg.Encrypt = true
+	if _, err := PromptCommitments(cfg, ns, "hi"); err == nil {
+		t.Error("computing the commitments of an encrypted prompt succeeded")
+	}
+}
+
+func TestCheckBlobVersion(t *testing.T) {
+	if err := checkBlobVersion(0); err != nil {
+		t.Errorf("version 0: %v", err)
+	}
+	err := checkBlobVersion(1)
+	if !errors.Is(err, ErrUnsupportedBlobVersion) || !strings.Contains(err.Error(), "only supports [0]") {
+		t.Errorf("error = %v, want ErrUnsupportedBlobVersion listing the supported versions", err)
+	}
+	cfg := testConfig()
+	cfg.BlobVersion = 1
+	if err := cfg.Validate(); !errors.Is(err, ErrUnsupportedBlobVersion) {
+		t.Errorf("validating version 1: %v, want ErrUnsupportedBlobVersion", err)
+	}
+}
+
+func TestSubmitBlobVersion(t *testing.T) {
+	c, api, _ := newTestClient(testConfig())
+	sub, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := sub.Blobs[0].ShareVersion; v != 0 {
+		t.Errorf("submitted a blob of share version %d, want 0 by default", v)
+	}
+
+	// A client configured without validation still refuses other versions
+	// before submitting anything.
+	c.Config.BlobVersion = 1
+	c.Config.Raw = true
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi"); !errors.Is(err, ErrUnsupportedBlobVersion) {
+		t.Errorf("error = %v, want ErrUnsupportedBlobVersion", err)
+	}
+	if _, err := PromptCommitments(c.Config, testNS(t, testNamespace), "hi"); !errors.Is(err, ErrUnsupportedBlobVersion) {
+		t.Errorf("computing commitments: %v, want ErrUnsupportedBlobVersion", err)
+	}
+	if fakeHeight(api) != 1 {
+		t.Errorf("chain is at height %d, want only the version 0 blob submitted", fakeHeight(api))
+	}
+}
@@ -0,0 +1,632 @@
+package scavenger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	appns "github.com/celestiaorg/celestia-openrpc/types/namespace"
+	openai "github.com/sashabaranov/go-openai"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultNodeIP is the RPC address of a light node running locally with
+// the default configuration.
+const DefaultNodeIP = "ws://localhost:26658"
+
+// Output formats selected with -output.
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// Config holds the settings used for a run.
+//
+// Values are merged with the following precedence, highest first:
+//
+//  1. command line flags
+//  2. environment variables (PROMPT_SCAVENGER_*)
+//  3. the YAML config file
+//  4. the built-in defaults from DefaultConfig
+//
+// The OpenAI key, the encryption key and the node auth token are never read
+// from config files, so they don't end up in them. The auth token can be
+// kept in a file of its own with AuthTokenFile instead.
+type Config struct {
+	// NodeIP is the RPC address of the celestia node, or a comma separated
+	// list of addresses of nodes to fail over between.
+	NodeIP string `yaml:"node"`
+	// Network is the network the node is on, which picks the explorer
+	// linked to after a submission. ExplorerURL, if set, is used instead.
+	Network     string `yaml:"network"`
+	ExplorerURL string `yaml:"explorer_url"`
+	// AuthTokenFile is a file containing the node's JWT auth token. It is
+	// only read if no token is given directly.
+	AuthTokenFile string `yaml:"auth_token_file"`
+	// MirrorNode is the RPC address of a second node, possibly on another
+	// network, that every submission is mirrored to, with the same auth
+	// token. A failed mirror only fails the run with MirrorRequired.
+	MirrorNode     string `yaml:"mirror_node"`
+	MirrorRequired bool   `yaml:"mirror_required"`
+
+	Namespace string `yaml:"namespace"`
+	// ExtraNamespaces are further namespaces the prompt is submitted to,
+	// in the same transaction as Namespace.
+	ExtraNamespaces []string `yaml:"extra_namespaces"`
+	// NamespaceLabel, if set, replaces Namespace with the namespace
+	// derived from it by LabelNamespaceID.
+	NamespaceLabel string `yaml:"namespace_label"`
+	// NamespaceVersion selects the namespace format. Only version 0 is
+	// defined for user namespaces so far, so it is the only one accepted.
+	NamespaceVersion uint8 `yaml:"namespace_version"`
+	// PadNamespace left-pads short namespace IDs with zeros.
+	PadNamespace bool `yaml:"pad_namespace"`
+
+	// GasPrice is the gas price for blob submissions, in utia per gas
+	// unit. The default lets the node pick it. gasPriceSet records that it
+	// was given explicitly, as the default is itself a negative number.
+	GasPrice    float64 `yaml:"gas_price"`
+	gasPriceSet bool
+	// GasPriceMin replaces GasPrice with the minimum gas price times
+	// GasPriceMultiplier. The node can't report the network's minimum, so
+	// it is the app's default minimum, see Client.gasPrice.
+	GasPriceMin        bool    `yaml:"gas_price_min"`
+	GasPriceMultiplier float64 `yaml:"gas_price_multiplier"`
+	// Compress selects how the prompt is compressed before submitting it,
+	// none or gzip.
+	Compress string `yaml:"compress"`
+	// Encrypt encrypts the prompt with the key from PROMPT_SCAVENGER_KEY
+	// before submitting it.
+	Encrypt bool `yaml:"encrypt"`
+	// Raw submits prompts as they are, instead of in a PromptEnvelope
+	// carrying the submission time and model.
+	Raw bool `yaml:"raw"`
+	// Moderate checks prompts with OpenAI's moderation endpoint before
+	// submitting them, blocking flagged ones with ModerateBlock or only
+	// warning about them with ModerateWarn. ModerationThreshold, if set,
+	// flags every category scoring at least that much instead of going by
+	// OpenAI's verdict.
+	Moderate            string  `yaml:"moderate"`
+	ModerationThreshold float64 `yaml:"moderation_threshold"`
+	// Redact replaces secrets and personal data in prompts with
+	// placeholders before submitting them, see RedactAll and
+	// RedactChainOnly. RedactRulesFile adds rules to the default ones.
+	Redact          string `yaml:"redact"`
+	RedactRulesFile string `yaml:"redact_rules_file"`
+	// SignKeyFile is a file holding a hex encoded Ed25519 key to sign
+	// prompt envelopes with. VerifySignature requires fetched prompts to
+	// carry a valid signature.
+	SignKeyFile     string `yaml:"sign_key_file"`
+	VerifySignature bool   `yaml:"verify_signature"`
+	// ChunkSize is the payload size above which prompts are split across
+	// several blobs. Zero disables chunking.
+	ChunkSize int `yaml:"chunk_size"`
+	// MaxBlobSize is the largest total payload size submitted in one
+	// transaction. Larger submissions fail before reaching the node.
+	MaxBlobSize int `yaml:"max_blob_size"`
+	// BlobVersion is the share version of submitted blobs. Version 0 is
+	// the only one the network supports so far.
+	BlobVersion uint8 `yaml:"blob_version"`
+	// SizeWarn and SizeLimit guard against costly submissions: payloads
+	// larger than SizeWarn are warned about, and those larger than
+	// SizeLimit only submitted with AssumeYes. Zero disables either.
+	SizeWarn  int `yaml:"size_warn"`
+	SizeLimit int `yaml:"size_limit"`
+	// Estimate prints the estimated fee and asks for confirmation before
+	// submitting, unless AssumeYes is set.
+	Estimate  bool `yaml:"estimate"`
+	AssumeYes bool `yaml:"-"`
+	// NoAsk makes Run stop after fetching the prompt back, without asking
+	// the model.
+	NoAsk bool `yaml:"-"`
+	// CheckBalance refuses to submit if the node's account can't pay the
+	// estimated fee.
+	CheckBalance bool `yaml:"check_balance"`
+	// Journal records submissions in JournalDir, so that a payload
+	// submitted before is reused rather than paid for twice.
+	Journal    bool   `yaml:"journal"`
+	JournalDir string `yaml:"journal_dir"`
+	// LedgerFile, if set, is the file every submission's cost is
+	// recorded in, see Ledger.
+	LedgerFile string `yaml:"ledger_file"`
+	// TxHash submits through the state API instead of blob.Submit, which
+	// reports the transaction hash.
+	TxHash bool `yaml:"tx_hash"`
+	// Memo is attached to the submission's transaction. Submitting fails
+	// with ErrMemoUnsupported unless the blob API is a MemoSubmitter.
+	Memo string `yaml:"memo"`
+	// SubmitAttempts is the number of times a blob submission is tried
+	// before giving up, and SubmitBackoff the delay before the first retry.
+	SubmitAttempts int           `yaml:"submit_attempts"`
+	SubmitBackoff  time.Duration `yaml:"submit_backoff"`
+
+	// Provider selects the model provider, see NewCompleter.
+	Provider     string `yaml:"provider"`
+	Model        string `yaml:"model"`
+	SystemPrompt string `yaml:"system_prompt"`
+	// WrapPrompt is a template fetched payloads are wrapped in before
+	// asking the model about them, with WrapPlaceholder standing for the
+	// payload. Empty sends the payload as is.
+	WrapPrompt string `yaml:"wrap_prompt"`
+	// SchemaFile is a JSON schema the response has to match, making the
+	// model respond with JSON instead of prose. Only OpenAI supports it.
+	SchemaFile string `yaml:"schema_file"`
+	// Truncate cuts prompts that don't fit into the model's context short
+	// instead of rejecting them.
+	Truncate bool `yaml:"truncate"`
+	// OpenAIBaseURL replaces OpenAI's API URL, for Azure or a local
+	// proxy. OpenAIOrg is the organization requests are billed to.
+	OpenAIBaseURL string `yaml:"openai_base_url"`
+	OpenAIOrg     string `yaml:"openai_org"`
+	// AzureDeployment is the deployment the Azure provider sends requests
+	// to, whatever the model, and AzureAPIVersion the API version it asks
+	// for.
+	AzureDeployment string `yaml:"azure_deployment"`
+	AzureAPIVersion string `yaml:"azure_api_version"`
+	// OpenAIProxy is the HTTP or HTTPS proxy requests to OpenAI go
+	// through, with credentials in the URL if it needs basic auth. If
+	// empty, HTTPS_PROXY and the other proxy variables apply.
+	OpenAIProxy string `yaml:"openai_proxy"`
+	// OpenAIAttempts is the number of times a completion is tried when
+	// OpenAI is rate limiting us or failing.
+	OpenAIAttempts int `yaml:"openai_attempts"`
+	// OpenAITimeout bounds each completion, including its retries, within
+	// the run's Timeout. Zero leaves only Timeout.
+	OpenAITimeout time.Duration `yaml:"openai_timeout"`
+	// RateLimit, if positive, caps the requests to the model a minute,
+	// across all concurrent runs, with bursts of up to RateBurst.
+	RateLimit float64 `yaml:"rate_limit"`
+	RateBurst int     `yaml:"rate_burst"`
+	Stream    bool    `yaml:"stream"`
+	// Sampling parameters are pointers so that "not set" can be told
+	// apart from an explicit zero.
+	Temperature *float32 `yaml:"temperature"`
+	MaxTokens   *int     `yaml:"max_tokens"`
+	TopP        *float32 `yaml:"top_p"`
+	// Seed asks the model to sample deterministically, so that the same
+	// request gets the same response. OpenAI only does its best at that.
+	Seed *int `yaml:"seed"`
+	// Choices is the number of alternative responses to ask for. Only
+	// OpenAI supports more than one.
+	Choices int `yaml:"choices"`
+
+	// Prices overrides the built-in price table used to estimate the cost
+	// of a completion, keyed by model.
+	Prices map[string]ModelPrice `yaml:"prices"`
+
+	// NoCache disables the response cache. CacheDir is where it is kept,
+	// by default in the user's cache directory, and CacheTTL how long
+	// entries stay valid.
+	NoCache  bool          `yaml:"no_cache"`
+	CacheDir string        `yaml:"cache_dir"`
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+
+	// Wait is how long to poll for a submitted blob until the node serves
+	// it. Zero fetches it right away.
+	Wait time.Duration `yaml:"wait"`
+	// NoFetch skips fetching the submitted blob back, asking the model
+	// about the local prompt instead. The blob isn't verified then.
+	NoFetch bool `yaml:"no_fetch"`
+	// Confirmations is how many blocks deep a submission has to be before
+	// the run goes on, waiting up to ConfirmationsTimeout. Zero goes on at
+	// the submission's height.
+	Confirmations        int           `yaml:"confirmations"`
+	ConfirmationsTimeout time.Duration `yaml:"confirmations_timeout"`
+	// VerifyProof checks the blob's inclusion proof after fetching it.
+	VerifyProof bool `yaml:"verify_proof"`
+	// StoreResponse submits the model's response as a second blob.
+	StoreResponse bool `yaml:"store_response"`
+	// ResponseNamespace, if set, is the namespace the response is stored
+	// in instead of the prompt's.
+	ResponseNamespace string `yaml:"response_namespace"`
+
+	// Concurrency is the number of prompts RunBatch runs, or blobs Bench
+	// submits, at the same time.
+	Concurrency int `yaml:"concurrency"`
+	// FetchConcurrency is the number of blobs fetched at the same time,
+	// such as the chunks of a prompt. The first failure cancels the other
+	// fetches, unless FetchKeepGoing is set.
+	FetchConcurrency int  `yaml:"fetch_concurrency"`
+	FetchKeepGoing   bool `yaml:"fetch_keep_going"`
+
+	// Timeout bounds the whole run. Zero means no timeout.
+	Timeout time.Duration `yaml:"timeout"`
+	// Output is the output format, text or json.
+	Output string `yaml:"output"`
+	// LogLevel is the minimum level logged: debug, info, warn or error.
+	// LogFormat is text or json, like Output.
+	LogLevel  string `yaml:"log_level"`
+	LogFormat string `yaml:"log_format"`
+
+	OpenAIKey string `yaml:"-"`
+	// OpenAIKeyFile is a file containing the OpenAI key, which keeps it
+	// out of the environment. It is preferred over OpenAIKey.
+	OpenAIKeyFile string `yaml:"openai_key_file"`
+	// EncryptionKey is the hex encoded AES key, also only read from the
+	// environment.
+	EncryptionKey string `yaml:"-"`
+	// AuthToken is the node's JWT auth token, from -jwt or the
+	// CELESTIA_NODE_AUTH_TOKEN environment variable.
+	AuthToken string `yaml:"-"`
+}
+
+// DefaultConfig returns the built-in defaults.
+func DefaultConfig() *Config {
+	return &Config{
+		NodeIP:             DefaultNodeIP,
+		Network:            NetworkArabica,
+		PadNamespace:       true,
+		Provider:           ProviderOpenAI,
+		Model:              openai.GPT3Dot5Turbo,
+		GasPrice:           blob.DefaultGasPrice(),
+		GasPriceMultiplier: 1,
+		Choices:            1,
+		Output:             OutputText,
+		LogLevel:           "info",
+		LogFormat:          OutputText,
+		Compress:           CompressNone,
+
+		MaxBlobSize:    DefaultMaxBlobSize,
+		SizeWarn:       64 << 10,
+		SizeLimit:      512 << 10,
+		SubmitAttempts: 3,
+		SubmitBackoff:  time.Second,
+
+		ConfirmationsTimeout: 5 * time.Minute,
+
+		OpenAIAttempts:  4,
+		RateBurst:       1,
+		AzureAPIVersion: DefaultAzureAPIVersion,
+
+		CacheTTL:         24 * time.Hour,
+		Concurrency:      1,
+		FetchConcurrency: 4,
+	}
+}
+
+// LoadConfig reads the YAML config file at path on top of the built-in
+// defaults. Unknown keys are rejected so typos don't go unnoticed.
+func LoadConfig(path string) (*Config, error) {
+	cfg := DefaultConfig()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	// An empty file is a valid, if pointless, config.
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+
+	// Decode the gas price once more to learn whether the file sets it.
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("error reading config file %s: %w", path, err)
+	}
+	var gas struct {
+		GasPrice *float64 `yaml:"gas_price"`
+	}
+	if err := yaml.NewDecoder(f).Decode(&gas); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	cfg.gasPriceSet = gas.GasPrice != nil
+	return cfg, nil
+}
+
+// NodeAddrs returns the configured node addresses, in the order they are
+// tried.
+func (c *Config) NodeAddrs() []string {
+	addrs := strings.Split(c.NodeIP, ",")
+	for i, addr := range addrs {
+		addrs[i] = strings.TrimSpace(addr)
+	}
+	return addrs
+}
+
+// Validate checks that the configured values are in range.
+func (c *Config) Validate() error {
+	if c.NodeIP == "" {
+		return fmt.Errorf("node address must not be empty")
+	}
+	for _, addr := range strings.Split(c.NodeIP, ",") {
+		if strings.TrimSpace(addr) == "" {
+			return fmt.Errorf("node addresses must not be empty, got %q", c.NodeIP)
+		}
+	}
+	if c.MirrorRequired && c.MirrorNode == "" {
+		return fmt.Errorf("mirror required but no mirror node configured")
+	}
+	for _, addr := range c.NodeAddrs() {
+		if c.MirrorNode != "" && c.MirrorNode == addr {
+			return fmt.Errorf("mirror node %s is one of the nodes already", addr)
+		}
+	}
+	if _, ok := providers[c.Provider]; !ok {
+		return fmt.Errorf("provider must be one of %s, got %q", providerNames(), c.Provider)
+	}
+	if c.Model == "" {
+		return fmt.Errorf("model must not be empty")
+	}
+	if c.Provider == ProviderAzure {
+		if c.AzureDeployment == "" {
+			return fmt.Errorf("the %s provider needs a deployment, set -azure-deployment", ProviderAzure)
+		}
+		if c.OpenAIBaseURL == "" {
+			return fmt.Errorf("the %s provider needs the resource's endpoint, set -openai-base-url", ProviderAzure)
+		}
+		if c.AzureAPIVersion == "" {
+			return fmt.Errorf("Azure API version must not be empty")
+		}
+	}
+	if c.OpenAIBaseURL != "" {
+		u, err := url.Parse(c.OpenAIBaseURL)
+		if err != nil {
+			return fmt.Errorf("invalid OpenAI base URL: %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("OpenAI base URL must be absolute, got %q", c.OpenAIBaseURL)
+		}
+	}
+	if c.WrapPrompt != "" {
+		if err := checkPromptWrap(c.WrapPrompt); err != nil {
+			return err
+		}
+	}
+	if c.OpenAIProxy != "" {
+		if _, err := parseProxyURL(c.OpenAIProxy); err != nil {
+			return err
+		}
+	}
+	if c.ExplorerURL != "" {
+		u, err := url.Parse(c.ExplorerURL)
+		if err != nil {
+			return fmt.Errorf("invalid explorer URL: %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("explorer URL must be absolute, got %q", c.ExplorerURL)
+		}
+	}
+	if c.Temperature != nil && (*c.Temperature < 0 || *c.Temperature > 2) {
+		return fmt.Errorf("temperature must be between 0 and 2, got %v", *c.Temperature)
+	}
+	if c.TopP != nil && (*c.TopP < 0 || *c.TopP > 1) {
+		return fmt.Errorf("top-p must be between 0 and 1, got %v", *c.TopP)
+	}
+	if err := checkBlobVersion(c.BlobVersion); err != nil {
+		return err
+	}
+	if c.NamespaceVersion != appns.NamespaceVersionZero {
+		return fmt.Errorf("namespace version %d is not supported, only version 0 is defined for user namespaces", c.NamespaceVersion)
+	}
+	if c.Output != OutputText && c.Output != OutputJSON {
+		return fmt.Errorf("output must be %q or %q, got %q", OutputText, OutputJSON, c.Output)
+	}
+	if err := new(slog.Level).UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return fmt.Errorf("log level must be debug, info, warn or error, got %q", c.LogLevel)
+	}
+	if c.LogFormat != OutputText && c.LogFormat != OutputJSON {
+		return fmt.Errorf("log format must be %q or %q, got %q", OutputText, OutputJSON, c.LogFormat)
+	}
+	if c.Choices < 1 {
+		return fmt.Errorf("number of choices must be at least 1, got %d", c.Choices)
+	}
+	if c.Choices > 1 && (!chatProvider(c.Provider) || c.Stream || c.SchemaFile != "") {
+		return fmt.Errorf("several choices are only supported by the %s and %s providers, without streaming or a schema", ProviderOpenAI, ProviderAzure)
+	}
+	if c.SchemaFile != "" && !chatProvider(c.Provider) {
+		return fmt.Errorf("structured responses with a schema are only supported by the %s and %s providers", ProviderOpenAI, ProviderAzure)
+	}
+	if c.SchemaFile != "" && c.Stream {
+		return fmt.Errorf("structured responses with a schema can't be streamed")
+	}
+	if c.Output == OutputJSON && c.Stream {
+		return fmt.Errorf("streaming can't be combined with JSON output")
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative, got %s", c.Timeout)
+	}
+	if c.OpenAITimeout < 0 {
+		return fmt.Errorf("OpenAI timeout must not be negative, got %s", c.OpenAITimeout)
+	}
+	if c.RateLimit < 0 {
+		return fmt.Errorf("rate limit must not be negative, got %v", c.RateLimit)
+	}
+	if c.RateBurst < 1 {
+		return fmt.Errorf("rate burst must be at least 1, got %d", c.RateBurst)
+	}
+	// The default gas price is negative, so only an explicit one is
+	// checked.
+	if (c.gasPriceSet || c.GasPrice != blob.DefaultGasPrice()) && (c.GasPrice <= 0 || math.IsNaN(c.GasPrice)) {
+		return fmt.Errorf("gas price must be positive, got %v", c.GasPrice)
+	}
+	if len(c.Memo) > MaxMemoLength {
+		return fmt.Errorf("memo must be at most %d bytes, got %d bytes", MaxMemoLength, len(c.Memo))
+	}
+	if c.GasPriceMultiplier <= 0 {
+		return fmt.Errorf("gas price multiplier must be positive, got %v", c.GasPriceMultiplier)
+	}
+	if c.Compress != CompressNone && c.Compress != CompressGzip {
+		return fmt.Errorf("compression must be %q or %q, got %q", CompressNone, CompressGzip, c.Compress)
+	}
+	if c.Encrypt {
+		if _, err := parseEncryptionKey(c.EncryptionKey); err != nil {
+			return err
+		}
+	}
+	if c.Moderate != ModerateOff && c.Moderate != ModerateBlock && c.Moderate != ModerateWarn {
+		return fmt.Errorf("moderation must be %q or %q, got %q", ModerateBlock, ModerateWarn, c.Moderate)
+	}
+	if c.Redact != RedactOff && c.Redact != RedactAll && c.Redact != RedactChainOnly {
+		return fmt.Errorf("redaction must be %q or %q, got %q", RedactAll, RedactChainOnly, c.Redact)
+	}
+	if c.RedactRulesFile != "" && c.Redact == RedactOff {
+		return fmt.Errorf("redaction rules need redaction to be enabled")
+	}
+	if c.ModerationThreshold < 0 || c.ModerationThreshold > 1 {
+		return fmt.Errorf("moderation threshold must be between 0 and 1, got %v", c.ModerationThreshold)
+	}
+	if c.SignKeyFile != "" && c.Raw {
+		return fmt.Errorf("signing needs the prompt envelope, so it can't be combined with raw prompts")
+	}
+	if c.Wait < 0 {
+		return fmt.Errorf("wait must not be negative, got %s", c.Wait)
+	}
+	if c.NoFetch && (c.Wait > 0 || c.VerifySignature) {
+		return fmt.Errorf("not fetching the blob can't be combined with waiting for it or verifying its signature")
+	}
+	if c.ResponseNamespace != "" && !c.StoreResponse {
+		return fmt.Errorf("a response namespace needs the response to be stored")
+	}
+	if c.NoAsk && c.StoreResponse {
+		return fmt.Errorf("storing the response needs the model to be asked")
+	}
+	if c.Confirmations < 0 {
+		return fmt.Errorf("confirmations must not be negative, got %d", c.Confirmations)
+	}
+	if c.Confirmations > 0 && c.ConfirmationsTimeout <= 0 {
+		return fmt.Errorf("confirmations timeout must be positive, got %s", c.ConfirmationsTimeout)
+	}
+	if c.CacheTTL < 0 {
+		return fmt.Errorf("cache TTL must not be negative, got %s", c.CacheTTL)
+	}
+	if c.Concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1, got %d", c.Concurrency)
+	}
+	if c.FetchConcurrency < 1 {
+		return fmt.Errorf("fetch concurrency must be at least 1, got %d", c.FetchConcurrency)
+	}
+	if c.SizeWarn < 0 || c.SizeLimit < 0 {
+		return fmt.Errorf("size thresholds must not be negative, got %d and %d", c.SizeWarn, c.SizeLimit)
+	}
+	if c.MaxBlobSize <= 0 {
+		return fmt.Errorf("max blob size must be positive, got %d", c.MaxBlobSize)
+	}
+	if c.ChunkSize < 0 {
+		return fmt.Errorf("chunk size must not be negative, got %d", c.ChunkSize)
+	}
+	if c.SubmitAttempts < 1 {
+		return fmt.Errorf("submit attempts must be at least 1, got %d", c.SubmitAttempts)
+	}
+	if c.SubmitBackoff < 0 {
+		return fmt.Errorf("submit backoff must not be negative, got %s", c.SubmitBackoff)
+	}
+	if c.OpenAIAttempts < 1 {
+		return fmt.Errorf("OpenAI attempts must be at least 1, got %d", c.OpenAIAttempts)
+	}
+	if c.MaxTokens != nil && *c.MaxTokens <= 0 {
+		return fmt.Errorf("max tokens must be positive, got %d", *c.MaxTokens)
+	}
+	return nil
+}
+
+// ResolveAuthToken returns the node auth token, reading it from
+// AuthTokenFile if it wasn't given directly. An empty token means auth is
+// disabled.
+func (c *Config) ResolveAuthToken() (string, error) {
+	if c.AuthToken != "" || c.AuthTokenFile == "" {
+		return c.AuthToken, nil
+	}
+	data, err := os.ReadFile(c.AuthTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading auth token file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ResolveOpenAIKey returns the OpenAI key, read from OpenAIKeyFile if set
+// and OpenAIKey otherwise.
+func (c *Config) ResolveOpenAIKey() (string, error) {
+	if c.OpenAIKeyFile == "" {
+		return c.OpenAIKey, nil
+	}
+	data, err := os.ReadFile(c.OpenAIKeyFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading OpenAI key file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// submitRetryPolicy returns the retry policy for blob submissions.
+func (c *Config) submitRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: c.SubmitAttempts,
+		BaseDelay:   c.SubmitBackoff,
+		MaxDelay:    30 * time.Second,
+	}
+}
+
+// openAIRetryPolicy returns the retry policy for completions. The delays
+// are only used when OpenAI doesn't say how long to wait.
+func (c *Config) openAIRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: c.OpenAIAttempts,
+		BaseDelay:   time.Second,
+		MaxDelay:    time.Minute,
+	}
+}
+
+// GasPriceAutoValue is the gas price that selects GasPriceMin, and
+// GasPriceMinValue an alias of it.
+const (
+	GasPriceAutoValue = "auto"
+	GasPriceMinValue  = "min"
+)
+
+// SetGasPrice sets the gas price from s, a number of utia per gas unit,
+// "auto" or "min".
+func (c *Config) SetGasPrice(s string) error {
+	if s == GasPriceAutoValue || s == GasPriceMinValue {
+		c.GasPriceMin = true
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	c.GasPrice, c.GasPriceMin, c.gasPriceSet = v, false, true
+	return nil
+}
+
+// ApplyEnv overrides the config with any values set in the environment.
+func (c *Config) ApplyEnv(getenv func(string) string) error {
+	if v := getenv("PROMPT_SCAVENGER_NODE"); v != "" {
+		c.NodeIP = v
+	}
+	if v := getenv("PROMPT_SCAVENGER_NETWORK"); v != "" {
+		c.Network = v
+	}
+	// CELESTIA_NAMESPACE is shared with other Celestia tools, our own
+	// variable takes precedence.
+	if v := getenv("CELESTIA_NAMESPACE"); v != "" {
+		c.Namespace = v
+	}
+	if v := getenv("PROMPT_SCAVENGER_NAMESPACE"); v != "" {
+		c.Namespace = v
+	}
+	if v := getenv("PROMPT_SCAVENGER_MODEL"); v != "" {
+		c.Model = v
+	}
+	if v := getenv("OPENAI_BASE_URL"); v != "" {
+		c.OpenAIBaseURL = v
+	}
+	if v := getenv("PROMPT_SCAVENGER_GAS_PRICE"); v != "" {
+		if err := c.SetGasPrice(v); err != nil {
+			return fmt.Errorf("invalid PROMPT_SCAVENGER_GAS_PRICE %q: %w", v, err)
+		}
+	}
+	c.OpenAIKey = getenv("OPENAI_KEY")
+	c.EncryptionKey = getenv("PROMPT_SCAVENGER_KEY")
+	c.AuthToken = getenv("CELESTIA_NODE_AUTH_TOKEN")
+	return nil
+}
@@ -0,0 +1,140 @@
+package scavenger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes data to name in a new temporary directory and returns
+// the file's path.
+func writeFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeFile(t, "config.yaml", "node: ws://other:26658\ntimeout: 30s\n")
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.NodeIP != "ws://other:26658" {
+		t.Errorf("node = %q, want the file's", cfg.NodeIP)
+	}
+	if cfg.Timeout != 30*time.Second {
+		t.Errorf("timeout = %s, want the file's", cfg.Timeout)
+	}
+	if cfg.Model != DefaultConfig().Model {
+		t.Errorf("model = %q, want the default", cfg.Model)
+	}
+}
+
+func TestLoadConfigEmpty(t *testing.T) {
+	cfg, err := LoadConfig(writeFile(t, "config.yaml", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.NodeIP != DefaultNodeIP {
+		t.Errorf("node = %q, want the default", cfg.NodeIP)
+	}
+}
+
+func TestLoadConfigUnknownKey(t *testing.T) {
+	_, err := LoadConfig(writeFile(t, "config.yaml", "modle: gpt-4\n"))
+	if err == nil || !strings.Contains(err.Error(), "modle") {
+		t.Fatalf("error = %v, want one naming the unknown key", err)
+	}
+}
+
+func TestLoadConfigSecrets(t *testing.T) {
+	// Secrets are never read from config files.
+	cfg, err := LoadConfig(writeFile(t, "config.yaml", "openaikey: sk-secret\n"))
+	if err == nil {
+		t.Fatalf("loaded config with OpenAI key %q", cfg.OpenAIKey)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"PROMPT_SCAVENGER_NODE":      "ws://env:26658",
+		"CELESTIA_NAMESPACE":         "shared",
+		"PROMPT_SCAVENGER_NAMESPACE": "ours",
+		"OPENAI_KEY":                 "sk-env",
+		"CELESTIA_NODE_AUTH_TOKEN":   "token",
+	}
+	cfg := DefaultConfig()
+	if err := cfg.ApplyEnv(func(key string) string { return env[key] }); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.NodeIP != "ws://env:26658" {
+		t.Errorf("node = %q, want the environment's", cfg.NodeIP)
+	}
+	if cfg.Namespace != "ours" {
+		t.Errorf("namespace = %q, want PROMPT_SCAVENGER_NAMESPACE's", cfg.Namespace)
+	}
+	if cfg.OpenAIKey != "sk-env" || cfg.AuthToken != "token" {
+		t.Errorf("secrets = %q and %q, want the environment's", cfg.OpenAIKey, cfg.AuthToken)
+	}
+}
+
+func TestValidateDefaults(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatalf("default config is invalid: %v", err)
+	}
+}
+
+func TestValidateSampling(t *testing.T) {
+	high, negative, zero := float32(2.5), float32(-0.1), 0
+	tests := []struct {
+		name string
+		set  func(*Config)
+	}{
+		{"temperature", func(c *Config) { c.Temperature = &high }},
+		{"top-p", func(c *Config) { c.TopP = &negative }},
+		{"max tokens", func(c *Config) { c.MaxTokens = &zero }},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		tt.set(cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("invalid %s passed validation", tt.name)
+		}
+	}
+}
+
+func TestApplyEnvCelestiaNamespace(t *testing.T) {
+	cfg := DefaultConfig()
+	env := map[string]string{"CELESTIA_NAMESPACE": testNamespace}
+	if err := cfg.ApplyEnv(func(key string) string { return env[key] }); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Namespace != testNamespace {
+		t.Errorf("namespace = %q, want CELESTIA_NAMESPACE's", cfg.Namespace)
+	}
+}
+
+func TestResolveOpenAIKey(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.OpenAIKey = "from-env"
+	if key, err := cfg.ResolveOpenAIKey(); err != nil || key != "from-env" {
+		t.Errorf("key = %q (error %v), want the configured key without a file", key, err)
+	}
+
+	// The file wins over the key, without its surrounding whitespace.
+	cfg.OpenAIKeyFile = writeFile(t, "openai-key", "  from-file\n")
+	if key, err := cfg.ResolveOpenAIKey(); err != nil || key != "from-file" {
+		t.Errorf("key = %q (error %v), want the trimmed file's", key, err)
+	}
+
+	cfg.OpenAIKeyFile = filepath.Join(t.TempDir(), "missing")
+	if _, err := cfg.ResolveOpenAIKey(); err == nil || !strings.Contains(err.Error(), "error reading OpenAI key file") {
+		t.Errorf("error = %v, want the missing file reported", err)
+	}
+}
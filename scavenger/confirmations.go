@@ -0,0 +1,57 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// confirmationPoll is how often the node's head is polled while waiting
+// for confirmations, a fraction of the block time.
+var confirmationPoll = 2 * time.Second
+
+// WaitConfirmations polls the node's head until the block at height is
+// the configured number of confirmations deep, that is until the head is
+// at height + Confirmations, or ConfirmationsTimeout passes. The depth is
+// logged whenever it grows.
+func (c *Client) WaitConfirmations(ctx context.Context, height uint64) error {
+	want := uint64(c.Config.Confirmations)
+	if want == 0 {
+		return nil
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, c.Config.ConfirmationsTimeout)
+	defer cancel()
+
+	var depth uint64
+	// timedOut reports running out of ConfirmationsTimeout rather than the
+	// run's own timeout.
+	timedOut := func() error {
+		return fmt.Errorf("block %d is only %d of %d confirmations deep after %s", height, depth, want, c.Config.ConfirmationsTimeout)
+	}
+	for {
+		head, err := nodeHeight(waitCtx, c.CurrentNode())
+		if err != nil {
+			if ctx.Err() == nil && errors.Is(waitCtx.Err(), context.DeadlineExceeded) {
+				return timedOut()
+			}
+			return fmt.Errorf("error getting the node's head: %w", err)
+		}
+		if head > height && head-height > depth {
+			depth = head - height
+			c.logger().Info("Confirmation depth", "height", height, "head", head, "depth", min(depth, want), "wanted", want)
+		}
+		if depth >= want {
+			return nil
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() == nil {
+				return timedOut()
+			}
+			return ctx.Err()
+		case <-time.After(confirmationPoll):
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/header"
+)
+
+// fastConfirmations makes waiting for confirmations poll without waiting
+// until the test ends.
+func fastConfirmations(t *testing.T) {
+	t.Helper()
+	old := confirmationPoll
+	confirmationPoll = time.Millisecond
+	t.Cleanup(func() { confirmationPoll = old })
+}
+
+// advancingHead makes c's node report a head starting at start and growing
+// by one block every poll, stopping at stop. It returns the number of
+// polls so far.
+func advancingHead(c *Client, start, stop uint64) func() int {
+	var (
+		mu    sync.Mutex
+		polls int
+	)
+	c.Node.Header.LocalHead = func(context.Context) (*header.ExtendedHeader, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		head := min(start+uint64(polls), stop)
+		polls++
+		return testHeader(head), nil
+	}
+	return func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return polls
+	}
+}
+
+func TestWaitConfirmations(t *testing.T) {
+	fastConfirmations(t)
+	cfg := testConfig()
+	cfg.Confirmations = 3
+	c, api, _ := newTestClient(cfg)
+	c.Node = fakeNode(api)
+	polls := advancingHead(c, 10, 100)
+	if err := c.WaitConfirmations(context.Background(), 10); err != nil {
+		t.Fatal(err)
+	}
+	// The head was at 10, 11, 12 and then 13, three blocks deep.
+	if polls() != 4 {
+		t.Errorf("polled %d times, want 4", polls())
+	}
+}
+
+func TestWaitConfirmationsTimeout(t *testing.T) {
+	fastConfirmations(t)
+	cfg := testConfig()
+	cfg.Confirmations = 3
+	cfg.ConfirmationsTimeout = 20 * time.Millisecond
+	c, api, _ := newTestClient(cfg)
+	c.Node = fakeNode(api)
+	advancingHead(c, 10, 11)
+	err := c.WaitConfirmations(context.Background(), 10)
+	if err == nil || !strings.Contains(err.Error(), "block 10 is only 1 of 3 confirmations deep") {
+		t.Errorf("error = %v, want the depth reached before the timeout", err)
+	}
+}
+
+func TestWaitConfirmationsHeadFails(t *testing.T) {
+	cfg := testConfig()
+	cfg.Confirmations = 1
+	c, api, _ := newTestClient(cfg)
+	c.Node = fakeNode(api)
+	down := errors.New("node is down")
+	c.Node.Header.LocalHead = func(context.Context) (*header.ExtendedHeader, error) {
+		return nil, down
+	}
+	if err := c.WaitConfirmations(context.Background(), 10); !errors.Is(err, down) {
+		t.Errorf("error = %v, want the head's", err)
+	}
+	// Without confirmations to wait for, the node isn't asked.
+	c.Config.Confirmations = 0
+	if err := c.WaitConfirmations(context.Background(), 10); err != nil {
+		t.Errorf("waiting for no confirmations: %v", err)
+	}
+}
+
+func TestClientRunConfirmations(t *testing.T) {
+	fastConfirmations(t)
+	cfg := testConfig()
+	cfg.Confirmations = 2
+	c, api, _ := newTestClient(cfg)
+	c.Node = fakeNode(api)
+	// The prompt lands at height 1.
+	advancingHead(c, 1, 100)
+	result, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := result.Timings["confirmations"]; !ok {
+		t.Error("no timing for the confirmations")
+	}
+
+	cfg.ConfirmationsTimeout = 10 * time.Millisecond
+	advancingHead(c, 1, 1)
+	if _, err := c.Run(context.Background(), "hi"); !errors.Is(err, ErrConfirmations) {
+		t.Errorf("error = %v, want ErrConfirmations", err)
+	}
+}
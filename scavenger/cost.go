@@ -0,0 +1,37 @@
+package scavenger
+
+import (
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ModelPrice is what a model charges, in dollars per million tokens.
+type ModelPrice struct {
+	Prompt     float64 `yaml:"prompt"`
+	Completion float64 `yaml:"completion"`
+}
+
+// defaultPrices are OpenAI's list prices at the time of writing. They can
+// be overridden per model with Config.Prices when they change.
+var defaultPrices = map[string]ModelPrice{
+	openai.GPT3Dot5Turbo:     {Prompt: 0.5, Completion: 1.5},
+	openai.GPT3Dot5Turbo0125: {Prompt: 0.5, Completion: 1.5},
+	openai.GPT3Dot5Turbo16K:  {Prompt: 3, Completion: 4},
+	openai.GPT4:              {Prompt: 30, Completion: 60},
+	openai.GPT4Turbo:         {Prompt: 10, Completion: 30},
+	openai.GPT4TurboPreview:  {Prompt: 10, Completion: 30},
+	openai.GPT4o:             {Prompt: 5, Completion: 15},
+}
+
+// EstimateCost returns the estimated cost in dollars of usage with the
+// configured model. It reports false if there is no price for the model.
+func (c *Config) EstimateCost(usage Usage) (float64, bool) {
+	price, ok := c.Prices[c.Model]
+	if !ok {
+		price, ok = defaultPrices[c.Model]
+	}
+	if !ok {
+		return 0, false
+	}
+	cost := float64(usage.PromptTokens)*price.Prompt + float64(usage.CompletionTokens)*price.Completion
+	return cost / 1e6, true
+}
@@ -0,0 +1,56 @@
+package scavenger
+
+import (
+	"context"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestEstimateCost(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Model = openai.GPT4o
+	cost, ok := cfg.EstimateCost(Usage{PromptTokens: 1000, CompletionTokens: 2000})
+	if want := (1000*5 + 2000*15) / 1e6; !ok || cost != want {
+		t.Errorf("cost = %v (%t), want %v from the list price", cost, ok, want)
+	}
+
+	// Configured prices take precedence over the built-in ones.
+	cfg.Prices = map[string]ModelPrice{openai.GPT4o: {Prompt: 1, Completion: 2}}
+	cost, ok = cfg.EstimateCost(Usage{PromptTokens: 1000, CompletionTokens: 500})
+	if !ok || cost != 0.002 {
+		t.Errorf("cost = %v (%t), want 0.002 from the configured price", cost, ok)
+	}
+
+	cfg.Model = "local-llama"
+	if _, ok := cfg.EstimateCost(Usage{PromptTokens: 1}); ok {
+		t.Error("a model without a price has a cost")
+	}
+}
+
+func TestRunReportsUsage(t *testing.T) {
+	cfg := testConfig()
+	cfg.Model = openai.GPT4o
+	c, _, completer := newTestClient(cfg)
+	completer.usage = Usage{PromptTokens: 100, CompletionTokens: 10, TotalTokens: 110}
+	result, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Usage == nil || *result.Usage != completer.usage {
+		t.Errorf("usage = %+v, want the completer's %+v", result.Usage, completer.usage)
+	}
+	if want := (100*5 + 10*15) / 1e6; result.CostUSD == nil || *result.CostUSD != want {
+		t.Errorf("cost = %v, want %v", result.CostUSD, want)
+	}
+}
+
+func TestLoadConfigPrices(t *testing.T) {
+	cfg, err := LoadConfig(writeFile(t, "config.yaml", "prices:\n  my-model:\n    prompt: 0.5\n    completion: 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Prices["my-model"]; got != (ModelPrice{Prompt: 0.5, Completion: 1}) {
+		t.Errorf("price = %+v, want the file's", got)
+	}
+}
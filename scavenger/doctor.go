@@ -0,0 +1,201 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	nodeclient "github.com/celestiaorg/celestia-openrpc"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// maxClockSkew is how far the local clock may be off the time of the
+// node's head. The head is up to a block time old, so this is generous.
+const maxClockSkew = time.Minute
+
+// ModelLister lists the models available to a key. Listing them is the
+// cheapest call showing that the key works.
+type ModelLister interface {
+	ListModels(ctx context.Context) (openai.ModelsList, error)
+}
+
+// newModelLister creates the ModelLister for the configured provider. Like
+// newNodeClient, it can be replaced in tests.
+var newModelLister = func(cfg *Config) (ModelLister, error) {
+	newClient := NewOpenAIClient
+	if cfg.Provider == ProviderAzure {
+		newClient = NewAzureClient
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return client.(openAIClient), nil
+}
+
+// nodeHeadTime returns the time of the network's head as the node sees
+// it. It can be replaced in tests.
+var nodeHeadTime = func(ctx context.Context, node *nodeclient.Client) (time.Time, error) {
+	head, err := node.Header.NetworkHead(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return head.Time(), nil
+}
+
+// DoctorCheck is the outcome of one check of Doctor. Hint says how to fix
+// a failed check.
+type DoctorCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	// Critical checks failing means runs can't work. The others only warn.
+	Critical bool   `json:"critical"`
+	Detail   string `json:"detail,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Hint     string `json:"hint,omitempty"`
+}
+
+// DoctorReport is the outcome of Doctor. It passed unless a critical check
+// failed.
+type DoctorReport struct {
+	Checks []DoctorCheck `json:"checks"`
+	Passed bool          `json:"passed"`
+}
+
+// Doctor checks the environment runs need: that the OpenAI key works, that
+// a node is reachable, that the namespace parses and that the local clock
+// agrees with the node's. Unlike SelfTest, nothing is submitted. Failing
+// checks are reported, not returned.
+func Doctor(ctx context.Context, cfg *Config) *DoctorReport {
+	report := &DoctorReport{Passed: true}
+	add := func(check DoctorCheck) {
+		report.Checks = append(report.Checks, check)
+		if check.Critical && !check.Passed {
+			report.Passed = false
+		}
+	}
+
+	add(checkOpenAIKey(ctx, cfg))
+	node, check := checkNode(ctx, cfg)
+	add(check)
+	add(checkNamespace(cfg))
+	// The clock is compared to the node's, so it can only be checked with
+	// a node.
+	if node != nil {
+		add(checkClock(ctx, node))
+		node.Close()
+	}
+	return report
+}
+
+// checkOpenAIKey checks that an OpenAI key is configured and accepted.
+func checkOpenAIKey(ctx context.Context, cfg *Config) DoctorCheck {
+	check := DoctorCheck{Name: "openai key", Critical: true}
+	key, err := cfg.ResolveOpenAIKey()
+	if err != nil {
+		check.Error = err.Error()
+		check.Hint = "check that -openai-key-file points to a readable file"
+		return check
+	}
+	if key == "" {
+		check.Error = "no key configured"
+		check.Hint = "set $OPENAI_KEY, or pass -openai-key-file"
+		return check
+	}
+	lister, err := newModelLister(cfg)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	models, err := lister.ListModels(ctx)
+	if err != nil {
+		check.Error = err.Error()
+		var apiErr *openai.APIError
+		if errors.As(err, &apiErr) && apiErr.HTTPStatusCode == http.StatusUnauthorized {
+			check.Hint = "the key was rejected, check that it is complete and not revoked"
+		} else {
+			check.Hint = "check that the API is reachable, and -openai-base-url and -openai-proxy if set"
+		}
+		return check
+	}
+	check.Passed = true
+	check.Detail = fmt.Sprintf("%d models available", len(models.Models))
+	return check
+}
+
+// checkNode checks that one of the configured nodes is reachable and
+// returns its head. The node is returned for further checks.
+func checkNode(ctx context.Context, cfg *Config) (*nodeclient.Client, DoctorCheck) {
+	check := DoctorCheck{Name: "node", Critical: true}
+	token, err := cfg.ResolveAuthToken()
+	if err != nil {
+		check.Error = err.Error()
+		check.Hint = "check that -jwt-file points to a readable file"
+		return nil, check
+	}
+	var failures []string
+	for _, addr := range cfg.NodeAddrs() {
+		node, height, err := dialNode(ctx, addr, token)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", addr, err))
+			continue
+		}
+		check.Passed = true
+		check.Detail = fmt.Sprintf("%s at height %d", addr, height)
+		return node, check
+	}
+	check.Error = strings.Join(failures, "; ")
+	check.Hint = "check that the node runs, that -node is its RPC address like http://localhost:26658, " +
+		"and that -jwt or $CELESTIA_NODE_AUTH_TOKEN holds its auth token"
+	return nil, check
+}
+
+// checkNamespace checks that the configured namespaces parse, including
+// the response namespace when responses are stored.
+func checkNamespace(cfg *Config) DoctorCheck {
+	check := DoctorCheck{Name: "namespace", Critical: true}
+	if cfg.Namespace == "" {
+		check.Error = "no namespace configured"
+		check.Hint = "pass -namespace as hex, e.g. -namespace deadbeef, or -namespace-label"
+		return check
+	}
+	namespaces, err := cfg.NamespaceIDs()
+	if err == nil && cfg.StoreResponse {
+		_, err = cfg.ResponseNamespaceID(namespaces[0])
+	}
+	if err != nil {
+		check.Error = err.Error()
+		check.Hint = "version 0 namespaces are hex of at most 10 bytes, shorter ones are padded with -pad-namespace"
+		return check
+	}
+	check.Passed = true
+	check.Detail = NamespaceHex(namespaces[0])
+	return check
+}
+
+// checkClock compares the local clock to the time of the node's head. A
+// clock far off makes timestamps, such as those of the ledger, misleading.
+func checkClock(ctx context.Context, node *nodeclient.Client) DoctorCheck {
+	check := DoctorCheck{Name: "clock"}
+	headTime, err := nodeHeadTime(ctx, node)
+	if err != nil {
+		check.Error = fmt.Sprintf("error getting the network head: %v", err)
+		return check
+	}
+	skew := time.Since(headTime).Round(time.Second)
+	switch {
+	case skew > maxClockSkew:
+		check.Error = fmt.Sprintf("the network head is %s behind the local clock", skew)
+		check.Hint = "sync the local clock, or check that the node isn't stuck syncing"
+	case skew < -maxClockSkew:
+		check.Error = fmt.Sprintf("the network head is %s ahead of the local clock", -skew)
+		check.Hint = "sync the local clock, e.g. with NTP"
+	default:
+		check.Passed = true
+		check.Detail = fmt.Sprintf("within %s of the network head", maxClockSkew)
+	}
+	return check
+}
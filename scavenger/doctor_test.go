@@ -0,0 +1,185 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	nodeclient "github.com/celestiaorg/celestia-openrpc"
+)
+
+// modelsServer returns the base URL of an OpenAI API listing two models
+// to requests with key, and rejecting other keys.
+func modelsServer(t *testing.T, key string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+key {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error": {"message": "Incorrect API key provided", "type": "invalid_request_error"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"object": "list", "data": [{"id": "gpt-4o"}, {"id": "gpt-4o-mini"}]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/v1"
+}
+
+// replaceHeadTime makes the network head's time be now plus offset until
+// the test ends.
+func replaceHeadTime(t *testing.T, offset time.Duration, err error) {
+	t.Helper()
+	old := nodeHeadTime
+	nodeHeadTime = func(context.Context, *nodeclient.Client) (time.Time, error) {
+		return time.Now().Add(offset), err
+	}
+	t.Cleanup(func() { nodeHeadTime = old })
+}
+
+// doctorConfig returns a config that passes every check of Doctor.
+func doctorConfig(t *testing.T) *Config {
+	t.Helper()
+	useFakeNodes(t, map[string]*FakeBlobAPI{DefaultNodeIP: {}})
+	replaceHeadTime(t, -5*time.Second, nil)
+	cfg := testConfig()
+	cfg.OpenAIKey = "sk-test"
+	cfg.OpenAIBaseURL = modelsServer(t, "sk-test")
+	return cfg
+}
+
+// doctorCheck returns the check of report named name.
+func doctorCheck(t *testing.T, report *DoctorReport, name string) DoctorCheck {
+	t.Helper()
+	for _, check := range report.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	t.Fatalf("no %s check in %+v", name, report.Checks)
+	return DoctorCheck{}
+}
+
+func TestDoctor(t *testing.T) {
+	report := Doctor(context.Background(), doctorConfig(t))
+	if !report.Passed {
+		t.Fatalf("report = %+v, want every check passed", report.Checks)
+	}
+	var names []string
+	for _, check := range report.Checks {
+		names = append(names, check.Name)
+		if !check.Passed || check.Error != "" || check.Hint != "" {
+			t.Errorf("check %+v failed", check)
+		}
+	}
+	if got := strings.Join(names, ","); got != "openai key,node,namespace,clock" {
+		t.Errorf("checks = %s, want the key, node, namespace and clock", got)
+	}
+	if got := doctorCheck(t, report, "openai key").Detail; got != "2 models available" {
+		t.Errorf("key detail = %q, want the number of models", got)
+	}
+	if got := doctorCheck(t, report, "node").Detail; got != DefaultNodeIP+" at height 0" {
+		t.Errorf("node detail = %q, want the address and height", got)
+	}
+}
+
+func TestDoctorOpenAIKey(t *testing.T) {
+	t.Run("missing", func(t *testing.T) {
+		cfg := doctorConfig(t)
+		cfg.OpenAIKey = ""
+		report := Doctor(context.Background(), cfg)
+		check := doctorCheck(t, report, "openai key")
+		if report.Passed || check.Passed || !strings.Contains(check.Hint, "OPENAI_KEY") {
+			t.Errorf("check = %+v, want it failed with a hint to set the key", check)
+		}
+	})
+	t.Run("rejected", func(t *testing.T) {
+		cfg := doctorConfig(t)
+		cfg.OpenAIKey = "sk-revoked"
+		report := Doctor(context.Background(), cfg)
+		check := doctorCheck(t, report, "openai key")
+		if report.Passed || check.Passed || !strings.Contains(check.Hint, "rejected") {
+			t.Errorf("check = %+v, want it failed with a hint about the rejected key", check)
+		}
+	})
+	t.Run("unreachable", func(t *testing.T) {
+		cfg := doctorConfig(t)
+		cfg.OpenAIBaseURL = strings.TrimSuffix(cfg.OpenAIBaseURL, "/v1") + "/elsewhere"
+		check := doctorCheck(t, Doctor(context.Background(), cfg), "openai key")
+		if check.Passed || !strings.Contains(check.Hint, "-openai-base-url") {
+			t.Errorf("check = %+v, want it failed with a hint about the base URL", check)
+		}
+	})
+}
+
+func TestDoctorNode(t *testing.T) {
+	cfg := doctorConfig(t)
+	cfg.NodeIP = "ws://down:26658"
+	report := Doctor(context.Background(), cfg)
+	check := doctorCheck(t, report, "node")
+	if report.Passed || check.Passed || !strings.Contains(check.Error, "ws://down:26658") || check.Hint == "" {
+		t.Errorf("check = %+v, want it failed naming the address, with a hint", check)
+	}
+	for _, check := range report.Checks {
+		if check.Name == "clock" {
+			t.Error("the clock was checked without a node")
+		}
+	}
+}
+
+func TestDoctorNamespace(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(*Config)
+		wantHint string
+	}{
+		{"missing", func(cfg *Config) { cfg.Namespace = "" }, "-namespace"},
+		{"too long", func(cfg *Config) { cfg.Namespace = strings.Repeat("ab", 11) }, "at most 10 bytes"},
+		{"response", func(cfg *Config) { cfg.StoreResponse, cfg.ResponseNamespace = true, "not hex" }, "at most 10 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := doctorConfig(t)
+			tt.setup(cfg)
+			report := Doctor(context.Background(), cfg)
+			check := doctorCheck(t, report, "namespace")
+			if report.Passed || check.Passed || !strings.Contains(check.Hint, tt.wantHint) {
+				t.Errorf("check = %+v, want it failed with a hint containing %q", check, tt.wantHint)
+			}
+		})
+	}
+}
+
+func TestDoctorClock(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset time.Duration
+		err    error
+		want   string
+	}{
+		{"behind", -2 * maxClockSkew, nil, "behind the local clock"},
+		{"ahead", 2 * maxClockSkew, nil, "ahead of the local clock"},
+		{"no head", 0, errors.New("syncing"), "error getting the network head: syncing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := doctorConfig(t)
+			replaceHeadTime(t, tt.offset, tt.err)
+			report := Doctor(context.Background(), cfg)
+			check := doctorCheck(t, report, "clock")
+			if check.Passed || check.Critical || !strings.Contains(check.Error, tt.want) {
+				t.Errorf("check = %+v, want a failed warning containing %q", check, tt.want)
+			}
+			if !report.Passed {
+				t.Error("the report failed for a clock that's only a warning")
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package scavenger
+
+import (
+	"context"
+	"fmt"
+)
+
+// DryRunResult describes what Run would submit.
+type DryRunResult struct {
+	Namespace string `json:"namespace"`
+	// Blobs is the number of blobs, more than one for chunked prompts.
+	Blobs int `json:"blobs"`
+	// Size is the total size of the blob payloads in bytes.
+	Size int         `json:"size"`
+	Fee  FeeEstimate `json:"fee"`
+	// NodeHeight is the height of the node's chain head, which shows the
+	// node is reachable and accepts our token.
+	NodeHeight uint64 `json:"node_height"`
+}
+
+// DryRun prepares the blobs for prompt like Run, but neither submits them
+// nor asks the model. It still checks that the node can be queried and
+// that the namespace is acceptable for blobs.
+func (c *Client) DryRun(ctx context.Context, prompt string) (*DryRunResult, error) {
+	cfg := c.Config
+	namespaceID, err := CreateNamespaceID(cfg.Namespace, cfg.NamespaceVersion, cfg.PadNamespace)
+	if err != nil {
+		return nil, StageError("namespace", fmt.Errorf("Failed to decode namespace: %w", err))
+	}
+	if err := ValidateBlobNamespace(namespaceID); err != nil {
+		return nil, StageError("namespace", fmt.Errorf("namespace can't be used for blobs: %w", err))
+	}
+
+	prompt, err = c.redact(prompt)
+	if err != nil {
+		return nil, err
+	}
+	data, err := promptData(cfg, prompt)
+	if err != nil {
+		return nil, err
+	}
+	payloads, err := encodePayloads(cfg, data)
+	if err != nil {
+		return nil, err
+	}
+	result := &DryRunResult{Namespace: NamespaceHex(namespaceID), Blobs: len(payloads)}
+	sizes := make([]int, len(payloads))
+	for i, payload := range payloads {
+		// Creating the blobs checks them the same way submitting would.
+		if _, err := newBlob(cfg.BlobVersion, namespaceID, payload); err != nil {
+			return nil, err
+		}
+		sizes[i] = len(payload)
+		result.Size += len(payload)
+	}
+	result.Fee = EstimateFee(sizes, c.gasPrice(ctx))
+	if err := checkBlobSize(payloads, cfg.MaxBlobSize); err != nil {
+		return nil, err
+	}
+
+	head, err := c.CurrentNode().Header.LocalHead(ctx)
+	if err != nil {
+		return nil, StageError("connect", fmt.Errorf("Failed to query node: %w", err))
+	}
+	result.NodeHeight = head.Height()
+	if cfg.CheckBalance {
+		if err := c.checkBalance(ctx, result.Fee); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,73 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/celestiaorg/celestia-openrpc/types/header"
+)
+
+// dryRunClient returns a test client whose node is at height 5.
+func dryRunClient(cfg *Config) (*Client, *FakeBlobAPI, *fakeCompleter) {
+	c, api, completer := newTestClient(cfg)
+	c.Node = fakeNode(api)
+	c.Node.Header.LocalHead = func(context.Context) (*header.ExtendedHeader, error) {
+		return testHeader(5), nil
+	}
+	return c, api, completer
+}
+
+func TestDryRun(t *testing.T) {
+	cfg := testConfig()
+	cfg.Raw = true
+	c, api, completer := dryRunClient(cfg)
+	result, err := c.DryRun(context.Background(), "what would happen?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fakeHeight(api) != 0 || len(completer.prompts()) != 0 {
+		t.Errorf("dry run made %d submissions and %d completions, want none", fakeHeight(api), len(completer.prompts()))
+	}
+	if result.Blobs != 1 || result.Size != len("what would happen?") {
+		t.Errorf("would submit %d blobs of %d bytes, want the prompt as one", result.Blobs, result.Size)
+	}
+	if result.Namespace != testNamespace || result.NodeHeight != 5 {
+		t.Errorf("namespace %s at node height %d, want the configured one at 5", result.Namespace, result.NodeHeight)
+	}
+	if result.Fee.Fee <= 0 || result.Fee.Gas == 0 {
+		t.Errorf("fee = %+v, want an estimate", result.Fee)
+	}
+}
+
+func TestDryRunChunked(t *testing.T) {
+	cfg := testConfig()
+	cfg.Raw = true
+	cfg.ChunkSize = 4
+	c, api, _ := dryRunClient(cfg)
+	result, err := c.DryRun(context.Background(), "a prompt of several chunks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Blobs < 2 || fakeHeight(api) != 0 {
+		t.Errorf("would submit %d blobs with %d submitted, want several chunks with none submitted", result.Blobs, fakeHeight(api))
+	}
+}
+
+func TestDryRunErrors(t *testing.T) {
+	cfg := testConfig()
+	cfg.Namespace = "01"
+	c, _, _ := dryRunClient(cfg)
+	if _, err := c.DryRun(context.Background(), "hi"); !errors.Is(err, ErrNamespace) || !errors.Is(err, ErrReservedNamespace) {
+		t.Errorf("error = %v, want a reserved namespace error", err)
+	}
+
+	down := errors.New("node is down")
+	c, _, _ = dryRunClient(testConfig())
+	c.Node.Header.LocalHead = func(context.Context) (*header.ExtendedHeader, error) {
+		return nil, down
+	}
+	if _, err := c.DryRun(context.Background(), "hi"); !errors.Is(err, ErrConnect) || !errors.Is(err, down) {
+		t.Errorf("error = %v, want a connect error", err)
+	}
+}
@@ -0,0 +1,58 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestOpenAICompleterEmpty(t *testing.T) {
+	blank := chatResponse(" \n")
+	blank.Choices[0].FinishReason = openai.FinishReasonContentFilter
+	tests := []struct {
+		name   string
+		resp   openai.ChatCompletionResponse
+		reason string
+	}{
+		{"no choices", openai.ChatCompletionResponse{}, ""},
+		{"blank content", chatResponse(""), ""},
+		{"filtered", blank, `finish reason "content_filter"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeChatClient{resp: tt.resp}
+			answer, _, err := testCompleter(t, DefaultConfig(), client, nil).Complete(context.Background(), ChatMessages("", "hi"))
+			if !errors.Is(err, ErrEmptyResponse) {
+				t.Fatalf("error = %v, want ErrEmptyResponse", err)
+			}
+			if answer != "" {
+				t.Errorf("answer = %q, want none", answer)
+			}
+			if tt.reason != "" && !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("error %q doesn't give the %s", err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestOpenAICompleterChoicesEmpty(t *testing.T) {
+	resp := chatResponse("")
+	resp.Choices = append(resp.Choices, openai.ChatCompletionChoice{FinishReason: openai.FinishReasonLength})
+	client := &fakeChatClient{resp: resp}
+	_, _, err := testCompleter(t, DefaultConfig(), client, nil).CompleteChoices(context.Background(), ChatMessages("", "hi"), 2)
+	if !errors.Is(err, ErrEmptyResponse) || !strings.Contains(err.Error(), `finish reason "length"`) {
+		t.Fatalf("error = %v, want ErrEmptyResponse with the finish reason", err)
+	}
+}
+
+func TestClientRunEmptyResponse(t *testing.T) {
+	c, _, completer := newTestClient(testConfig())
+	completer.err = emptyResponse(openai.FinishReasonLength)
+	_, err := c.Run(context.Background(), "hi")
+	if !errors.Is(err, ErrCompletion) || !errors.Is(err, ErrEmptyResponse) {
+		t.Fatalf("error = %v, want a completion error for the empty response", err)
+	}
+}
@@ -0,0 +1,81 @@
+package scavenger
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrDecrypt is returned when an encrypted payload can't be decrypted,
+// most likely because the key is wrong.
+var ErrDecrypt = errors.New("failed to decrypt payload")
+
+// parseEncryptionKey decodes a hex encoded AES-128, AES-192 or AES-256 key.
+func parseEncryptionKey(keyHex string) ([]byte, error) {
+	if keyHex == "" {
+		return nil, fmt.Errorf("PROMPT_SCAVENGER_KEY environment variable not set")
+	}
+	key, err := hex.DecodeString(keyHex)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding PROMPT_SCAVENGER_KEY hex: %w", err)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("PROMPT_SCAVENGER_KEY must be 16, 24 or 32 bytes, got %d bytes", len(key))
+	}
+}
+
+// newGCM creates an AES-GCM cipher for the hex encoded key.
+func newGCM(keyHex string) (cipher.AEAD, error) {
+	key, err := parseEncryptionKey(keyHex)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptPayload encrypts payload with AES-GCM. The result is
+// aesGCMMarker, followed by the random nonce and the sealed payload.
+func encryptPayload(keyHex string, payload []byte) ([]byte, error) {
+	gcm, err := newGCM(keyHex)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
+
+	out := append([]byte(aesGCMMarker), nonce...)
+	return gcm.Seal(out, nonce, payload, nil), nil
+}
+
+// decryptPayload decrypts a payload produced by encryptPayload.
+func decryptPayload(keyHex string, data []byte) ([]byte, error) {
+	gcm, err := newGCM(keyHex)
+	if err != nil {
+		return nil, fmt.Errorf("blob is encrypted: %w", err)
+	}
+
+	data = data[len(aesGCMMarker):]
+	if len(data) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, fmt.Errorf("%w: payload is too short", ErrDecrypt)
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	payload, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: wrong key or corrupted data", ErrDecrypt)
+	}
+	return payload, nil
+}
@@ -0,0 +1,119 @@
+package scavenger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	testKey  = "000102030405060708090a0b0c0d0e0f"
+	otherKey = "0f0e0d0c0b0a09080706050403020100"
+)
+
+func TestEncryptPayload(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Encrypt, cfg.EncryptionKey = true, testKey
+	prompt := []byte("a secret prompt")
+	payload, err := EncodePayload(cfg, prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(payload, []byte(aesGCMMarker)) || bytes.Contains(payload, prompt) {
+		t.Fatalf("payload %q isn't encrypted", payload)
+	}
+	again, err := EncodePayload(cfg, prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(payload, again) {
+		t.Error("encrypting twice gave the same payload, want a new nonce each time")
+	}
+	got, err := DecodePayload(cfg, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, prompt) {
+		t.Errorf("decrypted %q, want %q", got, prompt)
+	}
+}
+
+func TestEncryptCompressedPayload(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Encrypt, cfg.EncryptionKey, cfg.Compress = true, testKey, CompressGzip
+	prompt := []byte(strings.Repeat("compressed, then encrypted ", 20))
+	payload, err := EncodePayload(cfg, prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodePayload(cfg, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, prompt) {
+		t.Errorf("decoded %q, want %q", got, prompt)
+	}
+}
+
+func TestDecryptPayloadErrors(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Encrypt, cfg.EncryptionKey = true, testKey
+	payload, err := EncodePayload(cfg, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrong := DefaultConfig()
+	wrong.EncryptionKey = otherKey
+	if _, err := DecodePayload(wrong, payload); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("wrong key: error = %v, want ErrDecrypt", err)
+	}
+	if _, err := DecodePayload(cfg, []byte(aesGCMMarker+"short")); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("short payload: error = %v, want ErrDecrypt", err)
+	}
+	if _, err := DecodePayload(DefaultConfig(), payload); err == nil || !strings.Contains(err.Error(), "blob is encrypted") {
+		t.Errorf("no key: error = %v, want one saying the blob is encrypted", err)
+	}
+}
+
+func TestParseEncryptionKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"", "not set"},
+		{"zz", "error decoding"},
+		{"0001", "must be 16, 24 or 32 bytes, got 2 bytes"},
+	}
+	for _, tt := range tests {
+		_, err := parseEncryptionKey(tt.key)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseEncryptionKey(%q) error = %v, want one containing %q", tt.key, err, tt.want)
+		}
+	}
+	for _, key := range []string{testKey, strings.Repeat("ab", 24), strings.Repeat("ab", 32)} {
+		if _, err := parseEncryptionKey(key); err != nil {
+			t.Errorf("key of %d bytes: %v", len(key)/2, err)
+		}
+	}
+}
+
+func TestRunEncrypted(t *testing.T) {
+	cfg := testConfig()
+	cfg.Encrypt, cfg.EncryptionKey = true, testKey
+	c, api, _ := newTestClient(cfg)
+	result, err := c.Run(context.Background(), "a secret prompt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FetchedPayload != "a secret prompt" {
+		t.Errorf("fetched %q, want the prompt", result.FetchedPayload)
+	}
+	for _, b := range api.at(result.Height) {
+		if bytes.Contains(b.Data, []byte("secret")) {
+			t.Error("the prompt is readable on chain")
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package scavenger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// envelopeVersion is the version of PromptEnvelope written by SubmitPrompt.
+const envelopeVersion = 1
+
+// ErrNewerEnvelope is returned for prompt envelopes of a version newer
+// than envelopeVersion, which this build can't read.
+var ErrNewerEnvelope = errors.New("prompt envelope is newer than supported")
+
+// envelopeDecoders decode the envelope versions we know, by version. When
+// a version is added, the older ones stay, so their blobs stay readable.
+var envelopeDecoders = map[int]func(payload []byte) (*PromptEnvelope, error){
+	1: decodeEnvelopeV1,
+}
+
+// PromptMetadata is the context submitted along with a prompt.
+type PromptMetadata struct {
+	Version int `json:"v"`
+	// Timestamp is the submission time in Unix seconds.
+	Timestamp int64  `json:"ts"`
+	Model     string `json:"model,omitempty"`
+	// PublicKey is the hex encoded Ed25519 key of the signer, for signed
+	// prompts. Only VerifyPrompt tells whether they really signed it.
+	PublicKey string `json:"pubkey,omitempty"`
+}
+
+// PromptEnvelope is the payload of a prompt blob, unless prompts are
+// submitted raw.
+type PromptEnvelope struct {
+	PromptMetadata
+	Prompt string `json:"prompt"`
+	// Signature is the hex encoded Ed25519 signature of the envelope,
+	// set when submitting with SignKeyFile.
+	Signature string `json:"sig,omitempty"`
+}
+
+// promptData returns what SubmitPrompt submits for prompt: the prompt in
+// an envelope, or the prompt itself with Raw set.
+func promptData(cfg *Config, prompt string) ([]byte, error) {
+	if cfg.Raw {
+		return []byte(prompt), nil
+	}
+	envelope := PromptEnvelope{
+		PromptMetadata: PromptMetadata{
+			Version:   envelopeVersion,
+			Timestamp: time.Now().Unix(),
+			Model:     cfg.Model,
+		},
+		Prompt: prompt,
+	}
+	if cfg.SignKeyFile != "" {
+		key, err := readSigningKey(cfg.SignKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := envelope.sign(key); err != nil {
+			return nil, err
+		}
+	}
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding prompt envelope: %w", err)
+	}
+	return data, nil
+}
+
+// DecodePrompt extracts the prompt from a decoded payload. Payloads in a
+// PromptEnvelope have their metadata returned too, anything else is a raw
+// prompt, as submitted before envelopes existed or with Raw set.
+func DecodePrompt(payload []byte) ([]byte, *PromptMetadata, error) {
+	envelope, err := DecodeEnvelope(payload)
+	if err != nil || envelope.Version == 0 {
+		return payload, nil, err
+	}
+	return []byte(envelope.Prompt), &envelope.PromptMetadata, nil
+}
+
+// DecodeEnvelope decodes data as a PromptEnvelope of any version we know.
+// Data that isn't an envelope, such as a raw prompt or anything but JSON,
+// is returned as the prompt of an envelope of version 0, without
+// metadata. Envelopes newer than this build fail with ErrNewerEnvelope
+// rather than being misread.
+func DecodeEnvelope(data []byte) (PromptEnvelope, error) {
+	envelope, ok, err := decodeEnvelope(data)
+	if err != nil {
+		return PromptEnvelope{}, err
+	}
+	if !ok {
+		return PromptEnvelope{Prompt: string(data)}, nil
+	}
+	return *envelope, nil
+}
+
+// decodeEnvelope decodes payload as a PromptEnvelope, dispatching on its
+// version. It reports false if payload isn't one. Every version has the
+// "v" and "prompt" fields, which is how envelopes are told apart from raw
+// prompts.
+func decodeEnvelope(payload []byte) (*PromptEnvelope, bool, error) {
+	var fields struct {
+		Version *int    `json:"v"`
+		Prompt  *string `json:"prompt"`
+	}
+	if json.Unmarshal(payload, &fields) != nil || fields.Version == nil || fields.Prompt == nil {
+		return nil, false, nil
+	}
+	version := *fields.Version
+	decode, ok := envelopeDecoders[version]
+	switch {
+	case ok:
+	case version > envelopeVersion:
+		return nil, false, fmt.Errorf("%w: got version %d, this build reads up to version %d, upgrade prompt-scavenger to read it",
+			ErrNewerEnvelope, version, envelopeVersion)
+	default:
+		return nil, false, fmt.Errorf("unsupported prompt envelope version %d", version)
+	}
+	envelope, err := decode(payload)
+	if err != nil {
+		return nil, false, fmt.Errorf("error decoding prompt envelope version %d: %w", version, err)
+	}
+	return envelope, true, nil
+}
+
+// decodeEnvelopeV1 decodes a version 1 envelope, which is PromptEnvelope
+// as it is.
+func decodeEnvelopeV1(payload []byte) (*PromptEnvelope, error) {
+	var envelope PromptEnvelope
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return nil, err
+	}
+	return &envelope, nil
+}
@@ -0,0 +1,176 @@
+package scavenger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPromptEnvelope(t *testing.T) {
+	cfg := testConfig()
+	cfg.Model = "gpt-4o"
+	before := time.Now().Unix()
+	data, err := promptData(cfg, "what is a blob?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("envelope %s isn't JSON: %v", data, err)
+	}
+	if fields["v"] != float64(envelopeVersion) || fields["prompt"] != "what is a blob?" || fields["model"] != "gpt-4o" {
+		t.Errorf("envelope = %s, want the version, prompt and model", data)
+	}
+
+	prompt, meta, err := DecodePrompt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(prompt) != "what is a blob?" {
+		t.Errorf("prompt = %q, want the enveloped one", prompt)
+	}
+	if meta == nil || meta.Version != envelopeVersion || meta.Model != "gpt-4o" || meta.Timestamp < before {
+		t.Errorf("metadata = %+v, want the version, model and submission time", meta)
+	}
+}
+
+func TestDecodePromptRaw(t *testing.T) {
+	for _, payload := range []string{
+		"a plain prompt",
+		`{"question": "JSON, but not an envelope"}`,
+		`{"v": 1}`,
+		`["v", "prompt"]`,
+	} {
+		prompt, meta, err := DecodePrompt([]byte(payload))
+		if err != nil {
+			t.Errorf("decoding %q: %v", payload, err)
+			continue
+		}
+		if string(prompt) != payload || meta != nil {
+			t.Errorf("decoded %q as %q with metadata %+v, want it as a raw prompt", payload, prompt, meta)
+		}
+	}
+}
+
+func TestPromptDataRaw(t *testing.T) {
+	cfg := testConfig()
+	cfg.Raw = true
+	data, err := promptData(cfg, `{"v": 1, "prompt": "looks like one"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"v": 1, "prompt": "looks like one"}` {
+		t.Errorf("raw prompt was submitted as %s", data)
+	}
+}
+
+func TestFetchPromptMetadata(t *testing.T) {
+	c, _, _ := newTestClient(testConfig())
+	sub, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched, err := c.FetchPrompt(context.Background(), sub.Height, sub.Namespace, sub.Commitments())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fetched.Payload) != "hi" || fetched.Metadata == nil || fetched.Metadata.Model != c.Config.Model {
+		t.Errorf("fetched %q with metadata %+v, want the prompt out of its envelope", fetched.Payload, fetched.Metadata)
+	}
+
+	c.Config.Raw = true
+	sub, err = c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched, err = c.FetchPrompt(context.Background(), sub.Height, sub.Namespace, sub.Commitments())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fetched.Payload) != "hi" || fetched.Metadata != nil {
+		t.Errorf("fetched %q with metadata %+v, want the raw prompt without", fetched.Payload, fetched.Metadata)
+	}
+}
+
+func TestDecodeEnvelopeV1(t *testing.T) {
+	envelope, err := DecodeEnvelope([]byte(`{"v": 1, "ts": 1714564800, "model": "gpt-4o", "prompt": "hi", "sig": "abcd"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PromptEnvelope{
+		PromptMetadata: PromptMetadata{Version: 1, Timestamp: 1714564800, Model: "gpt-4o"},
+		Prompt:         "hi",
+		Signature:      "abcd",
+	}
+	if envelope != want {
+		t.Errorf("envelope = %+v, want %+v", envelope, want)
+	}
+}
+
+func TestDecodeEnvelopeRaw(t *testing.T) {
+	for _, data := range []string{"a plain prompt", `{"v": 1`, "\xff\xfe", `{"prompt": "no version"}`} {
+		envelope, err := DecodeEnvelope([]byte(data))
+		if err != nil {
+			t.Errorf("decoding %q: %v", data, err)
+			continue
+		}
+		if envelope != (PromptEnvelope{Prompt: data}) {
+			t.Errorf("decoded %q as %+v, want it as the prompt of a version 0 envelope", data, envelope)
+		}
+	}
+}
+
+func TestDecodeEnvelopeVersions(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		newer bool
+		want  string
+	}{
+		{"newer", `{"v": 7, "prompt": "from the future"}`, true, "upgrade prompt-scavenger"},
+		{"zero", `{"v": 0, "prompt": "hi"}`, false, "unsupported prompt envelope version 0"},
+		{"negative", `{"v": -1, "prompt": "hi"}`, false, "unsupported prompt envelope version -1"},
+		{"malformed", `{"v": 1, "prompt": "hi", "ts": "yesterday"}`, false, "error decoding prompt envelope version 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeEnvelope([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.want)
+			}
+			if errors.Is(err, ErrNewerEnvelope) != tt.newer {
+				t.Errorf("error %v is ErrNewerEnvelope: %v, want %v", err, !tt.newer, tt.newer)
+			}
+		})
+	}
+}
+
+func TestDecodeEnvelopeDispatch(t *testing.T) {
+	envelopeDecoders[2] = func([]byte) (*PromptEnvelope, error) {
+		return &PromptEnvelope{PromptMetadata: PromptMetadata{Version: 2}, Prompt: "decoded as v2"}, nil
+	}
+	t.Cleanup(func() { delete(envelopeDecoders, 2) })
+	envelope, err := DecodeEnvelope([]byte(`{"v": 2, "prompt": "hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if envelope.Prompt != "decoded as v2" {
+		t.Errorf("envelope = %+v, want the version's decoder used", envelope)
+	}
+}
+
+func TestFetchPromptNewerEnvelope(t *testing.T) {
+	c, _, _ := newTestClient(testConfig())
+	c.Config.Raw = true
+	sub, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), `{"v": 2, "prompt": "from the future"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.FetchPrompt(context.Background(), sub.Height, sub.Namespace, sub.Commitments())
+	if !errors.Is(err, ErrNewerEnvelope) {
+		t.Errorf("error = %v, want ErrNewerEnvelope", err)
+	}
+}
@@ -0,0 +1,34 @@
+package scavenger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Networks with a known explorer.
+const (
+	NetworkArabica = "arabica"
+	NetworkMocha   = "mocha"
+	NetworkMainnet = "mainnet"
+)
+
+// explorers are the Celenium base URLs of the known networks.
+var explorers = map[string]string{
+	NetworkArabica: "https://arabica.celenium.io",
+	NetworkMocha:   "https://mocha.celenium.io",
+	NetworkMainnet: "https://celenium.io",
+}
+
+// ExplorerBlockURL returns the link to the block at height in the
+// configured explorer. It reports false if there is no explorer for the
+// network and none was configured explicitly.
+func (c *Config) ExplorerBlockURL(height uint64) (string, bool) {
+	base := c.ExplorerURL
+	if base == "" {
+		base = explorers[c.Network]
+	}
+	if base == "" {
+		return "", false
+	}
+	return fmt.Sprintf("%s/block/%d", strings.TrimSuffix(base, "/"), height), true
+}
@@ -0,0 +1,43 @@
+package scavenger
+
+import "testing"
+
+func TestExplorerBlockURL(t *testing.T) {
+	tests := []struct {
+		network, explorer string
+		want              string
+		ok                bool
+	}{
+		{NetworkArabica, "", "https://arabica.celenium.io/block/7", true},
+		{NetworkMocha, "", "https://mocha.celenium.io/block/7", true},
+		{NetworkMainnet, "", "https://celenium.io/block/7", true},
+		{"private", "", "", false},
+		{"private", "https://explorer.example/", "https://explorer.example/block/7", true},
+		{NetworkMainnet, "https://explorer.example", "https://explorer.example/block/7", true},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.Network, cfg.ExplorerURL = tt.network, tt.explorer
+		got, ok := cfg.ExplorerBlockURL(7)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("network %q with explorer %q: got %q, %v, want %q, %v", tt.network, tt.explorer, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestExplorerDefaultsToArabica(t *testing.T) {
+	got, _ := DefaultConfig().ExplorerBlockURL(1)
+	if got != "https://arabica.celenium.io/block/1" {
+		t.Errorf("default explorer link = %q, want arabica's", got)
+	}
+}
+
+func TestValidateExplorerURL(t *testing.T) {
+	for _, explorer := range []string{"celenium.io", "/block", "http://%zz"} {
+		cfg := testConfig()
+		cfg.ExplorerURL = explorer
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("explorer URL %q was accepted", explorer)
+		}
+	}
+}
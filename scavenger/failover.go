@@ -0,0 +1,176 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// After maxEndpointFailures failures in a row, an endpoint is skipped for
+// unhealthyPeriod, unless all endpoints are.
+const (
+	maxEndpointFailures = 3
+	unhealthyPeriod     = 30 * time.Second
+)
+
+// endpoint is one of the nodes a failoverBlobAPI spreads calls over.
+type endpoint struct {
+	addr string
+	api  BlobAPI
+
+	failures       int
+	unhealthyUntil time.Time
+}
+
+// failoverBlobAPI is a BlobAPI over several nodes. Calls go to the first
+// healthy node, and move on to the next one when a node fails with a
+// transient error.
+type failoverBlobAPI struct {
+	endpoints []*endpoint
+	logger    func() *slog.Logger
+
+	mu sync.Mutex
+	// preferred is the index of the endpoint tried first. It rotates to
+	// the next endpoint when the preferred one becomes unhealthy.
+	preferred int
+	now       func() time.Time
+}
+
+// newFailoverBlobAPI creates a failoverBlobAPI that tries the endpoints in
+// order.
+func newFailoverBlobAPI(endpoints []*endpoint, logger func() *slog.Logger) *failoverBlobAPI {
+	return &failoverBlobAPI{endpoints: endpoints, logger: logger, now: time.Now}
+}
+
+// order returns the endpoints in the order they should be tried: healthy
+// ones first, starting from the preferred one.
+func (f *failoverBlobAPI) order() []*endpoint {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	var healthy, unhealthy []*endpoint
+	for i := range f.endpoints {
+		ep := f.endpoints[(f.preferred+i)%len(f.endpoints)]
+		if now.Before(ep.unhealthyUntil) {
+			unhealthy = append(unhealthy, ep)
+		} else {
+			healthy = append(healthy, ep)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// report records the outcome of a call to ep.
+func (f *failoverBlobAPI) report(ep *endpoint, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		ep.failures = 0
+		return
+	}
+	ep.failures++
+	if ep.failures < maxEndpointFailures {
+		return
+	}
+	ep.failures = 0
+	ep.unhealthyUntil = f.now().Add(unhealthyPeriod)
+	if f.endpoints[f.preferred] == ep {
+		f.preferred = (f.preferred + 1) % len(f.endpoints)
+	}
+	f.logger().Warn("Node endpoint marked unhealthy", "endpoint", ep.addr, "for", unhealthyPeriod)
+}
+
+// do runs call against the endpoints in order until one doesn't fail with
+// a transient error. Calls that aren't idempotent, like submissions, only
+// move on when they didn't reach the endpoint, since they may have landed
+// otherwise.
+func (f *failoverBlobAPI) do(ctx context.Context, method string, idempotent bool, call func(BlobAPI) error) error {
+	var errs []error
+	for _, ep := range f.order() {
+		err := call(ep.api)
+		f.report(ep, transientOnly(err))
+		if err == nil || !isTransient(err) || !idempotent && !notSent(err) {
+			// Which endpoint served is only news after failing over.
+			level := slog.LevelDebug
+			if len(errs) > 0 {
+				level = slog.LevelInfo
+			}
+			f.logger().Log(ctx, level, "Node endpoint served request", "endpoint", ep.addr, "method", method)
+			return err
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+		f.logger().Warn("Node endpoint failed, trying the next one", "endpoint", ep.addr, "method", method, "error", err)
+	}
+	return errors.Join(errs...)
+}
+
+// transientOnly returns err if it is transient. Other errors, like a
+// missing blob, say nothing about the health of the endpoint.
+func transientOnly(err error) error {
+	if err != nil && isTransient(err) {
+		return err
+	}
+	return nil
+}
+
+func (f *failoverBlobAPI) Submit(ctx context.Context, blobs []*blob.Blob, gasPrice float64) (height uint64, err error) {
+	err = f.do(ctx, "Submit", false, func(api BlobAPI) error {
+		height, err = api.Submit(ctx, blobs, gasPrice)
+		return err
+	})
+	return height, err
+}
+
+// SubmitWithResult is TxSubmitter.SubmitWithResult, as long as all the
+// endpoints support it.
+func (f *failoverBlobAPI) SubmitWithResult(ctx context.Context, blobs []*blob.Blob, gasPrice float64) (result *SubmitResult, err error) {
+	err = f.do(ctx, "SubmitWithResult", false, func(api BlobAPI) error {
+		txSubmitter, ok := api.(TxSubmitter)
+		if !ok {
+			return errors.New("the blob API doesn't report transaction hashes")
+		}
+		result, err = txSubmitter.SubmitWithResult(ctx, blobs, gasPrice)
+		return err
+	})
+	return result, err
+}
+
+func (f *failoverBlobAPI) Get(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (b *blob.Blob, err error) {
+	err = f.do(ctx, "Get", true, func(api BlobAPI) error {
+		b, err = api.Get(ctx, height, ns, commitment)
+		return err
+	})
+	return b, err
+}
+
+func (f *failoverBlobAPI) GetAll(ctx context.Context, height uint64, namespaces []share.Namespace) (blobs []*blob.Blob, err error) {
+	err = f.do(ctx, "GetAll", true, func(api BlobAPI) error {
+		blobs, err = api.GetAll(ctx, height, namespaces)
+		return err
+	})
+	return blobs, err
+}
+
+func (f *failoverBlobAPI) GetProof(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (proof *blob.Proof, err error) {
+	err = f.do(ctx, "GetProof", true, func(api BlobAPI) error {
+		proof, err = api.GetProof(ctx, height, ns, commitment)
+		return err
+	})
+	return proof, err
+}
+
+func (f *failoverBlobAPI) Included(ctx context.Context, height uint64, ns share.Namespace, proof *blob.Proof, commitment blob.Commitment) (included bool, err error) {
+	err = f.do(ctx, "Included", true, func(api BlobAPI) error {
+		included, err = api.Included(ctx, height, ns, proof, commitment)
+		return err
+	})
+	return included, err
+}
@@ -0,0 +1,156 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+)
+
+// testFailover returns a failoverBlobAPI over apis, named "node0",
+// "node1" and so on, with its clock at now.
+func testFailover(now *time.Time, apis ...*FakeBlobAPI) *failoverBlobAPI {
+	endpoints := make([]*endpoint, len(apis))
+	for i, api := range apis {
+		endpoints[i] = &endpoint{addr: "node" + string(rune('0'+i)), api: api}
+	}
+	f := newFailoverBlobAPI(endpoints, func() *slog.Logger { return discardLogger })
+	f.now = func() time.Time { return *now }
+	return f
+}
+
+func TestFailoverGet(t *testing.T) {
+	ns := testNS(t, testNamespace)
+	b := testBlob(t, ns, "prompt")
+	down, up := &FakeBlobAPI{GetErr: syscall.ECONNREFUSED}, &FakeBlobAPI{}
+	height, err := up.Submit(context.Background(), []*blob.Blob{b}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	got, err := testFailover(&now, down, up).Get(context.Background(), height, ns, b.Commitment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got.Data) != "prompt" {
+		t.Errorf("got %q, want the blob from the second endpoint", got.Data)
+	}
+}
+
+func TestFailoverPermanentError(t *testing.T) {
+	ns := testNS(t, testNamespace)
+	now := time.Now()
+	f := testFailover(&now, &FakeBlobAPI{}, &FakeBlobAPI{GetErr: errors.New("must not be asked")})
+	_, err := f.Get(context.Background(), 1, ns, testBlob(t, ns, "prompt").Commitment)
+	if !errors.Is(err, blob.ErrBlobNotFound) {
+		t.Errorf("error = %v, want the first endpoint's missing blob", err)
+	}
+}
+
+func TestFailoverSubmit(t *testing.T) {
+	ns := testNS(t, testNamespace)
+	blobs := []*blob.Blob{testBlob(t, ns, "prompt")}
+	now := time.Now()
+
+	// A refused connection means the submission never reached the node.
+	refused, up := &FakeBlobAPI{SubmitErr: syscall.ECONNREFUSED}, &FakeBlobAPI{}
+	if _, err := testFailover(&now, refused, up).Submit(context.Background(), blobs, 0); err != nil {
+		t.Fatal(err)
+	}
+	if fakeHeight(up) != 1 {
+		t.Error("submission wasn't moved on to the second endpoint")
+	}
+
+	// Other failures may have landed, so they aren't submitted twice.
+	unavailable := errors.New("503 service unavailable")
+	up = &FakeBlobAPI{}
+	_, err := testFailover(&now, &FakeBlobAPI{SubmitErr: unavailable}, up).Submit(context.Background(), blobs, 0)
+	if !errors.Is(err, unavailable) || fakeHeight(up) != 0 {
+		t.Errorf("got %v with %d blocks on the second endpoint, want the first one's error and none", err, fakeHeight(up))
+	}
+}
+
+func TestFailoverUnhealthy(t *testing.T) {
+	ns := testNS(t, testNamespace)
+	commitment := testBlob(t, ns, "prompt").Commitment
+	now := time.Now()
+	down, up := &FakeBlobAPI{GetErr: syscall.ECONNREFUSED}, &FakeBlobAPI{}
+	f := testFailover(&now, down, up)
+	for i := 0; i < maxEndpointFailures; i++ {
+		_, _ = f.Get(context.Background(), 1, ns, commitment)
+	}
+	if order := f.order(); order[0].api != up {
+		t.Fatalf("first endpoint tried is %s, want the healthy one", order[0].addr)
+	}
+
+	// Once the unhealthy period is over, the endpoint is tried again, but
+	// behind the one preferred meanwhile.
+	now = now.Add(unhealthyPeriod)
+	down.GetErr = nil
+	if order := f.order(); len(order) != 2 || order[0].api != up || order[1].api != down {
+		t.Errorf("endpoints are tried in order %s, %s, want node1, node0", order[0].addr, order[1].addr)
+	}
+}
+
+func TestFailoverAllUnhealthy(t *testing.T) {
+	ns := testNS(t, testNamespace)
+	commitment := testBlob(t, ns, "prompt").Commitment
+	now := time.Now()
+	f := testFailover(&now, &FakeBlobAPI{GetErr: syscall.ECONNREFUSED}, &FakeBlobAPI{GetErr: syscall.ECONNRESET})
+	for i := 0; i < maxEndpointFailures; i++ {
+		_, err := f.Get(context.Background(), 1, ns, commitment)
+		if !errors.Is(err, syscall.ECONNREFUSED) || !errors.Is(err, syscall.ECONNRESET) {
+			t.Fatalf("error = %v, want both endpoints' errors", err)
+		}
+	}
+	// Unhealthy endpoints are still tried, rather than failing outright.
+	if order := f.order(); len(order) != 2 {
+		t.Errorf("%d endpoints are tried, want both", len(order))
+	}
+}
+
+func TestNewClientFailover(t *testing.T) {
+	api := &FakeBlobAPI{}
+	useFakeNodes(t, map[string]*FakeBlobAPI{"ws://up:26658": api})
+	cfg := testConfig()
+	cfg.NodeIP = "ws://down:26658, ws://up:26658"
+	c, err := NewClient(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if c.NodeAddr != "ws://up:26658" {
+		t.Errorf("node address = %s, want the reachable node", c.NodeAddr)
+	}
+	if _, ok := c.Blobs.(*failoverBlobAPI); !ok {
+		t.Errorf("blob API is %T, want one failing over", c.Blobs)
+	}
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if fakeHeight(api) != 1 {
+		t.Error("prompt wasn't submitted to the reachable node")
+	}
+}
+
+func TestNewClientSingleNode(t *testing.T) {
+	useFakeNodes(t, map[string]*FakeBlobAPI{DefaultNodeIP: {}})
+	c, err := NewClient(context.Background(), testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, ok := c.Blobs.(*failoverBlobAPI); ok {
+		t.Error("a single node is behind a failover blob API")
+	}
+
+	cfg := testConfig()
+	cfg.NodeIP = "ws://down:26658,ws://down2:26658"
+	if _, err := NewClient(context.Background(), cfg); err == nil {
+		t.Error("connecting with no reachable node succeeded")
+	}
+}
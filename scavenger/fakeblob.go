@@ -0,0 +1,160 @@
+package scavenger
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// FakeBlobAPI is an in-memory BlobAPI for tests. Every Submit is included
+// in a new block, starting at height 1. Missing blobs fail with
+// blob.ErrBlobNotFound, as they do on a node. The Err fields, if set, are
+// returned by the corresponding method instead.
+type FakeBlobAPI struct {
+	SubmitErr   error
+	GetErr      error
+	GetProofErr error
+	IncludedErr error
+	// GasPrice is the minimum gas price MinGasPrice reports, and
+	// GasPriceErr its error if set.
+	GasPrice    float64
+	GasPriceErr error
+	// Memo is the memo of the last SubmitWithMemo.
+	Memo string
+
+	mu      sync.Mutex
+	heights [][]*blob.Blob
+}
+
+// Submit stores blobs at the next height.
+func (f *FakeBlobAPI) Submit(ctx context.Context, blobs []*blob.Blob, gasPrice float64) (uint64, error) {
+	result, err := f.SubmitWithResult(ctx, blobs, gasPrice)
+	if err != nil {
+		return 0, err
+	}
+	return result.Height, nil
+}
+
+// SubmitWithResult is like Submit, and makes up a transaction hash from
+// the height and the commitments.
+func (f *FakeBlobAPI) SubmitWithResult(_ context.Context, blobs []*blob.Blob, _ float64) (*SubmitResult, error) {
+	if f.SubmitErr != nil {
+		return nil, f.SubmitErr
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.heights = append(f.heights, blobs)
+	height := uint64(len(f.heights))
+
+	h := sha256.New()
+	_ = binary.Write(h, binary.BigEndian, height)
+	for _, b := range blobs {
+		h.Write(b.Commitment)
+	}
+	return &SubmitResult{Height: height, TxHash: strings.ToUpper(hex.EncodeToString(h.Sum(nil)))}, nil
+}
+
+// MinGasPrice returns GasPrice.
+func (f *FakeBlobAPI) MinGasPrice(context.Context) (float64, error) {
+	if f.GasPriceErr != nil {
+		return 0, f.GasPriceErr
+	}
+	return f.GasPrice, nil
+}
+
+// SubmitWithMemo is like SubmitWithResult, and records memo.
+func (f *FakeBlobAPI) SubmitWithMemo(ctx context.Context, blobs []*blob.Blob, gasPrice float64, memo string) (*SubmitResult, error) {
+	result, err := f.SubmitWithResult(ctx, blobs, gasPrice)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	f.Memo = memo
+	f.mu.Unlock()
+	return result, nil
+}
+
+// Get returns the blob with commitment in ns at height.
+func (f *FakeBlobAPI) Get(_ context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (*blob.Blob, error) {
+	if f.GetErr != nil {
+		return nil, f.GetErr
+	}
+	b := f.find(height, ns, commitment)
+	if b == nil {
+		return nil, blob.ErrBlobNotFound
+	}
+	return b, nil
+}
+
+// GetAll returns the blobs in namespaces at height.
+func (f *FakeBlobAPI) GetAll(_ context.Context, height uint64, namespaces []share.Namespace) ([]*blob.Blob, error) {
+	if f.GetErr != nil {
+		return nil, f.GetErr
+	}
+	var found []*blob.Blob
+	for _, b := range f.at(height) {
+		for _, ns := range namespaces {
+			if inNamespace(b, ns) {
+				found = append(found, b)
+				break
+			}
+		}
+	}
+	if len(found) == 0 {
+		return nil, blob.ErrBlobNotFound
+	}
+	return found, nil
+}
+
+// GetProof returns an empty proof for a stored blob. The fake doesn't
+// build real proofs, Included only checks that the blob exists.
+func (f *FakeBlobAPI) GetProof(_ context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (*blob.Proof, error) {
+	if f.GetProofErr != nil {
+		return nil, f.GetProofErr
+	}
+	if f.find(height, ns, commitment) == nil {
+		return nil, blob.ErrBlobNotFound
+	}
+	return &blob.Proof{}, nil
+}
+
+// Included reports whether the blob with commitment is stored in ns at
+// height.
+func (f *FakeBlobAPI) Included(_ context.Context, height uint64, ns share.Namespace, _ *blob.Proof, commitment blob.Commitment) (bool, error) {
+	if f.IncludedErr != nil {
+		return false, f.IncludedErr
+	}
+	return f.find(height, ns, commitment) != nil, nil
+}
+
+// at returns the blobs stored at height.
+func (f *FakeBlobAPI) at(height uint64) []*blob.Blob {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if height == 0 || height > uint64(len(f.heights)) {
+		return nil
+	}
+	return f.heights[height-1]
+}
+
+// find returns the stored blob with commitment in ns at height, or nil.
+func (f *FakeBlobAPI) find(height uint64, ns share.Namespace, commitment blob.Commitment) *blob.Blob {
+	for _, b := range f.at(height) {
+		if inNamespace(b, ns) && b.Commitment.Equal(commitment) {
+			return b
+		}
+	}
+	return nil
+}
+
+// inNamespace reports whether b was created in ns.
+func inNamespace(b *blob.Blob, ns share.Namespace) bool {
+	return b.NamespaceVersion == uint32(ns.Version()) && bytes.Equal(b.NamespaceId, ns.ID())
+}
@@ -0,0 +1,90 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+func TestFakeBlobAPI(t *testing.T) {
+	ctx := context.Background()
+	ns, other := testNS(t, testNamespace), testNS(t, "00000000000000006f74")
+	api := &FakeBlobAPI{}
+	a, b := testBlob(t, ns, "a"), testBlob(t, other, "b")
+	height, err := api.Submit(ctx, []*blob.Blob{a, b}, blob.DefaultGasPrice())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height != 1 {
+		t.Errorf("first submission at height %d, want 1", height)
+	}
+	result, err := api.SubmitWithResult(ctx, []*blob.Blob{testBlob(t, ns, "c")}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Height != 2 || len(result.TxHash) != 64 {
+		t.Errorf("second submission at height %d with hash %q, want height 2 and a hash", result.Height, result.TxHash)
+	}
+
+	got, err := api.Get(ctx, 1, ns, a.Commitment)
+	if err != nil || string(got.Data) != "a" {
+		t.Errorf("Get = %v, %v, want the blob submitted", got, err)
+	}
+	// Blobs are only found in their namespace, at their height.
+	if _, err := api.Get(ctx, 1, other, a.Commitment); !errors.Is(err, blob.ErrBlobNotFound) {
+		t.Errorf("Get in another namespace: error = %v, want ErrBlobNotFound", err)
+	}
+	if _, err := api.Get(ctx, 2, ns, a.Commitment); !errors.Is(err, blob.ErrBlobNotFound) {
+		t.Errorf("Get at another height: error = %v, want ErrBlobNotFound", err)
+	}
+	if _, err := api.Get(ctx, 9, ns, a.Commitment); !errors.Is(err, blob.ErrBlobNotFound) {
+		t.Errorf("Get above the chain: error = %v, want ErrBlobNotFound", err)
+	}
+
+	all, err := api.GetAll(ctx, 1, []share.Namespace{ns})
+	if err != nil || len(all) != 1 {
+		t.Errorf("GetAll in one namespace = %d blobs, %v, want 1", len(all), err)
+	}
+	if all, _ := api.GetAll(ctx, 1, []share.Namespace{ns, other}); len(all) != 2 {
+		t.Errorf("GetAll in both namespaces = %d blobs, want 2", len(all))
+	}
+
+	proof, err := api.GetProof(ctx, 1, ns, a.Commitment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := api.Included(ctx, 1, ns, proof, a.Commitment); err != nil || !ok {
+		t.Errorf("Included = %t, %v, want the blob included", ok, err)
+	}
+	if ok, _ := api.Included(ctx, 2, ns, proof, a.Commitment); ok {
+		t.Error("blob included at another height")
+	}
+}
+
+func TestFakeBlobAPIErrors(t *testing.T) {
+	ctx := context.Background()
+	ns := testNS(t, testNamespace)
+	fail := errors.New("injected")
+	api := &FakeBlobAPI{SubmitErr: fail, GetErr: fail, GetProofErr: fail, IncludedErr: fail}
+	if _, err := api.Submit(ctx, []*blob.Blob{testBlob(t, ns, "a")}, 0); !errors.Is(err, fail) {
+		t.Errorf("Submit: error = %v, want the injected one", err)
+	}
+	if fakeHeight(api) != 0 {
+		t.Error("failed submission was stored")
+	}
+	if _, err := api.Get(ctx, 1, ns, nil); !errors.Is(err, fail) {
+		t.Errorf("Get: error = %v, want the injected one", err)
+	}
+	if _, err := api.GetAll(ctx, 1, []share.Namespace{ns}); !errors.Is(err, fail) {
+		t.Errorf("GetAll: error = %v, want the injected one", err)
+	}
+	if _, err := api.GetProof(ctx, 1, ns, nil); !errors.Is(err, fail) {
+		t.Errorf("GetProof: error = %v, want the injected one", err)
+	}
+	if _, err := api.Included(ctx, 1, ns, nil, nil); !errors.Is(err, fail) {
+		t.Errorf("Included: error = %v, want the injected one", err)
+	}
+}
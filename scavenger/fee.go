@@ -0,0 +1,108 @@
+package scavenger
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/celestiaorg/celestia-openrpc/types/appconsts"
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+)
+
+// Gas constants of a PayForBlobs transaction, mirroring the network's
+// defaults. Together with the blob sizes they give a close upper bound of
+// the gas the node will estimate.
+const (
+	// pfbGasFixedCost is the gas charged for every PayForBlobs transaction.
+	pfbGasFixedCost = 75000
+	// bytesPerBlobInfo is the transaction size added by each blob, which is
+	// charged at txSizeCostPerByte.
+	bytesPerBlobInfo  = 70
+	txSizeCostPerByte = 10
+)
+
+// DefaultMaxBlobSize is the most blob data a block holds with the
+// network's default square size. A submission can't be any larger.
+const DefaultMaxBlobSize = appconsts.DefaultMaxBytes
+
+// ErrBlobTooLarge is returned for submissions larger than the configured
+// maximum blob size.
+var ErrBlobTooLarge = errors.New("blob too large")
+
+// checkBlobSize fails with ErrBlobTooLarge if the payloads together are
+// larger than maxSize. All chunks of a prompt go into the same block, so
+// chunking doesn't help, but compressing does.
+func checkBlobSize(payloads [][]byte, maxSize int) error {
+	size := 0
+	for _, payload := range payloads {
+		size += len(payload)
+	}
+	if size > maxSize {
+		return fmt.Errorf("%w: %d bytes, the limit is %d bytes (try -compress gzip)", ErrBlobTooLarge, size, maxSize)
+	}
+	return nil
+}
+
+// ErrOverSizeLimit is returned for submissions larger than the configured
+// size limit, unless the user agreed to them up front.
+var ErrOverSizeLimit = errors.New("submission over the size limit")
+
+// checkSizeGuard warns about submissions of size bytes above the warning
+// threshold, and fails with ErrOverSizeLimit for those above the limit,
+// unless AssumeYes is set. Both report the estimated fee, since that is
+// what the thresholds are about.
+func (c *Client) checkSizeGuard(size int, est FeeEstimate) error {
+	cfg := c.Config
+	if cfg.SizeLimit > 0 && size > cfg.SizeLimit {
+		if !cfg.AssumeYes {
+			return fmt.Errorf("%w: %d bytes, the limit is %d bytes, estimated %s (pass -yes to submit anyway)", ErrOverSizeLimit, size, cfg.SizeLimit, est)
+		}
+		c.logger().Warn("Submitting over the size limit", "bytes", size, "limit", cfg.SizeLimit, "estimate", est.String())
+		return nil
+	}
+	if cfg.SizeWarn > 0 && size > cfg.SizeWarn {
+		c.logger().Warn("Submitting a large payload", "bytes", size, "threshold", cfg.SizeWarn, "estimate", est.String())
+	}
+	return nil
+}
+
+// FeeEstimate is the approximate cost of submitting a set of blobs.
+type FeeEstimate struct {
+	Shares   int     `json:"shares"`
+	Gas      uint64  `json:"gas"`
+	GasPrice float64 `json:"gas_price"`
+	// Fee is in utia.
+	Fee float64 `json:"fee"`
+}
+
+// EstimateFee estimates the cost of submitting blobs with the given payload
+// sizes at gasPrice. The default gas price is estimated with the network's
+// default minimum gas price.
+func EstimateFee(sizes []int, gasPrice float64) FeeEstimate {
+	if gasPrice == blob.DefaultGasPrice() {
+		gasPrice = appconsts.DefaultMinGasPrice
+	}
+
+	est := FeeEstimate{Gas: pfbGasFixedCost, GasPrice: gasPrice}
+	for _, size := range sizes {
+		shares := sparseSharesNeeded(size)
+		est.Shares += shares
+		est.Gas += uint64(shares*appconsts.ShareSize*appconsts.DefaultGasPerBlobByte) + bytesPerBlobInfo*txSizeCostPerByte
+	}
+	est.Fee = float64(est.Gas) * gasPrice
+	return est
+}
+
+// sparseSharesNeeded returns the number of shares a blob of size bytes
+// takes up.
+func sparseSharesNeeded(size int) int {
+	if size <= appconsts.FirstSparseShareContentSize {
+		return 1
+	}
+	rest := size - appconsts.FirstSparseShareContentSize
+	return 1 + (rest+appconsts.ContinuationSparseShareContentSize-1)/appconsts.ContinuationSparseShareContentSize
+}
+
+// String formats the estimate for the user, with the fee in TIA.
+func (e FeeEstimate) String() string {
+	return fmt.Sprintf("%d blob share(s), ~%d gas at %v utia/gas, ~%.6f TIA", e.Shares, e.Gas, e.GasPrice, e.Fee/1e6)
+}
@@ -0,0 +1,175 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/celestiaorg/celestia-openrpc/types/appconsts"
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+)
+
+func TestSparseSharesNeeded(t *testing.T) {
+	tests := []struct {
+		size, want int
+	}{
+		{0, 1},
+		{1, 1},
+		{appconsts.FirstSparseShareContentSize, 1},
+		{appconsts.FirstSparseShareContentSize + 1, 2},
+		{appconsts.FirstSparseShareContentSize + appconsts.ContinuationSparseShareContentSize, 2},
+		{appconsts.FirstSparseShareContentSize + appconsts.ContinuationSparseShareContentSize + 1, 3},
+	}
+	for _, tt := range tests {
+		if got := sparseSharesNeeded(tt.size); got != tt.want {
+			t.Errorf("sparseSharesNeeded(%d) = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateFee(t *testing.T) {
+	est := EstimateFee([]int{100}, 0.002)
+	if est.Shares != 1 || est.Gas != 79796 || est.GasPrice != 0.002 {
+		t.Errorf("estimate = %+v, want 1 share and 79796 gas at 0.002", est)
+	}
+	if want := 79796 * 0.002; est.Fee != want {
+		t.Errorf("fee = %v, want %v", est.Fee, want)
+	}
+
+	// Every blob adds its shares and its blob info.
+	est = EstimateFee([]int{100, appconsts.FirstSparseShareContentSize + 1}, 0.002)
+	if est.Shares != 3 || est.Gas != 88688 {
+		t.Errorf("estimate = %+v, want 3 shares and 88688 gas", est)
+	}
+
+	// The default gas price is estimated at the default minimum.
+	est = EstimateFee([]int{100}, blob.DefaultGasPrice())
+	if est.GasPrice != appconsts.DefaultMinGasPrice {
+		t.Errorf("gas price = %v, want the default minimum %v", est.GasPrice, appconsts.DefaultMinGasPrice)
+	}
+}
+
+func TestFeeEstimateString(t *testing.T) {
+	est := FeeEstimate{Shares: 1, Gas: 80000, GasPrice: 0.002, Fee: 160}
+	if got, want := est.String(), "1 blob share(s), ~80000 gas at 0.002 utia/gas, ~0.000160 TIA"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestClientConfirm(t *testing.T) {
+	c, api, _ := newTestClient(testConfig())
+	var estimates []FeeEstimate
+	declined := errors.New("declined")
+	c.Confirm = func(est FeeEstimate) error {
+		estimates = append(estimates, est)
+		return declined
+	}
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi"); !errors.Is(err, declined) {
+		t.Fatalf("error = %v, want the confirmation's", err)
+	}
+	if fakeHeight(api) != 0 {
+		t.Error("the prompt was submitted without confirmation")
+	}
+	if len(estimates) != 1 || estimates[0].Shares != 1 {
+		t.Errorf("confirmed estimates %+v, want one of a single share", estimates)
+	}
+
+	c.Confirm = func(FeeEstimate) error { return nil }
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if fakeHeight(api) != 1 {
+		t.Error("the confirmed prompt wasn't submitted")
+	}
+}
+
+func TestCheckBlobSize(t *testing.T) {
+	if err := checkBlobSize([][]byte{make([]byte, 60), make([]byte, 40)}, 100); err != nil {
+		t.Errorf("payloads at the limit: %v", err)
+	}
+	err := checkBlobSize([][]byte{make([]byte, 60), make([]byte, 41)}, 100)
+	if !errors.Is(err, ErrBlobTooLarge) {
+		t.Fatalf("error = %v, want ErrBlobTooLarge", err)
+	}
+	if !strings.Contains(err.Error(), "101 bytes, the limit is 100 bytes") || !strings.Contains(err.Error(), "-compress") {
+		t.Errorf("error %q doesn't give the sizes and suggest compressing", err)
+	}
+}
+
+func TestSubmitBlobTooLarge(t *testing.T) {
+	cfg := testConfig()
+	cfg.Raw = true
+	cfg.MaxBlobSize = 5
+	c, api, _ := newTestClient(cfg)
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "12345"); err != nil {
+		t.Fatalf("prompt at the limit: %v", err)
+	}
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "123456"); !errors.Is(err, ErrBlobTooLarge) {
+		t.Errorf("error = %v, want ErrBlobTooLarge", err)
+	}
+	if fakeHeight(api) != 1 {
+		t.Errorf("chain is at height %d, want the oversized prompt not submitted", fakeHeight(api))
+	}
+
+	cfg.MaxBlobSize = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("max blob size 0 was accepted")
+	}
+}
+
+func TestSubmitSizeGuard(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		assumeYes bool
+		warning   string
+		err       error
+	}{
+		{"small", 10, false, "", nil},
+		{"large", 20, false, "Submitting a large payload", nil},
+		{"over the limit", 40, false, "", ErrOverSizeLimit},
+		{"over the limit with -yes", 40, true, "Submitting over the size limit", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Raw = true
+			cfg.SizeWarn, cfg.SizeLimit = 16, 32
+			cfg.AssumeYes = tt.assumeYes
+			c, api, _ := newTestClient(cfg)
+			var logs strings.Builder
+			c.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+			_, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), strings.Repeat("x", tt.size))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if submitted := fakeHeight(api) == 1; submitted != (tt.err == nil) {
+				t.Errorf("submitted = %v, want %v", submitted, tt.err == nil)
+			}
+			if tt.warning == "" && strings.Contains(logs.String(), "level=WARN") {
+				t.Errorf("logged %q, want no warning", logs.String())
+			}
+			if tt.warning != "" && !strings.Contains(logs.String(), tt.warning) {
+				t.Errorf("logged %q, want %q", logs.String(), tt.warning)
+			}
+		})
+	}
+}
+
+func TestSubmitSizeGuardMessage(t *testing.T) {
+	cfg := testConfig()
+	cfg.Raw = true
+	cfg.SizeLimit = 4
+	c, _, _ := newTestClient(cfg)
+	_, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "too long")
+	if err == nil || !strings.Contains(err.Error(), "8 bytes, the limit is 4 bytes, estimated") || !strings.Contains(err.Error(), "-yes") {
+		t.Errorf("error = %v, want the size, limit, estimate and way out", err)
+	}
+
+	cfg.SizeWarn = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("negative size threshold was accepted")
+	}
+}
@@ -0,0 +1,83 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// fetchBlobs fetches the blobs with the given commitments at height, up to
+// concurrency at once, and returns them in the order of commitments. The
+// first failure cancels the fetches still running, unless keepGoing is
+// set, in which case every failure is reported.
+func fetchBlobs(
+	ctx context.Context,
+	api BlobAPI,
+	height uint64,
+	ns share.Namespace,
+	commitments []blob.Commitment,
+	concurrency int,
+	keepGoing bool,
+) ([]*blob.Blob, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	blobs := make([]*blob.Blob, len(commitments))
+	errs := make([]error, len(commitments))
+	var (
+		mu       sync.Mutex
+		firstErr error
+	)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(concurrency, 1), len(commitments)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				b, err := api.Get(ctx, height, ns, commitments[i])
+				if err != nil {
+					errs[i] = fmt.Errorf("Failed to fetch blob %x: %w", commitments[i], err)
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = errs[i]
+						if !keepGoing {
+							cancel()
+						}
+					}
+					mu.Unlock()
+					continue
+				}
+				blobs[i] = b
+			}
+		}()
+	}
+	for i := range commitments {
+		select {
+		case jobs <- i:
+			continue
+		case <-ctx.Done():
+		}
+		break
+	}
+	close(jobs)
+	wg.Wait()
+
+	// After a cancellation, the other fetches fail with the context's
+	// error, which would only hide the one that caused it.
+	if !keepGoing && firstErr != nil {
+		return nil, firstErr
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	// Otherwise, the caller's ctx was done before every fetch started.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return blobs, nil
+}
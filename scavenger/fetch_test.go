@@ -0,0 +1,155 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+func TestFetchPrompt(t *testing.T) {
+	c, _, _ := newTestClient(testConfig())
+	ns := testNS(t, testNamespace)
+	sub, err := c.SubmitPrompt(context.Background(), ns, "fetch me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched, err := c.FetchPrompt(context.Background(), sub.Height, ns, sub.Commitments())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fetched.Payload) != "fetch me" {
+		t.Errorf("payload = %q, want the prompt", fetched.Payload)
+	}
+	if fetched.Metadata == nil || fetched.Metadata.Model != c.Config.Model {
+		t.Errorf("metadata = %+v, want the envelope's", fetched.Metadata)
+	}
+	if err := c.VerifyBlobs(sub, fetched); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFetchPromptMissing(t *testing.T) {
+	c, _, _ := newTestClient(testConfig())
+	ns := testNS(t, testNamespace)
+	sub, err := c.SubmitPrompt(context.Background(), ns, "fetch me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.FetchPrompt(context.Background(), sub.Height+1, ns, sub.Commitments()); !IsBlobNotFound(err) {
+		t.Errorf("error = %v, want blob not found", err)
+	}
+}
+
+// slowBlobAPI delays getting each blob by the delay for its data, and
+// fails for the data in fail. A negative delay blocks until the context is
+// done. It records how many gets ran at once.
+type slowBlobAPI struct {
+	*FakeBlobAPI
+	delays map[string]time.Duration
+	fail   map[string]error
+
+	mu                  sync.Mutex
+	running, maxRunning int
+}
+
+func (a *slowBlobAPI) Get(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (*blob.Blob, error) {
+	a.mu.Lock()
+	a.running++
+	a.maxRunning = max(a.maxRunning, a.running)
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		a.running--
+		a.mu.Unlock()
+	}()
+
+	b, err := a.FakeBlobAPI.Get(ctx, height, ns, commitment)
+	if err != nil {
+		return nil, err
+	}
+	data := string(b.Data)
+	if delay := a.delays[data]; delay < 0 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	} else {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err := a.fail[data]; err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// submitSlowBlobs submits blobs with data at height 1 of a slowBlobAPI
+// and returns it with their commitments.
+func submitSlowBlobs(t *testing.T, data ...string) (*slowBlobAPI, []blob.Commitment) {
+	t.Helper()
+	api := &FakeBlobAPI{}
+	submitBlobs(t, api, data...)
+	var commitments []blob.Commitment
+	for _, b := range api.at(1) {
+		commitments = append(commitments, b.Commitment)
+	}
+	return &slowBlobAPI{FakeBlobAPI: api, delays: map[string]time.Duration{}, fail: map[string]error{}}, commitments
+}
+
+func TestFetchBlobsOrder(t *testing.T) {
+	data := []string{"a", "b", "c", "d", "e"}
+	api, commitments := submitSlowBlobs(t, data...)
+	// The first blobs take the longest.
+	for i, d := range data {
+		api.delays[d] = time.Duration(len(data)-i) * 5 * time.Millisecond
+	}
+	blobs, err := fetchBlobs(context.Background(), api, 1, testNS(t, testNamespace), commitments, 3, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, b := range blobs {
+		if string(b.Data) != data[i] {
+			t.Errorf("blob %d = %q, want %q", i, b.Data, data[i])
+		}
+	}
+	if api.maxRunning != 3 {
+		t.Errorf("%d fetches ran at once, want 3", api.maxRunning)
+	}
+}
+
+func TestFetchBlobsCancelsOnError(t *testing.T) {
+	api, commitments := submitSlowBlobs(t, "a", "b", "c")
+	broken := errors.New("broken")
+	api.fail["a"] = broken
+	api.delays["b"], api.delays["c"] = -1, -1
+	_, err := fetchBlobs(context.Background(), api, 1, testNS(t, testNamespace), commitments, 3, false)
+	if !errors.Is(err, broken) || errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want only the failing fetch's", err)
+	}
+}
+
+func TestFetchBlobsKeepGoing(t *testing.T) {
+	api, commitments := submitSlowBlobs(t, "a", "b", "c")
+	first, second := errors.New("first"), errors.New("second")
+	api.fail["a"], api.fail["c"] = first, second
+	api.delays["c"] = 10 * time.Millisecond
+	_, err := fetchBlobs(context.Background(), api, 1, testNS(t, testNamespace), commitments, 1, true)
+	if !errors.Is(err, first) || !errors.Is(err, second) {
+		t.Errorf("error = %v, want both failures", err)
+	}
+}
+
+func TestFetchBlobsCanceled(t *testing.T) {
+	api, commitments := submitSlowBlobs(t, "a", "b")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := fetchBlobs(ctx, api, 1, testNS(t, testNamespace), commitments, 1, true); !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want the cancellation", err)
+	}
+}
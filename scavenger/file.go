@@ -0,0 +1,57 @@
+package scavenger
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// fileMarker prefixes payloads holding a file rather than a prompt. It is
+// followed by the length of the content type as a uvarint, the content
+// type and the file's bytes, unchanged.
+const fileMarker = "PSF\x01"
+
+// encodeFile wraps data in a file payload with its content type.
+func encodeFile(contentType string, data []byte) []byte {
+	buf := make([]byte, 0, len(fileMarker)+binary.MaxVarintLen64+len(contentType)+len(data))
+	buf = append(buf, fileMarker...)
+	buf = binary.AppendUvarint(buf, uint64(len(contentType)))
+	buf = append(buf, contentType...)
+	return append(buf, data...)
+}
+
+// decodeFile unwraps a file payload. It reports false for payloads that
+// aren't files.
+func decodeFile(payload []byte) (string, []byte, bool, error) {
+	if !IsFilePayload(payload) {
+		return "", nil, false, nil
+	}
+	rest := payload[len(fileMarker):]
+	n, size := binary.Uvarint(rest)
+	if size <= 0 || n > uint64(len(rest)-size) {
+		return "", nil, false, fmt.Errorf("invalid file payload header")
+	}
+	rest = rest[size:]
+	return string(rest[:n]), rest[n:], true, nil
+}
+
+// IsFilePayload reports whether the decoded payload holds a file submitted
+// with SubmitFile rather than a prompt.
+func IsFilePayload(payload []byte) bool {
+	return bytes.HasPrefix(payload, []byte(fileMarker))
+}
+
+// SubmitFile submits the bytes of a file to ns, along with its content
+// type. Like prompts, files are compressed, encrypted and chunked as
+// configured, but FetchPrompt gives back the bytes as they were.
+func (c *Client) SubmitFile(ctx context.Context, ns share.Namespace, contentType string, data []byte) (*Submission, error) {
+	file := encodeFile(contentType, data)
+	payloads, err := encodePayloads(c.Config, file)
+	if err != nil {
+		return nil, err
+	}
+	return c.submit(ctx, ns, file, payloads)
+}
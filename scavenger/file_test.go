@@ -0,0 +1,88 @@
+package scavenger
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// binaryData is a small file that isn't valid UTF-8 and holds every byte
+// value.
+func binaryData() []byte {
+	data := make([]byte, 300)
+	for i := range data {
+		data[i] = byte(255 - i%256)
+	}
+	return data
+}
+
+func TestEncodeFile(t *testing.T) {
+	payload := encodeFile("image/png", binaryData())
+	if !IsFilePayload(payload) {
+		t.Fatal("file payload isn't recognized")
+	}
+	contentType, data, ok, err := decodeFile(payload)
+	if err != nil || !ok {
+		t.Fatalf("decoding failed (ok %v, error %v)", ok, err)
+	}
+	if contentType != "image/png" || !bytes.Equal(data, binaryData()) {
+		t.Errorf("decoded %q with %d bytes, want the file back", contentType, len(data))
+	}
+
+	if _, _, ok, err := decodeFile([]byte("a prompt")); ok || err != nil {
+		t.Errorf("prompt was decoded as a file (error %v)", err)
+	}
+	// A content type longer than the payload.
+	if _, _, _, err := decodeFile([]byte(fileMarker + "\x7fimage/png")); err == nil {
+		t.Error("decoding a truncated file payload succeeded")
+	}
+}
+
+func TestSubmitFileRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  func(*Config)
+	}{
+		{"raw", func(cfg *Config) { cfg.Raw = true }},
+		{"envelope", func(*Config) {}},
+		{"compressed", func(cfg *Config) { cfg.Compress = CompressGzip }},
+		{"encrypted", func(cfg *Config) { cfg.Encrypt, cfg.EncryptionKey = true, testKey }},
+		{"chunked", func(cfg *Config) { cfg.Raw, cfg.ChunkSize = true, 64 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			tt.cfg(cfg)
+			c, _, _ := newTestClient(cfg)
+			sub, err := c.SubmitFile(context.Background(), testNS(t, testNamespace), "application/octet-stream", binaryData())
+			if err != nil {
+				t.Fatal(err)
+			}
+			fetched, err := c.FetchPrompt(context.Background(), sub.Height, sub.Namespace, sub.Commitments())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(fetched.Payload, binaryData()) {
+				t.Errorf("fetched %x, want the file byte for byte", fetched.Payload)
+			}
+			if fetched.ContentType != "application/octet-stream" {
+				t.Errorf("content type = %q, want the submitted one", fetched.ContentType)
+			}
+		})
+	}
+}
+
+func TestFetchPromptNotFile(t *testing.T) {
+	c, _, _ := newTestClient(testConfig())
+	sub, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "just text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched, err := c.FetchPrompt(context.Background(), sub.Height, sub.Namespace, sub.Commitments())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched.ContentType != "" || string(fetched.Payload) != "just text" {
+		t.Errorf("fetched %q as %q, want the prompt without a content type", fetched.Payload, fetched.ContentType)
+	}
+}
@@ -0,0 +1,47 @@
+package scavenger
+
+import (
+	"context"
+
+	"github.com/celestiaorg/celestia-openrpc/types/appconsts"
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+)
+
+// GasPricer is implemented by blob APIs that can report the network's
+// minimum gas price. The node API can't, so for nodes the minimum the app
+// defaults to is used instead.
+type GasPricer interface {
+	MinGasPrice(ctx context.Context) (float64, error)
+}
+
+// gasPrice returns the gas price to submit with. With GasPriceMin set, it
+// is the minimum gas price times GasPriceMultiplier: the one the blob API
+// reports if it is a GasPricer, the app's default otherwise. If a
+// GasPricer fails, the node picks the price instead.
+func (c *Client) gasPrice(ctx context.Context) float64 {
+	cfg := c.Config
+	if !cfg.GasPriceMin {
+		return cfg.GasPrice
+	}
+
+	pricer, ok := c.Blobs.(GasPricer)
+	if !ok {
+		price := appconsts.DefaultMinGasPrice * cfg.GasPriceMultiplier
+		c.logger().Info("Using the default minimum gas price",
+			"gas_price", price,
+			"default_minimum", appconsts.DefaultMinGasPrice,
+			"multiplier", cfg.GasPriceMultiplier)
+		return price
+	}
+	minimum, err := pricer.MinGasPrice(ctx)
+	if err != nil {
+		c.logger().Warn("Failed to query the network's gas price, letting the node pick it", "error", err)
+		return blob.DefaultGasPrice()
+	}
+	price := minimum * cfg.GasPriceMultiplier
+	c.logger().Info("Using the network's minimum gas price",
+		"gas_price", price,
+		"network_minimum", minimum,
+		"multiplier", cfg.GasPriceMultiplier)
+	return price
+}
@@ -0,0 +1,165 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/celestiaorg/celestia-openrpc/types/appconsts"
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+)
+
+// gasRecordingAPI is a BlobAPI recording the gas price of every Submit.
+// It only has the methods of BlobAPI, so the client submits with Submit.
+type gasRecordingAPI struct {
+	BlobAPI
+
+	mu     sync.Mutex
+	prices []float64
+}
+
+func (a *gasRecordingAPI) Submit(ctx context.Context, blobs []*blob.Blob, gasPrice float64) (uint64, error) {
+	a.mu.Lock()
+	a.prices = append(a.prices, gasPrice)
+	a.mu.Unlock()
+	return a.BlobAPI.Submit(ctx, blobs, gasPrice)
+}
+
+func TestSubmitGasPrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		gasPrice string
+		want     float64
+	}{
+		{"default", "", blob.DefaultGasPrice()},
+		{"configured", "0.25", 0.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			if tt.gasPrice != "" {
+				if err := cfg.SetGasPrice(tt.gasPrice); err != nil {
+					t.Fatal(err)
+				}
+			}
+			api := &gasRecordingAPI{BlobAPI: &FakeBlobAPI{}}
+			c := &Client{Config: cfg, Blobs: api}
+			if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi"); err != nil {
+				t.Fatal(err)
+			}
+			if len(api.prices) != 1 || api.prices[0] != tt.want {
+				t.Errorf("submitted with gas prices %v, want %v", api.prices, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetGasPrice(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.SetGasPrice("0.01"); err != nil || cfg.GasPrice != 0.01 {
+		t.Errorf("gas price = %v (error %v), want 0.01", cfg.GasPrice, err)
+	}
+	if err := cfg.SetGasPrice("cheap"); err == nil {
+		t.Error("setting a gas price that isn't a number succeeded")
+	}
+}
+
+func TestValidateGasPrice(t *testing.T) {
+	for _, price := range []float64{0, -0.5} {
+		cfg := testConfig()
+		cfg.GasPrice = price
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("gas price %v was accepted", price)
+		}
+	}
+	// The default tells the node to pick the price, but only if it isn't
+	// given explicitly.
+	if err := testConfig().Validate(); err != nil {
+		t.Errorf("default gas price: %v", err)
+	}
+	for _, s := range []string{"-1", "NaN"} {
+		cfg := testConfig()
+		if err := cfg.SetGasPrice(s); err != nil {
+			t.Fatal(err)
+		}
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "gas price must be positive") {
+			t.Errorf("gas price %s: error = %v, want it rejected", s, err)
+		}
+	}
+	for _, s := range []string{"-1", ".nan"} {
+		cfg, err := LoadConfig(writeFile(t, "config.yaml", "gas_price: "+s+"\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.Namespace = testNamespace
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "gas price must be positive") {
+			t.Errorf("config file gas price %s: error = %v, want it rejected", s, err)
+		}
+	}
+}
+
+// gasPricingAPI is a gasRecordingAPI that is a GasPricer too, reporting
+// the minimum gas price of its FakeBlobAPI.
+type gasPricingAPI struct {
+	*gasRecordingAPI
+	fake *FakeBlobAPI
+}
+
+func (a *gasPricingAPI) MinGasPrice(ctx context.Context) (float64, error) {
+	return a.fake.MinGasPrice(ctx)
+}
+
+func TestSubmitGasPriceMin(t *testing.T) {
+	tests := []struct {
+		name   string
+		pricer bool
+		price  float64
+		err    error
+		want   float64
+	}{
+		{"network minimum", true, 0.5, nil, 1},
+		{"network fails", true, 0, errors.New("not implemented"), blob.DefaultGasPrice()},
+		{"no pricer", false, 0, nil, appconsts.DefaultMinGasPrice * 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			if err := cfg.SetGasPrice(GasPriceMinValue); err != nil {
+				t.Fatal(err)
+			}
+			cfg.GasPriceMultiplier = 2
+			fake := &FakeBlobAPI{GasPrice: tt.price, GasPriceErr: tt.err}
+			recording := &gasRecordingAPI{BlobAPI: fake}
+			c := &Client{Config: cfg, Blobs: recording}
+			if tt.pricer {
+				c.Blobs = &gasPricingAPI{gasRecordingAPI: recording, fake: fake}
+			}
+			if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi"); err != nil {
+				t.Fatal(err)
+			}
+			if len(recording.prices) != 1 || recording.prices[0] != tt.want {
+				t.Errorf("submitted with gas prices %v, want %v", recording.prices, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetGasPriceMin(t *testing.T) {
+	cfg := DefaultConfig()
+	for _, s := range []string{GasPriceAutoValue, GasPriceMinValue} {
+		cfg.GasPriceMin = false
+		if err := cfg.SetGasPrice(s); err != nil || !cfg.GasPriceMin {
+			t.Fatalf("-gas-price %s: gas price min = %v (error %v), want it set", s, cfg.GasPriceMin, err)
+		}
+	}
+	if err := cfg.SetGasPrice("0.1"); err != nil || cfg.GasPriceMin || cfg.GasPrice != 0.1 {
+		t.Errorf("gas price %v with min %v (error %v), want a fixed price replacing min", cfg.GasPrice, cfg.GasPriceMin, err)
+	}
+	cfg = testConfig()
+	cfg.GasPriceMultiplier = 0
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "multiplier") {
+		t.Errorf("error = %v, want a zero gas price multiplier rejected", err)
+	}
+}
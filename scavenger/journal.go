@@ -0,0 +1,141 @@
+package scavenger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Journal record states. A record is pending while its submission is in
+// flight and done once the network reported the height.
+const (
+	JournalPending = "pending"
+	JournalDone    = "done"
+)
+
+// JournalRecord is what the journal knows about one submission.
+type JournalRecord struct {
+	Status      string    `json:"status"`
+	Namespace   string    `json:"namespace"`
+	Height      uint64    `json:"height,omitempty"`
+	TxHash      string    `json:"tx_hash,omitempty"`
+	Commitments []string  `json:"commitments,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Journal records submissions on disk, one JSON file per payload, so that
+// rerunning after a crash reuses a submission instead of paying for it
+// twice.
+type Journal struct {
+	Dir string
+}
+
+// NewJournal creates a journal in dir. An empty dir selects a directory
+// next to the response cache, so clearing the cache keeps the journal.
+func NewJournal(dir string) (*Journal, error) {
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("error finding journal directory: %w", err)
+		}
+		dir = filepath.Join(base, "prompt-scavenger-journal")
+	}
+	return &Journal{Dir: dir}, nil
+}
+
+// JournalKey derives the journal key of a submission from its namespace,
+// its content and whether cfg encrypts and compresses it, as the same
+// prompt submitted encrypted or compressed is a different blob.
+func JournalKey(cfg *Config, ns []byte, content []byte) string {
+	encoding := fmt.Sprintf("encrypt=%t,compress=%s", cfg.Encrypt, cfg.Compress)
+	h := sha256.New()
+	for _, b := range [][]byte{ns, content, []byte(encoding)} {
+		// The lengths keep the fields from running into each other.
+		fmt.Fprintf(h, "%d:%s", len(b), b)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// path returns the file the record for key is stored in.
+func (j *Journal) path(key string) string {
+	return filepath.Join(j.Dir, key+".json")
+}
+
+// Get returns the record for key, if there is one.
+func (j *Journal) Get(key string) (*JournalRecord, bool, error) {
+	data, err := os.ReadFile(j.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading journal record: %w", err)
+	}
+
+	var record JournalRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, false, fmt.Errorf("error decoding journal record: %w", err)
+	}
+	return &record, true, nil
+}
+
+// Put stores record under key, stamped with the current time. The file is
+// replaced atomically and synced, so a crash leaves either the old or the
+// new record.
+func (j *Journal) Put(key string, record *JournalRecord) error {
+	record.UpdatedAt = time.Now()
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error encoding journal record: %w", err)
+	}
+	if err := os.MkdirAll(j.Dir, 0o700); err != nil {
+		return fmt.Errorf("error creating journal directory: %w", err)
+	}
+	tmp := j.path(key) + ".tmp"
+	if err := writeFileSync(tmp, data); err != nil {
+		return fmt.Errorf("error writing journal record: %w", err)
+	}
+	if err := os.Rename(tmp, j.path(key)); err != nil {
+		return fmt.Errorf("error writing journal record: %w", err)
+	}
+	// Syncing the directory makes the rename itself survive a crash.
+	if err := syncDir(j.Dir); err != nil {
+		return fmt.Errorf("error writing journal record: %w", err)
+	}
+	return nil
+}
+
+// writeFileSync writes data to the file name and syncs it to disk before
+// closing it.
+func writeFileSync(name string, data []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir syncs the directory dir, and with it the entries renamed into it.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+	return d.Close()
+}
@@ -0,0 +1,152 @@
+package scavenger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournalRoundTrip(t *testing.T) {
+	j, err := NewJournal(filepath.Join(t.TempDir(), "journal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := j.Get("missing"); ok || err != nil {
+		t.Fatalf("got a record for a missing key (error %v)", err)
+	}
+	want := &JournalRecord{Status: JournalDone, Namespace: testNamespace, Height: 3, Commitments: []string{"ab"}}
+	if err := j.Put("key", want); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := j.Get("key")
+	if err != nil || !ok {
+		t.Fatalf("record wasn't found (error %v)", err)
+	}
+	if got.Status != want.Status || got.Height != want.Height || len(got.Commitments) != 1 || got.UpdatedAt.IsZero() {
+		t.Errorf("read %+v, want %+v with a time stamp", got, want)
+	}
+	if err := os.WriteFile(j.path("bad"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := j.Get("bad"); err == nil {
+		t.Error("reading a corrupt record succeeded")
+	}
+}
+
+func TestJournalKey(t *testing.T) {
+	cfg := testConfig()
+	key := JournalKey(cfg, []byte("ns"), []byte("prompt"))
+	if key != JournalKey(cfg, []byte("ns"), []byte("prompt")) {
+		t.Error("the same submission got different keys")
+	}
+	if key == JournalKey(cfg, []byte("ns"), []byte("other")) || key == JournalKey(cfg, []byte("other"), []byte("prompt")) {
+		t.Error("different submissions got the same key")
+	}
+	if JournalKey(cfg, []byte("nsp"), []byte("rompt")) == key {
+		t.Error("moving bytes between the fields kept the key")
+	}
+
+	encrypted, compressed := testConfig(), testConfig()
+	encrypted.Encrypt = true
+	compressed.Compress = CompressGzip
+	if JournalKey(encrypted, []byte("ns"), []byte("prompt")) == key || JournalKey(compressed, []byte("ns"), []byte("prompt")) == key {
+		t.Error("encoding the submission differently kept the key")
+	}
+}
+
+// journalClient returns a test client submitting to api, with a journal
+// in dir.
+func journalClient(t *testing.T, api *FakeBlobAPI, dir string) *Client {
+	t.Helper()
+	c, _, _ := newTestClient(testConfig())
+	c.Blobs = api
+	c.Journal = &Journal{Dir: dir}
+	return c
+}
+
+func TestClientJournalRestart(t *testing.T) {
+	api, dir := &FakeBlobAPI{}, t.TempDir()
+	ns := testNS(t, testNamespace)
+	first, err := journalClient(t, api, dir).SubmitPrompt(context.Background(), ns, "only once")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new process with the same journal reuses the submission.
+	second, err := journalClient(t, api, dir).SubmitPrompt(context.Background(), ns, "only once")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fakeHeight(api) != 1 {
+		t.Fatalf("chain is at height %d, want the prompt submitted once", fakeHeight(api))
+	}
+	if second.Height != first.Height || string(second.Blobs[0].Commitment) != string(first.Blobs[0].Commitment) {
+		t.Errorf("second submission at %d, want the first one's at %d", second.Height, first.Height)
+	}
+	record, ok, err := (&Journal{Dir: dir}).Get(JournalKey(testConfig(), ns, []byte("only once")))
+	if err != nil || !ok || record.Status != JournalDone || record.Height != first.Height {
+		t.Errorf("journal record = %+v (error %v), want a done one at the submission's height", record, err)
+	}
+
+	// Another prompt isn't mistaken for it.
+	if _, err := journalClient(t, api, dir).SubmitPrompt(context.Background(), ns, "another"); err != nil {
+		t.Fatal(err)
+	}
+	if fakeHeight(api) != 2 {
+		t.Errorf("chain is at height %d, want the other prompt submitted", fakeHeight(api))
+	}
+}
+
+func TestClientJournalPending(t *testing.T) {
+	api, dir := &FakeBlobAPI{}, t.TempDir()
+	ns := testNS(t, testNamespace)
+	key := JournalKey(testConfig(), ns, []byte("interrupted"))
+	if err := (&Journal{Dir: dir}).Put(key, &JournalRecord{Status: JournalPending, Namespace: NamespaceHex(ns)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := journalClient(t, api, dir).SubmitPrompt(context.Background(), ns, "interrupted"); err != nil {
+		t.Fatal(err)
+	}
+	if fakeHeight(api) != 1 {
+		t.Errorf("chain is at height %d, want the interrupted submission made again", fakeHeight(api))
+	}
+	record, _, err := (&Journal{Dir: dir}).Get(key)
+	if err != nil || record.Status != JournalDone {
+		t.Errorf("journal record = %+v (error %v), want the pending one replaced", record, err)
+	}
+}
+
+func TestClientJournalMissingBlobs(t *testing.T) {
+	dir := t.TempDir()
+	ns := testNS(t, testNamespace)
+	if _, err := journalClient(t, &FakeBlobAPI{}, dir).SubmitPrompt(context.Background(), ns, "lost"); err != nil {
+		t.Fatal(err)
+	}
+	// Another chain doesn't have the journaled blobs.
+	api := &FakeBlobAPI{}
+	if _, err := journalClient(t, api, dir).SubmitPrompt(context.Background(), ns, "lost"); err != nil {
+		t.Fatal(err)
+	}
+	if fakeHeight(api) != 1 {
+		t.Errorf("chain is at height %d, want the prompt submitted again", fakeHeight(api))
+	}
+}
+
+func TestClientJournalEncoding(t *testing.T) {
+	api, dir := &FakeBlobAPI{}, t.TempDir()
+	ns := testNS(t, testNamespace)
+	if _, err := journalClient(t, api, dir).SubmitPrompt(context.Background(), ns, "twice"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The compressed prompt is another blob, so it is submitted too.
+	c := journalClient(t, api, dir)
+	c.Config.Compress = CompressGzip
+	if _, err := c.SubmitPrompt(context.Background(), ns, "twice"); err != nil {
+		t.Fatal(err)
+	}
+	if fakeHeight(api) != 2 {
+		t.Errorf("chain is at height %d, want the compressed prompt submitted as well", fakeHeight(api))
+	}
+}
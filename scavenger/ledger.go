@@ -0,0 +1,118 @@
+package scavenger
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// LedgerRecord is what the ledger records about one submitted
+// transaction.
+type LedgerRecord struct {
+	Time        time.Time `json:"time"`
+	Namespaces  []string  `json:"namespaces"`
+	Height      uint64    `json:"height"`
+	TxHash      string    `json:"tx_hash,omitempty"`
+	Commitments []string  `json:"commitments"`
+	// Size is the payload bytes of all blobs of the transaction.
+	Size     int     `json:"size"`
+	GasPrice float64 `json:"gas_price"`
+	// Fee is the estimated fee in utia, see EstimateFee.
+	Fee float64 `json:"fee"`
+	// Mirror is set for submissions to the mirror node.
+	Mirror bool `json:"mirror,omitempty"`
+}
+
+// Ledger is an append-only log of submissions, one JSON record per line,
+// to account for what they cost.
+type Ledger struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// NewLedger creates a ledger appending to the file at path.
+func NewLedger(path string) *Ledger {
+	return &Ledger{Path: path}
+}
+
+// Append adds record to the ledger. The record is synced to disk before
+// Append returns, so a crash right after submitting doesn't lose it.
+func (l *Ledger) Append(record *LedgerRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error encoding ledger record: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(l.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening ledger: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing ledger record: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing ledger record: %w", err)
+	}
+	return f.Close()
+}
+
+// LedgerSummary totals the records of a ledger.
+type LedgerSummary struct {
+	Submissions int `json:"submissions"`
+	Size        int `json:"size"`
+	// Fee is the estimated total in utia.
+	Fee   float64   `json:"fee"`
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+}
+
+// Summarize reads the ledger and totals its records. A ledger that doesn't
+// exist yet has no submissions.
+func (l *Ledger) Summarize() (*LedgerSummary, error) {
+	summary := &LedgerSummary{}
+	f, err := os.Open(l.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return summary, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening ledger: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	// Records of submissions with many blobs outgrow the default 64 KiB
+	// limit on lines.
+	scanner.Buffer(nil, 8<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record LedgerRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("error decoding ledger record on line %d: %w", line, err)
+		}
+		summary.Submissions++
+		summary.Size += record.Size
+		summary.Fee += record.Fee
+		if summary.First.IsZero() || record.Time.Before(summary.First) {
+			summary.First = record.Time
+		}
+		if record.Time.After(summary.Last) {
+			summary.Last = record.Time
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading ledger: %w", err)
+	}
+	return summary, nil
+}
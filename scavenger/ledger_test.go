@@ -0,0 +1,145 @@
+package scavenger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readLedger returns the records of the ledger at path.
+func readLedger(t *testing.T, path string) []LedgerRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var records []LedgerRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 8<<20)
+	for scanner.Scan() {
+		var record LedgerRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestSubmitLedger(t *testing.T) {
+	cfg := testConfig()
+	cfg.Raw = true
+	c, _, _ := newTestClient(cfg)
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	c.Ledger = NewLedger(path)
+	var fees float64
+	for _, prompt := range []string{"first", "the second"} {
+		if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), prompt); err != nil {
+			t.Fatal(err)
+		}
+		fees += EstimateFee([]int{len(prompt)}, cfg.GasPrice).Fee
+	}
+
+	records := readLedger(t, path)
+	if len(records) != 2 {
+		t.Fatalf("ledger has %d records, want one per submission", len(records))
+	}
+	for i, record := range records {
+		if record.Height != uint64(i+1) || len(record.Commitments) != 1 || record.Namespaces[0] != testNamespace {
+			t.Errorf("record %d = %+v, want the submission at height %d in %s", i, record, i+1, testNamespace)
+		}
+	}
+	if records[1].Size != len("the second") {
+		t.Errorf("size = %d, want the payload's %d bytes", records[1].Size, len("the second"))
+	}
+
+	summary, err := c.Ledger.Summarize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Submissions != 2 || summary.Size != len("first")+len("the second") {
+		t.Errorf("summary = %+v, want both submissions totaled", summary)
+	}
+	if math.Abs(summary.Fee-fees) > 1e-9 {
+		t.Errorf("total fee = %v, want %v", summary.Fee, fees)
+	}
+}
+
+func TestSubmitLedgerFails(t *testing.T) {
+	// The blobs are paid for, so a ledger that can't be written doesn't
+	// fail the submission.
+	c, api, _ := newTestClient(testConfig())
+	c.Ledger = NewLedger(filepath.Join(t.TempDir(), "missing", "ledger.jsonl"))
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if fakeHeight(api) != 1 {
+		t.Error("prompt wasn't submitted")
+	}
+}
+
+func TestLedgerSummarize(t *testing.T) {
+	l := NewLedger(filepath.Join(t.TempDir(), "ledger.jsonl"))
+	summary, err := l.Summarize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Submissions != 0 {
+		t.Errorf("summary of a missing ledger = %+v, want no submissions", summary)
+	}
+
+	early := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+	for _, record := range []*LedgerRecord{
+		{Time: late, Height: 2, Size: 10, Fee: 100},
+		{Time: early, Height: 1, Size: 5, Fee: 50},
+	} {
+		if err := l.Append(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	summary, err = l.Summarize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := LedgerSummary{Submissions: 2, Size: 15, Fee: 150, First: early, Last: late}
+	if !summary.First.Equal(want.First) || !summary.Last.Equal(want.Last) || summary.Submissions != want.Submissions || summary.Size != want.Size || summary.Fee != want.Fee {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+}
+
+func TestLedgerSummarizeCorrupt(t *testing.T) {
+	path := writeFile(t, "ledger.jsonl", `{"height": 1, "size": 5}`+"\n\nnot json\n")
+	_, err := NewLedger(path).Summarize()
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("error = %v, want the bad line named", err)
+	}
+}
+
+func TestLedgerSummarizeLongRecord(t *testing.T) {
+	l := NewLedger(filepath.Join(t.TempDir(), "ledger.jsonl"))
+	commitments := make([]string, 2000)
+	for i := range commitments {
+		commitments[i] = strings.Repeat("ab", 32)
+	}
+	if err := l.Append(&LedgerRecord{Height: 1, Size: 5, Commitments: commitments}); err != nil {
+		t.Fatal(err)
+	}
+	summary, err := l.Summarize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Submissions != 1 || summary.Size != 5 {
+		t.Errorf("summary = %+v, want the long record counted", summary)
+	}
+}
@@ -0,0 +1,127 @@
+package scavenger
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// previewSize is the number of characters of a payload ListBlobs shows.
+const previewSize = 60
+
+// ListedBlob summarizes a blob found by ListBlobs.
+type ListedBlob struct {
+	Height     uint64 `json:"height"`
+	Commitment string `json:"commitment"`
+	// Size is the size of the blob's data in bytes, before decoding.
+	Size    int    `json:"size"`
+	Preview string `json:"preview"`
+}
+
+// ListBlobs fetches every blob in ns at the heights from to to, both
+// included. Heights without blobs are skipped.
+func (c *Client) ListBlobs(ctx context.Context, ns share.Namespace, from, to uint64) ([]ListedBlob, error) {
+	if from == 0 || to < from {
+		return nil, fmt.Errorf("invalid height range %d to %d", from, to)
+	}
+	var listed []ListedBlob
+	for height := from; height <= to; height++ {
+		blobs, err := c.Blobs.GetAll(ctx, height, []share.Namespace{ns})
+		if err != nil && !IsBlobNotFound(err) {
+			return nil, fmt.Errorf("Failed to fetch blobs at height %d: %w", height, err)
+		}
+		if len(blobs) == 0 {
+			c.logger().Debug("No blobs at height", "height", height, "namespace", NamespaceHex(ns))
+			continue
+		}
+		for _, b := range blobs {
+			listed = append(listed, ListedBlob{
+				Height:     height,
+				Commitment: hex.EncodeToString(b.Commitment),
+				Size:       len(b.Data),
+				Preview:    previewBlob(c.Config, b),
+			})
+		}
+	}
+	return listed, nil
+}
+
+// previewBlob describes the payload of b in a line of at most previewSize
+// characters. Blobs that can't be decoded are described rather than
+// failing the listing, since a namespace can hold anyone's blobs.
+func previewBlob(cfg *Config, b *blob.Blob) string {
+	text, desc := blobText(cfg, b)
+	if desc != "" {
+		return desc
+	}
+	preview := strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(preview) > previewSize {
+		preview = string([]rune(preview)[:previewSize-3]) + "..."
+	}
+	return preview
+}
+
+// blobText decodes b to the text of its prompt. Blobs that don't hold
+// text, such as chunks, files and binary data, are described instead.
+func blobText(cfg *Config, b *blob.Blob) (text, desc string) {
+	if c, err := decodeChunk(b.Data); err == nil {
+		return "", fmt.Sprintf("[chunk %d of %d]", c.index+1, c.total)
+	}
+	payload, err := DecodePayload(cfg, b.Data)
+	if err != nil {
+		return "", fmt.Sprintf("[undecodable: %v]", err)
+	}
+	if contentType, data, isFile, err := decodeFile(payload); err == nil && isFile {
+		return "", fmt.Sprintf("[file of type %s, %d bytes]", contentType, len(data))
+	}
+	if prompts, isPack, err := DecodePack(payload); isPack {
+		if err != nil {
+			return "", fmt.Sprintf("[undecodable: %v]", err)
+		}
+		return strings.Join(prompts, "\n"), ""
+	}
+	if prompt, _, err := DecodePrompt(payload); err == nil {
+		payload = prompt
+	}
+	if !utf8.Valid(payload) {
+		return "", fmt.Sprintf("[binary, %d bytes]", len(payload))
+	}
+	return string(payload), ""
+}
+
+// SearchBlobs is like ListBlobs, but only returns the blobs whose text
+// matches, at most limit of them unless limit is zero. Blobs that don't
+// hold text never match.
+func (c *Client) SearchBlobs(ctx context.Context, ns share.Namespace, from, to uint64, match func(text string) bool, limit int) ([]ListedBlob, error) {
+	if from == 0 || to < from {
+		return nil, fmt.Errorf("invalid height range %d to %d", from, to)
+	}
+	var found []ListedBlob
+	for height := from; height <= to; height++ {
+		blobs, err := c.Blobs.GetAll(ctx, height, []share.Namespace{ns})
+		if err != nil && !IsBlobNotFound(err) {
+			return nil, fmt.Errorf("Failed to fetch blobs at height %d: %w", height, err)
+		}
+		for _, b := range blobs {
+			text, desc := blobText(c.Config, b)
+			if desc != "" || !match(text) {
+				continue
+			}
+			found = append(found, ListedBlob{
+				Height:     height,
+				Commitment: hex.EncodeToString(b.Commitment),
+				Size:       len(b.Data),
+				Preview:    previewBlob(c.Config, b),
+			})
+			if len(found) == limit {
+				return found, nil
+			}
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,158 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+)
+
+// submitBlobs submits blobs with data to testNamespace in one block of api.
+func submitBlobs(t *testing.T, api *FakeBlobAPI, data ...string) {
+	t.Helper()
+	ns := testNS(t, testNamespace)
+	blobs := make([]*blob.Blob, len(data))
+	for i, d := range data {
+		blobs[i] = testBlob(t, ns, d)
+	}
+	if _, err := api.Submit(context.Background(), blobs, 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListBlobs(t *testing.T) {
+	c, api, _ := newTestClient(testConfig())
+	submitBlobs(t, api, "first", "second", "third")
+	// Height 2 has a blob in another namespace only.
+	if _, err := api.Submit(context.Background(), []*blob.Blob{testBlob(t, testNS(t, "0000000000000000aaaa"), "other")}, 0); err != nil {
+		t.Fatal(err)
+	}
+	submitBlobs(t, api, "fourth")
+
+	listed, err := c.ListBlobs(context.Background(), testNS(t, testNamespace), 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var previews []string
+	for _, b := range listed {
+		previews = append(previews, b.Preview)
+	}
+	if got := strings.Join(previews, ","); got != "first,second,third,fourth" {
+		t.Fatalf("listed %s, want the blobs of the namespace in order", got)
+	}
+	if listed[0].Height != 1 || listed[3].Height != 3 || listed[0].Size != len("first") {
+		t.Errorf("listed %+v, want their heights and sizes", listed)
+	}
+	blobs := api.at(1)
+	if listed[1].Commitment != hexCommitment(blobs[1]) {
+		t.Errorf("commitment = %s, want the blob's", listed[1].Commitment)
+	}
+}
+
+// hexCommitment returns the hex encoded commitment of b.
+func hexCommitment(b *blob.Blob) string {
+	return CommitmentsHex([]*blob.Blob{b})[0]
+}
+
+func TestListBlobsEmpty(t *testing.T) {
+	c, _, _ := newTestClient(testConfig())
+	listed, err := c.ListBlobs(context.Background(), testNS(t, testNamespace), 1, 3)
+	if err != nil || len(listed) != 0 {
+		t.Errorf("listed %v (error %v), want nothing at heights without blobs", listed, err)
+	}
+}
+
+func TestListBlobsErrors(t *testing.T) {
+	c, api, _ := newTestClient(testConfig())
+	for _, r := range [][2]uint64{{0, 1}, {3, 2}} {
+		if _, err := c.ListBlobs(context.Background(), testNS(t, testNamespace), r[0], r[1]); err == nil {
+			t.Errorf("listing heights %d to %d succeeded", r[0], r[1])
+		}
+	}
+	broken := errors.New("broken")
+	api.GetErr = broken
+	if _, err := c.ListBlobs(context.Background(), testNS(t, testNamespace), 1, 1); !errors.Is(err, broken) {
+		t.Errorf("error = %v, want the node's", err)
+	}
+}
+
+func TestPreviewBlob(t *testing.T) {
+	cfg := testConfig()
+	ns := testNS(t, testNamespace)
+	envelope, err := promptData(cfg, "an enveloped\nprompt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := splitChunks([]byte("a prompt of several chunks"), 4)
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{envelope, "an enveloped prompt"},
+		{[]byte(strings.Repeat("long ", 20)), strings.Repeat("long ", 12)[:previewSize-3] + "..."},
+		{chunks[0], "[chunk 1 of 7]"},
+		{encodeFile("image/png", []byte{1, 2, 3}), "[file of type image/png, 3 bytes]"},
+		{[]byte{0xff, 0xfe}, "[binary, 2 bytes]"},
+	}
+	for _, tt := range tests {
+		b, err := blob.NewBlobV0(ns, tt.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := previewBlob(cfg, b); got != tt.want {
+			t.Errorf("preview of %q = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestSearchBlobs(t *testing.T) {
+	c, api, _ := newTestClient(testConfig())
+	submitBlobs(t, api, "the blob space", "nothing here")
+	submitBlobs(t, api, "no match")
+	submitBlobs(t, api, "more blobs", "a Blob again")
+	match := func(text string) bool { return strings.Contains(text, "blob") }
+
+	found, err := c.SearchBlobs(context.Background(), testNS(t, testNamespace), 1, 3, match, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, b := range found {
+		got = append(got, fmt.Sprintf("%d:%s", b.Height, b.Preview))
+	}
+	if want := "1:the blob space,3:more blobs"; strings.Join(got, ",") != want {
+		t.Errorf("found %v, want %s", got, want)
+	}
+	if found[0].Commitment != hexCommitment(api.at(1)[0]) {
+		t.Errorf("commitment = %s, want the matching blob's", found[0].Commitment)
+	}
+
+	// The limit stops the search.
+	found, err = c.SearchBlobs(context.Background(), testNS(t, testNamespace), 1, 3, match, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Height != 1 {
+		t.Errorf("found %+v, want only the first match", found)
+	}
+}
+
+func TestSearchBlobsErrors(t *testing.T) {
+	c, api, _ := newTestClient(testConfig())
+	match := func(string) bool { return true }
+	if _, err := c.SearchBlobs(context.Background(), testNS(t, testNamespace), 3, 2, match, 0); err == nil {
+		t.Error("searching a backwards range succeeded")
+	}
+	// Heights without blobs in the namespace have no matches.
+	if found, err := c.SearchBlobs(context.Background(), testNS(t, testNamespace), 1, 2, match, 0); err != nil || len(found) != 0 {
+		t.Errorf("found %v (error %v) in empty heights, want nothing", found, err)
+	}
+	down := errors.New("node is down")
+	api.GetErr = down
+	if _, err := c.SearchBlobs(context.Background(), testNS(t, testNamespace), 1, 2, match, 0); !errors.Is(err, down) {
+		t.Errorf("error = %v, want the node's", err)
+	}
+}
@@ -0,0 +1,55 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSubmitMemo(t *testing.T) {
+	cfg := testConfig()
+	cfg.Memo = "invoice 42"
+	c, api, _ := newTestClient(cfg)
+	result, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.Memo != "invoice 42" {
+		t.Errorf("submitted with memo %q, want the configured one", api.Memo)
+	}
+	if result.Memo != "invoice 42" {
+		t.Errorf("result memo = %q, want the configured one", result.Memo)
+	}
+}
+
+func TestSubmitMemoUnsupported(t *testing.T) {
+	cfg := testConfig()
+	cfg.Memo = "invoice 42"
+	api := &FakeBlobAPI{}
+	c := &Client{Config: cfg, Blobs: blobAPIOnly{api}}
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi"); !errors.Is(err, ErrMemoUnsupported) || !strings.Contains(err.Error(), "not supported by this node version") {
+		t.Fatalf("error = %v, want ErrMemoUnsupported", err)
+	}
+	if fakeHeight(api) != 0 {
+		t.Error("the prompt was submitted without its memo")
+	}
+
+	// Without a memo, any blob API will do.
+	cfg.Memo = ""
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateMemo(t *testing.T) {
+	cfg := testConfig()
+	cfg.Memo = strings.Repeat("m", MaxMemoLength)
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("memo of %d bytes: %v", MaxMemoLength, err)
+	}
+	cfg.Memo += "m"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "memo must be at most") {
+		t.Errorf("error = %v, want the long memo rejected", err)
+	}
+}
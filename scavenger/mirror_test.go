@@ -0,0 +1,97 @@
+package scavenger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSubmitMirror(t *testing.T) {
+	c, api, _ := newTestClient(testConfig())
+	mirror := &FakeBlobAPI{}
+	// The mirror's chain is ahead, so the heights differ.
+	submitBlobs(t, mirror, "earlier")
+	c.Mirror = mirror
+	sub, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Height != 1 || sub.MirrorHeight != 2 {
+		t.Fatalf("submitted at height %d and mirrored at %d, want 1 and 2", sub.Height, sub.MirrorHeight)
+	}
+	primary, mirrored := api.at(1), mirror.at(2)
+	if len(mirrored) != len(primary) || !bytes.Equal(mirrored[0].Commitment, primary[0].Commitment) {
+		t.Error("mirrored blobs aren't the submitted ones")
+	}
+}
+
+func TestSubmitMirrorFails(t *testing.T) {
+	down := errors.New("mirror is down")
+	for _, required := range []bool{false, true} {
+		cfg := testConfig()
+		cfg.MirrorRequired = required
+		c, api, _ := newTestClient(cfg)
+		c.Mirror = &FakeBlobAPI{SubmitErr: down}
+		sub, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi")
+		if fakeHeight(api) != 1 {
+			t.Errorf("required %v: prompt wasn't submitted to the node", required)
+		}
+		if required {
+			if !errors.Is(err, down) || !strings.Contains(err.Error(), "submitted at height 1, but not mirrored") {
+				t.Errorf("error = %v, want the required mirror's failure", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("optional mirror: %v", err)
+		}
+		if sub.MirrorHeight != 0 {
+			t.Errorf("mirror height = %d, want none", sub.MirrorHeight)
+		}
+	}
+}
+
+func TestClientRunMirror(t *testing.T) {
+	c, _, _ := newTestClient(testConfig())
+	c.Mirror = &FakeBlobAPI{}
+	c.Ledger = NewLedger(filepath.Join(t.TempDir(), "ledger.jsonl"))
+	result, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.MirrorHeight != 1 {
+		t.Errorf("mirror height = %d, want 1", result.MirrorHeight)
+	}
+	records := readLedger(t, c.Ledger.Path)
+	if len(records) != 2 || records[0].Mirror || !records[1].Mirror {
+		t.Errorf("ledger = %+v, want the submission and then its mirror", records)
+	}
+}
+
+func TestNewClientMirrorUnreachable(t *testing.T) {
+	api := &FakeBlobAPI{}
+	useFakeNodes(t, map[string]*FakeBlobAPI{DefaultNodeIP: api})
+	cfg := testConfig()
+	cfg.MirrorNode = "ws://mirror:26658"
+	c, err := NewClient(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	// The submission goes on, and says why it wasn't mirrored.
+	sub, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.MirrorHeight != 0 {
+		t.Errorf("mirror height = %d, want none from an unreachable mirror", sub.MirrorHeight)
+	}
+
+	cfg.MirrorRequired = true
+	if _, err := NewClient(context.Background(), cfg); err == nil || !strings.Contains(err.Error(), "cannot reach mirror node at ws://mirror:26658") {
+		t.Errorf("error = %v, want the required mirror unreachable", err)
+	}
+}
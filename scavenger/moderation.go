@@ -0,0 +1,119 @@
+package scavenger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Moderation modes, selecting what happens to prompts the moderation
+// endpoint flags.
+const (
+	ModerateOff   = ""
+	ModerateBlock = "block"
+	ModerateWarn  = "warn"
+)
+
+// ErrFlagged is returned when moderation refuses to submit a prompt.
+var ErrFlagged = errors.New("prompt flagged by moderation")
+
+// ModerationClient is the part of the OpenAI client used to moderate
+// prompts.
+type ModerationClient interface {
+	Moderations(context.Context, openai.ModerationRequest) (openai.ModerationResponse, error)
+}
+
+// NewModerationClient creates an OpenAI client for the moderation
+// endpoint, authenticated with the configured key.
+func NewModerationClient(cfg *Config) (ModerationClient, error) {
+	key, err := cfg.ResolveOpenAIKey()
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return nil, fmt.Errorf("OPENAI_KEY environment variable not set, it is needed for moderation")
+	}
+	config, err := openAIConfig(cfg, key)
+	if err != nil {
+		return nil, err
+	}
+	return openai.NewClientWithConfig(config), nil
+}
+
+// flaggedCategories returns the categories result flags, with their
+// scores. Without a threshold, the categories OpenAI flagged are returned,
+// otherwise those scoring at least threshold.
+func flaggedCategories(result openai.Result, threshold float64) ([]string, error) {
+	var flags map[string]bool
+	var scores map[string]float64
+	if err := roundTrip(result.Categories, &flags); err != nil {
+		return nil, err
+	}
+	if err := roundTrip(result.CategoryScores, &scores); err != nil {
+		return nil, err
+	}
+	var flagged []string
+	for category, score := range scores {
+		if (threshold == 0 && flags[category]) || (threshold > 0 && score >= threshold) {
+			flagged = append(flagged, fmt.Sprintf("%s (%.2f)", category, score))
+		}
+	}
+	sort.Strings(flagged)
+	return flagged, nil
+}
+
+// roundTrip converts from into to through JSON, which turns the category
+// structs into maps keyed by the categories' names.
+func roundTrip(from, to any) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return fmt.Errorf("error encoding moderation result: %w", err)
+	}
+	if err := json.Unmarshal(data, to); err != nil {
+		return fmt.Errorf("error decoding moderation result: %w", err)
+	}
+	return nil
+}
+
+// moderate checks prompt with the moderation endpoint before it is
+// submitted, since blobs are public and can't be taken back. Flagged
+// prompts fail with ErrFlagged, or are only warned about with ModerateWarn.
+func (c *Client) moderate(ctx context.Context, prompt string) error {
+	cfg := c.Config
+	if cfg.Moderate == ModerateOff {
+		return nil
+	}
+	if c.Moderation == nil {
+		moderation, err := NewModerationClient(cfg)
+		if err != nil {
+			return err
+		}
+		c.Moderation = moderation
+	}
+	resp, err := c.Moderation.Moderations(ctx, openai.ModerationRequest{Input: prompt})
+	if err != nil {
+		return fmt.Errorf("Failed to moderate prompt: %w", err)
+	}
+
+	var flagged []string
+	for _, result := range resp.Results {
+		categories, err := flaggedCategories(result, cfg.ModerationThreshold)
+		if err != nil {
+			return err
+		}
+		flagged = append(flagged, categories...)
+	}
+	if len(flagged) == 0 {
+		return nil
+	}
+	if cfg.Moderate == ModerateWarn {
+		c.logger().Warn("Prompt flagged by moderation, submitting anyway", "categories", strings.Join(flagged, ", "))
+		return nil
+	}
+	return fmt.Errorf("%w, not submitting it: %s", ErrFlagged, strings.Join(flagged, ", "))
+}
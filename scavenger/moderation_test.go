@@ -0,0 +1,126 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// fakeModeration is a ModerationClient returning result for every
+// request. It records the inputs it is asked about.
+type fakeModeration struct {
+	result openai.Result
+	err    error
+	inputs []string
+}
+
+func (f *fakeModeration) Moderations(_ context.Context, req openai.ModerationRequest) (openai.ModerationResponse, error) {
+	f.inputs = append(f.inputs, req.Input)
+	return openai.ModerationResponse{Results: []openai.Result{f.result}}, f.err
+}
+
+// flaggedResult is a result flagging violence with score 0.9, and scoring
+// hate 0.4 without flagging it.
+func flaggedResult() openai.Result {
+	return openai.Result{
+		Flagged:        true,
+		Categories:     openai.ResultCategories{Violence: true},
+		CategoryScores: openai.ResultCategoryScores{Violence: 0.9, Hate: 0.4},
+	}
+}
+
+func TestModerateBlock(t *testing.T) {
+	cfg := testConfig()
+	cfg.Moderate = ModerateBlock
+	c, api, _ := newTestClient(cfg)
+	moderation := &fakeModeration{result: flaggedResult()}
+	c.Moderation = moderation
+	_, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "a violent prompt")
+	if !errors.Is(err, ErrFlagged) {
+		t.Fatalf("error = %v, want ErrFlagged", err)
+	}
+	if !strings.Contains(err.Error(), "violence (0.90)") || strings.Contains(err.Error(), "hate") {
+		t.Errorf("error %q doesn't list only the flagged categories", err)
+	}
+	if fakeHeight(api) != 0 {
+		t.Error("flagged prompt was submitted")
+	}
+	if len(moderation.inputs) != 1 || moderation.inputs[0] != "a violent prompt" {
+		t.Errorf("moderated %q, want the prompt", moderation.inputs)
+	}
+}
+
+func TestModerateWarn(t *testing.T) {
+	cfg := testConfig()
+	cfg.Moderate = ModerateWarn
+	c, api, _ := newTestClient(cfg)
+	c.Moderation = &fakeModeration{result: flaggedResult()}
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "a violent prompt"); err != nil {
+		t.Fatal(err)
+	}
+	if fakeHeight(api) != 1 {
+		t.Error("flagged prompt wasn't submitted with a warning")
+	}
+}
+
+func TestModerateClean(t *testing.T) {
+	cfg := testConfig()
+	cfg.Moderate = ModerateBlock
+	c, api, _ := newTestClient(cfg)
+	c.Moderation = &fakeModeration{result: openai.Result{CategoryScores: openai.ResultCategoryScores{Hate: 0.01}}}
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "a kind prompt"); err != nil {
+		t.Fatal(err)
+	}
+	if fakeHeight(api) != 1 {
+		t.Error("clean prompt wasn't submitted")
+	}
+}
+
+func TestModerateOff(t *testing.T) {
+	c, _, _ := newTestClient(testConfig())
+	moderation := &fakeModeration{result: flaggedResult()}
+	c.Moderation = moderation
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "anything"); err != nil {
+		t.Fatal(err)
+	}
+	if len(moderation.inputs) != 0 {
+		t.Error("prompt was moderated with moderation off")
+	}
+}
+
+func TestModerateError(t *testing.T) {
+	cfg := testConfig()
+	cfg.Moderate = ModerateWarn
+	c, api, _ := newTestClient(cfg)
+	down := errors.New("moderation is down")
+	c.Moderation = &fakeModeration{err: down}
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi"); !errors.Is(err, down) {
+		t.Errorf("error = %v, want the moderation's", err)
+	}
+	if fakeHeight(api) != 0 {
+		t.Error("prompt was submitted without being moderated")
+	}
+}
+
+func TestFlaggedCategoriesThreshold(t *testing.T) {
+	tests := []struct {
+		threshold float64
+		want      string
+	}{
+		{0, "violence (0.90)"},
+		{0.3, "hate (0.40), violence (0.90)"},
+		{0.95, ""},
+	}
+	for _, tt := range tests {
+		flagged, err := flaggedCategories(flaggedResult(), tt.threshold)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(flagged, ", "); got != tt.want {
+			t.Errorf("threshold %v flags %q, want %q", tt.threshold, got, tt.want)
+		}
+	}
+}
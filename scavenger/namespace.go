@@ -0,0 +1,160 @@
+package scavenger
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/celestiaorg/celestia-openrpc/types/appconsts"
+	appns "github.com/celestiaorg/celestia-openrpc/types/namespace"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// CreateNamespaceID converts a hex string to a NamespaceID of the given
+// namespace version. With pad set, IDs shorter than the version's ID size
+// are left-padded with zeros, the same way Celestia treats short
+// namespaces. Otherwise the ID has to be exactly the right size.
+func CreateNamespaceID(nIDString string, version uint8, pad bool) (share.Namespace, error) {
+	// First, we parse the passed hex string into a []byte slice
+	namespaceBytes, err := hex.DecodeString(nIDString)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding hex string: %w", err)
+	}
+
+	switch version {
+	case appns.NamespaceVersionZero:
+		// Version 0 namespace IDs are 10 bytes long, the remaining bytes
+		// of the namespace are zero.
+		id, err := padNamespaceID(namespaceBytes, appns.NamespaceVersionZeroIDSize, pad)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version 0 namespace: %w", err)
+		}
+		// Next, we create a new NamespaceID using the parsed bytes. The
+		// version and the zero prefix of the ID are left as zeros.
+		ns := make(share.Namespace, appconsts.NamespaceSize)
+		copy(ns[len(ns)-len(id):], id)
+		return ns, ValidateBlobNamespace(ns)
+	default:
+		// Celestia only defines version 0 for user namespaces.
+		return nil, fmt.Errorf("unsupported namespace version %d, only version 0 is defined for user namespaces", version)
+	}
+}
+
+// NamespaceIDs converts Namespace and ExtraNamespaces to NamespaceIDs,
+// Namespace first. Each is checked on its own, and none may repeat.
+func (c *Config) NamespaceIDs() ([]share.Namespace, error) {
+	hexes := append([]string{c.Namespace}, c.ExtraNamespaces...)
+	namespaces := make([]share.Namespace, len(hexes))
+	seen := make(map[string]bool, len(hexes))
+	for i, nsHex := range hexes {
+		ns, err := CreateNamespaceID(nsHex, c.NamespaceVersion, c.PadNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("namespace %q: %w", nsHex, err)
+		}
+		if seen[string(ns)] {
+			return nil, fmt.Errorf("namespace %s is given more than once", NamespaceHex(ns))
+		}
+		seen[string(ns)] = true
+		namespaces[i] = ns
+	}
+	return namespaces, nil
+}
+
+// ResponseNamespaceID returns the namespace responses to a prompt
+// submitted to prompt are stored in: ResponseNamespace, or prompt itself
+// if that isn't set.
+func (c *Config) ResponseNamespaceID(prompt share.Namespace) (share.Namespace, error) {
+	if c.ResponseNamespace == "" {
+		return prompt, nil
+	}
+	ns, err := CreateNamespaceID(c.ResponseNamespace, c.NamespaceVersion, c.PadNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("response namespace %q: %w", c.ResponseNamespace, err)
+	}
+	return ns, nil
+}
+
+// ErrReservedNamespace is returned for namespaces reserved by Celestia,
+// which blobs can't be submitted to.
+var ErrReservedNamespace = errors.New("namespace is reserved")
+
+// ValidateBlobNamespace checks that blobs can be submitted to ns. Unlike
+// share.Namespace.ValidateForBlob, it says which reserved range ns is in
+// and suggests a namespace that can be used instead.
+func ValidateBlobNamespace(ns share.Namespace) error {
+	var reserved string
+	switch {
+	case ns.Equals(share.ParitySharesNamespace):
+		reserved = "the namespace of parity shares"
+	case ns.Equals(share.TailPaddingNamespace):
+		reserved = "the namespace of tail padding"
+	case bytes.Compare(ns, share.MaxReservedNamespace) <= 0:
+		reserved = fmt.Sprintf("the primary reserved range, which ends at %s", NamespaceHex(share.MaxReservedNamespace))
+	default:
+		return ns.ValidateForBlob()
+	}
+	suggestion, err := RandomNamespaceID()
+	if err != nil {
+		return fmt.Errorf("%w: %s is in %s", ErrReservedNamespace, NamespaceHex(ns), reserved)
+	}
+	return fmt.Errorf("%w: %s is in %s, use another one such as %s, or -random-namespace",
+		ErrReservedNamespace, NamespaceHex(ns), reserved, suggestion)
+}
+
+// NamespaceHex returns the ID of the version 0 namespace ns as hex, in the
+// form the -namespace flag takes.
+func NamespaceHex(ns share.Namespace) string {
+	id := ns.ID()
+	return hex.EncodeToString(id[len(id)-appns.NamespaceVersionZeroIDSize:])
+}
+
+// padNamespaceID left-pads id with zeros to size bytes. Longer IDs are
+// rejected rather than truncated, as are short ones when pad is false.
+func padNamespaceID(id []byte, size int, pad bool) ([]byte, error) {
+	switch {
+	case len(id) == 0:
+		return nil, fmt.Errorf("namespace ID is empty")
+	case len(id) > size:
+		return nil, fmt.Errorf("namespace ID must be at most %d bytes, got %d bytes", size, len(id))
+	case len(id) < size && !pad:
+		return nil, fmt.Errorf("namespace ID must be exactly %d bytes, got %d bytes (padding is disabled)", size, len(id))
+	}
+
+	padded := make([]byte, size)
+	copy(padded[size-len(id):], id)
+	return padded, nil
+}
+
+// RandomNamespaceID generates a random version 0 namespace ID and returns
+// it as hex, in the same form the -namespace flag takes. IDs that fall in
+// the reserved range are drawn again.
+func RandomNamespaceID() (string, error) {
+	id := make([]byte, appns.NamespaceVersionZeroIDSize)
+	for {
+		if _, err := rand.Read(id); err != nil {
+			return "", fmt.Errorf("error generating random namespace: %w", err)
+		}
+		if _, err := share.NewBlobNamespaceV0(id); err == nil {
+			return hex.EncodeToString(id), nil
+		}
+	}
+}
+
+// LabelNamespaceID derives a version 0 namespace ID from a human readable
+// label and returns it as hex, in the same form the -namespace flag takes.
+// The ID is the start of the label's SHA-256 hash. Hashes that fall in the
+// reserved range are hashed again, so the same label always gives the same
+// usable namespace.
+func LabelNamespaceID(label string) string {
+	sum := sha256.Sum256([]byte(label))
+	for {
+		id := sum[:appns.NamespaceVersionZeroIDSize]
+		if _, err := share.NewBlobNamespaceV0(id); err == nil {
+			return hex.EncodeToString(id)
+		}
+		sum = sha256.Sum256(sum[:])
+	}
+}
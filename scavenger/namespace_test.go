@@ -0,0 +1,168 @@
+package scavenger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+func TestCreateNamespaceIDVersion(t *testing.T) {
+	ns, err := CreateNamespaceID(testNamespace, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns.Version() != 0 || NamespaceHex(ns) != testNamespace {
+		t.Errorf("got version %d namespace %s, want version 0 namespace %s", ns.Version(), NamespaceHex(ns), testNamespace)
+	}
+
+	// Only version 0 is defined for user namespaces.
+	if _, err := CreateNamespaceID(testNamespace, 1, false); err == nil || !strings.Contains(err.Error(), "unsupported namespace version 1") {
+		t.Errorf("version 1: error = %v, want it to be unsupported", err)
+	}
+}
+
+func TestValidateNamespaceVersion(t *testing.T) {
+	cfg := testConfig()
+	cfg.NamespaceVersion = 1
+	if err := cfg.Validate(); err == nil {
+		t.Error("namespace version 1 passed validation")
+	}
+}
+
+func TestCreateNamespaceIDPadding(t *testing.T) {
+	tests := []struct {
+		id      string
+		pad     bool
+		want    string
+		wantErr bool
+	}{
+		{"706f6e67", true, "000000000000706f6e67", false},
+		{"706f6e67", false, "", true},
+		{"0102030405060708090a", false, "0102030405060708090a", false},
+		{"0102030405060708090a0b", true, "", true},
+		{"", true, "", true},
+		{"xyz", true, "", true},
+	}
+	for _, tt := range tests {
+		ns, err := CreateNamespaceID(tt.id, 0, tt.pad)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("CreateNamespaceID(%q, pad %v) succeeded, want an error", tt.id, tt.pad)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("CreateNamespaceID(%q, pad %v): %v", tt.id, tt.pad, err)
+			continue
+		}
+		if got := NamespaceHex(ns); got != tt.want {
+			t.Errorf("CreateNamespaceID(%q, pad %v) = %s, want %s", tt.id, tt.pad, got, tt.want)
+		}
+	}
+}
+
+func TestRandomNamespaceID(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		id, err := RandomNamespaceID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ns, err := CreateNamespaceID(id, 0, false)
+		if err != nil {
+			t.Fatalf("random namespace %s is invalid: %v", id, err)
+		}
+		if err := ValidateBlobNamespace(ns); err != nil {
+			t.Fatalf("random namespace %s can't take blobs: %v", id, err)
+		}
+		if seen[id] {
+			t.Fatalf("random namespace %s was generated twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestLabelNamespaceID(t *testing.T) {
+	id := LabelNamespaceID("my prompts")
+	if id != LabelNamespaceID("my prompts") {
+		t.Error("the same label gave different namespaces")
+	}
+	if id == LabelNamespaceID("other prompts") {
+		t.Error("different labels gave the same namespace")
+	}
+	// The start of sha256("my prompts"), which is outside the reserved
+	// range.
+	if id != "1774a8719b0f1a55b642" {
+		t.Errorf("namespace = %s, want the start of the label's hash", id)
+	}
+	for _, label := range []string{"", "a", "my prompts", "ünïcödé"} {
+		ns, err := CreateNamespaceID(LabelNamespaceID(label), 0, false)
+		if err != nil {
+			t.Fatalf("namespace of label %q is invalid: %v", label, err)
+		}
+		if err := ValidateBlobNamespace(ns); err != nil {
+			t.Errorf("namespace of label %q can't take blobs: %v", label, err)
+		}
+	}
+}
+
+func TestNamespaceIDs(t *testing.T) {
+	cfg := testConfig()
+	cfg.ExtraNamespaces = []string{"aaaa", "bbbb"}
+	namespaces, err := cfg.NamespaceIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, ns := range namespaces {
+		got = append(got, NamespaceHex(ns))
+	}
+	want := []string{testNamespace, "0000000000000000aaaa", "0000000000000000bbbb"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("namespaces = %v, want %v", got, want)
+	}
+
+	cfg.ExtraNamespaces = []string{"aaaa", "zz"}
+	if _, err := cfg.NamespaceIDs(); err == nil || !strings.Contains(err.Error(), `namespace "zz"`) {
+		t.Errorf("error = %v, want the invalid namespace named", err)
+	}
+	cfg.ExtraNamespaces = []string{"aaaa", "0000000000000000aaaa"}
+	if _, err := cfg.NamespaceIDs(); err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Errorf("error = %v, want the repeated namespace rejected", err)
+	}
+}
+
+func TestValidateBlobNamespace(t *testing.T) {
+	tests := []struct {
+		name string
+		ns   share.Namespace
+		want string
+	}{
+		{"parity shares", share.ParitySharesNamespace, "the namespace of parity shares"},
+		{"tail padding", share.TailPaddingNamespace, "the namespace of tail padding"},
+		{"primary reserved", share.MaxReservedNamespace, "the primary reserved range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBlobNamespace(tt.ns)
+			if !errors.Is(err, ErrReservedNamespace) || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want %s named", err, tt.want)
+			}
+			if !strings.Contains(err.Error(), "use another one such as") {
+				t.Errorf("error %q suggests no namespace", err)
+			}
+		})
+	}
+	if err := ValidateBlobNamespace(testNS(t, testNamespace)); err != nil {
+		t.Errorf("namespace %s: %v", testNamespace, err)
+	}
+}
+
+func TestCreateNamespaceIDReserved(t *testing.T) {
+	// Short IDs padded with zeros land in the primary reserved range.
+	if _, err := CreateNamespaceID("01", 0, true); !errors.Is(err, ErrReservedNamespace) {
+		t.Errorf("error = %v, want ErrReservedNamespace", err)
+	}
+}
@@ -0,0 +1,95 @@
+package scavenger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// countingBlobAPI is a FakeBlobAPI counting the calls fetching blobs.
+type countingBlobAPI struct {
+	*FakeBlobAPI
+	gets atomic.Int32
+}
+
+func (a *countingBlobAPI) Get(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (*blob.Blob, error) {
+	a.gets.Add(1)
+	return a.FakeBlobAPI.Get(ctx, height, ns, commitment)
+}
+
+func (a *countingBlobAPI) GetAll(ctx context.Context, height uint64, namespaces []share.Namespace) ([]*blob.Blob, error) {
+	a.gets.Add(1)
+	return a.FakeBlobAPI.GetAll(ctx, height, namespaces)
+}
+
+func TestRunNoFetch(t *testing.T) {
+	cfg := testConfig()
+	cfg.NoFetch = true
+	api := &countingBlobAPI{FakeBlobAPI: &FakeBlobAPI{}}
+	var logs bytes.Buffer
+	c := &Client{Config: cfg, Blobs: api, Completer: &fakeCompleter{}, Logger: slog.New(slog.NewTextHandler(&logs, nil))}
+	result, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := api.gets.Load(); n != 0 {
+		t.Errorf("blobs were fetched %d times, want none", n)
+	}
+	if result.Response != "answer to hi" || result.Height != 1 || result.Commitment == "" {
+		t.Errorf("result = %+v, want the local prompt answered after submitting it", result)
+	}
+	if result.FetchedPayload != "" {
+		t.Errorf("fetched payload = %q, want none", result.FetchedPayload)
+	}
+	if _, ok := result.Timings["fetch"]; ok {
+		t.Error("a fetch was timed")
+	}
+	if !strings.Contains(logs.String(), "isn't verified") {
+		t.Errorf("logged %q, want a note that the blob isn't verified", logs.String())
+	}
+}
+
+func TestRunNoFetchRedact(t *testing.T) {
+	cfg := testConfig()
+	cfg.NoFetch = true
+	cfg.Redact = RedactAll
+	c, _, completer := newTestClient(cfg)
+	if _, err := c.Run(context.Background(), secretPrompt); err != nil {
+		t.Fatal(err)
+	}
+	if got := completer.prompts(); len(got) != 1 || got[0] != "mail [REDACTED:email]" {
+		t.Errorf("model was asked %q, want the redacted prompt as it was submitted", got)
+	}
+}
+
+func TestRunFetches(t *testing.T) {
+	api := &countingBlobAPI{FakeBlobAPI: &FakeBlobAPI{}}
+	c := &Client{Config: testConfig(), Blobs: api, Completer: &fakeCompleter{}}
+	result, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.gets.Load() == 0 || result.FetchedPayload != "hi" {
+		t.Errorf("fetched %d times with payload %q, want the blob read back by default", api.gets.Load(), result.FetchedPayload)
+	}
+}
+
+func TestValidateNoFetch(t *testing.T) {
+	for name, setup := range map[string]func(*Config){
+		"wait":             func(cfg *Config) { cfg.Wait = 1 },
+		"verify signature": func(cfg *Config) { cfg.VerifySignature = true },
+	} {
+		cfg := testConfig()
+		cfg.NoFetch = true
+		setup(cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("not fetching with %s was accepted", name)
+		}
+	}
+}
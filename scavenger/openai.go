@@ -0,0 +1,485 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// knownModels are the OpenAI chat models we know to work with
+// OpenAICompleter.
+// Other models are still passed through, since newer ones show up faster
+// than this list is updated.
+var knownModels = map[string]bool{
+	openai.GPT3Dot5Turbo:     true,
+	openai.GPT3Dot5Turbo0125: true,
+	openai.GPT3Dot5Turbo16K:  true,
+	openai.GPT4:              true,
+	openai.GPT4Turbo:         true,
+	openai.GPT4TurboPreview:  true,
+	openai.GPT4o:             true,
+}
+
+// IsKnownModel reports whether model is in the allowlist of known OpenAI
+// models.
+func IsKnownModel(model string) bool {
+	return knownModels[model]
+}
+
+// ErrEmptyResponse is returned when the model responds without any text,
+// which would otherwise be stored or printed as if it were an answer.
+var ErrEmptyResponse = errors.New("model returned an empty response")
+
+// emptyResponse returns ErrEmptyResponse with the reason the model gave
+// for finishing, if any. A reason like "length" or "content_filter" tells
+// why there's no text.
+func emptyResponse(reason openai.FinishReason) error {
+	if reason == "" {
+		return ErrEmptyResponse
+	}
+	return fmt.Errorf("%w (finish reason %q)", ErrEmptyResponse, reason)
+}
+
+// ChatClient is the part of the OpenAI client used by OpenAICompleter.
+type ChatClient interface {
+	CreateChatCompletion(
+		context.Context,
+		openai.ChatCompletionRequest,
+	) (openai.ChatCompletionResponse, error)
+	CreateChatCompletionStream(
+		context.Context,
+		openai.ChatCompletionRequest,
+	) (ChatStream, error)
+}
+
+// ChatStream is a stream of completion deltas, as returned by
+// openai.Client.CreateChatCompletionStream.
+type ChatStream interface {
+	Recv() (openai.ChatCompletionStreamResponse, error)
+	Close() error
+}
+
+// openAIClient adapts *openai.Client to the ChatClient interface.
+type openAIClient struct {
+	*openai.Client
+}
+
+// CreateChatCompletion requests a completion. Errors carry the
+// Retry-After hint of the response, if any.
+func (c openAIClient) CreateChatCompletion(
+	ctx context.Context,
+	req openai.ChatCompletionRequest,
+) (resp openai.ChatCompletionResponse, err error) {
+	err = withRetryAfter(ctx, func(ctx context.Context) error {
+		resp, err = c.Client.CreateChatCompletion(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// CreateChatCompletionStream opens a completion stream. Like
+// CreateChatCompletion, errors carry the Retry-After hint.
+func (c openAIClient) CreateChatCompletionStream(
+	ctx context.Context,
+	req openai.ChatCompletionRequest,
+) (stream ChatStream, err error) {
+	err = withRetryAfter(ctx, func(ctx context.Context) error {
+		stream, err = c.Client.CreateChatCompletionStream(ctx, req)
+		return err
+	})
+	return stream, err
+}
+
+// NewOpenAIClient creates an OpenAI client authenticated with the
+// configured key. A configured base URL points it at an OpenAI compatible
+// API instead, such as a local proxy.
+func NewOpenAIClient(cfg *Config) (ChatClient, error) {
+	key, err := cfg.ResolveOpenAIKey()
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return nil, fmt.Errorf("OPENAI_KEY environment variable not set")
+	}
+	config, err := openAIConfig(cfg, key)
+	if err != nil {
+		return nil, err
+	}
+	return openAIClient{openai.NewClientWithConfig(config)}, nil
+}
+
+// openAIConfig builds the OpenAI client config from cfg, authenticated
+// with key.
+func openAIConfig(cfg *Config, key string) (openai.ClientConfig, error) {
+	config := openai.DefaultConfig(key)
+	if cfg.OpenAIBaseURL != "" {
+		config.BaseURL = cfg.OpenAIBaseURL
+	}
+	config.OrgID = cfg.OpenAIOrg
+	transport, err := openAITransport(cfg)
+	if err != nil {
+		return openai.ClientConfig{}, err
+	}
+	config.HTTPClient = &http.Client{Transport: retryAfterTransport{transport}}
+	return config, nil
+}
+
+// openAITransport returns the transport for requests to OpenAI. The
+// default transport already honors HTTPS_PROXY, so only a configured
+// proxy needs one of its own.
+func openAITransport(cfg *Config) (http.RoundTripper, error) {
+	if cfg.OpenAIProxy == "" {
+		return http.DefaultTransport, nil
+	}
+	proxy, err := parseProxyURL(cfg.OpenAIProxy)
+	if err != nil {
+		return nil, err
+	}
+	// The transport sends the URL's user and password to the proxy as
+	// basic auth.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxy)
+	return transport, nil
+}
+
+// parseProxyURL parses the URL of an HTTP or HTTPS proxy. Errors leave
+// out the URL, which may hold the proxy's password.
+func parseProxyURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, errors.New("invalid OpenAI proxy URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("OpenAI proxy URL must start with http:// or https://, got scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.New("OpenAI proxy URL must include a host")
+	}
+	return u, nil
+}
+
+// Usage is the number of tokens a completion used.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+	// SystemFingerprint identifies the backend configuration that served
+	// the completion, if the provider reports it. It changes when results
+	// for the same seed may differ. RunResult reports it on its own.
+	SystemFingerprint string `json:"-"`
+}
+
+// usageFrom converts the usage reported by OpenAI, along with the
+// response's system fingerprint.
+func usageFrom(u openai.Usage, fingerprint string) Usage {
+	return Usage{
+		PromptTokens:      u.PromptTokens,
+		CompletionTokens:  u.CompletionTokens,
+		TotalTokens:       u.TotalTokens,
+		SystemFingerprint: fingerprint,
+	}
+}
+
+// OpenAICompleter completes prompts with OpenAI's chat API, using the
+// model and sampling parameters from Config.
+type OpenAICompleter struct {
+	Client ChatClient
+	Config *Config
+	// StreamOutput receives the response as it arrives when streaming is
+	// enabled. If nil, the streamed text is discarded.
+	StreamOutput io.Writer
+	// Schema, if set, makes the model respond with JSON matching it
+	// rather than prose.
+	Schema *Schema
+}
+
+// newOpenAICompleter creates an OpenAICompleter with a client for the
+// configured key.
+func newOpenAICompleter(cfg *Config, w io.Writer) (Completer, error) {
+	client, err := NewOpenAIClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newChatCompleter(cfg, client, w)
+}
+
+// newChatCompleter creates an OpenAICompleter using client, which may be
+// for any API compatible with OpenAI's.
+func newChatCompleter(cfg *Config, client ChatClient, w io.Writer) (Completer, error) {
+	completer := &OpenAICompleter{Client: client, Config: cfg, StreamOutput: w}
+	var err error
+	if cfg.SchemaFile != "" {
+		completer.Schema, err = LoadSchema(cfg.SchemaFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return completer, nil
+}
+
+// Complete sends messages to the configured model.
+func (c *OpenAICompleter) Complete(ctx context.Context, messages []Message) (string, Usage, error) {
+	cfg := c.Config
+	req, err := c.request(messages)
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	if c.Schema != nil {
+		return c.completeStructured(ctx, req)
+	}
+
+	if cfg.Stream {
+		w := c.StreamOutput
+		if w == nil {
+			w = io.Discard
+		}
+		return streamCompletion(ctx, c.Client, req, w, cfg.openAIRetryPolicy())
+	}
+
+	resp, err := c.create(ctx, req)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	usage := usageFrom(resp.Usage, resp.SystemFingerprint)
+	if len(resp.Choices) == 0 {
+		return "", usage, emptyResponse("")
+	}
+	choice := resp.Choices[0]
+	if strings.TrimSpace(choice.Message.Content) == "" {
+		return "", usage, emptyResponse(choice.FinishReason)
+	}
+	return choice.Message.Content, usage, nil
+}
+
+// CompleteChoices is like Complete, but asks the model for n alternative
+// responses. It may return fewer, as blank choices are left out. Choices
+// are neither streamed nor structured.
+func (c *OpenAICompleter) CompleteChoices(ctx context.Context, messages []Message, n int) ([]string, Usage, error) {
+	req, err := c.request(messages)
+	if err != nil {
+		return nil, Usage{}, err
+	}
+	req.N = n
+	resp, err := c.create(ctx, req)
+	if err != nil {
+		return nil, Usage{}, err
+	}
+	usage := usageFrom(resp.Usage, resp.SystemFingerprint)
+	var (
+		choices []string
+		reason  openai.FinishReason
+	)
+	for _, choice := range resp.Choices {
+		if strings.TrimSpace(choice.Message.Content) == "" {
+			reason = choice.FinishReason
+			continue
+		}
+		choices = append(choices, choice.Message.Content)
+	}
+	if len(choices) == 0 {
+		return nil, usage, emptyResponse(reason)
+	}
+	return choices, usage, nil
+}
+
+// create sends req, retrying rate limits and server errors.
+func (c *OpenAICompleter) create(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	var resp openai.ChatCompletionResponse
+	err := retryOpenAI(ctx, c.Config.openAIRetryPolicy(), func(ctx context.Context) error {
+		var err error
+		resp, err = c.Client.CreateChatCompletion(ctx, req)
+		return err
+	})
+	if err != nil {
+		return resp, fmt.Errorf("ChatCompletion error: %w", err)
+	}
+	return resp, nil
+}
+
+// request builds the request for messages with the configured model and
+// sampling parameters.
+func (c *OpenAICompleter) request(messages []Message) (openai.ChatCompletionRequest, error) {
+	cfg := c.Config
+	// Prompts that don't fit into the model's context fail with an opaque
+	// API error, so we check them up front.
+	messages, err := fitMessages(cfg, messages)
+	if err != nil {
+		return openai.ChatCompletionRequest{}, err
+	}
+	req := openai.ChatCompletionRequest{
+		Model:    cfg.Model,
+		Messages: openAIMessages(messages),
+	}
+	// Sampling parameters are only set when configured, so that OpenAI's
+	// defaults apply otherwise.
+	if cfg.Temperature != nil {
+		req.Temperature = *cfg.Temperature
+	}
+	if cfg.MaxTokens != nil {
+		req.MaxTokens = *cfg.MaxTokens
+	}
+	if cfg.TopP != nil {
+		req.TopP = *cfg.TopP
+	}
+	if cfg.Seed != nil {
+		seed := *cfg.Seed
+		req.Seed = &seed
+	}
+	return req, nil
+}
+
+// structuredFunction is the name of the function the model is made to
+// call for structured responses. Its parameters are the response.
+const structuredFunction = "respond"
+
+// structuredAttempts is how many times the model is asked for a response
+// matching the schema before giving up.
+const structuredAttempts = 2
+
+// completeStructured completes req with a response matching the schema,
+// by making the model call a function taking the schema as parameters.
+// Responses that don't match it are asked for again.
+func (c *OpenAICompleter) completeStructured(ctx context.Context, req openai.ChatCompletionRequest) (string, Usage, error) {
+	req.Tools = []openai.Tool{{
+		Type: openai.ToolTypeFunction,
+		Function: &openai.FunctionDefinition{
+			Name:        structuredFunction,
+			Description: "Respond to the user with structured data.",
+			Parameters:  c.Schema.Raw,
+		},
+	}}
+	req.ToolChoice = openai.ToolChoice{
+		Type:     openai.ToolTypeFunction,
+		Function: openai.ToolFunction{Name: structuredFunction},
+	}
+
+	var usage Usage
+	var invalid error
+	for attempt := 0; attempt < structuredAttempts; attempt++ {
+		var resp openai.ChatCompletionResponse
+		err := retryOpenAI(ctx, c.Config.openAIRetryPolicy(), func(ctx context.Context) error {
+			var err error
+			resp, err = c.Client.CreateChatCompletion(ctx, req)
+			return err
+		})
+		if err != nil {
+			return "", Usage{}, fmt.Errorf("ChatCompletion error: %w", err)
+		}
+		u := usageFrom(resp.Usage, resp.SystemFingerprint)
+		usage.PromptTokens += u.PromptTokens
+		usage.CompletionTokens += u.CompletionTokens
+		usage.TotalTokens += u.TotalTokens
+		usage.SystemFingerprint = u.SystemFingerprint
+
+		if len(resp.Choices) == 0 {
+			return "", usage, emptyResponse("")
+		}
+		toolCalls := resp.Choices[0].Message.ToolCalls
+		if len(toolCalls) == 0 {
+			invalid = fmt.Errorf("%w: model didn't return structured data", ErrSchemaMismatch)
+			continue
+		}
+		response := toolCalls[0].Function.Arguments
+		if invalid = c.Schema.Validate([]byte(response)); invalid == nil {
+			return response, usage, nil
+		}
+	}
+	return "", usage, invalid
+}
+
+// openAIMessages converts messages to OpenAI's message type.
+func openAIMessages(messages []Message) []openai.ChatCompletionMessage {
+	converted := make([]openai.ChatCompletionMessage, len(messages))
+	for i, m := range messages {
+		converted[i] = openai.ChatCompletionMessage{Role: m.Role, Content: m.Content}
+	}
+	return converted
+}
+
+// streamCompletion streams the completion for req, writing every delta to
+// w as soon as it arrives. It returns the whole response once the stream
+// ends. If the stream breaks off, the text received so far is returned
+// together with the error. Once ctx is done, the stream is closed right
+// away instead of drained, and the text so far is returned with ctx's
+// error. A stream without any text is ErrEmptyResponse.
+func streamCompletion(
+	ctx context.Context,
+	client ChatClient,
+	req openai.ChatCompletionRequest,
+	w io.Writer,
+	policy RetryPolicy,
+) (string, Usage, error) {
+	req.Stream = true
+	// Streamed responses only report usage if we ask for it, in a final
+	// message without choices.
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	// Only opening the stream is retried. Once text has been written to
+	// w, retrying would duplicate it.
+	var stream ChatStream
+	err := retryOpenAI(ctx, policy, func(ctx context.Context) error {
+		var err error
+		stream, err = client.CreateChatCompletionStream(ctx, req)
+		return err
+	})
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("ChatCompletionStream error: %w", err)
+	}
+	defer stream.Close()
+	// The request is aborted with ctx, but a stream not tied to it would
+	// keep Recv blocked, so we close it too.
+	stop := context.AfterFunc(ctx, func() { stream.Close() })
+	defer stop()
+
+	var (
+		full   strings.Builder
+		usage  Usage
+		reason openai.FinishReason
+	)
+	for {
+		resp, err := stream.Recv()
+		// Closing the stream after ctx is done fails Recv with errors of
+		// its own, or even io.EOF, which would hide the cancellation.
+		if err != nil && ctx.Err() != nil {
+			return full.String(), usage, fmt.Errorf("stream interrupted after %d bytes: %w", full.Len(), ctx.Err())
+		}
+		if errors.Is(err, io.EOF) {
+			if strings.TrimSpace(full.String()) == "" {
+				return "", usage, emptyResponse(reason)
+			}
+			return full.String(), usage, nil
+		}
+		if err != nil {
+			return full.String(), usage, fmt.Errorf("stream interrupted after %d bytes: %w", full.Len(), err)
+		}
+		if resp.Usage != nil {
+			usage = usageFrom(*resp.Usage, usage.SystemFingerprint)
+		}
+		if resp.SystemFingerprint != "" {
+			usage.SystemFingerprint = resp.SystemFingerprint
+		}
+		if len(resp.Choices) == 0 {
+			continue
+		}
+
+		if r := resp.Choices[0].FinishReason; r != "" {
+			reason = r
+		}
+		delta := resp.Choices[0].Delta.Content
+		full.WriteString(delta)
+		if _, err := io.WriteString(w, delta); err != nil {
+			return full.String(), usage, fmt.Errorf("error writing stream output: %w", err)
+		}
+		if f, ok := w.(interface{ Flush() error }); ok {
+			if err := f.Flush(); err != nil {
+				return full.String(), usage, fmt.Errorf("error flushing stream output: %w", err)
+			}
+		}
+	}
+}
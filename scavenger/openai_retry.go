@@ -0,0 +1,124 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// RetryAfterError is a failed OpenAI request together with the delay the
+// API asked us to wait before retrying it, from the Retry-After header.
+type RetryAfterError struct {
+	Err   error
+	Delay time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("%v (retry after %s)", e.Err, e.Delay)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// retryAfterKey is the context key of the *time.Duration the
+// retryAfterTransport stores the Retry-After hint of a response in.
+type retryAfterKey struct{}
+
+// retryAfterTransport records the Retry-After header of failed responses.
+// go-openai doesn't expose the headers of errors, so this is the only way
+// to get at it.
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip performs the request and records the Retry-After hint in the
+// request context, if it asks for one.
+func (t retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if hint, ok := req.Context().Value(retryAfterKey{}).(*time.Duration); ok && resp.StatusCode >= http.StatusBadRequest {
+		*hint = parseRetryAfter(resp.Header.Get("Retry-After"))
+	}
+	return resp, nil
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or
+// as an HTTP date. It returns zero if there is no usable hint.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// withRetryAfter calls fn with a context that collects the Retry-After
+// hint of its request, and attaches the hint to fn's error.
+func withRetryAfter(ctx context.Context, fn func(ctx context.Context) error) error {
+	var hint time.Duration
+	err := fn(context.WithValue(ctx, retryAfterKey{}, &hint))
+	if err != nil && hint > 0 {
+		return &RetryAfterError{Err: err, Delay: hint}
+	}
+	return err
+}
+
+// isRetryableOpenAIError reports whether err is a rate limit or a server
+// error. Other failures, such as an invalid API key, won't go away by
+// retrying.
+func isRetryableOpenAIError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	status := 0
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	}
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// retryOpenAI calls fn until it succeeds, fails with an error that isn't
+// retryable, the attempts are used up, or ctx is done. The delay between
+// attempts is the one the API asked for, if it did, and exponential
+// backoff otherwise.
+func retryOpenAI(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if attempt >= policy.MaxAttempts || !isRetryableOpenAIError(err) {
+			return err
+		}
+
+		delay := policy.delay(attempt)
+		var retryAfter *RetryAfterError
+		if errors.As(err, &retryAfter) {
+			delay = retryAfter.Delay
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package scavenger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// apiError is an OpenAI API error with the HTTP status code.
+func apiError(status int) error {
+	return &openai.APIError{HTTPStatusCode: status, Message: http.StatusText(status)}
+}
+
+func TestRetryOpenAI(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"rate limited", []error{apiError(http.StatusTooManyRequests)}, 2, false},
+		{"server errors", []error{apiError(http.StatusBadGateway), apiError(http.StatusInternalServerError)}, 3, false},
+		{"attempts used up", []error{apiError(500), apiError(500), apiError(500)}, 3, true},
+		{"bad key", []error{apiError(http.StatusUnauthorized)}, 1, true},
+		{"canceled", []error{context.Canceled}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := retryOpenAI(context.Background(), fastRetry, func(context.Context) error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr || calls != tt.wantCalls {
+				t.Errorf("got error %v after %d calls, want an error %t after %d", err, calls, tt.wantErr, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryOpenAIRetryAfter(t *testing.T) {
+	// The policy would wait an hour, but the API asks for less.
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	calls := 0
+	start := time.Now()
+	err := retryOpenAI(context.Background(), policy, func(context.Context) error {
+		calls++
+		if calls == 1 {
+			return &RetryAfterError{Err: apiError(http.StatusTooManyRequests), Delay: time.Millisecond}
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Fatalf("got error %v after %d calls, want success after 2", err, calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Minute {
+		t.Errorf("retrying took %s, want the API's delay", elapsed)
+	}
+}
+
+func TestRetryOpenAICanceledWhileWaiting(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	err := retryOpenAI(ctx, policy, func(context.Context) error { return apiError(500) })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want the deadline", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"2", 2 * time.Second},
+		{"0.5", 500 * time.Millisecond},
+		{"-1", 0},
+		{"soon", 0},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(future); got < 58*time.Minute || got > time.Hour {
+		t.Errorf("parseRetryAfter(%q) = %s, want about an hour", future, got)
+	}
+}
+
+func TestOpenAICompleterRetries(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0.01")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error": {"message": "slow down", "type": "rate_limit"}}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(chatResponse("finally"))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.OpenAIKey = "test-key"
+	cfg.OpenAIBaseURL = server.URL
+	cfg.OpenAIAttempts = 2
+	client, err := NewOpenAIClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer, _, err := testCompleter(t, cfg, client, nil).Complete(context.Background(), ChatMessages("", "hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "finally" || requests.Load() != 2 {
+		t.Errorf("answer %q after %d requests, want the second's", answer, requests.Load())
+	}
+}
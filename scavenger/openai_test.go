@@ -0,0 +1,267 @@
+package scavenger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// fakeChatClient is a ChatClient answering every request with resp, or
+// streaming the deltas of stream. It records the requests it gets.
+type fakeChatClient struct {
+	resp   openai.ChatCompletionResponse
+	err    error
+	stream []openai.ChatCompletionStreamResponse
+	// streamErr, if set, is returned by Recv once stream is used up, in
+	// place of io.EOF.
+	streamErr error
+
+	mu       sync.Mutex
+	requests []openai.ChatCompletionRequest
+}
+
+func (c *fakeChatClient) CreateChatCompletion(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	c.record(req)
+	return c.resp, c.err
+}
+
+func (c *fakeChatClient) CreateChatCompletionStream(_ context.Context, req openai.ChatCompletionRequest) (ChatStream, error) {
+	c.record(req)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &fakeChatStream{responses: c.stream, err: c.streamErr}, nil
+}
+
+func (c *fakeChatClient) record(req openai.ChatCompletionRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, req)
+}
+
+// lastRequest returns the last request the client got.
+func (c *fakeChatClient) lastRequest(t *testing.T) openai.ChatCompletionRequest {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.requests) == 0 {
+		t.Fatal("no request was sent")
+	}
+	return c.requests[len(c.requests)-1]
+}
+
+// fakeChatStream returns responses one by one, and then err or io.EOF.
+type fakeChatStream struct {
+	responses []openai.ChatCompletionStreamResponse
+	err       error
+	closed    bool
+}
+
+func (s *fakeChatStream) Recv() (openai.ChatCompletionStreamResponse, error) {
+	if len(s.responses) == 0 {
+		if s.err != nil {
+			return openai.ChatCompletionStreamResponse{}, s.err
+		}
+		return openai.ChatCompletionStreamResponse{}, io.EOF
+	}
+	resp := s.responses[0]
+	s.responses = s.responses[1:]
+	return resp, nil
+}
+
+func (s *fakeChatStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+// chatResponse is a response with a single choice answering content.
+func chatResponse(content string) openai.ChatCompletionResponse {
+	return openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Role: RoleAssistant, Content: content}}},
+		Usage:   openai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}
+}
+
+// testCompleter returns an OpenAICompleter for cfg answering through
+// client.
+func testCompleter(t *testing.T, cfg *Config, client ChatClient, w io.Writer) *OpenAICompleter {
+	t.Helper()
+	completer, err := newChatCompleter(cfg, client, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return completer.(*OpenAICompleter)
+}
+
+func TestOpenAICompleterModel(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Model = openai.GPT4o
+	client := &fakeChatClient{resp: chatResponse("pong")}
+	answer, usage, err := testCompleter(t, cfg, client, nil).Complete(context.Background(), ChatMessages("", "ping"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "pong" {
+		t.Errorf("answer = %q, want %q", answer, "pong")
+	}
+	if usage.TotalTokens != 15 {
+		t.Errorf("total tokens = %d, want 15", usage.TotalTokens)
+	}
+	if got := client.lastRequest(t).Model; got != openai.GPT4o {
+		t.Errorf("requested model %q, want %q", got, openai.GPT4o)
+	}
+}
+
+func TestIsKnownModel(t *testing.T) {
+	if !IsKnownModel(openai.GPT3Dot5Turbo) {
+		t.Errorf("%s isn't known", openai.GPT3Dot5Turbo)
+	}
+	if IsKnownModel("gpt-9000") {
+		t.Error("made up model is known")
+	}
+}
+
+// streamDeltas returns stream responses with one delta each of deltas,
+// followed by a usage report without choices.
+func streamDeltas(deltas ...string) []openai.ChatCompletionStreamResponse {
+	var responses []openai.ChatCompletionStreamResponse
+	for _, delta := range deltas {
+		responses = append(responses, openai.ChatCompletionStreamResponse{
+			Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: delta}}},
+		})
+	}
+	return append(responses, openai.ChatCompletionStreamResponse{
+		Usage: &openai.Usage{PromptTokens: 3, CompletionTokens: len(deltas), TotalTokens: 3 + len(deltas)},
+	})
+}
+
+func TestOpenAICompleterStream(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Stream = true
+	client := &fakeChatClient{stream: streamDeltas("Hel", "lo", "!")}
+	var out strings.Builder
+	answer, usage, err := testCompleter(t, cfg, client, &out).Complete(context.Background(), ChatMessages("", "hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "Hello!" || out.String() != "Hello!" {
+		t.Errorf("answer = %q and streamed %q, want %q for both", answer, out.String(), "Hello!")
+	}
+	if usage.CompletionTokens != 3 {
+		t.Errorf("completion tokens = %d, want 3 from the final message", usage.CompletionTokens)
+	}
+	req := client.lastRequest(t)
+	if !req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+		t.Error("stream wasn't requested with usage")
+	}
+}
+
+func TestOpenAICompleterStreamEmpty(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Stream = true
+	client := &fakeChatClient{stream: streamDeltas(" ", "\n")}
+	_, _, err := testCompleter(t, cfg, client, io.Discard).Complete(context.Background(), ChatMessages("", "hi"))
+	if !errors.Is(err, ErrEmptyResponse) {
+		t.Fatalf("error = %v, want ErrEmptyResponse", err)
+	}
+}
+
+func TestOpenAICompleterStreamBroken(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Stream = true
+	broken := errors.New("connection reset")
+	client := &fakeChatClient{stream: streamDeltas("partial")[:1], streamErr: broken}
+	answer, _, err := testCompleter(t, cfg, client, io.Discard).Complete(context.Background(), ChatMessages("", "hi"))
+	if !errors.Is(err, broken) {
+		t.Fatalf("error = %v, want the stream's", err)
+	}
+	if answer != "partial" {
+		t.Errorf("answer = %q, want the text received so far", answer)
+	}
+}
+
+func TestOpenAICompleterSystemPrompt(t *testing.T) {
+	client := &fakeChatClient{resp: chatResponse("ok")}
+	_, _, err := testCompleter(t, DefaultConfig(), client, nil).Complete(context.Background(), ChatMessages("be brief", "hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := client.lastRequest(t).Messages
+	if len(messages) != 2 || messages[0].Role != openai.ChatMessageRoleSystem || messages[0].Content != "be brief" {
+		t.Errorf("messages = %v, want the system prompt first", messages)
+	}
+}
+
+func TestOpenAICompleterSampling(t *testing.T) {
+	temperature, topP, maxTokens := float32(0.2), float32(0.9), 64
+	cfg := DefaultConfig()
+	cfg.Temperature, cfg.TopP, cfg.MaxTokens = &temperature, &topP, &maxTokens
+	client := &fakeChatClient{resp: chatResponse("ok")}
+	if _, _, err := testCompleter(t, cfg, client, nil).Complete(context.Background(), ChatMessages("", "hi")); err != nil {
+		t.Fatal(err)
+	}
+	req := client.lastRequest(t)
+	if req.Temperature != temperature || req.TopP != topP || req.MaxTokens != maxTokens {
+		t.Errorf("request has temperature %v, top-p %v and max tokens %d, want the configured ones", req.Temperature, req.TopP, req.MaxTokens)
+	}
+
+	// Unset parameters are left to OpenAI's defaults.
+	if _, _, err := testCompleter(t, DefaultConfig(), client, nil).Complete(context.Background(), ChatMessages("", "hi")); err != nil {
+		t.Fatal(err)
+	}
+	req = client.lastRequest(t)
+	if req.Temperature != 0 || req.TopP != 0 || req.MaxTokens != 0 {
+		t.Errorf("request has temperature %v, top-p %v and max tokens %d, want none", req.Temperature, req.TopP, req.MaxTokens)
+	}
+}
+
+func TestOpenAIBaseURL(t *testing.T) {
+	var gotPath, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(chatResponse("from the proxy"))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.OpenAIKey = "test-key"
+	cfg.OpenAIBaseURL = server.URL + "/v1"
+	client, err := NewOpenAIClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer, _, err := testCompleter(t, cfg, client, nil).Complete(context.Background(), ChatMessages("", "hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "from the proxy" {
+		t.Errorf("answer = %q, want the server's", answer)
+	}
+	if gotPath != "/v1/chat/completions" || gotAuth != "Bearer test-key" {
+		t.Errorf("server got %s with authorization %q, want the chat completions under the base URL with the key", gotPath, gotAuth)
+	}
+}
+
+func TestValidateOpenAIBaseURL(t *testing.T) {
+	for _, base := range []string{"localhost:8080", "/v1", "http://%zz"} {
+		cfg := testConfig()
+		cfg.OpenAIBaseURL = base
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("base URL %q was accepted", base)
+		}
+	}
+	cfg := testConfig()
+	cfg.OpenAIBaseURL = "http://localhost:8080/v1"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("base URL %q: %v", cfg.OpenAIBaseURL, err)
+	}
+}
@@ -0,0 +1,241 @@
+package scavenger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// packMarker prefixes payloads holding several prompts packed into one
+// blob. It is followed by a gzip stream of the manifest and the entries:
+// the number of prompts as a big endian uint32, the offset of every entry
+// within the entries as big endian uint32s, and the entries, each the
+// length of its prompt as a big endian uint32 followed by the prompt.
+const packMarker = "PSP\x01"
+
+// ErrCorruptPack is returned for packs whose manifest doesn't match their
+// entries.
+var ErrCorruptPack = errors.New("corrupt prompt pack")
+
+// encodePack packs prompts into a single payload.
+func encodePack(prompts []string) ([]byte, error) {
+	var entries []byte
+	manifest := binary.BigEndian.AppendUint32(nil, uint32(len(prompts)))
+	for _, prompt := range prompts {
+		manifest = binary.BigEndian.AppendUint32(manifest, uint32(len(entries)))
+		entries = binary.BigEndian.AppendUint32(entries, uint32(len(prompt)))
+		entries = append(entries, prompt...)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(packMarker)
+	zw := gzip.NewWriter(&buf)
+	for _, data := range [][]byte{manifest, entries} {
+		if _, err := zw.Write(data); err != nil {
+			return nil, fmt.Errorf("error compressing prompt pack: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("error compressing prompt pack: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodePack unpacks the prompts of a decoded pack payload, checking the
+// manifest against the entries. It reports false for payloads that aren't
+// packs.
+func DecodePack(payload []byte) ([]string, bool, error) {
+	if !IsPackPayload(payload) {
+		return nil, false, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(payload[len(packMarker):]))
+	if err != nil {
+		return nil, true, fmt.Errorf("%w: %v", ErrCorruptPack, err)
+	}
+	defer zr.Close()
+	data, err := readDecompressed(zr)
+	if err != nil {
+		return nil, true, fmt.Errorf("%w: %w", ErrCorruptPack, err)
+	}
+
+	if len(data) < 4 {
+		return nil, true, fmt.Errorf("%w: missing manifest", ErrCorruptPack)
+	}
+	count := uint64(binary.BigEndian.Uint32(data))
+	if count == 0 {
+		return nil, true, fmt.Errorf("%w: no prompts", ErrCorruptPack)
+	}
+	if 4+4*count > uint64(len(data)) {
+		return nil, true, fmt.Errorf("%w: manifest of %d prompts is truncated", ErrCorruptPack, count)
+	}
+	offsets, entries := data[4:4+4*count], data[4+4*count:]
+
+	prompts := make([]string, count)
+	var next uint64
+	for i := range prompts {
+		offset := uint64(binary.BigEndian.Uint32(offsets[4*i:]))
+		if offset != next {
+			return nil, true, fmt.Errorf("%w: prompt %d is at offset %d, expected %d", ErrCorruptPack, i, offset, next)
+		}
+		if offset+4 > uint64(len(entries)) {
+			return nil, true, fmt.Errorf("%w: prompt %d is truncated", ErrCorruptPack, i)
+		}
+		size := uint64(binary.BigEndian.Uint32(entries[offset:]))
+		next = offset + 4 + size
+		if next > uint64(len(entries)) {
+			return nil, true, fmt.Errorf("%w: prompt %d is truncated", ErrCorruptPack, i)
+		}
+		prompts[i] = string(entries[offset+4 : next])
+	}
+	if next != uint64(len(entries)) {
+		return nil, true, fmt.Errorf("%w: %d bytes after the last prompt", ErrCorruptPack, uint64(len(entries))-next)
+	}
+	return prompts, true, nil
+}
+
+// IsPackPayload reports whether the decoded payload holds prompts packed
+// with SubmitPack rather than a single prompt.
+func IsPackPayload(payload []byte) bool {
+	return bytes.HasPrefix(payload, []byte(packMarker))
+}
+
+// SubmitPack submits prompts packed into a single payload to ns. Every
+// prompt is redacted and moderated like with SubmitPrompt, but they aren't
+// put into envelopes. The pack is then encoded and chunked as configured.
+func (c *Client) SubmitPack(ctx context.Context, ns share.Namespace, prompts []string) (*Submission, error) {
+	redacted := make([]string, len(prompts))
+	for i, prompt := range prompts {
+		var err error
+		redacted[i], err = c.redact(prompt)
+		if err != nil {
+			return nil, fmt.Errorf("prompt %d: %w", i, err)
+		}
+		if err := c.moderate(ctx, redacted[i]); err != nil {
+			return nil, fmt.Errorf("prompt %d: %w", i, err)
+		}
+	}
+	pack, err := encodePack(redacted)
+	if err != nil {
+		return nil, err
+	}
+	payloads, err := encodePayloads(c.Config, pack)
+	if err != nil {
+		return nil, err
+	}
+	return c.submit(ctx, ns, pack, payloads)
+}
+
+// RunPack is like RunBatch, but submits all prompts packed into a single
+// blob. Once the pack is fetched back and verified, the model is asked
+// about every prompt of it, up to the configured concurrency at once.
+// Failing to submit or fetch the pack fails every prompt, so it is
+// returned instead.
+func (c *Client) RunPack(ctx context.Context, prompts []string) ([]BatchResult, error) {
+	cfg := c.Config
+	namespaceID, err := CreateNamespaceID(cfg.Namespace, cfg.NamespaceVersion, cfg.PadNamespace)
+	if err != nil {
+		return nil, StageError("namespace", fmt.Errorf("Failed to decode namespace: %w", err))
+	}
+
+	sub, err := c.SubmitPack(ctx, namespaceID, prompts)
+	if err != nil {
+		return nil, StageError("submit", err)
+	}
+	if err := c.WaitConfirmations(ctx, sub.Height); err != nil {
+		return nil, StageError("confirmations", err)
+	}
+	if cfg.Wait > 0 {
+		if err := c.waitForBlob(ctx, sub.Height, namespaceID, sub.Blobs[0].Commitment); err != nil {
+			return nil, StageError("fetch", fmt.Errorf("Failed to fetch blob: %w", err))
+		}
+	}
+	fetched, err := c.FetchPrompt(ctx, sub.Height, namespaceID, sub.Commitments())
+	if err != nil {
+		return nil, StageError("fetch", err)
+	}
+	if err := c.VerifyBlobs(sub, fetched); err != nil {
+		return nil, StageError("verification", fmt.Errorf("Fetched blob failed verification: %w", err))
+	}
+	if len(fetched.Prompts) != len(prompts) {
+		return nil, StageError("verification", fmt.Errorf("%w: packed %d prompts, fetched %d", ErrCorruptPack, len(prompts), len(fetched.Prompts)))
+	}
+	if cfg.VerifyProof {
+		if err := c.VerifyInclusion(ctx, sub); err != nil {
+			return nil, StageError("proof verification", err)
+		}
+	}
+	c.logger().Info("Fetched prompt pack",
+		"height", sub.Height,
+		"namespace", NamespaceHex(namespaceID),
+		"commitment", hex.EncodeToString(sub.Blobs[0].Commitment),
+		"prompts", len(fetched.Prompts))
+
+	base := RunResult{
+		Namespace:     NamespaceHex(namespaceID),
+		Height:        sub.Height,
+		Commitment:    hex.EncodeToString(sub.Blobs[0].Commitment),
+		TxHash:        sub.TxHash,
+		MirrorHeight:  sub.MirrorHeight,
+		Memo:          cfg.Memo,
+		ProofVerified: cfg.VerifyProof,
+	}
+	if len(sub.Blobs) > 1 {
+		base.Commitments = CommitmentsHex(sub.Blobs)
+	}
+	// The model gets the prompts as fetched, unless they were only
+	// redacted for the chain.
+	ask := fetched.Prompts
+	if cfg.Redact == RedactChainOnly {
+		ask = prompts
+	}
+	results := c.AskPack(ctx, ask, &base)
+	for i := range results {
+		results[i].Prompt = prompts[i]
+		if r := results[i].Result; r != nil {
+			r.SubmittedPayload = prompts[i]
+			r.FetchedPayload = fetched.Prompts[i]
+		}
+	}
+	return results, nil
+}
+
+// AskPack asks the model about every prompt of a fetched pack, up to the
+// configured concurrency at once. The results are in the order of prompts
+// and copy base, which says where the pack was fetched from. Like with
+// RunBatch, a failing prompt doesn't stop the others.
+func (c *Client) AskPack(ctx context.Context, prompts []string, base *RunResult) []BatchResult {
+	cfg := c.Config
+	results := make([]BatchResult, len(prompts))
+	for i, prompt := range prompts {
+		results[i] = BatchResult{Index: i, Prompt: prompt}
+	}
+	forEach(ctx, len(prompts), cfg.Concurrency, func(i int) {
+		start := time.Now()
+		choices, usage, err := c.AskChoices(ctx, WrapPrompt(cfg, prompts[i]), cfg.Choices)
+		if err != nil {
+			results[i].Error = StageError("completion", fmt.Errorf("Failed to process message with %s: %w", cfg.Model, err)).Error()
+			return
+		}
+		result := *base
+		result.FetchedPayload = prompts[i]
+		result.Model = cfg.Model
+		result.Response = choices[0]
+		result.Structured = StructuredResponse(cfg, choices[0])
+		result.SetUsage(cfg, usage)
+		if len(choices) > 1 {
+			result.Choices = choices
+		}
+		result.Timings = map[string]time.Duration{"completion": time.Since(start)}
+		results[i].Result = &result
+	}, func(i int, err error) {
+		results[i].Error = err.Error()
+	})
+	return results
+}
@@ -0,0 +1,178 @@
+package scavenger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// rawPack returns a pack payload compressing data as is, so that it can
+// be corrupt.
+func rawPack(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.WriteString(packMarker)
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// uint32s returns vs as big endian uint32s.
+func uint32s(vs ...uint32) []byte {
+	var b []byte
+	for _, v := range vs {
+		b = binary.BigEndian.AppendUint32(b, v)
+	}
+	return b
+}
+
+func TestPackRoundTrip(t *testing.T) {
+	prompts := []string{"first", "", "naïve\nmultiline", strings.Repeat("x", 1000)}
+	pack, err := encodePack(prompts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsPackPayload(pack) {
+		t.Fatal("the pack isn't recognized as one")
+	}
+	got, isPack, err := DecodePack(pack)
+	if err != nil || !isPack {
+		t.Fatalf("decoding the pack = %v, %v", isPack, err)
+	}
+	if !reflect.DeepEqual(got, prompts) {
+		t.Errorf("unpacked %q, want %q", got, prompts)
+	}
+}
+
+func TestDecodePackNotPack(t *testing.T) {
+	prompts, isPack, err := DecodePack([]byte("just a prompt"))
+	if prompts != nil || isPack || err != nil {
+		t.Errorf("decoding a prompt = %q, %v, %v, want it left alone", prompts, isPack, err)
+	}
+}
+
+func TestDecodePackCorrupt(t *testing.T) {
+	entry := append(uint32s(2), "hi"...)
+	tests := []struct {
+		name    string
+		payload []byte
+		want    string
+	}{
+		{"not gzip", []byte(packMarker + "plain"), "corrupt prompt pack"},
+		{"no manifest", rawPack(t, []byte{0, 1}), "missing manifest"},
+		{"no prompts", rawPack(t, uint32s(0)), "no prompts"},
+		{"truncated manifest", rawPack(t, uint32s(3, 0)), "manifest of 3 prompts is truncated"},
+		{"wrong offset", rawPack(t, append(uint32s(1, 4), entry...)), "prompt 0 is at offset 4, expected 0"},
+		{"truncated entry", rawPack(t, append(uint32s(1, 0), uint32s(10)...)), "prompt 0 is truncated"},
+		{"trailing bytes", rawPack(t, append(append(uint32s(1, 0), entry...), "!!"...)), "2 bytes after the last prompt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, isPack, err := DecodePack(tt.payload)
+			if !isPack || !errors.Is(err, ErrCorruptPack) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("decoding = %v, %v, want a corrupt pack error containing %q", isPack, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodePackTooLarge(t *testing.T) {
+	data := append(uint32s(1, 0, maxDecompressedSize), make([]byte, maxDecompressedSize)...)
+	_, isPack, err := DecodePack(rawPack(t, data))
+	if !isPack || !errors.Is(err, ErrCorruptPack) || !errors.Is(err, ErrDecompressedTooLarge) {
+		t.Errorf("decoding = %v, %v, want a corrupt pack too large to decompress", isPack, err)
+	}
+}
+
+func TestFetchPromptPack(t *testing.T) {
+	c, _, _ := newTestClient(testConfig())
+	prompts := []string{"one", "two", "three"}
+	sub, err := c.SubmitPack(context.Background(), testNS(t, testNamespace), prompts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched, err := c.FetchPrompt(context.Background(), sub.Height, sub.Namespace, sub.Commitments())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fetched.Prompts, prompts) || string(fetched.Payload) != "one\ntwo\nthree" {
+		t.Errorf("fetched prompts %q and payload %q, want the packed prompts", fetched.Prompts, fetched.Payload)
+	}
+
+	// Packs have no envelopes to sign.
+	c.Config.VerifySignature = true
+	if _, err := c.FetchPrompt(context.Background(), sub.Height, sub.Namespace, sub.Commitments()); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("error = %v, want ErrUnsigned", err)
+	}
+}
+
+func TestRunPack(t *testing.T) {
+	cfg := testConfig()
+	cfg.Concurrency = 2
+	api := &FakeBlobAPI{}
+	c := &Client{Config: cfg, Blobs: api, Completer: &refusingCompleter{refuse: "b"}}
+	prompts := []string{"a", "b", "c"}
+	results, err := c.RunPack(context.Background(), prompts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fakeHeight(api) != 1 {
+		t.Errorf("chain is at height %d, want the prompts in a single block", fakeHeight(api))
+	}
+	if len(results) != len(prompts) {
+		t.Fatalf("got %d results, want %d", len(results), len(prompts))
+	}
+	for i, result := range results {
+		if result.Index != i || result.Prompt != prompts[i] {
+			t.Errorf("result %d is for prompt %d %q, want the results in order", i, result.Index, result.Prompt)
+		}
+		if prompts[i] == "b" {
+			if !strings.Contains(result.Error, "refused") || result.Result != nil {
+				t.Errorf("refused prompt has result %+v and error %q, want only the error", result.Result, result.Error)
+			}
+			continue
+		}
+		r := result.Result
+		if result.Error != "" || r.Response != "answer to "+prompts[i] || r.FetchedPayload != prompts[i] {
+			t.Errorf("prompt %q got %+v and error %q, want its own answer", prompts[i], r, result.Error)
+		}
+		if r.Height != 1 || r.Commitment != results[0].Result.Commitment {
+			t.Errorf("prompt %q is at height %d with commitment %s, want the pack's", prompts[i], r.Height, r.Commitment)
+		}
+	}
+}
+
+func TestRunPackChunked(t *testing.T) {
+	cfg := testConfig()
+	cfg.Raw = true
+	cfg.ChunkSize = 16
+	c, _, _ := newTestClient(cfg)
+	prompts := []string{strings.Repeat("a", 40), strings.Repeat("b", 40)}
+	results, err := c.RunPack(context.Background(), prompts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range results {
+		if result.Result == nil || result.Result.FetchedPayload != prompts[i] || len(result.Result.Commitments) < 2 {
+			t.Errorf("prompt %d got %+v and error %q, want it fetched from several chunks", i, result.Result, result.Error)
+		}
+	}
+}
+
+func TestRunPackSubmitFails(t *testing.T) {
+	c, api, _ := newTestClient(testConfig())
+	api.SubmitErr = errors.New("out of funds")
+	if _, err := c.RunPack(context.Background(), []string{"a", "b"}); !errors.Is(err, ErrSubmit) {
+		t.Errorf("error = %v, want a submit error for the whole pack", err)
+	}
+}
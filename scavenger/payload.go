@@ -0,0 +1,115 @@
+package scavenger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Compression schemes selected with -compress.
+const (
+	CompressNone = "none"
+	CompressGzip = "gzip"
+)
+
+// Encoded payloads are prefixed with a marker made of a magic string and
+// a version byte, so the fetch side knows how to decode them. Versions
+// let future schemes coexist with the current ones.
+const (
+	// gzipMarker prefixes gzip compressed payloads.
+	gzipMarker = "PSZ\x01"
+	// aesGCMMarker prefixes AES-GCM encrypted payloads.
+	aesGCMMarker = "PSE\x01"
+)
+
+// maxDecompressedSize is the most a compressed payload may decompress to.
+// Blobs come from anyone submitting to a namespace, so a small blob must
+// not be able to expand into more than a few blobs' worth of memory.
+const maxDecompressedSize = 4 * DefaultMaxBlobSize
+
+// ErrDecompressedTooLarge is returned for compressed data expanding to
+// more than maxDecompressedSize.
+var ErrDecompressedTooLarge = errors.New("decompressed payload too large")
+
+// EncodePayload prepares the prompt for submission, compressing and then
+// encrypting it if configured.
+func EncodePayload(cfg *Config, prompt []byte) ([]byte, error) {
+	payload := prompt
+	if cfg.Compress == CompressGzip {
+		var err error
+		payload, err = compressPayload(payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Encrypt {
+		return encryptPayload(cfg.EncryptionKey, payload)
+	}
+	return payload, nil
+}
+
+// DecodePayload reverses EncodePayload, detecting from the data itself
+// how it was encoded. Plain payloads are returned as they are.
+func DecodePayload(cfg *Config, data []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, []byte(aesGCMMarker)) {
+		var err error
+		data, err = decryptPayload(cfg.EncryptionKey, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if bytes.HasPrefix(data, []byte(gzipMarker)) {
+		return decompressPayload(data)
+	}
+	return data, nil
+}
+
+// compressPayload gzip compresses payload and prefixes it with
+// gzipMarker. If that doesn't make the payload smaller, it's returned
+// unchanged.
+func compressPayload(payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(gzipMarker)
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(payload); err != nil {
+		return nil, fmt.Errorf("error compressing payload: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("error compressing payload: %w", err)
+	}
+
+	if buf.Len() >= len(payload) {
+		return payload, nil
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressPayload inflates a payload produced by compressPayload.
+func decompressPayload(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data[len(gzipMarker):]))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing payload: %w", err)
+	}
+	defer zr.Close()
+
+	payload, err := readDecompressed(zr)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing payload: %w", err)
+	}
+	return payload, nil
+}
+
+// readDecompressed reads all of the decompressing reader zr, failing with
+// ErrDecompressedTooLarge rather than read more than maxDecompressedSize.
+func readDecompressed(zr io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(zr, maxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDecompressedSize {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrDecompressedTooLarge, maxDecompressedSize)
+	}
+	return data, nil
+}
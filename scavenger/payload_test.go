@@ -0,0 +1,88 @@
+package scavenger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompressPayload(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Compress = CompressGzip
+	prompt := []byte(strings.Repeat("compress me please ", 50))
+	payload, err := EncodePayload(cfg, prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(payload, []byte(gzipMarker)) || len(payload) >= len(prompt) {
+		t.Fatalf("encoded %d bytes to %d, want a smaller gzip payload", len(prompt), len(payload))
+	}
+	// Decoding detects the compression, whatever is configured.
+	got, err := DecodePayload(DefaultConfig(), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, prompt) {
+		t.Errorf("decoded %q, want %q", got, prompt)
+	}
+}
+
+func TestCompressPayloadIncompressible(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Compress = CompressGzip
+	payload, err := EncodePayload(cfg, []byte("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(payload) != "hi" {
+		t.Errorf("encoded %q, want the prompt left as it is", payload)
+	}
+}
+
+func TestDecompressPayloadCorrupt(t *testing.T) {
+	if _, err := DecodePayload(DefaultConfig(), []byte(gzipMarker+"not gzip")); err == nil {
+		t.Error("decoding a corrupt gzip payload succeeded")
+	}
+}
+
+func TestDecompressPayloadTooLarge(t *testing.T) {
+	bomb, err := compressPayload(make([]byte, maxDecompressedSize+1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodePayload(DefaultConfig(), bomb); !errors.Is(err, ErrDecompressedTooLarge) {
+		t.Errorf("error = %v, want ErrDecompressedTooLarge", err)
+	}
+
+	largest, err := compressPayload(make([]byte, maxDecompressedSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := DecodePayload(DefaultConfig(), largest); err != nil || len(got) != maxDecompressedSize {
+		t.Errorf("decoding the largest payload = %d bytes, %v", len(got), err)
+	}
+}
+
+func TestValidateCompress(t *testing.T) {
+	cfg := testConfig()
+	cfg.Compress = "zstd"
+	if err := cfg.Validate(); err == nil {
+		t.Error("unknown compression was accepted")
+	}
+}
+
+func TestRunCompressed(t *testing.T) {
+	cfg := testConfig()
+	cfg.Compress = CompressGzip
+	c, _, _ := newTestClient(cfg)
+	prompt := strings.Repeat("a compressible prompt ", 20)
+	result, err := c.Run(context.Background(), prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FetchedPayload != prompt {
+		t.Errorf("fetched %q, want %q", result.FetchedPayload, prompt)
+	}
+}
@@ -0,0 +1,61 @@
+package scavenger
+
+import (
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+)
+
+// ProgressStage is the part of the flow a progress update is about.
+type ProgressStage string
+
+const (
+	ProgressBuilding   ProgressStage = "building"
+	ProgressSubmitting ProgressStage = "submitting"
+	ProgressWaiting    ProgressStage = "waiting"
+	ProgressFetching   ProgressStage = "fetching"
+)
+
+// Progress writes a line per progress update, such as
+// "submitting 0/2048 bytes", for long submissions. A nil Progress reports
+// nothing. It is safe for concurrent use.
+type Progress struct {
+	mu  sync.Mutex
+	w   io.Writer
+	buf []byte
+}
+
+// NewProgress returns a Progress writing to w.
+func NewProgress(w io.Writer) *Progress {
+	return &Progress{w: w, buf: make([]byte, 0, 64)}
+}
+
+// Report reports that done of total bytes have passed stage. The line is
+// built in a reused buffer, so reporting doesn't allocate.
+func (p *Progress) Report(stage ProgressStage, done, total int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	b := append(p.buf[:0], stage...)
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, int64(done), 10)
+	b = append(b, '/')
+	b = strconv.AppendInt(b, int64(total), 10)
+	b = append(b, " bytes\n"...)
+	p.buf = b
+	// Progress is best effort, a failing writer doesn't fail the run.
+	_, _ = p.w.Write(b)
+}
+
+// blobsSize returns the total size of the blobs' data.
+func blobsSize(blobs []*blob.Blob) int {
+	size := 0
+	for _, b := range blobs {
+		size += len(b.Data)
+	}
+	return size
+}
@@ -0,0 +1,53 @@
+package scavenger
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressReport(t *testing.T) {
+	var out strings.Builder
+	p := NewProgress(&out)
+	p.Report(ProgressSubmitting, 0, 2048)
+	p.Report(ProgressSubmitting, 2048, 2048)
+	if want := "submitting 0/2048 bytes\nsubmitting 2048/2048 bytes\n"; out.String() != want {
+		t.Errorf("reported %q, want %q", out.String(), want)
+	}
+
+	// A nil Progress reports nothing.
+	var none *Progress
+	none.Report(ProgressFetching, 1, 2)
+}
+
+func TestProgressReportAllocs(t *testing.T) {
+	p := NewProgress(io.Discard)
+	if allocs := testing.AllocsPerRun(100, func() { p.Report(ProgressFetching, 1024, 4096) }); allocs != 0 {
+		t.Errorf("report allocates %v times, want none", allocs)
+	}
+}
+
+func TestClientRunProgress(t *testing.T) {
+	cfg := testConfig()
+	cfg.Raw = true
+	cfg.Wait = time.Second
+	c, _, _ := newTestClient(cfg)
+	var out strings.Builder
+	c.Progress = NewProgress(&out)
+	if _, err := c.Run(context.Background(), "hello"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"building 0/5 bytes",
+		"submitting 0/5 bytes",
+		"submitting 5/5 bytes",
+		"waiting 0/5 bytes",
+		"fetching 0/5 bytes",
+		"fetching 5/5 bytes",
+	}
+	if got := strings.Split(strings.TrimSpace(out.String()), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("progress = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package scavenger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Roles of the messages sent to the model.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is a single chat message sent to the model.
+type Message struct {
+	Role    string
+	Content string
+}
+
+// Completer answers a conversation with a model. Implementations exist per
+// provider, so the rest of the flow doesn't depend on any of them.
+type Completer interface {
+	Complete(ctx context.Context, messages []Message) (string, Usage, error)
+}
+
+// ChoicesCompleter is implemented by Completers that can answer with
+// several alternative responses at once.
+type ChoicesCompleter interface {
+	CompleteChoices(ctx context.Context, messages []Message, n int) ([]string, Usage, error)
+}
+
+// ProviderOpenAI is the default provider.
+const ProviderOpenAI = "openai"
+
+// providers maps the names accepted by -provider to constructors of their
+// Completers. Streamed responses are written to w.
+var providers = map[string]func(cfg *Config, w io.Writer) (Completer, error){
+	ProviderOpenAI: newOpenAICompleter,
+	ProviderAzure:  newAzureCompleter,
+}
+
+// chatProvider reports whether provider answers with OpenAI's chat API,
+// and so supports everything OpenAICompleter does.
+func chatProvider(provider string) bool {
+	return provider == ProviderOpenAI || provider == ProviderAzure
+}
+
+// NewCompleter creates the Completer for the configured provider.
+func NewCompleter(cfg *Config, w io.Writer) (Completer, error) {
+	newCompleter, ok := providers[cfg.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q", cfg.Provider)
+	}
+	return newCompleter(cfg, w)
+}
+
+// providerNames returns the known provider names, sorted.
+func providerNames() string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// CompletePrompt sends msg, preceded by the configured system prompt, to
+// completer and returns the response and the tokens it used.
+func CompletePrompt(ctx context.Context, completer Completer, cfg *Config, msg string) (string, Usage, error) {
+	return completer.Complete(ctx, ChatMessages(cfg.SystemPrompt, msg))
+}
+
+// ChatMessages builds the messages sent to the model: the optional system
+// prompt followed by the user's message.
+func ChatMessages(systemPrompt, msg string) []Message {
+	var messages []Message
+	if systemPrompt != "" {
+		messages = append(messages, Message{Role: RoleSystem, Content: systemPrompt})
+	}
+	return append(messages, Message{Role: RoleUser, Content: msg})
+}
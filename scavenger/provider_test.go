@@ -0,0 +1,79 @@
+package scavenger
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChatMessages(t *testing.T) {
+	tests := []struct {
+		system string
+		want   []Message
+	}{
+		{"", []Message{{RoleUser, "hi"}}},
+		{"be brief", []Message{{RoleSystem, "be brief"}, {RoleUser, "hi"}}},
+	}
+	for _, tt := range tests {
+		if got := ChatMessages(tt.system, "hi"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ChatMessages(%q) = %v, want %v", tt.system, got, tt.want)
+		}
+	}
+}
+
+// registerProvider adds a provider answering with completer until the
+// test ends.
+func registerProvider(t *testing.T, name string, completer Completer) {
+	t.Helper()
+	providers[name] = func(*Config, io.Writer) (Completer, error) { return completer, nil }
+	t.Cleanup(func() { delete(providers, name) })
+}
+
+func TestNewCompleter(t *testing.T) {
+	fake := &fakeCompleter{answer: "from the fake"}
+	registerProvider(t, "fake", fake)
+	cfg := DefaultConfig()
+	cfg.Provider = "fake"
+	completer, err := NewCompleter(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completer != Completer(fake) {
+		t.Errorf("got completer %T, want the registered one", completer)
+	}
+
+	cfg.Provider = "nope"
+	if _, err := NewCompleter(cfg, nil); err == nil || !strings.Contains(err.Error(), `unknown provider "nope"`) {
+		t.Errorf("error = %v, want an unknown provider", err)
+	}
+}
+
+func TestValidateProvider(t *testing.T) {
+	cfg := testConfig()
+	cfg.Provider = "nope"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "provider must be one of "+ProviderAzure+", "+ProviderOpenAI) {
+		t.Errorf("error = %v, want the known providers", err)
+	}
+}
+
+func TestClientAskProvider(t *testing.T) {
+	fake := &fakeCompleter{}
+	registerProvider(t, "fake", fake)
+	cfg := testConfig()
+	cfg.Provider = "fake"
+	cfg.SystemPrompt = "be brief"
+	c := &Client{Config: cfg, Blobs: &FakeBlobAPI{}}
+	answer, _, err := c.Ask(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "answer to hi" || c.Completer != Completer(fake) {
+		t.Errorf("answer = %q from %T, want the configured provider's", answer, c.Completer)
+	}
+	if got := fake.calls[0]; !reflect.DeepEqual(got, ChatMessages("be brief", "hi")) {
+		t.Errorf("provider was sent %v, want the system prompt and the prompt", got)
+	}
+}
@@ -0,0 +1,62 @@
+package scavenger
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limiterNow and limiterAfter are the clock of RateLimiter. They can be
+// replaced in tests.
+var (
+	limiterNow   = time.Now
+	limiterAfter = time.After
+)
+
+// RateLimiter is a token bucket spacing out requests to a steady rate,
+// while letting up to a burst of them through at once after a pause. It
+// is safe for concurrent use, so workers sharing it share the rate.
+type RateLimiter struct {
+	interval time.Duration
+	burst    int
+
+	mu sync.Mutex
+	// next is when the next request may start once the bucket is empty.
+	next time.Time
+}
+
+// NewRateLimiter creates a RateLimiter allowing perMinute requests a
+// minute, with bursts of up to burst requests. A burst below 1 counts as
+// 1.
+func NewRateLimiter(perMinute float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		interval: time.Duration(float64(time.Minute) / perMinute),
+		burst:    max(burst, 1),
+	}
+}
+
+// Wait blocks until a request may start, or ctx is done. A request given
+// up on still uses its slot.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := limiterNow()
+	// A full bucket holds burst requests, so a request may start as
+	// early as burst-1 intervals before next, but not before now.
+	start := l.next
+	if earliest := now.Add(-time.Duration(l.burst-1) * l.interval); start.Before(earliest) {
+		start = earliest
+	}
+	l.next = start.Add(l.interval)
+	l.mu.Unlock()
+
+	wait := start.Sub(now)
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-limiterAfter(wait):
+		return nil
+	}
+}
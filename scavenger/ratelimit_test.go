@@ -0,0 +1,199 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLimiterClock is the clock of RateLimiter in tests. Waiting returns
+// at once, moving the clock on by the wait if advance is set, or never if
+// block is set. It records the waits.
+type fakeLimiterClock struct {
+	advance bool
+	block   bool
+
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+// useFakeLimiterClock replaces the clock of RateLimiter until the test
+// ends.
+func useFakeLimiterClock(t *testing.T, clock *fakeLimiterClock) *fakeLimiterClock {
+	t.Helper()
+	clock.now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	oldNow, oldAfter := limiterNow, limiterAfter
+	limiterNow = func() time.Time {
+		clock.mu.Lock()
+		defer clock.mu.Unlock()
+		return clock.now
+	}
+	limiterAfter = func(d time.Duration) <-chan time.Time {
+		clock.mu.Lock()
+		defer clock.mu.Unlock()
+		clock.waits = append(clock.waits, d)
+		ch := make(chan time.Time, 1)
+		if clock.block {
+			return ch
+		}
+		if clock.advance {
+			clock.now = clock.now.Add(d)
+		}
+		ch <- clock.now
+		return ch
+	}
+	t.Cleanup(func() { limiterNow, limiterAfter = oldNow, oldAfter })
+	return clock
+}
+
+// sleep moves the clock on by d.
+func (c *fakeLimiterClock) sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// takeWaits returns the waits so far, forgetting them.
+func (c *fakeLimiterClock) takeWaits() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	waits := c.waits
+	c.waits = nil
+	return waits
+}
+
+// waitN waits for l n times, failing the test on errors.
+func waitN(t *testing.T, l *RateLimiter, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRateLimiterSpacing(t *testing.T) {
+	clock := useFakeLimiterClock(t, &fakeLimiterClock{advance: true})
+	l := NewRateLimiter(30, 1)
+	waitN(t, l, 4)
+	want := []time.Duration{2 * time.Second, 2 * time.Second, 2 * time.Second}
+	if got := clock.takeWaits(); !reflect.DeepEqual(got, want) {
+		t.Errorf("waits = %v, want the first request at once and the others %v apart", got, 2*time.Second)
+	}
+
+	// Time passed since the last request counts towards the next.
+	clock.sleep(1500 * time.Millisecond)
+	waitN(t, l, 1)
+	if got := clock.takeWaits(); !reflect.DeepEqual(got, []time.Duration{500 * time.Millisecond}) {
+		t.Errorf("waits = %v, want the rest of the interval", got)
+	}
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	clock := useFakeLimiterClock(t, &fakeLimiterClock{advance: true})
+	l := NewRateLimiter(60, 3)
+	waitN(t, l, 4)
+	if got := clock.takeWaits(); !reflect.DeepEqual(got, []time.Duration{time.Second}) {
+		t.Errorf("waits = %v, want a burst of 3 at once and then a wait", got)
+	}
+
+	// After a pause the bucket is full again, but doesn't hold more.
+	clock.sleep(time.Minute)
+	waitN(t, l, 4)
+	if got := clock.takeWaits(); !reflect.DeepEqual(got, []time.Duration{time.Second}) {
+		t.Errorf("waits after a pause = %v, want another burst of 3", got)
+	}
+}
+
+func TestRateLimiterConcurrent(t *testing.T) {
+	clock := useFakeLimiterClock(t, &fakeLimiterClock{})
+	l := NewRateLimiter(60, 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	waits := clock.takeWaits()
+	slices.Sort(waits)
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second}
+	if !reflect.DeepEqual(waits, want) {
+		t.Errorf("waits = %v, want %v for workers sharing the limiter", waits, want)
+	}
+}
+
+func TestRateLimiterCanceled(t *testing.T) {
+	clock := useFakeLimiterClock(t, &fakeLimiterClock{block: true})
+	l := NewRateLimiter(60, 1)
+	waitN(t, l, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want the context's", err)
+	}
+
+	// The request given up on still used its slot.
+	clock.block = false
+	clock.takeWaits()
+	waitN(t, l, 1)
+	if got := clock.takeWaits(); !reflect.DeepEqual(got, []time.Duration{2 * time.Second}) {
+		t.Errorf("waits = %v, want the slot after the abandoned one", got)
+	}
+}
+
+func TestClientRateLimit(t *testing.T) {
+	clock := useFakeLimiterClock(t, &fakeLimiterClock{advance: true})
+	c, _, completer := newTestClient(testConfig())
+	c.RateLimiter = NewRateLimiter(6, 1)
+	for _, prompt := range []string{"a", "b", "c"} {
+		if _, err := c.Run(context.Background(), prompt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []time.Duration{10 * time.Second, 10 * time.Second}
+	if got := clock.takeWaits(); !reflect.DeepEqual(got, want) || len(completer.prompts()) != 3 {
+		t.Errorf("waits = %v for %d requests, want %v", got, len(completer.prompts()), want)
+	}
+}
+
+func TestClientRateLimitCanceled(t *testing.T) {
+	useFakeLimiterClock(t, &fakeLimiterClock{block: true})
+	c, _, completer := newTestClient(testConfig())
+	c.RateLimiter = NewRateLimiter(60, 1)
+	if _, err := c.Run(context.Background(), "first"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.Run(ctx, "second")
+	if !errors.Is(err, ErrCompletion) || !strings.Contains(err.Error(), "waiting for the rate limit") {
+		t.Fatalf("error = %v, want a completion error waiting for the rate limit", err)
+	}
+	if len(completer.prompts()) != 1 {
+		t.Errorf("model was asked %d times, want only before the rate limit", len(completer.prompts()))
+	}
+}
+
+func TestValidateRateLimit(t *testing.T) {
+	cfg := testConfig()
+	cfg.RateLimit = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("a negative rate limit was accepted")
+	}
+	cfg = testConfig()
+	cfg.RateLimit, cfg.RateBurst = 30, 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("a burst of 0 was accepted")
+	}
+}
@@ -0,0 +1,221 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	nodeclient "github.com/celestiaorg/celestia-openrpc"
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+	"github.com/filecoin-project/go-jsonrpc"
+)
+
+// reconnectPolicy is the backoff between attempts to dial a node again
+// after losing the connection to it.
+var reconnectPolicy = RetryPolicy{MaxAttempts: 5, BaseDelay: 500 * time.Millisecond, MaxDelay: 10 * time.Second}
+
+var (
+	// ErrClientClosed is returned by calls made after the client was
+	// closed.
+	ErrClientClosed = errors.New("client is closed")
+	// ErrSubmitUncertain is returned when the connection was lost during a
+	// submission. It may still land, so it isn't submitted again.
+	ErrSubmitUncertain = errors.New("submission may have landed")
+)
+
+// isConnectionLost reports whether err means the connection to the node
+// is gone, as opposed to the node failing the call.
+func isConnectionLost(err error) bool {
+	var connErr *jsonrpc.RPCConnectionError
+	return errors.As(err, &connErr) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, net.ErrClosed)
+}
+
+// notSent reports whether err means a call never reached the node, so
+// running it again can't repeat what it did.
+func notSent(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, ErrClientClosed)
+}
+
+// dialNode connects to the node at addr and asks it for its head, since
+// dialing alone doesn't show the node works. It returns the head's height.
+func dialNode(ctx context.Context, addr, token string) (*nodeclient.Client, uint64, error) {
+	node, err := newNodeClient(ctx, addr, token)
+	if err != nil {
+		return nil, 0, err
+	}
+	height, err := nodeHeight(ctx, node)
+	if err != nil {
+		node.Close()
+		return nil, 0, err
+	}
+	return node, height, nil
+}
+
+// nodeConn is the connection to one node. It is dialed again when the
+// connection is lost, and closed once.
+type nodeConn struct {
+	addr   string
+	token  string
+	logger func() *slog.Logger
+
+	// reconnecting is held while dialing again, so only one call dials at
+	// a time. mu only guards the fields, so current and Close don't wait
+	// for the dialing.
+	reconnecting sync.Mutex
+	mu           sync.Mutex
+	node         *nodeclient.Client
+	closed       bool
+}
+
+// current returns the node client of the latest connection.
+func (n *nodeConn) current() *nodeclient.Client {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.node
+}
+
+// reconnect replaces stale, the client whose connection was lost, with a
+// new connection, retrying with backoff. If another call reconnected in
+// the meantime, its client is returned instead.
+func (n *nodeConn) reconnect(ctx context.Context, stale *nodeclient.Client) (*nodeclient.Client, error) {
+	n.reconnecting.Lock()
+	defer n.reconnecting.Unlock()
+	n.mu.Lock()
+	closed, node := n.closed, n.node
+	n.mu.Unlock()
+	if closed {
+		return nil, ErrClientClosed
+	}
+	if node != stale {
+		return node, nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		node, height, err := dialNode(ctx, n.addr, n.token)
+		if err == nil {
+			return n.swap(node, height, attempt)
+		}
+		if attempt >= reconnectPolicy.MaxAttempts {
+			return nil, fmt.Errorf("cannot reconnect to node at %s after %d attempts: %w", n.addr, attempt, err)
+		}
+
+		delay := reconnectPolicy.delay(attempt)
+		n.logger().Warn("Reconnecting to node failed, retrying", "node", n.addr, "attempt", attempt, "error", err, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return nil, errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// swap replaces the lost connection with node, unless the connection was
+// closed while dialing.
+func (n *nodeConn) swap(node *nodeclient.Client, height uint64, attempts int) (*nodeclient.Client, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		node.Close()
+		return nil, ErrClientClosed
+	}
+	n.node.Close()
+	n.node = node
+	n.logger().Info("Reconnected to node", "node", n.addr, "height", height, "attempts", attempts)
+	return node, nil
+}
+
+// Close closes the connection. Later calls do nothing.
+func (n *nodeConn) Close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.closed {
+		n.node.Close()
+		n.closed = true
+	}
+}
+
+// reconnectingBlobAPI is the BlobAPI of a node that reconnects to the node
+// when a call fails because the connection was lost, and then tries the
+// call once more. Submissions are only tried again if they never reached
+// the node, since they may have landed otherwise. It is also a
+// TxSubmitter.
+type reconnectingBlobAPI struct {
+	conn *nodeConn
+}
+
+// do runs call against the current connection, reconnecting if the
+// connection was lost. Idempotent calls are run again then, others only if
+// they didn't reach the node.
+func (r reconnectingBlobAPI) do(ctx context.Context, idempotent bool, call func(BlobAPI) error) error {
+	node := r.conn.current()
+	err := call(NodeBlobAPI(node))
+	if err == nil || !isConnectionLost(err) {
+		return err
+	}
+	r.conn.logger().Warn("Lost connection to node, reconnecting", "node", r.conn.addr, "error", err)
+	node, rerr := r.conn.reconnect(ctx, node)
+	if rerr != nil {
+		return errors.Join(err, rerr)
+	}
+	if !idempotent && !notSent(err) {
+		return fmt.Errorf("%w, the connection to the node was lost during it: %w", ErrSubmitUncertain, err)
+	}
+	return call(NodeBlobAPI(node))
+}
+
+func (r reconnectingBlobAPI) Submit(ctx context.Context, blobs []*blob.Blob, gasPrice float64) (height uint64, err error) {
+	err = r.do(ctx, false, func(api BlobAPI) error {
+		height, err = api.Submit(ctx, blobs, gasPrice)
+		return err
+	})
+	return height, err
+}
+
+func (r reconnectingBlobAPI) SubmitWithResult(ctx context.Context, blobs []*blob.Blob, gasPrice float64) (result *SubmitResult, err error) {
+	err = r.do(ctx, false, func(api BlobAPI) error {
+		result, err = api.(TxSubmitter).SubmitWithResult(ctx, blobs, gasPrice)
+		return err
+	})
+	return result, err
+}
+
+func (r reconnectingBlobAPI) Get(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (b *blob.Blob, err error) {
+	err = r.do(ctx, true, func(api BlobAPI) error {
+		b, err = api.Get(ctx, height, ns, commitment)
+		return err
+	})
+	return b, err
+}
+
+func (r reconnectingBlobAPI) GetAll(ctx context.Context, height uint64, namespaces []share.Namespace) (blobs []*blob.Blob, err error) {
+	err = r.do(ctx, true, func(api BlobAPI) error {
+		blobs, err = api.GetAll(ctx, height, namespaces)
+		return err
+	})
+	return blobs, err
+}
+
+func (r reconnectingBlobAPI) GetProof(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (proof *blob.Proof, err error) {
+	err = r.do(ctx, true, func(api BlobAPI) error {
+		proof, err = api.GetProof(ctx, height, ns, commitment)
+		return err
+	})
+	return proof, err
+}
+
+func (r reconnectingBlobAPI) Included(ctx context.Context, height uint64, ns share.Namespace, proof *blob.Proof, commitment blob.Commitment) (included bool, err error) {
+	err = r.do(ctx, true, func(api BlobAPI) error {
+		included, err = api.Included(ctx, height, ns, proof, commitment)
+		return err
+	})
+	return included, err
+}
@@ -0,0 +1,173 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	nodeclient "github.com/celestiaorg/celestia-openrpc"
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// fastReconnect makes reconnecting retry without waiting until the test
+// ends.
+func fastReconnect(t *testing.T) {
+	t.Helper()
+	old := reconnectPolicy
+	reconnectPolicy.BaseDelay, reconnectPolicy.MaxDelay = time.Millisecond, time.Millisecond
+	t.Cleanup(func() { reconnectPolicy = old })
+}
+
+// flakyNodes makes NewClient connect to nodes serving api, whose first
+// connection is dropped as soon as it is used: its calls fail with
+// firstErr. After the first, the next refused dials are refused. The
+// returned function reports the number of dials so far.
+func flakyNodes(t *testing.T, api *FakeBlobAPI, firstErr error, refused int) func() int {
+	t.Helper()
+	var (
+		mu    sync.Mutex
+		dials int
+	)
+	replaceNodeClient(t, func(_ context.Context, addr, _ string) (*nodeclient.Client, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		dials++
+		node := fakeNode(api)
+		switch {
+		case dials == 1:
+			node.Blob.Submit = func(context.Context, []*blob.Blob, float64) (uint64, error) {
+				return 0, firstErr
+			}
+			node.Blob.Get = func(context.Context, uint64, share.Namespace, blob.Commitment) (*blob.Blob, error) {
+				return nil, firstErr
+			}
+		case dials <= 1+refused:
+			return nil, fmt.Errorf("dial %s: %w", addr, syscall.ECONNREFUSED)
+		}
+		return node, nil
+	})
+	return func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return dials
+	}
+}
+
+// connectionReset is the error of a call whose connection was dropped.
+var connectionReset = fmt.Errorf("read tcp: %w", syscall.ECONNRESET)
+
+func TestReconnectGet(t *testing.T) {
+	fastReconnect(t)
+	api := &FakeBlobAPI{}
+	submitBlobs(t, api, "hello")
+	dials := flakyNodes(t, api, connectionReset, 2)
+	c, err := NewClient(context.Background(), testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	first := c.CurrentNode()
+
+	b, err := c.Blobs.Get(context.Background(), 1, testNS(t, testNamespace), api.at(1)[0].Commitment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b.Data) != "hello" {
+		t.Errorf("got %q, want the blob from the new connection", b.Data)
+	}
+	if dials() != 4 || c.CurrentNode() == first {
+		t.Errorf("dialed %d times, want the node dialed again until it answered", dials())
+	}
+}
+
+func TestReconnectSubmitUncertain(t *testing.T) {
+	fastReconnect(t)
+	api := &FakeBlobAPI{}
+	dials := flakyNodes(t, api, connectionReset, 0)
+	c, err := NewClient(context.Background(), testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi"); !errors.Is(err, ErrSubmitUncertain) {
+		t.Fatalf("error = %v, want ErrSubmitUncertain", err)
+	}
+	if fakeHeight(api) != 0 || dials() != 2 {
+		t.Errorf("submitted %d times after %d dials, want no submission again after reconnecting once", fakeHeight(api), dials())
+	}
+}
+
+func TestReconnectSubmitNotSent(t *testing.T) {
+	fastReconnect(t)
+	api := &FakeBlobAPI{}
+	flakyNodes(t, api, fmt.Errorf("dial: %w", syscall.ECONNREFUSED), 0)
+	c, err := NewClient(context.Background(), testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	sub, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Height != 1 || fakeHeight(api) != 1 {
+		t.Errorf("submitted at height %d, want the submission that never reached the node sent once more", sub.Height)
+	}
+}
+
+func TestReconnectGivesUp(t *testing.T) {
+	fastReconnect(t)
+	api := &FakeBlobAPI{}
+	submitBlobs(t, api, "hello")
+	dials := flakyNodes(t, api, connectionReset, reconnectPolicy.MaxAttempts)
+	c, err := NewClient(context.Background(), testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	_, err = c.Blobs.Get(context.Background(), 1, testNS(t, testNamespace), api.at(1)[0].Commitment)
+	if !errors.Is(err, syscall.ECONNREFUSED) || !strings.Contains(err.Error(), fmt.Sprintf("after %d attempts", reconnectPolicy.MaxAttempts)) {
+		t.Errorf("error = %v, want reconnecting to give up", err)
+	}
+	if dials() != 1+reconnectPolicy.MaxAttempts {
+		t.Errorf("dialed %d times, want %d", dials(), 1+reconnectPolicy.MaxAttempts)
+	}
+}
+
+func TestReconnectAfterClose(t *testing.T) {
+	api := &FakeBlobAPI{}
+	submitBlobs(t, api, "hello")
+	dials := flakyNodes(t, api, connectionReset, 0)
+	c, err := NewClient(context.Background(), testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	c.Close()
+	_, err = c.Blobs.Get(context.Background(), 1, testNS(t, testNamespace), api.at(1)[0].Commitment)
+	if !errors.Is(err, ErrClientClosed) || dials() != 1 {
+		t.Errorf("error = %v after %d dials, want ErrClientClosed without dialing again", err, dials())
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	api := &FakeBlobAPI{}
+	dials := flakyNodes(t, api, connectionReset, 0)
+	c, err := NewClient(context.Background(), testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if ok, err := c.Reconnect(context.Background(), errors.New("blob: not found")); ok || err != nil {
+		t.Errorf("reconnected %v (error %v) for a failing call, want no reconnect", ok, err)
+	}
+	if ok, err := c.Reconnect(context.Background(), connectionReset); !ok || err != nil || dials() != 2 {
+		t.Errorf("reconnected %v (error %v) after %d dials, want the node dialed again", ok, err, dials())
+	}
+}
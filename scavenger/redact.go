@@ -0,0 +1,109 @@
+package scavenger
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Redaction modes. RedactAll redacts the prompt for both the chain and the
+// model, RedactChainOnly only for the chain, sending the model the prompt
+// as given.
+const (
+	RedactOff       = ""
+	RedactAll       = "all"
+	RedactChainOnly = "chain-only"
+)
+
+// RedactRule replaces every match of Pattern with a placeholder naming the
+// rule.
+type RedactRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// defaultRedactRules catch the most common secrets and personal data.
+// They aim for obvious cases rather than catching everything.
+var defaultRedactRules = []RedactRule{
+	{"api-key", regexp.MustCompile(`\b(?:sk-[A-Za-z0-9_-]{20,}|sk-ant-[A-Za-z0-9_-]{20,}|gh[pousr]_[A-Za-z0-9]{36,}|AKIA[0-9A-Z]{16}|xox[abposr]-[A-Za-z0-9-]{10,})\b`)},
+	{"email", regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+	{"credit-card", regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)},
+}
+
+// LoadRedactRules reads rules from path, one per line as a name and a
+// regular expression separated by whitespace. Empty lines and lines
+// starting with # are skipped.
+func LoadRedactRules(path string) ([]RedactRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading redaction rules: %w", err)
+	}
+	var rules []RedactRule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.IndexFunc(line, unicode.IsSpace)
+		if i < 0 {
+			return nil, fmt.Errorf("redaction rule on line %d must be a name and a regular expression", n)
+		}
+		name, expr := line[:i], strings.TrimSpace(line[i:])
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction rule %s on line %d: %w", name, n, err)
+		}
+		rules = append(rules, RedactRule{Name: name, Pattern: pattern})
+	}
+	return rules, scanner.Err()
+}
+
+// Redact replaces what rules match in text with placeholders, and returns
+// the redacted text along with the number of replacements.
+func Redact(rules []RedactRule, text string) (string, int) {
+	count := 0
+	for _, rule := range rules {
+		placeholder := "[REDACTED:" + rule.Name + "]"
+		text = rule.Pattern.ReplaceAllStringFunc(text, func(string) string {
+			count++
+			return placeholder
+		})
+	}
+	return text, count
+}
+
+// redactPrompt redacts prompt as configured, with the default rules and
+// those from RedactRulesFile.
+func redactPrompt(cfg *Config, prompt string) (string, int, error) {
+	if cfg.Redact == RedactOff {
+		return prompt, 0, nil
+	}
+	rules := defaultRedactRules
+	if cfg.RedactRulesFile != "" {
+		custom, err := LoadRedactRules(cfg.RedactRulesFile)
+		if err != nil {
+			return "", 0, err
+		}
+		rules = append(append([]RedactRule(nil), rules...), custom...)
+	}
+	redacted, count := Redact(rules, prompt)
+	return redacted, count, nil
+}
+
+// redact redacts prompt before it goes on chain, logging how much was
+// redacted.
+func (c *Client) redact(prompt string) (string, error) {
+	redacted, count, err := redactPrompt(c.Config, prompt)
+	if err != nil {
+		return "", err
+	}
+	if count > 0 {
+		c.logger().Info("Redacted prompt before submitting", "redactions", count)
+	}
+	return redacted, nil
+}
@@ -0,0 +1,61 @@
+package scavenger
+
+import (
+	"context"
+	"time"
+)
+
+// RepeatedRun is the outcome of one run of a repeated prompt.
+type RepeatedRun struct {
+	// Iteration counts the runs from 1.
+	Iteration  int    `json:"iteration"`
+	Height     uint64 `json:"height,omitempty"`
+	Commitment string `json:"commitment,omitempty"`
+	Response   string `json:"response,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// RepeatSummary collects the runs of a repeated prompt.
+type RepeatSummary struct {
+	Runs      []RepeatedRun `json:"runs"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+}
+
+// repeatAfter is how Repeat waits between runs. It can be replaced in
+// tests.
+var repeatAfter = time.After
+
+// Repeat runs prompt n times like Run, waiting delay between the runs. A
+// failing run doesn't stop the others, its error is recorded in the
+// summary instead. If ctx is done, the runs so far are returned with its
+// error.
+func (c *Client) Repeat(ctx context.Context, prompt string, n int, delay time.Duration) (*RepeatSummary, error) {
+	summary := &RepeatSummary{Runs: make([]RepeatedRun, 0, n)}
+	for i := 1; i <= n; i++ {
+		if i > 1 && delay > 0 {
+			select {
+			case <-ctx.Done():
+				return summary, ctx.Err()
+			case <-repeatAfter(delay):
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		run := RepeatedRun{Iteration: i}
+		result, err := c.Run(ctx, prompt)
+		if err != nil {
+			run.Error = err.Error()
+			summary.Failed++
+			c.logger().Warn("Run failed", "iteration", i, "runs", n, "error", err)
+		} else {
+			run.Height, run.Commitment, run.Response = result.Height, result.Commitment, result.Response
+			summary.Succeeded++
+			c.logger().Info("Run done", "iteration", i, "runs", n, "height", result.Height)
+		}
+		summary.Runs = append(summary.Runs, run)
+	}
+	return summary, nil
+}
@@ -0,0 +1,104 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeRepeatClock replaces repeatAfter until the test ends with a clock
+// that doesn't wait, and returns the delays it was asked to wait. If tick
+// is set, it is called on every wait.
+func fakeRepeatClock(t *testing.T, tick func()) *[]time.Duration {
+	t.Helper()
+	var waits []time.Duration
+	old := repeatAfter
+	repeatAfter = func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		if tick != nil {
+			tick()
+		}
+		ch := make(chan time.Time, 1)
+		ch <- time.Now()
+		return ch
+	}
+	t.Cleanup(func() { repeatAfter = old })
+	return &waits
+}
+
+func TestRepeat(t *testing.T) {
+	waits := fakeRepeatClock(t, nil)
+	c, api, completer := newTestClient(testConfig())
+	summary, err := c.Repeat(context.Background(), "hi", 3, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fakeHeight(api) != 3 || summary.Succeeded != 3 || summary.Failed != 0 {
+		t.Fatalf("submitted %d times with summary %+v, want 3 successful runs", fakeHeight(api), summary)
+	}
+	for i, run := range summary.Runs {
+		if run.Iteration != i+1 || run.Height != uint64(i+1) || run.Commitment == "" || run.Response != "answer to hi" {
+			t.Errorf("run %d = %+v, want its submission and response", i, run)
+		}
+	}
+	if len(completer.prompts()) != 3 {
+		t.Errorf("model was asked %d times, want once per run", len(completer.prompts()))
+	}
+	// The delay is between runs only.
+	if len(*waits) != 2 || (*waits)[0] != time.Minute || (*waits)[1] != time.Minute {
+		t.Errorf("waited %v, want a minute twice", *waits)
+	}
+}
+
+func TestRepeatNoAsk(t *testing.T) {
+	waits := fakeRepeatClock(t, nil)
+	cfg := testConfig()
+	cfg.NoAsk = true
+	c, api, completer := newTestClient(cfg)
+	summary, err := c.Repeat(context.Background(), "hi", 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fakeHeight(api) != 2 || len(completer.prompts()) != 0 || summary.Runs[1].Response != "" {
+		t.Errorf("submitted %d times and asked %d times, want 2 submissions without asking", fakeHeight(api), len(completer.prompts()))
+	}
+	if len(*waits) != 0 {
+		t.Errorf("waited %v without a delay", *waits)
+	}
+}
+
+func TestRepeatFailures(t *testing.T) {
+	c, api, _ := newTestClient(testConfig())
+	full := errors.New("mempool is full")
+	fakeRepeatClock(t, func() {
+		// The second run fails, the third one succeeds again.
+		if api.SubmitErr == nil && fakeHeight(api) == 1 {
+			api.SubmitErr = full
+		} else {
+			api.SubmitErr = nil
+		}
+	})
+	c.Config.SubmitAttempts = 1
+	summary, err := c.Repeat(context.Background(), "hi", 3, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Succeeded != 2 || summary.Failed != 1 || summary.Runs[1].Error == "" {
+		t.Errorf("summary = %+v, want the second run failed and the others done", summary)
+	}
+}
+
+func TestRepeatCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fakeRepeatClock(t, cancel)
+	c, api, _ := newTestClient(testConfig())
+	summary, err := c.Repeat(ctx, "hi", 5, time.Second)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want the cancellation", err)
+	}
+	if len(summary.Runs) != 1 || fakeHeight(api) != 1 {
+		t.Errorf("ran %d times with %d submissions, want only the run before the cancellation", len(summary.Runs), fakeHeight(api))
+	}
+}
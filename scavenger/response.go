@@ -0,0 +1,63 @@
+package scavenger
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// ResponseEnvelope is the payload of a blob storing a model's response.
+// It links back to the blob holding the prompt, so the pair can be
+// reassembled later.
+type ResponseEnvelope struct {
+	PromptHeight     uint64 `json:"prompt_height"`
+	PromptCommitment string `json:"prompt_commitment"`
+	Model            string `json:"model"`
+	Response         string `json:"response"`
+	// Choice numbers the response from 1 when the model was asked for
+	// several.
+	Choice int `json:"choice,omitempty"`
+}
+
+// StoreResponse submits the model's response as a blob linked to the
+// submitted prompt, in the prompt's namespace unless ResponseNamespace is
+// set.
+func (c *Client) StoreResponse(ctx context.Context, prompt *Submission, response string) (*Submission, error) {
+	return c.StoreResponses(ctx, prompt, []string{response})
+}
+
+// StoreResponses is like StoreResponse for several choices of responses.
+// Each is stored as a blob of its own, all in one transaction, so the
+// submission has the blobs in the order of responses. Responses are
+// encoded like prompts, so an encrypted prompt's answer is encrypted too.
+func (c *Client) StoreResponses(ctx context.Context, prompt *Submission, responses []string) (*Submission, error) {
+	ns, err := c.Config.ResponseNamespaceID(prompt.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	envelopes := make([][]byte, len(responses))
+	payloads := make([][]byte, len(responses))
+	for i, response := range responses {
+		envelope := ResponseEnvelope{
+			PromptHeight:     prompt.Height,
+			PromptCommitment: hex.EncodeToString(prompt.Blobs[0].Commitment),
+			Model:            c.Config.Model,
+			Response:         response,
+		}
+		if len(responses) > 1 {
+			envelope.Choice = i + 1
+		}
+		data, err := json.Marshal(envelope)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding response envelope: %w", err)
+		}
+		envelopes[i] = data
+		payloads[i], err = EncodePayload(c.Config, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.submit(ctx, ns, bytes.Join(envelopes, nil), payloads)
+}
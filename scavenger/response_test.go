@@ -0,0 +1,182 @@
+package scavenger
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fetchResponse fetches the response envelope stored at height with
+// commitmentHex in ns from api.
+func fetchResponse(t *testing.T, api BlobAPI, height uint64, nsHex, commitmentHex string) ResponseEnvelope {
+	t.Helper()
+	commitment, err := hex.DecodeString(commitmentHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := api.Get(context.Background(), height, testNS(t, nsHex), commitment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envelope ResponseEnvelope
+	if err := json.Unmarshal(b.Data, &envelope); err != nil {
+		t.Fatal(err)
+	}
+	return envelope
+}
+
+func TestRunStoreResponse(t *testing.T) {
+	cfg := testConfig()
+	cfg.StoreResponse = true
+	c, api, completer := newTestClient(cfg)
+	completer.answer = "42"
+	result, err := c.Run(context.Background(), "what is the answer?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ResponseHeight != result.Height+1 {
+		t.Errorf("response stored at height %d, want the one after the prompt's %d", result.ResponseHeight, result.Height)
+	}
+	envelope := fetchResponse(t, api, result.ResponseHeight, testNamespace, result.ResponseCommitment)
+	want := ResponseEnvelope{PromptHeight: result.Height, PromptCommitment: result.Commitment, Model: cfg.Model, Response: "42"}
+	if envelope != want {
+		t.Errorf("stored response = %+v, want %+v", envelope, want)
+	}
+}
+
+func TestStoreResponses(t *testing.T) {
+	c, api, _ := newTestClient(testConfig())
+	prompt, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "prompt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := c.StoreResponses(context.Background(), prompt, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.Blobs) != 2 {
+		t.Fatalf("stored %d blobs, want one per choice", len(stored.Blobs))
+	}
+	for i, b := range stored.Blobs {
+		envelope := fetchResponse(t, api, stored.Height, testNamespace, hex.EncodeToString(b.Commitment))
+		if envelope.Choice != i+1 || envelope.Response != []string{"a", "b"}[i] {
+			t.Errorf("choice %d = %+v, want it numbered from 1", i, envelope)
+		}
+	}
+}
+
+func TestResponseNamespaceID(t *testing.T) {
+	prompt := testNS(t, testNamespace)
+	cfg := testConfig()
+	ns, err := cfg.ResponseNamespaceID(prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ns, prompt) {
+		t.Errorf("response namespace = %s, want the prompt's %s by default", ns, prompt)
+	}
+
+	cfg.ResponseNamespace = "00000000616e73776572"
+	ns, err = cfg.ResponseNamespaceID(prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ns, testNS(t, cfg.ResponseNamespace)) {
+		t.Errorf("response namespace = %s, want %s", ns, cfg.ResponseNamespace)
+	}
+
+	cfg.ResponseNamespace = "not hex"
+	if _, err := cfg.ResponseNamespaceID(prompt); err == nil || !strings.Contains(err.Error(), "response namespace") {
+		t.Errorf("error = %v, want one naming the response namespace", err)
+	}
+}
+
+func TestRunResponseNamespace(t *testing.T) {
+	const answers = "00000000616e73776572"
+	cfg := testConfig()
+	cfg.StoreResponse = true
+	cfg.ResponseNamespace = answers
+	c, api, completer := newTestClient(cfg)
+	completer.answer = "42"
+	result, err := c.Run(context.Background(), "what is the answer?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Namespace != testNamespace || result.ResponseNamespace != answers {
+		t.Errorf("namespaces = %s and %s, want the prompt's and the response's", result.Namespace, result.ResponseNamespace)
+	}
+	envelope := fetchResponse(t, api, result.ResponseHeight, answers, result.ResponseCommitment)
+	if envelope.Response != "42" || envelope.PromptCommitment != result.Commitment {
+		t.Errorf("stored response = %+v, want the answer linked to the prompt", envelope)
+	}
+	commitment, err := hex.DecodeString(result.ResponseCommitment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.Get(context.Background(), result.ResponseHeight, testNS(t, testNamespace), commitment); err == nil {
+		t.Error("the response is in the prompt's namespace too")
+	}
+}
+
+func TestRunResponseNamespaceInvalid(t *testing.T) {
+	cfg := testConfig()
+	cfg.StoreResponse = true
+	cfg.ResponseNamespace = "not hex"
+	c, api, completer := newTestClient(cfg)
+	if _, err := c.Run(context.Background(), "hi"); !errors.Is(err, ErrNamespace) {
+		t.Fatalf("error = %v, want a namespace error", err)
+	}
+	if fakeHeight(api) != 0 || len(completer.prompts()) != 0 {
+		t.Error("the prompt was submitted or asked despite the invalid response namespace")
+	}
+}
+
+func TestValidateResponseNamespace(t *testing.T) {
+	cfg := testConfig()
+	cfg.ResponseNamespace = "00000000616e73776572"
+	if err := cfg.Validate(); err == nil {
+		t.Error("a response namespace without storing the response was accepted")
+	}
+	cfg.StoreResponse = true
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunStoreResponseEncrypted(t *testing.T) {
+	cfg := testConfig()
+	cfg.StoreResponse = true
+	cfg.Encrypt, cfg.EncryptionKey = true, testKey
+	c, api, completer := newTestClient(cfg)
+	completer.answer = "the secret is 42"
+	result, err := c.Run(context.Background(), "what is the secret?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitment, err := hex.DecodeString(result.ResponseCommitment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := api.Get(context.Background(), result.ResponseHeight, testNS(t, testNamespace), commitment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b.Data, []byte(aesGCMMarker)) || bytes.Contains(b.Data, []byte("42")) {
+		t.Fatalf("stored response %q isn't encrypted", b.Data)
+	}
+	data, err := DecodePayload(cfg, b.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envelope ResponseEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if envelope.Response != "the secret is 42" {
+		t.Errorf("decrypted response = %q, want the answer", envelope.Response)
+	}
+}
@@ -0,0 +1,106 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/filecoin-project/go-jsonrpc"
+)
+
+// RetryPolicy controls how often and how quickly a failed call is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It doubles with every
+	// further attempt, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// NoRetry tries a call only once.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// delay returns the backoff before the given retry (1 for the first
+// retry), with up to 50% of random jitter added so concurrent clients
+// don't retry in lockstep.
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := p.BaseDelay << (retry - 1)
+	if d < 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
+
+// submitFunc submits blobs to the network, as BlobAPI.Submit does.
+type submitFunc func(context.Context, []*blob.Blob, float64) (*SubmitResult, error)
+
+// submitWithRetry calls submit until it succeeds, the error is not
+// transient, the attempts are used up, or ctx is done. Retries are
+// logged as warnings.
+func submitWithRetry(
+	ctx context.Context,
+	submit submitFunc,
+	blobs []*blob.Blob,
+	gasPrice float64,
+	policy RetryPolicy,
+	logger *slog.Logger,
+) (*SubmitResult, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := submit(ctx, blobs, gasPrice)
+		if err == nil {
+			return result, nil
+		}
+		if attempt >= policy.MaxAttempts || !isTransient(err) {
+			return nil, err
+		}
+
+		delay := policy.delay(attempt)
+		logger.Warn("Submit attempt failed, retrying",
+			"attempt", attempt,
+			"max_attempts", policy.MaxAttempts,
+			"error", err,
+			"delay", delay)
+		select {
+		case <-ctx.Done():
+			return nil, errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// isTransient reports whether err looks like a temporary network or node
+// failure that is worth retrying. Anything else, like an invalid namespace
+// or insufficient funds, is treated as permanent.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrSubmitUncertain) {
+		return false
+	}
+
+	var connErr *jsonrpc.RPCConnectionError
+	if errors.As(err, &connErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	// The node reports server-side failures as plain RPC error strings.
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"timed out", "timeout", "unavailable", "502", "503", "504"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package scavenger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+)
+
+// fastRetry retries quickly, so tests don't wait for the backoff.
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+// failingSubmit returns a submitFunc failing with errs in turn, and
+// succeeding at height 7 once they are used up. It counts its calls in
+// calls.
+func failingSubmit(calls *int, errs ...error) submitFunc {
+	return func(context.Context, []*blob.Blob, float64) (*SubmitResult, error) {
+		*calls++
+		if *calls <= len(errs) {
+			return nil, errs[*calls-1]
+		}
+		return &SubmitResult{Height: 7}, nil
+	}
+}
+
+func TestSubmitWithRetry(t *testing.T) {
+	var calls int
+	submit := failingSubmit(&calls, syscall.ECONNREFUSED, errors.New("503 service unavailable"))
+	result, err := submitWithRetry(context.Background(), submit, nil, 0, fastRetry, discardLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Height != 7 || calls != 3 {
+		t.Errorf("got height %d after %d calls, want height 7 after 3", result.Height, calls)
+	}
+}
+
+func TestSubmitWithRetryPermanent(t *testing.T) {
+	var calls int
+	insufficient := errors.New("insufficient funds")
+	_, err := submitWithRetry(context.Background(), failingSubmit(&calls, insufficient), nil, 0, fastRetry, discardLogger)
+	if !errors.Is(err, insufficient) || calls != 1 {
+		t.Errorf("got %v after %d calls, want the error after 1", err, calls)
+	}
+}
+
+func TestSubmitWithRetryAttempts(t *testing.T) {
+	var calls int
+	timeout := errors.New("request timed out")
+	_, err := submitWithRetry(context.Background(), failingSubmit(&calls, timeout, timeout, timeout), nil, 0, fastRetry, discardLogger)
+	if !errors.Is(err, timeout) || calls != fastRetry.MaxAttempts {
+		t.Errorf("got %v after %d calls, want the error after %d", err, calls, fastRetry.MaxAttempts)
+	}
+}
+
+func TestSubmitWithRetryCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var calls int
+	slow := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	_, err := submitWithRetry(ctx, failingSubmit(&calls, syscall.ECONNRESET), nil, 0, slow, discardLogger)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want the context's", err)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{syscall.ECONNREFUSED, true},
+		{fmt.Errorf("dial: %w", syscall.ECONNRESET), true},
+		{errors.New("502 Bad Gateway"), true},
+		{errors.New("invalid namespace"), false},
+		{context.DeadlineExceeded, false},
+		{fmt.Errorf("%w: %w", ErrSubmitUncertain, syscall.ECONNRESET), false},
+	}
+	for _, tt := range tests {
+		if got := isTransient(tt.err); got != tt.want {
+			t.Errorf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 3 * time.Second}
+	for retry, base := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 3 * time.Second, 10: 3 * time.Second} {
+		// The jitter adds up to half the delay.
+		if d := p.delay(retry); d < base || d > base+base/2 {
+			t.Errorf("delay(%d) = %s, want between %s and %s", retry, d, base, base+base/2)
+		}
+	}
+}
+
+func TestClientLogsRetries(t *testing.T) {
+	var out bytes.Buffer
+	c, api, _ := newTestClient(testConfig())
+	c.Logger = slog.New(slog.NewTextHandler(&out, nil))
+	api.SubmitErr = syscall.ECONNREFUSED
+	c.Config.SubmitAttempts = 2
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi"); err == nil {
+		t.Fatal("submitting to a refusing node succeeded")
+	}
+	if !strings.Contains(out.String(), "Submit attempt failed, retrying") {
+		t.Errorf("log = %q, want the retry", out.String())
+	}
+}
@@ -0,0 +1,349 @@
+package scavenger
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RunResult is everything a run produced, from the submitted blob to the
+// model's response.
+type RunResult struct {
+	Namespace  string `json:"namespace"`
+	Height     uint64 `json:"height"`
+	Commitment string `json:"commitment"`
+	// TxHash is only known when submitting with TxHash set.
+	TxHash string `json:"tx_hash,omitempty"`
+	// MirrorHeight is the height on the mirror node, if the blobs were
+	// mirrored. Their commitments are the same there.
+	MirrorHeight uint64 `json:"mirror_height,omitempty"`
+	// Memo is the memo attached to the transaction, if any.
+	Memo string `json:"memo,omitempty"`
+	// Commitments lists every chunk's commitment for chunked prompts.
+	Commitments []string `json:"commitments,omitempty"`
+	// Namespaces lists the commitments in every namespace, Namespace
+	// first, when the prompt was submitted to several.
+	Namespaces       []NamespaceCommitments `json:"namespaces,omitempty"`
+	SubmittedPayload string                 `json:"submitted_payload,omitempty"`
+	FetchedPayload   string                 `json:"fetched_payload"`
+	// ContentType is set for blobs holding a file, whose bytes aren't
+	// included as FetchedPayload.
+	ContentType string `json:"content_type,omitempty"`
+	// Metadata is set for prompts fetched from a PromptEnvelope.
+	Metadata *PromptMetadata `json:"metadata,omitempty"`
+	// Signer is the public key that signed the prompt, set when the
+	// signature was verified.
+	Signer   string `json:"signer,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Response string `json:"response,omitempty"`
+	// Choices are all the responses when several were asked for.
+	// Response is the first of them.
+	Choices []string `json:"choices,omitempty"`
+	// Structured is the response as JSON, set when it had to match a
+	// schema.
+	Structured json.RawMessage `json:"structured,omitempty"`
+	// Usage is set when the model was asked, and CostUSD too if the
+	// model's price is known.
+	Usage   *Usage   `json:"usage,omitempty"`
+	CostUSD *float64 `json:"cost_usd,omitempty"`
+	// SystemFingerprint is the model backend's fingerprint, if reported.
+	// A changed fingerprint explains a changed response for the same seed.
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+	ProofVerified     bool   `json:"proof_verified,omitempty"`
+
+	// Set when the response was stored on chain with StoreResponse.
+	ResponseNamespace  string `json:"response_namespace,omitempty"`
+	ResponseHeight     uint64 `json:"response_height,omitempty"`
+	ResponseCommitment string `json:"response_commitment,omitempty"`
+	// ResponseCommitments lists the commitment of every stored choice, in
+	// order.
+	ResponseCommitments []string `json:"response_commitments,omitempty"`
+
+	// ThreadTurns is the length of the thread after a run continuing one,
+	// including the new prompt and response.
+	ThreadTurns int `json:"thread_turns,omitempty"`
+
+	// Cached is set when the response came from the cache, in which case
+	// nothing was submitted or fetched.
+	Cached bool `json:"cached,omitempty"`
+
+	// Timings is how long each stage of the run took, by stage, without
+	// the time spent confirming the fee.
+	Timings map[string]time.Duration `json:"timings,omitempty"`
+}
+
+// localPrompt stands in for fetching sub back with NoFetch: it has the
+// submitted blobs and the prompt as the fetched one would be, redacted if
+// it was for the model too.
+func localPrompt(cfg *Config, sub *Submission, prompt string) (*FetchedPrompt, error) {
+	if cfg.Redact == RedactAll {
+		var err error
+		prompt, _, err = redactPrompt(cfg, prompt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &FetchedPrompt{
+		Namespace: sub.Namespace,
+		Height:    sub.Height,
+		Blobs:     sub.Blobs,
+		Payload:   []byte(prompt),
+	}, nil
+}
+
+// NamespaceCommitments are the commitments of a prompt's blobs in one
+// namespace.
+type NamespaceCommitments struct {
+	Namespace   string   `json:"namespace"`
+	Commitments []string `json:"commitments"`
+}
+
+// SetUsage records the tokens used to answer the prompt and their
+// estimated cost.
+func (r *RunResult) SetUsage(cfg *Config, usage Usage) {
+	r.Usage = &usage
+	r.SystemFingerprint = usage.SystemFingerprint
+	if cost, ok := cfg.EstimateCost(usage); ok {
+		r.CostUSD = &cost
+	}
+}
+
+// Run connects to the configured node, submits prompt, fetches it back
+// and asks the model about it. The timeout in cfg covers the whole run.
+func Run(ctx context.Context, cfg *Config, prompt string) (*RunResult, error) {
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	c, err := NewClient(ctx, cfg)
+	if err != nil {
+		return nil, StageError("connect", err)
+	}
+	defer c.Close()
+	return c.Run(ctx, prompt)
+}
+
+// Run submits prompt to the configured namespace, fetches it back and
+// verifies it, unless NoFetch is set, and asks the model about it, unless
+// NoAsk is set. If the
+// response to the prompt is in the cache, it is returned right away
+// instead.
+func (c *Client) Run(ctx context.Context, prompt string) (*RunResult, error) {
+	cfg := c.Config
+
+	// Structured responses depend on the schema too, so they aren't
+	// cached, and neither are several choices.
+	cache := c.Cache
+	if cfg.SchemaFile != "" || cfg.Choices > 1 || cfg.NoAsk {
+		cache = nil
+	}
+	// The model is asked with the prompt wrapped, so the wrapping is part
+	// of the key.
+	cacheKey := CacheKey(cfg, WrapPrompt(cfg, prompt))
+	if cache != nil {
+		entry, ok, err := cache.Get(cacheKey)
+		if err != nil {
+			c.logger().Warn("Ignoring cache", "error", err)
+		}
+		if ok {
+			result := &RunResult{
+				SubmittedPayload: prompt,
+				Model:            entry.Model,
+				Response:         entry.Response,
+				Cached:           true,
+			}
+			result.SetUsage(cfg, entry.Usage)
+			return result, nil
+		}
+	}
+
+	// We time each stage, leaving out the time the user takes to confirm
+	// the fee.
+	timings := make(map[string]time.Duration)
+	var confirmTime time.Duration
+	ctx = withConfirmTime(ctx, &confirmTime)
+	record := func(stage string, start time.Time) {
+		timings[stage] = time.Since(start) - confirmTime
+		confirmTime = 0
+	}
+
+	// Next, we convert the namespace hex strings to the
+	// concrete NamespaceID type
+	namespaces, err := cfg.NamespaceIDs()
+	if err != nil {
+		return nil, StageError("namespace", fmt.Errorf("Failed to decode namespace: %w", err))
+	}
+	namespaceID := namespaces[0]
+	// The response namespace is checked now too, rather than after paying
+	// for the prompt's submission.
+	if cfg.StoreResponse {
+		if _, err := cfg.ResponseNamespaceID(namespaceID); err != nil {
+			return nil, StageError("namespace", fmt.Errorf("Failed to decode namespace: %w", err))
+		}
+	}
+
+	// We can then create and submit a blob using the NamespaceID and our
+	// prompt. Large prompts are split across several blobs, and with
+	// further namespaces, the prompt goes to all of them at once. We only
+	// fetch it back from the first.
+	start := time.Now()
+	subs, err := c.SubmitPromptTo(ctx, namespaces, prompt)
+	if err != nil {
+		return nil, StageError("submit", err)
+	}
+	record("submit", start)
+	sub := subs[0]
+
+	// If asked to, we let the block get a few heights deep before trusting
+	// it.
+	if cfg.Confirmations > 0 {
+		start = time.Now()
+		if err := c.WaitConfirmations(ctx, sub.Height); err != nil {
+			return nil, StageError("confirmations", err)
+		}
+		record("confirmations", start)
+	}
+
+	// Now we will fetch the blobs back from the network, unless we trust
+	// the node and go on with the prompt we have.
+	var fetched *FetchedPrompt
+	if cfg.NoFetch {
+		c.logger().Info("Not fetching the blob back, it isn't verified against the submission",
+			"height", sub.Height,
+			"namespace", NamespaceHex(namespaceID))
+		fetched, err = localPrompt(cfg, sub, prompt)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Right after submitting, the node may not serve the blobs yet,
+		// so we can wait for it.
+		size := blobsSize(sub.Blobs)
+		if cfg.Wait > 0 {
+			c.Progress.Report(ProgressWaiting, 0, size)
+			start = time.Now()
+			if err := c.waitForBlob(ctx, sub.Height, namespaceID, sub.Blobs[0].Commitment); err != nil {
+				return nil, StageError("fetch", fmt.Errorf("Failed to fetch blob: %w", err))
+			}
+			record("wait", start)
+		}
+		c.Progress.Report(ProgressFetching, 0, size)
+		start = time.Now()
+		fetched, err = c.FetchPrompt(ctx, sub.Height, namespaceID, sub.Commitments())
+		if err != nil {
+			return nil, StageError("fetch", err)
+		}
+		record("fetch", start)
+		c.Progress.Report(ProgressFetching, blobsSize(fetched.Blobs), size)
+
+		// Before using it, we make sure the fetched blob is what we
+		// submitted.
+		if err := c.VerifyBlobs(sub, fetched); err != nil {
+			return nil, StageError("verification", fmt.Errorf("Fetched blob failed verification: %w", err))
+		}
+		c.logger().Info("Fetched blob",
+			"height", sub.Height,
+			"namespace", NamespaceHex(namespaceID),
+			"commitment", hex.EncodeToString(sub.Blobs[0].Commitment),
+			"payload", string(fetched.Payload))
+	}
+
+	// For trust-minimized use, we can also check the blob was included in
+	// the block.
+	if cfg.VerifyProof {
+		start = time.Now()
+		if err := c.VerifyInclusion(ctx, sub); err != nil {
+			return nil, StageError("proof verification", err)
+		}
+		record("proof", start)
+		c.logger().Info("Inclusion of blob confirmed",
+			"height", sub.Height,
+			"namespace", NamespaceHex(namespaceID),
+			"commitment", hex.EncodeToString(sub.Blobs[0].Commitment))
+	}
+
+	result := &RunResult{
+		Namespace:        NamespaceHex(namespaceID),
+		Height:           sub.Height,
+		Commitment:       hex.EncodeToString(sub.Blobs[0].Commitment),
+		TxHash:           sub.TxHash,
+		MirrorHeight:     sub.MirrorHeight,
+		Memo:             cfg.Memo,
+		SubmittedPayload: prompt,
+		Metadata:         fetched.Metadata,
+		Signer:           fetched.Signer,
+		ProofVerified:    cfg.VerifyProof,
+		Timings:          timings,
+	}
+	if !cfg.NoFetch {
+		result.FetchedPayload = string(fetched.Payload)
+	}
+	if len(sub.Blobs) > 1 {
+		result.Commitments = CommitmentsHex(sub.Blobs)
+	}
+	if len(subs) > 1 {
+		for _, s := range subs {
+			result.Namespaces = append(result.Namespaces, NamespaceCommitments{
+				Namespace:   NamespaceHex(s.Namespace),
+				Commitments: CommitmentsHex(s.Blobs),
+			})
+		}
+	}
+	// Submitting and fetching may be all we are after.
+	if cfg.NoAsk {
+		return result, nil
+	}
+
+	// The model gets the prompt as fetched, unless it was only redacted for
+	// the chain.
+	askPrompt := string(fetched.Payload)
+	if cfg.Redact == RedactChainOnly {
+		askPrompt = prompt
+	}
+	askPrompt = WrapPrompt(cfg, askPrompt)
+	start = time.Now()
+	choices, usage, err := c.AskChoices(ctx, askPrompt, cfg.Choices)
+	if err != nil {
+		return nil, StageError("completion", fmt.Errorf("Failed to process message with %s: %w", cfg.Model, err))
+	}
+	record("completion", start)
+	answer := choices[0]
+	result.Model = cfg.Model
+	result.Response = answer
+	result.Structured = StructuredResponse(cfg, answer)
+	result.SetUsage(cfg, usage)
+	if len(choices) > 1 {
+		result.Choices = choices
+	}
+
+	// Optionally, we store the response on chain too, linked to the prompt.
+	if cfg.StoreResponse {
+		start = time.Now()
+		stored, err := c.StoreResponses(ctx, sub, choices)
+		if err != nil {
+			return nil, StageError("store response", fmt.Errorf("Failed to store response: %w", err))
+		}
+		record("store_response", start)
+		result.ResponseNamespace = NamespaceHex(stored.Namespace)
+		result.ResponseHeight = stored.Height
+		result.ResponseCommitment = hex.EncodeToString(stored.Blobs[0].Commitment)
+		if len(stored.Blobs) > 1 {
+			result.ResponseCommitments = CommitmentsHex(stored.Blobs)
+		}
+		c.logger().Info("Response stored",
+			"height", stored.Height,
+			"namespace", result.ResponseNamespace,
+			"commitment", result.ResponseCommitment)
+	}
+
+	if cache != nil {
+		if err := cache.Put(cacheKey, &CacheEntry{Model: cfg.Model, Response: answer, Usage: usage}); err != nil {
+			c.logger().Warn("Failed to cache response", "error", err)
+		}
+	}
+	return result, nil
+}
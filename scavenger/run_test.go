@@ -0,0 +1,190 @@
+package scavenger
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+)
+
+func TestClientRun(t *testing.T) {
+	c, api, completer := newTestClient(testConfig())
+	result, err := c.Run(context.Background(), "what is a blob?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Height != 1 || fakeHeight(api) != 1 {
+		t.Errorf("result at height %d with the chain at %d, want both at 1", result.Height, fakeHeight(api))
+	}
+	blobs := api.at(1)
+	if len(blobs) != 1 || result.Commitment != hex.EncodeToString(blobs[0].Commitment) {
+		t.Errorf("commitment = %s, want the submitted blob's", result.Commitment)
+	}
+	if result.SubmittedPayload != "what is a blob?" || result.FetchedPayload != "what is a blob?" {
+		t.Errorf("submitted %q and fetched %q, want the prompt for both", result.SubmittedPayload, result.FetchedPayload)
+	}
+	if got := completer.prompts(); len(got) != 1 || got[0] != "what is a blob?" {
+		t.Errorf("model was asked %q, want the fetched prompt once", got)
+	}
+	if result.Response != "answer to what is a blob?" || result.Model != c.Config.Model {
+		t.Errorf("response %q from %q, want the completer's answer from the configured model", result.Response, result.Model)
+	}
+}
+
+func TestClientRunNoAsk(t *testing.T) {
+	cfg := testConfig()
+	cfg.NoAsk = true
+	c, _, completer := newTestClient(cfg)
+	result, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(completer.prompts()) != 0 || result.Response != "" {
+		t.Error("the model was asked")
+	}
+}
+
+func TestClientRunStageErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(*Client, *FakeBlobAPI, *fakeCompleter)
+		want  error
+	}{
+		{"namespace", func(c *Client, _ *FakeBlobAPI, _ *fakeCompleter) { c.Config.Namespace = "zz" }, ErrNamespace},
+		{"submit", func(_ *Client, api *FakeBlobAPI, _ *fakeCompleter) { api.SubmitErr = errors.New("out of funds") }, ErrSubmit},
+		{"fetch", func(_ *Client, api *FakeBlobAPI, _ *fakeCompleter) { api.GetErr = blob.ErrBlobNotFound }, ErrFetch},
+		{"completion", func(_ *Client, _ *FakeBlobAPI, f *fakeCompleter) { f.err = errors.New("rate limited") }, ErrCompletion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, api, completer := newTestClient(testConfig())
+			c.Config.SubmitAttempts = 1
+			tt.setup(c, api, completer)
+			if _, err := c.Run(context.Background(), "hi"); !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunResultJSON(t *testing.T) {
+	c, _, completer := newTestClient(testConfig())
+	completer.usage = Usage{PromptTokens: 3, CompletionTokens: 4, TotalTokens: 7}
+	result, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"namespace", "height", "commitment", "fetched_payload", "response", "usage", "timings"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("JSON result has no %q: %s", key, data)
+		}
+	}
+	// Fields of features not used are left out.
+	for _, key := range []string{"tx_hash", "commitments", "choices", "structured", "cached", "response_height"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("JSON result has %q, want it omitted: %s", key, data)
+		}
+	}
+	for _, stage := range []string{"submit", "fetch", "completion"} {
+		if _, ok := result.Timings[stage]; !ok {
+			t.Errorf("no timing for stage %s", stage)
+		}
+	}
+}
+
+func TestRunResultSetUsage(t *testing.T) {
+	var result RunResult
+	result.SetUsage(DefaultConfig(), Usage{PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500, SystemFingerprint: "fp"})
+	if result.Usage == nil || result.Usage.TotalTokens != 1500 || result.SystemFingerprint != "fp" {
+		t.Errorf("usage = %+v with fingerprint %q, want the one set", result.Usage, result.SystemFingerprint)
+	}
+}
+
+func TestSubmitPromptTo(t *testing.T) {
+	cfg := testConfig()
+	cfg.ExtraNamespaces = []string{"aaaa", "bbbb"}
+	namespaces, err := cfg.NamespaceIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, api, _ := newTestClient(cfg)
+	subs, err := c.SubmitPromptTo(context.Background(), namespaces, "everywhere")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fakeHeight(api) != 1 || len(api.at(1)) != len(namespaces) {
+		t.Fatalf("submitted %d blobs in %d transactions, want %d in one", len(api.at(1)), fakeHeight(api), len(namespaces))
+	}
+	if len(subs) != len(namespaces) {
+		t.Fatalf("got %d submissions, want one per namespace", len(subs))
+	}
+	for i, sub := range subs {
+		if sub.Height != 1 || !sub.Namespace.Equals(namespaces[i]) {
+			t.Errorf("submission %d is in %s at height %d, want %s at 1", i, sub.Namespace, sub.Height, namespaces[i])
+		}
+		fetched, err := c.FetchPrompt(context.Background(), sub.Height, sub.Namespace, sub.Commitments())
+		if err != nil {
+			t.Fatalf("namespace %s: %v", sub.Namespace, err)
+		}
+		if string(fetched.Payload) != "everywhere" {
+			t.Errorf("namespace %s has %q, want the prompt", sub.Namespace, fetched.Payload)
+		}
+	}
+}
+
+func TestClientRunNamespaces(t *testing.T) {
+	cfg := testConfig()
+	cfg.ExtraNamespaces = []string{"aaaa"}
+	c, api, _ := newTestClient(cfg)
+	result, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(api.at(1)) != 2 || len(result.Namespaces) != 2 {
+		t.Fatalf("submitted %d blobs and reported %d namespaces, want 2 of each", len(api.at(1)), len(result.Namespaces))
+	}
+	if result.Namespaces[0].Namespace != result.Namespace || len(result.Namespaces[1].Commitments) != 1 {
+		t.Errorf("namespaces = %+v, want the main one first and a commitment in each", result.Namespaces)
+	}
+}
+
+func TestClientRunTimings(t *testing.T) {
+	cfg := testConfig()
+	cfg.Wait = time.Second
+	cfg.VerifyProof = true
+	c, _, _ := newTestClient(cfg)
+	const confirmDelay = 50 * time.Millisecond
+	c.Confirm = func(FeeEstimate) error {
+		time.Sleep(confirmDelay)
+		return nil
+	}
+	result, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stages []string
+	for stage := range result.Timings {
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+	if want := []string{"completion", "fetch", "proof", "submit", "wait"}; strings.Join(stages, ",") != strings.Join(want, ",") {
+		t.Errorf("timed stages %v, want %v", stages, want)
+	}
+	if submit := result.Timings["submit"]; submit >= confirmDelay {
+		t.Errorf("submit took %v, want the %v confirming the fee left out", submit, confirmDelay)
+	}
+}
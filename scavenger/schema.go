@@ -0,0 +1,60 @@
+package scavenger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// ErrSchemaMismatch is returned when a structured response doesn't match
+// the configured schema.
+var ErrSchemaMismatch = errors.New("response doesn't match the schema")
+
+// Schema is a JSON schema structured responses have to match.
+type Schema struct {
+	// Raw is the schema as loaded, which is sent to the model.
+	Raw      json.RawMessage
+	compiled *jsonschema.Schema
+}
+
+// LoadSchema loads and compiles the JSON schema in path.
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading schema file: %w", err)
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(path, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("error parsing schema: %w", err)
+	}
+	compiled, err := compiler.Compile(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &Schema{Raw: json.RawMessage(data), compiled: compiled}, nil
+}
+
+// Validate checks that data is JSON matching the schema.
+func (s *Schema) Validate(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("%w: not valid JSON: %v", ErrSchemaMismatch, err)
+	}
+	if err := s.compiled.Validate(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrSchemaMismatch, err)
+	}
+	return nil
+}
+
+// StructuredResponse returns the response as JSON when a schema is
+// configured, in which case the model was made to answer with it.
+func StructuredResponse(cfg *Config, response string) json.RawMessage {
+	if cfg.SchemaFile == "" {
+		return nil
+	}
+	return json.RawMessage(response)
+}
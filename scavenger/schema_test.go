@@ -0,0 +1,128 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const testSchema = `{
+	"type": "object",
+	"properties": {"answer": {"type": "string"}},
+	"required": ["answer"]
+}`
+
+// sequenceChatClient answers the requests it gets with resps in turn,
+// repeating the last one once they are used up.
+type sequenceChatClient struct {
+	fakeChatClient
+	resps []openai.ChatCompletionResponse
+}
+
+func (c *sequenceChatClient) CreateChatCompletion(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	c.record(req)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := min(len(c.requests), len(c.resps)) - 1
+	return c.resps[i], nil
+}
+
+// toolResponse is a response calling the structured response function
+// with arguments.
+func toolResponse(arguments string) openai.ChatCompletionResponse {
+	resp := chatResponse("")
+	resp.Choices[0].Message.ToolCalls = []openai.ToolCall{{
+		Type:     openai.ToolTypeFunction,
+		Function: openai.FunctionCall{Name: structuredFunction, Arguments: arguments},
+	}}
+	return resp
+}
+
+// schemaConfig returns a config with testSchema as the schema.
+func schemaConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.SchemaFile = writeFile(t, "schema.json", testSchema)
+	return cfg
+}
+
+func TestLoadSchema(t *testing.T) {
+	schema, err := LoadSchema(writeFile(t, "schema.json", testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.Validate([]byte(`{"answer": "yes"}`)); err != nil {
+		t.Errorf("matching JSON: %v", err)
+	}
+	for _, data := range []string{`{"reply": "yes"}`, `{"answer": 42}`, `not JSON`} {
+		if err := schema.Validate([]byte(data)); !errors.Is(err, ErrSchemaMismatch) {
+			t.Errorf("validating %s: error = %v, want ErrSchemaMismatch", data, err)
+		}
+	}
+	for _, data := range []string{"{", `{"type": "no such type"}`} {
+		if _, err := LoadSchema(writeFile(t, "bad.json", data)); err == nil {
+			t.Errorf("schema %s was loaded", data)
+		}
+	}
+}
+
+func TestCompleteStructured(t *testing.T) {
+	client := &sequenceChatClient{resps: []openai.ChatCompletionResponse{toolResponse(`{"answer": "yes"}`)}}
+	answer, usage, err := testCompleter(t, schemaConfig(t), client, nil).Complete(context.Background(), ChatMessages("", "well?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != `{"answer": "yes"}` || usage.TotalTokens != 15 {
+		t.Errorf("answer = %s with %d tokens, want the function's arguments", answer, usage.TotalTokens)
+	}
+	req := client.lastRequest(t)
+	if len(req.Tools) != 1 || req.Tools[0].Function.Name != structuredFunction || req.ToolChoice == nil {
+		t.Errorf("request = %+v, want the model made to call the response function", req)
+	}
+}
+
+func TestCompleteStructuredRetry(t *testing.T) {
+	client := &sequenceChatClient{resps: []openai.ChatCompletionResponse{
+		toolResponse(`{"reply": "yes"}`),
+		toolResponse(`{"answer": "yes"}`),
+	}}
+	answer, usage, err := testCompleter(t, schemaConfig(t), client, nil).Complete(context.Background(), ChatMessages("", "well?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != `{"answer": "yes"}` || len(client.requests) != 2 {
+		t.Errorf("answer = %s after %d requests, want the second, valid one", answer, len(client.requests))
+	}
+	if usage.TotalTokens != 30 {
+		t.Errorf("total tokens = %d, want both attempts counted", usage.TotalTokens)
+	}
+}
+
+func TestCompleteStructuredInvalid(t *testing.T) {
+	for name, resp := range map[string]openai.ChatCompletionResponse{
+		"mismatch": toolResponse(`{"answer": 42}`),
+		"prose":    chatResponse("yes"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := &sequenceChatClient{resps: []openai.ChatCompletionResponse{resp}}
+			_, _, err := testCompleter(t, schemaConfig(t), client, nil).Complete(context.Background(), ChatMessages("", "well?"))
+			if !errors.Is(err, ErrSchemaMismatch) {
+				t.Errorf("error = %v, want ErrSchemaMismatch", err)
+			}
+			if len(client.requests) != structuredAttempts {
+				t.Errorf("model was asked %d times, want %d", len(client.requests), structuredAttempts)
+			}
+		})
+	}
+}
+
+func TestStructuredResponse(t *testing.T) {
+	if got := StructuredResponse(DefaultConfig(), "plain text"); got != nil {
+		t.Errorf("structured response = %s without a schema, want none", got)
+	}
+	if got := StructuredResponse(schemaConfig(t), `{"answer": "yes"}`); string(got) != `{"answer": "yes"}` {
+		t.Errorf("structured response = %s, want the response", got)
+	}
+}
@@ -0,0 +1,55 @@
+package scavenger
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOpenAICompleterSeed(t *testing.T) {
+	seed := 42
+	cfg := DefaultConfig()
+	cfg.Seed = &seed
+	resp := chatResponse("ok")
+	resp.SystemFingerprint = "fp_44709d6fcb"
+	client := &fakeChatClient{resp: resp}
+	_, usage, err := testCompleter(t, cfg, client, nil).Complete(context.Background(), ChatMessages("", "hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := client.lastRequest(t).Seed; got == nil || *got != 42 {
+		t.Errorf("requested seed %v, want 42", got)
+	}
+	if usage.SystemFingerprint != "fp_44709d6fcb" {
+		t.Errorf("fingerprint = %q, want the response's", usage.SystemFingerprint)
+	}
+
+	// Without a seed, none is sent.
+	if _, _, err := testCompleter(t, DefaultConfig(), client, nil).Complete(context.Background(), ChatMessages("", "hi")); err != nil {
+		t.Fatal(err)
+	}
+	if got := client.lastRequest(t).Seed; got != nil {
+		t.Errorf("requested seed %d, want none", *got)
+	}
+}
+
+func TestClientRunFingerprint(t *testing.T) {
+	c, _, completer := newTestClient(testConfig())
+	completer.usage = Usage{TotalTokens: 3, SystemFingerprint: "fp_1"}
+	result, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SystemFingerprint != "fp_1" {
+		t.Errorf("fingerprint = %q, want the completion's", result.SystemFingerprint)
+	}
+}
+
+func TestCacheKeySeed(t *testing.T) {
+	one, two := 1, 2
+	unseeded := DefaultConfig()
+	seeded, other := DefaultConfig(), DefaultConfig()
+	seeded.Seed, other.Seed = &one, &two
+	if CacheKey(seeded, "hi") == CacheKey(unseeded, "hi") || CacheKey(seeded, "hi") == CacheKey(other, "hi") {
+		t.Error("the seed doesn't change the cache key")
+	}
+}
@@ -0,0 +1,87 @@
+package scavenger
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
+// selfTestPayloadSize is the size of the random payload SelfTest submits.
+const selfTestPayloadSize = 64
+
+// SelfTestStage is the outcome of one stage of a self test.
+type SelfTestStage struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// SelfTestReport is the outcome of a self test. The stages are the ones
+// that ran, in order. Only the last one can have failed.
+type SelfTestReport struct {
+	Namespace string          `json:"namespace,omitempty"`
+	Height    uint64          `json:"height,omitempty"`
+	Stages    []SelfTestStage `json:"stages"`
+	Passed    bool            `json:"passed"`
+}
+
+// SelfTest checks that the node works end to end: it submits a small
+// random payload to a random namespace, fetches it back and checks that it
+// is unchanged. With VerifyProof set, the inclusion proof is checked too.
+// The model isn't asked. A failing stage is reported, not returned.
+func (c *Client) SelfTest(ctx context.Context) *SelfTestReport {
+	report := &SelfTestReport{}
+	stage := func(name string, run func() error) bool {
+		start := time.Now()
+		err := run()
+		s := SelfTestStage{Name: name, Duration: time.Since(start)}
+		if err != nil {
+			s.Error = err.Error()
+		}
+		report.Stages = append(report.Stages, s)
+		return err == nil
+	}
+
+	var (
+		sub     *Submission
+		payload = make([]byte, selfTestPayloadSize)
+	)
+	ok := stage("prepare", func() error {
+		if _, err := rand.Read(payload); err != nil {
+			return fmt.Errorf("error generating payload: %w", err)
+		}
+		nsHex, err := RandomNamespaceID()
+		if err != nil {
+			return err
+		}
+		report.Namespace = nsHex
+		return nil
+	})
+	ok = ok && stage("submit", func() error {
+		ns, err := CreateNamespaceID(report.Namespace, 0, true)
+		if err != nil {
+			return err
+		}
+		sub, err = c.submitNew(ctx, ns, [][]byte{payload})
+		if err != nil {
+			return err
+		}
+		report.Height = sub.Height
+		return nil
+	})
+	ok = ok && stage("fetch", func() error {
+		b, err := c.Blobs.Get(ctx, sub.Height, sub.Namespace, sub.Blobs[0].Commitment)
+		if err != nil {
+			return fmt.Errorf("Failed to fetch blob: %w", err)
+		}
+		return VerifyBlob(sub.Namespace, sub.Blobs[0], b)
+	})
+	if c.Config.VerifyProof {
+		ok = ok && stage("proof", func() error {
+			return c.VerifyInclusion(ctx, sub)
+		})
+	}
+	report.Passed = ok
+	return report
+}
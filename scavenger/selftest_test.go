@@ -0,0 +1,83 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// tamperingBlobAPI serves other blobs than the ones submitted.
+type tamperingBlobAPI struct{ *FakeBlobAPI }
+
+func (a tamperingBlobAPI) Get(_ context.Context, _ uint64, ns share.Namespace, _ blob.Commitment) (*blob.Blob, error) {
+	return blob.NewBlobV0(ns, []byte("tampered"))
+}
+
+// stageNames returns the names of the stages of report.
+func stageNames(report *SelfTestReport) []string {
+	names := make([]string, len(report.Stages))
+	for i, s := range report.Stages {
+		names[i] = s.Name
+	}
+	return names
+}
+
+func TestSelfTest(t *testing.T) {
+	cfg := testConfig()
+	cfg.VerifyProof = true
+	c, api, completer := newTestClient(cfg)
+	report := c.SelfTest(context.Background())
+	if !report.Passed {
+		t.Fatalf("self test failed: %+v", report.Stages)
+	}
+	if got := stageNames(report); len(got) != 4 || got[3] != "proof" {
+		t.Errorf("stages = %v, want prepare, submit, fetch and proof", got)
+	}
+	if report.Height != 1 || fakeHeight(api) != 1 {
+		t.Errorf("report at height %d, want the one submission at 1", report.Height)
+	}
+	if report.Namespace == testNamespace {
+		t.Error("self test submitted to the configured namespace, want a throwaway one")
+	}
+	if len(completer.prompts()) != 0 {
+		t.Error("self test asked the model")
+	}
+}
+
+func TestSelfTestFailures(t *testing.T) {
+	broken := errors.New("broken")
+	tests := []struct {
+		name  string
+		api   func(*FakeBlobAPI) BlobAPI
+		stage string
+	}{
+		{"submit", func(api *FakeBlobAPI) BlobAPI { api.SubmitErr = broken; return api }, "submit"},
+		{"fetch", func(api *FakeBlobAPI) BlobAPI { api.GetErr = broken; return api }, "fetch"},
+		{"mismatch", func(api *FakeBlobAPI) BlobAPI { return tamperingBlobAPI{api} }, "fetch"},
+		{"proof", func(api *FakeBlobAPI) BlobAPI { api.GetProofErr = broken; return api }, "proof"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.VerifyProof = true
+			c, api, _ := newTestClient(cfg)
+			c.Blobs = tt.api(api)
+			report := c.SelfTest(context.Background())
+			if report.Passed {
+				t.Fatal("self test passed")
+			}
+			last := report.Stages[len(report.Stages)-1]
+			if last.Name != tt.stage || last.Error == "" {
+				t.Errorf("stages = %+v, want them to stop at a failing %s", report.Stages, tt.stage)
+			}
+			for _, s := range report.Stages[:len(report.Stages)-1] {
+				if s.Error != "" {
+					t.Errorf("stage %s failed before the last one: %s", s.Name, s.Error)
+				}
+			}
+		})
+	}
+}
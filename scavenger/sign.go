@@ -0,0 +1,96 @@
+package scavenger
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	// ErrUnsigned is returned when verifying a prompt that wasn't signed.
+	ErrUnsigned = errors.New("prompt is not signed")
+	// ErrInvalidSignature is returned when a prompt's signature doesn't
+	// match the prompt and the public key submitted with it.
+	ErrInvalidSignature = errors.New("invalid prompt signature")
+)
+
+// readSigningKey reads the Ed25519 key in path, a hex encoded 32 byte seed
+// or 64 byte private key.
+func readSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading signing key file: %w", err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding signing key hex: %w", err)
+	}
+	switch len(key) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(key), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(key), nil
+	default:
+		return nil, fmt.Errorf("signing key must be %d or %d bytes, got %d bytes", ed25519.SeedSize, ed25519.PrivateKeySize, len(key))
+	}
+}
+
+// signedMessage returns the bytes the signature of e is over: the envelope
+// as JSON, without the signature.
+func (e PromptEnvelope) signedMessage() ([]byte, error) {
+	e.Signature = ""
+	return json.Marshal(e)
+}
+
+// sign signs e with key, setting its public key and signature.
+func (e *PromptEnvelope) sign(key ed25519.PrivateKey) error {
+	e.PublicKey = hex.EncodeToString(key.Public().(ed25519.PublicKey))
+	message, err := e.signedMessage()
+	if err != nil {
+		return fmt.Errorf("error encoding prompt envelope: %w", err)
+	}
+	e.Signature = hex.EncodeToString(ed25519.Sign(key, message))
+	return nil
+}
+
+// Verify checks the signature of e, and returns the public key it was
+// signed with.
+func (e *PromptEnvelope) Verify() (ed25519.PublicKey, error) {
+	if e.Signature == "" {
+		return nil, ErrUnsigned
+	}
+	publicKey, err := hex.DecodeString(e.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: malformed public key", ErrInvalidSignature)
+	}
+	signature, err := hex.DecodeString(e.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+	message, err := e.signedMessage()
+	if err != nil {
+		return nil, fmt.Errorf("error encoding prompt envelope: %w", err)
+	}
+	if !ed25519.Verify(publicKey, message, signature) {
+		return nil, fmt.Errorf("%w: signature by %x doesn't match the prompt", ErrInvalidSignature, publicKey)
+	}
+	return publicKey, nil
+}
+
+// VerifyPrompt checks the signature of the prompt in a decoded payload,
+// and returns the public key it was signed with. Raw prompts aren't
+// signed, so they fail with ErrUnsigned.
+func VerifyPrompt(payload []byte) (ed25519.PublicKey, error) {
+	envelope, ok, err := decodeEnvelope(payload)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUnsigned
+	}
+	return envelope.Verify()
+}
@@ -0,0 +1,154 @@
+package scavenger
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testSeed is the seed of the signing key used in the tests.
+const testSeed = "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"
+
+// signingConfig returns a test config signing with testSeed.
+func signingConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg := testConfig()
+	cfg.SignKeyFile = writeFile(t, "key", testSeed+"\n")
+	return cfg
+}
+
+// testPublicKey returns the hex encoded public key of testSeed.
+func testPublicKey(t *testing.T) string {
+	t.Helper()
+	seed, err := hex.DecodeString(testSeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey))
+}
+
+func TestSignedEnvelope(t *testing.T) {
+	data, err := promptData(signingConfig(t), "signed prompt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := VerifyPrompt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(signer) != testPublicKey(t) {
+		t.Errorf("signer = %x, want the key's public key", signer)
+	}
+}
+
+func TestVerifyPromptInvalid(t *testing.T) {
+	data, err := promptData(signingConfig(t), "signed prompt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envelope PromptEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		t.Fatal(err)
+	}
+	otherKey := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+
+	tests := []struct {
+		name   string
+		tamper func(e *PromptEnvelope)
+	}{
+		{"tampered prompt", func(e *PromptEnvelope) { e.Prompt = "another prompt" }},
+		{"tampered model", func(e *PromptEnvelope) { e.Model = "gpt-9000" }},
+		{"wrong key", func(e *PromptEnvelope) {
+			e.PublicKey = hex.EncodeToString(otherKey.Public().(ed25519.PublicKey))
+		}},
+		{"malformed key", func(e *PromptEnvelope) { e.PublicKey = "zz" }},
+		{"malformed signature", func(e *PromptEnvelope) { e.Signature = "zz" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := envelope
+			tt.tamper(&e)
+			tampered, err := json.Marshal(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := VerifyPrompt(tampered); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestVerifyPromptUnsigned(t *testing.T) {
+	data, err := promptData(testConfig(), "unsigned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, payload := range [][]byte{data, []byte("a raw prompt")} {
+		if _, err := VerifyPrompt(payload); !errors.Is(err, ErrUnsigned) {
+			t.Errorf("verifying %q: error = %v, want ErrUnsigned", payload, err)
+		}
+	}
+}
+
+func TestReadSigningKey(t *testing.T) {
+	seed, err := readSigningKey(writeFile(t, "seed", testSeed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, err := readSigningKey(writeFile(t, "private", hex.EncodeToString(seed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !seed.Equal(private) {
+		t.Error("seed and private key files gave different keys")
+	}
+	for _, data := range []string{"not hex", "abcd"} {
+		if _, err := readSigningKey(writeFile(t, "bad", data)); err == nil {
+			t.Errorf("key file %q was accepted", data)
+		}
+	}
+}
+
+func TestFetchPromptVerifySignature(t *testing.T) {
+	c, _, _ := newTestClient(signingConfig(t))
+	c.Config.VerifySignature = true
+	sub, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "signed prompt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched, err := c.FetchPrompt(context.Background(), sub.Height, sub.Namespace, sub.Commitments())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched.Signer != testPublicKey(t) || string(fetched.Payload) != "signed prompt" {
+		t.Errorf("fetched %q signed by %s, want the prompt signed by %s", fetched.Payload, fetched.Signer, testPublicKey(t))
+	}
+
+	// Unsigned prompts still fetch without verifying.
+	c.Config.SignKeyFile, c.Config.VerifySignature = "", false
+	sub, err = c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "unsigned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.FetchPrompt(context.Background(), sub.Height, sub.Namespace, sub.Commitments()); err != nil {
+		t.Fatal(err)
+	}
+	c.Config.VerifySignature = true
+	_, err = c.FetchPrompt(context.Background(), sub.Height, sub.Namespace, sub.Commitments())
+	if !errors.Is(err, ErrUnsigned) {
+		t.Errorf("error = %v, want ErrUnsigned", err)
+	}
+}
+
+func TestValidateSignRaw(t *testing.T) {
+	cfg := signingConfig(t)
+	cfg.Raw = true
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "raw") {
+		t.Errorf("error = %v, want signing raw prompts rejected", err)
+	}
+}
@@ -0,0 +1,62 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Errors from StageError wrap one of these, telling which stage of a run
+// failed. Callers can tell them apart with errors.Is.
+var (
+	ErrNamespace  = errors.New("namespace error")
+	ErrConnect    = errors.New("node connection error")
+	ErrSubmit     = errors.New("submission error")
+	ErrFetch      = errors.New("fetch error")
+	ErrVerify     = errors.New("verification error")
+	ErrCompletion = errors.New("completion error")
+	// ErrConfirmations is returned when the blobs were included, but not
+	// confirmed by enough blocks in time.
+	ErrConfirmations = errors.New("confirmations error")
+)
+
+// stageErrors maps the stages of a run to their errors.
+var stageErrors = map[string]error{
+	"namespace":          ErrNamespace,
+	"connect":            ErrConnect,
+	"submit":             ErrSubmit,
+	"store response":     ErrSubmit,
+	"fetch":              ErrFetch,
+	"verification":       ErrVerify,
+	"proof verification": ErrVerify,
+	"completion":         ErrCompletion,
+	"confirmations":      ErrConfirmations,
+}
+
+// stageError is an error that happened in a stage of a run. Its message is
+// that of the error, so wrapping doesn't change what users see.
+type stageError struct {
+	stage error
+	err   error
+}
+
+func (e *stageError) Error() string   { return e.err.Error() }
+func (e *stageError) Unwrap() []error { return []error{e.stage, e.err} }
+
+// StageError marks err as having happened in stage, so that it matches
+// the stage's error, and calls out the stage if err was caused by the run
+// timing out. Errors marked already keep their stage.
+func StageError(stage string, err error) error {
+	var marked *stageError
+	if err == nil || errors.As(err, &marked) {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out during %s: %w", stage, err)
+	}
+	kind, ok := stageErrors[stage]
+	if !ok {
+		return err
+	}
+	return &stageError{stage: kind, err: err}
+}
@@ -0,0 +1,57 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStageError(t *testing.T) {
+	cause := &fs.PathError{Op: "open", Path: "key", Err: os.ErrNotExist}
+	err := StageError("submit", cause)
+	if !errors.Is(err, ErrSubmit) || errors.Is(err, ErrFetch) {
+		t.Errorf("error %v isn't only a submit error", err)
+	}
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("error %v doesn't wrap its cause", err)
+	}
+	if err.Error() != cause.Error() {
+		t.Errorf("message = %q, want the cause's %q", err, cause)
+	}
+
+	// Marked errors keep their first stage, even wrapped.
+	wrapped := StageError("completion", fmt.Errorf("run: %w", err))
+	if !errors.Is(wrapped, ErrSubmit) || errors.Is(wrapped, ErrCompletion) {
+		t.Errorf("error %v changed its stage", wrapped)
+	}
+
+	if StageError("submit", nil) != nil {
+		t.Error("StageError made an error of nil")
+	}
+	if got := StageError("no such stage", cause); got != error(cause) {
+		t.Errorf("error of an unknown stage = %v, want the cause as it is", got)
+	}
+}
+
+func TestStageErrorTimeout(t *testing.T) {
+	err := StageError("fetch", context.DeadlineExceeded)
+	if !errors.Is(err, ErrFetch) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error %v isn't a fetch error from the deadline", err)
+	}
+	if !strings.Contains(err.Error(), "timed out during fetch") {
+		t.Errorf("message %q doesn't name the stage that timed out", err)
+	}
+}
+
+func TestClientRunVerifyError(t *testing.T) {
+	c, api, _ := newTestClient(testConfig())
+	c.Blobs = tamperingBlobAPI{api}
+	if _, err := c.Run(context.Background(), "hi"); !errors.Is(err, ErrVerify) {
+		t.Fatalf("error = %v, want a verification error", err)
+	}
+}
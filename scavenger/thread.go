@@ -0,0 +1,184 @@
+package scavenger
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// maxThreadTurns bounds how many turns of a thread are followed, so a
+// malicious or broken chain can't keep us fetching forever.
+const maxThreadTurns = 100
+
+// BlobRef points to a blob on chain.
+type BlobRef struct {
+	Height     uint64 `json:"height"`
+	Commitment string `json:"commitment"`
+}
+
+// String formats the reference for error messages.
+func (r BlobRef) String() string {
+	return fmt.Sprintf("%s at height %d", r.Commitment, r.Height)
+}
+
+// ThreadTurn is the payload of a blob holding one turn of a conversation.
+// Parent links to the previous turn.
+type ThreadTurn struct {
+	Parent  *BlobRef `json:"parent,omitempty"`
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Model   string   `json:"model,omitempty"`
+}
+
+// decodeTurn decodes a turn of a thread. Besides ThreadTurns, a thread can
+// start with a prompt and the response stored for it with StoreResponse,
+// so a single run can be continued as a thread.
+func decodeTurn(data []byte) (Message, *BlobRef) {
+	var turn struct {
+		// ThreadTurn fields.
+		Parent  *BlobRef `json:"parent"`
+		Role    string   `json:"role"`
+		Content string   `json:"content"`
+		// ResponseEnvelope fields.
+		PromptHeight     uint64 `json:"prompt_height"`
+		PromptCommitment string `json:"prompt_commitment"`
+		Response         string `json:"response"`
+	}
+	if json.Unmarshal(data, &turn) == nil {
+		switch {
+		case turn.Role == RoleUser || turn.Role == RoleAssistant:
+			return Message{Role: turn.Role, Content: turn.Content}, turn.Parent
+		case turn.PromptCommitment != "":
+			parent := &BlobRef{Height: turn.PromptHeight, Commitment: turn.PromptCommitment}
+			return Message{Role: RoleAssistant, Content: turn.Response}, parent
+		}
+	}
+	// Anything else is a prompt, which is where the thread starts.
+	if prompt, _, err := DecodePrompt(data); err == nil {
+		data = prompt
+	}
+	return Message{Role: RoleUser, Content: string(data)}, nil
+}
+
+// FetchThread fetches the turns of the thread ending at head by following
+// their parent links, and returns them as messages, oldest first.
+func (c *Client) FetchThread(ctx context.Context, ns share.Namespace, head BlobRef) ([]Message, error) {
+	var messages []Message
+	seen := make(map[BlobRef]bool)
+	for ref := &head; ref != nil; {
+		if seen[*ref] {
+			return nil, fmt.Errorf("thread has a cycle at %s", ref)
+		}
+		if len(seen) == maxThreadTurns {
+			return nil, fmt.Errorf("thread is longer than %d turns", maxThreadTurns)
+		}
+		seen[*ref] = true
+
+		commitment, err := hex.DecodeString(ref.Commitment)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commitment in thread: %w", err)
+		}
+		b, err := c.Blobs.Get(ctx, ref.Height, ns, commitment)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to fetch thread turn %s: %w", ref, err)
+		}
+		data, err := DecodePayload(c.Config, b.Data)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode thread turn %s: %w", ref, err)
+		}
+
+		var message Message
+		message, ref = decodeTurn(data)
+		messages = append(messages, message)
+	}
+
+	// We walked the thread backwards.
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
+
+// submitTurn submits turn as a blob.
+func (c *Client) submitTurn(ctx context.Context, ns share.Namespace, turn ThreadTurn) (*Submission, error) {
+	data, err := json.Marshal(turn)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding thread turn: %w", err)
+	}
+	payload, err := EncodePayload(c.Config, data)
+	if err != nil {
+		return nil, err
+	}
+	return c.submit(ctx, ns, data, [][]byte{payload})
+}
+
+// blobRef returns a reference to the first blob of sub.
+func (s *Submission) blobRef() *BlobRef {
+	return &BlobRef{Height: s.Height, Commitment: hex.EncodeToString(s.Blobs[0].Commitment)}
+}
+
+// RunThread continues the thread ending at head with prompt. The model is
+// sent the whole conversation, and both the prompt and its response are
+// submitted as new turns of the thread. The response's turn is the new
+// head of the thread.
+func (c *Client) RunThread(ctx context.Context, head BlobRef, prompt string) (*RunResult, error) {
+	cfg := c.Config
+	namespaceID, err := CreateNamespaceID(cfg.Namespace, cfg.NamespaceVersion, cfg.PadNamespace)
+	if err != nil {
+		return nil, StageError("namespace", fmt.Errorf("Failed to decode namespace: %w", err))
+	}
+
+	history, err := c.FetchThread(ctx, namespaceID, head)
+	if err != nil {
+		return nil, StageError("fetch", err)
+	}
+
+	onChain, err := c.redact(prompt)
+	if err != nil {
+		return nil, StageError("submit", err)
+	}
+	if err := c.moderate(ctx, onChain); err != nil {
+		return nil, StageError("submit", err)
+	}
+	sub, err := c.submitTurn(ctx, namespaceID, ThreadTurn{Parent: &head, Role: RoleUser, Content: onChain})
+	if err != nil {
+		return nil, StageError("submit", err)
+	}
+
+	var messages []Message
+	if cfg.SystemPrompt != "" {
+		messages = append(messages, Message{Role: RoleSystem, Content: cfg.SystemPrompt})
+	}
+	messages = append(messages, history...)
+	if cfg.Redact != RedactChainOnly {
+		prompt = onChain
+	}
+	messages = append(messages, Message{Role: RoleUser, Content: prompt})
+	answer, usage, err := c.Converse(ctx, messages)
+	if err != nil {
+		return nil, StageError("completion", fmt.Errorf("Failed to process message with %s: %w", cfg.Model, err))
+	}
+
+	stored, err := c.submitTurn(ctx, namespaceID, ThreadTurn{Parent: sub.blobRef(), Role: RoleAssistant, Content: answer, Model: cfg.Model})
+	if err != nil {
+		return nil, StageError("store response", fmt.Errorf("Failed to store response: %w", err))
+	}
+
+	result := &RunResult{
+		Namespace:          NamespaceHex(namespaceID),
+		Height:             sub.Height,
+		Commitment:         hex.EncodeToString(sub.Blobs[0].Commitment),
+		SubmittedPayload:   prompt,
+		Model:              cfg.Model,
+		Response:           answer,
+		Structured:         StructuredResponse(cfg, answer),
+		ResponseHeight:     stored.Height,
+		ResponseCommitment: hex.EncodeToString(stored.Blobs[0].Commitment),
+		ThreadTurns:        len(history) + 2,
+	}
+	result.SetUsage(cfg, usage)
+	return result, nil
+}
@@ -0,0 +1,92 @@
+package scavenger
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunThread(t *testing.T) {
+	cfg := testConfig()
+	cfg.StoreResponse = true
+	cfg.SystemPrompt = "be brief"
+	c, _, completer := newTestClient(cfg)
+	first, err := c.Run(context.Background(), "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A run with its stored response starts the thread.
+	head := BlobRef{Height: first.ResponseHeight, Commitment: first.ResponseCommitment}
+	second, err := c.RunThread(context.Background(), head, "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ThreadTurns != 4 || second.Response != "answer to second" {
+		t.Errorf("thread has %d turns and response %q, want 4 and the answer to the new prompt", second.ThreadTurns, second.Response)
+	}
+	want := []Message{
+		{RoleSystem, "be brief"},
+		{RoleUser, "first"},
+		{RoleAssistant, "answer to first"},
+		{RoleUser, "second"},
+	}
+	if got := completer.calls[len(completer.calls)-1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("model was sent %v, want %v", got, want)
+	}
+
+	// The response's turn is the new head.
+	head = BlobRef{Height: second.ResponseHeight, Commitment: second.ResponseCommitment}
+	messages, err := c.FetchThread(context.Background(), testNS(t, testNamespace), head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(messages, append(want[1:], Message{RoleAssistant, "answer to second"})) {
+		t.Errorf("thread = %v, want the four turns oldest first", messages)
+	}
+}
+
+func TestFetchThreadErrors(t *testing.T) {
+	c, _, _ := newTestClient(testConfig())
+	ns := testNS(t, testNamespace)
+	tests := []struct {
+		name string
+		head BlobRef
+		want string
+	}{
+		{"invalid commitment", BlobRef{Height: 1, Commitment: "zz"}, "invalid commitment"},
+		{"missing turn", BlobRef{Height: 1, Commitment: "abcd"}, "Failed to fetch thread turn abcd at height 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.FetchThread(context.Background(), ns, tt.head)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeTurn(t *testing.T) {
+	parent := &BlobRef{Height: 3, Commitment: "ab"}
+	tests := []struct {
+		name       string
+		data       string
+		want       Message
+		wantParent *BlobRef
+	}{
+		{"turn", `{"parent":{"height":3,"commitment":"ab"},"role":"user","content":"hi"}`, Message{RoleUser, "hi"}, parent},
+		{"response", `{"prompt_height":3,"prompt_commitment":"ab","response":"hello"}`, Message{RoleAssistant, "hello"}, parent},
+		{"raw prompt", "just a prompt", Message{RoleUser, "just a prompt"}, nil},
+		{"other JSON", `{"role":"system","content":"x"}`, Message{RoleUser, `{"role":"system","content":"x"}`}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotParent := decodeTurn([]byte(tt.data))
+			if got != tt.want || !reflect.DeepEqual(gotParent, tt.wantParent) {
+				t.Errorf("decodeTurn = %v, %v, want %v, %v", got, gotParent, tt.want, tt.wantParent)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAskOpenAITimeout(t *testing.T) {
+	cfg := testConfig()
+	cfg.OpenAITimeout = 10 * time.Millisecond
+	c, _, completer := newTestClient(cfg)
+	completer.block = true
+	_, _, err := c.Ask(context.Background(), "hi")
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "within the OpenAI timeout of 10ms") {
+		t.Fatalf("error = %v, want the OpenAI timeout called out", err)
+	}
+}
+
+func TestClientRunOpenAITimeout(t *testing.T) {
+	cfg := testConfig()
+	cfg.OpenAITimeout = 10 * time.Millisecond
+	c, api, completer := newTestClient(cfg)
+	completer.block = true
+	_, err := c.Run(context.Background(), "hi")
+	if !errors.Is(err, ErrCompletion) || !strings.Contains(err.Error(), "OpenAI timeout") {
+		t.Fatalf("error = %v, want a completion error from the OpenAI timeout", err)
+	}
+	// The submission isn't bound by the OpenAI timeout.
+	if fakeHeight(api) != 1 {
+		t.Errorf("chain at height %d, want the prompt submitted", fakeHeight(api))
+	}
+}
+
+func TestAskRunTimeout(t *testing.T) {
+	// When the run times out first, the OpenAI timeout isn't blamed.
+	cfg := testConfig()
+	cfg.OpenAITimeout = time.Minute
+	c, _, completer := newTestClient(cfg)
+	completer.block = true
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err := c.Ask(ctx, "hi")
+	if !errors.Is(err, context.DeadlineExceeded) || strings.Contains(err.Error(), "OpenAI timeout") {
+		t.Fatalf("error = %v, want the run's deadline", err)
+	}
+}
+
+func TestValidateOpenAITimeout(t *testing.T) {
+	cfg := testConfig()
+	cfg.OpenAITimeout = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("negative OpenAI timeout was accepted")
+	}
+}
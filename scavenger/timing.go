@@ -0,0 +1,25 @@
+package scavenger
+
+import (
+	"context"
+	"time"
+)
+
+// confirmTimeKey is the context key under which Run collects the time
+// spent waiting for the user to confirm submissions, which doesn't count
+// towards the stage timings.
+type confirmTimeKey struct{}
+
+// withConfirmTime returns a context that adds the time spent confirming
+// submissions to *d.
+func withConfirmTime(ctx context.Context, d *time.Duration) context.Context {
+	return context.WithValue(ctx, confirmTimeKey{}, d)
+}
+
+// addConfirmTime records d as spent confirming a submission, if ctx
+// collects it.
+func addConfirmTime(ctx context.Context, d time.Duration) {
+	if total, ok := ctx.Value(confirmTimeKey{}).(*time.Duration); ok {
+		*total += d
+	}
+}
@@ -0,0 +1,79 @@
+package scavenger
+
+import (
+	"fmt"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func init() {
+	// Use the encodings bundled into the binary instead of downloading
+	// them on first use.
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// contextWindows are the context sizes of the known OpenAI models, in
+// tokens. They bound the prompt and the response together.
+var contextWindows = map[string]int{
+	openai.GPT3Dot5Turbo:     16385,
+	openai.GPT3Dot5Turbo0125: 16385,
+	openai.GPT3Dot5Turbo16K:  16385,
+	openai.GPT4:              8192,
+	openai.GPT4Turbo:         128000,
+	openai.GPT4TurboPreview:  128000,
+	openai.GPT4o:             128000,
+}
+
+// Every message is wrapped in formatting tokens, and the reply is primed
+// with a few more. See OpenAI's cookbook on counting tokens.
+const (
+	tokensPerMessage = 3
+	tokensPerReply   = 3
+)
+
+// fitMessages checks that messages fit into the context window of the
+// configured model, leaving room for MaxTokens of response. Prompts that
+// are too long are rejected, or with Truncate set, the user's message is
+// cut short so they fit. Models we don't know the window or tokenizer of
+// aren't checked.
+func fitMessages(cfg *Config, messages []Message) ([]Message, error) {
+	window, ok := contextWindows[cfg.Model]
+	if !ok {
+		return messages, nil
+	}
+	enc, err := tiktoken.EncodingForModel(cfg.Model)
+	if err != nil {
+		return messages, nil
+	}
+
+	limit := window
+	if cfg.MaxTokens != nil {
+		limit -= *cfg.MaxTokens
+	}
+
+	total := tokensPerReply
+	for _, m := range messages {
+		total += tokensPerMessage + len(enc.EncodeOrdinary(m.Role)) + len(enc.EncodeOrdinary(m.Content))
+	}
+	if total <= limit {
+		return messages, nil
+	}
+	if !cfg.Truncate {
+		return nil, fmt.Errorf("prompt is %d tokens, limit is %d", total, limit)
+	}
+
+	// The user's message is the last one. Everything else, including the
+	// system prompt, is kept as is.
+	last := len(messages) - 1
+	content := enc.EncodeOrdinary(messages[last].Content)
+	keep := limit - (total - len(content))
+	if keep <= 0 {
+		return nil, fmt.Errorf("prompt is %d tokens without the user message, limit is %d", total-len(content), limit)
+	}
+
+	fitted := append([]Message(nil), messages...)
+	fitted[last].Content = enc.Decode(content[:keep])
+	return fitted, nil
+}
@@ -0,0 +1,101 @@
+package scavenger
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkoukk/tiktoken-go"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// countTokens counts the tokens of messages the way fitMessages does.
+func countTokens(t *testing.T, model string, messages []Message) int {
+	t.Helper()
+	enc, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := tokensPerReply
+	for _, m := range messages {
+		total += tokensPerMessage + len(enc.EncodeOrdinary(m.Role)) + len(enc.EncodeOrdinary(m.Content))
+	}
+	return total
+}
+
+func TestFitMessages(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Model = openai.GPT4
+	messages := ChatMessages("be brief", "hi")
+	got, err := fitMessages(cfg, messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[1].Content != "hi" {
+		t.Errorf("short prompt changed to %q", got[1].Content)
+	}
+
+	long := ChatMessages("be brief", strings.Repeat("word ", 10000))
+	if _, err := fitMessages(cfg, long); err == nil || !strings.Contains(err.Error(), "limit is 8192") {
+		t.Errorf("error = %v, want the prompt rejected over the limit", err)
+	}
+
+	// The response's tokens come off the limit.
+	maxTokens := 8000
+	cfg.MaxTokens = &maxTokens
+	medium := ChatMessages("", strings.Repeat("word ", 500))
+	if _, err := fitMessages(cfg, medium); err == nil || !strings.Contains(err.Error(), "limit is 192") {
+		t.Errorf("error = %v, want the limit less the max tokens", err)
+	}
+}
+
+func TestFitMessagesTruncate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Model = openai.GPT4
+	cfg.Truncate = true
+	long := ChatMessages("be brief", strings.Repeat("word ", 10000))
+	got, err := fitMessages(cfg, long)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0] != long[0] {
+		t.Errorf("system prompt changed to %q", got[0].Content)
+	}
+	if !strings.HasPrefix(long[1].Content, got[1].Content) || len(got[1].Content) >= len(long[1].Content) {
+		t.Error("user message wasn't cut short")
+	}
+	if n := countTokens(t, cfg.Model, got); n > 8192 {
+		t.Errorf("truncated messages are %d tokens, over the limit", n)
+	}
+	if long[1].Content != strings.Repeat("word ", 10000) {
+		t.Error("the given messages were modified")
+	}
+
+	// Without room for any of the user's message, truncating fails.
+	huge := ChatMessages(strings.Repeat("word ", 10000), "hi")
+	if _, err := fitMessages(cfg, huge); err == nil {
+		t.Error("fitting a system prompt over the limit succeeded")
+	}
+}
+
+func TestFitMessagesUnknownModel(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Model = "local-llama"
+	long := ChatMessages("", strings.Repeat("word ", 100000))
+	if _, err := fitMessages(cfg, long); err != nil {
+		t.Errorf("unknown model's prompt was checked: %v", err)
+	}
+}
+
+func TestOpenAICompleterRejectsLongPrompt(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Model = openai.GPT4
+	client := &fakeChatClient{resp: chatResponse("ok")}
+	_, _, err := testCompleter(t, cfg, client, nil).Complete(context.Background(), ChatMessages("", strings.Repeat("word ", 10000)))
+	if err == nil {
+		t.Fatal("long prompt was sent")
+	}
+	if len(client.requests) != 0 {
+		t.Error("long prompt reached the API")
+	}
+}
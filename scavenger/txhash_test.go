@@ -0,0 +1,55 @@
+package scavenger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// blobAPIOnly hides everything of a BlobAPI but its BlobAPI methods, such
+// as TxSubmitter.
+type blobAPIOnly struct{ BlobAPI }
+
+func TestClientRunTxHash(t *testing.T) {
+	cfg := testConfig()
+	cfg.TxHash = true
+	c, _, _ := newTestClient(cfg)
+	result, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.TxHash) != 64 {
+		t.Errorf("tx hash = %q, want the one the fake reported", result.TxHash)
+	}
+}
+
+func TestClientRunNoTxHash(t *testing.T) {
+	c, _, _ := newTestClient(testConfig())
+	var out bytes.Buffer
+	c.Logger = slog.New(slog.NewTextHandler(&out, nil))
+	result, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TxHash != "" {
+		t.Errorf("tx hash = %q, want none from blob.Submit", result.TxHash)
+	}
+	if !strings.Contains(out.String(), "not reported by blob.Submit") {
+		t.Errorf("log %q doesn't note the missing hash", out.String())
+	}
+}
+
+func TestClientTxHashUnsupported(t *testing.T) {
+	cfg := testConfig()
+	cfg.TxHash = true
+	c, api, _ := newTestClient(cfg)
+	c.Blobs = blobAPIOnly{api}
+	if _, err := c.SubmitPrompt(context.Background(), testNS(t, testNamespace), "hi"); err == nil {
+		t.Fatal("submitting for a hash through a blob API without them succeeded")
+	}
+	if fakeHeight(api) != 0 {
+		t.Error("blobs were submitted anyway")
+	}
+}
@@ -0,0 +1,86 @@
+package scavenger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+var (
+	// ErrCommitmentMismatch is returned when a fetched blob's commitment
+	// differs from the one we submitted.
+	ErrCommitmentMismatch = errors.New("blob commitment mismatch")
+	// ErrDataMismatch is returned when a fetched blob's data differs from
+	// the payload we submitted.
+	ErrDataMismatch = errors.New("blob data mismatch")
+	// ErrNotIncluded is returned when the inclusion proof for a blob
+	// doesn't check out.
+	ErrNotIncluded = errors.New("blob inclusion not proven")
+)
+
+// VerifyBlob checks that the blob fetched from the node is the one we
+// submitted. Besides comparing the reported commitment, we recompute it
+// from the fetched data, so a node returning the right commitment with
+// the wrong data is caught too.
+func VerifyBlob(ns share.Namespace, submitted, fetched *blob.Blob) error {
+	if !fetched.Commitment.Equal(submitted.Commitment) {
+		return fmt.Errorf("%w: submitted %x, fetched %x", ErrCommitmentMismatch, submitted.Commitment, fetched.Commitment)
+	}
+	if !bytes.Equal(fetched.Data, submitted.Data) {
+		return fmt.Errorf("%w: submitted %d bytes, fetched %d bytes", ErrDataMismatch, len(submitted.Data), len(fetched.Data))
+	}
+
+	recomputed, err := blob.NewBlob(uint8(fetched.ShareVersion), ns, fetched.Data)
+	if err != nil {
+		return fmt.Errorf("error recomputing commitment: %w", err)
+	}
+	if !recomputed.Commitment.Equal(submitted.Commitment) {
+		return fmt.Errorf("%w: fetched data commits to %x, expected %x", ErrCommitmentMismatch, recomputed.Commitment, submitted.Commitment)
+	}
+	return nil
+}
+
+// VerifyCommitment checks that the fetched blob has commitment, both as
+// reported and as recomputed from its data. It is the check VerifyBlob
+// makes when the submitted blob isn't at hand, only its commitment.
+func VerifyCommitment(ns share.Namespace, fetched *blob.Blob, commitment blob.Commitment) error {
+	if !fetched.Commitment.Equal(commitment) {
+		return fmt.Errorf("%w: expected %x, fetched %x", ErrCommitmentMismatch, commitment, fetched.Commitment)
+	}
+	recomputed, err := blob.NewBlob(uint8(fetched.ShareVersion), ns, fetched.Data)
+	if err != nil {
+		return fmt.Errorf("error recomputing commitment: %w", err)
+	}
+	if !recomputed.Commitment.Equal(commitment) {
+		return fmt.Errorf("%w: fetched data commits to %x, expected %x", ErrCommitmentMismatch, recomputed.Commitment, commitment)
+	}
+	return nil
+}
+
+// VerifyInclusion fetches the inclusion proof for the blob with the given
+// commitment and has the node check it against the block at height.
+func VerifyInclusion(
+	ctx context.Context,
+	api BlobAPI,
+	height uint64,
+	ns share.Namespace,
+	commitment blob.Commitment,
+) error {
+	proof, err := api.GetProof(ctx, height, ns, commitment)
+	if err != nil {
+		return fmt.Errorf("error fetching inclusion proof: %w", err)
+	}
+
+	included, err := api.Included(ctx, height, ns, proof, commitment)
+	if err != nil {
+		return fmt.Errorf("error checking inclusion proof: %w", err)
+	}
+	if !included {
+		return fmt.Errorf("%w: proof for %x at height %d was rejected", ErrNotIncluded, commitment, height)
+	}
+	return nil
+}
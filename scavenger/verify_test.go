@@ -0,0 +1,127 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// testBlob creates a version 0 blob with data in ns.
+func testBlob(t *testing.T, ns share.Namespace, data string) *blob.Blob {
+	t.Helper()
+	b, err := blob.NewBlobV0(ns, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestVerifyBlob(t *testing.T) {
+	ns := testNS(t, testNamespace)
+	submitted := testBlob(t, ns, "prompt")
+	if err := VerifyBlob(ns, submitted, testBlob(t, ns, "prompt")); err != nil {
+		t.Fatalf("same blob failed verification: %v", err)
+	}
+
+	if err := VerifyBlob(ns, submitted, testBlob(t, ns, "other prompt")); !errors.Is(err, ErrCommitmentMismatch) {
+		t.Errorf("other blob: error = %v, want ErrCommitmentMismatch", err)
+	}
+
+	// A node returning the right commitment with other data is caught too.
+	forged := testBlob(t, ns, "other prompt")
+	forged.Commitment = submitted.Commitment
+	if err := VerifyBlob(ns, submitted, forged); !errors.Is(err, ErrDataMismatch) {
+		t.Errorf("forged blob: error = %v, want ErrDataMismatch", err)
+	}
+}
+
+func TestVerifyCommitment(t *testing.T) {
+	ns := testNS(t, testNamespace)
+	b := testBlob(t, ns, "prompt")
+	if err := VerifyCommitment(ns, b, b.Commitment); err != nil {
+		t.Fatal(err)
+	}
+	forged := testBlob(t, ns, "other")
+	forged.Commitment = b.Commitment
+	if err := VerifyCommitment(ns, forged, b.Commitment); !errors.Is(err, ErrCommitmentMismatch) {
+		t.Errorf("error = %v, want ErrCommitmentMismatch", err)
+	}
+}
+
+// forgingBlobAPI is a FakeBlobAPI serving other data than was submitted,
+// under the submitted commitment.
+type forgingBlobAPI struct {
+	*FakeBlobAPI
+}
+
+func (f forgingBlobAPI) Get(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (*blob.Blob, error) {
+	b, err := f.FakeBlobAPI.Get(ctx, height, ns, commitment)
+	if err != nil {
+		return nil, err
+	}
+	forged, err := blob.NewBlobV0(ns, append([]byte("forged "), b.Data...))
+	if err != nil {
+		return nil, err
+	}
+	forged.Commitment = b.Commitment
+	return forged, nil
+}
+
+func TestRunForgedBlob(t *testing.T) {
+	c, api, _ := newTestClient(testConfig())
+	c.Blobs = forgingBlobAPI{api}
+	c.Config.Raw = true
+	_, err := c.Run(context.Background(), "prompt")
+	if !errors.Is(err, ErrVerify) || !errors.Is(err, ErrDataMismatch) {
+		t.Fatalf("error = %v, want a verification error", err)
+	}
+}
+
+// rejectingBlobAPI is a FakeBlobAPI rejecting every inclusion proof.
+type rejectingBlobAPI struct {
+	*FakeBlobAPI
+}
+
+func (rejectingBlobAPI) Included(context.Context, uint64, share.Namespace, *blob.Proof, blob.Commitment) (bool, error) {
+	return false, nil
+}
+
+func TestVerifyInclusion(t *testing.T) {
+	ns := testNS(t, testNamespace)
+	api := &FakeBlobAPI{}
+	b := testBlob(t, ns, "prompt")
+	height, err := api.Submit(context.Background(), []*blob.Blob{b}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyInclusion(context.Background(), api, height, ns, b.Commitment); err != nil {
+		t.Fatalf("included blob: %v", err)
+	}
+	if err := VerifyInclusion(context.Background(), api, height+1, ns, b.Commitment); !IsBlobNotFound(err) {
+		t.Errorf("missing blob: error = %v, want blob not found", err)
+	}
+	if err := VerifyInclusion(context.Background(), rejectingBlobAPI{api}, height, ns, b.Commitment); !errors.Is(err, ErrNotIncluded) {
+		t.Errorf("rejected proof: error = %v, want ErrNotIncluded", err)
+	}
+}
+
+func TestRunVerifyProof(t *testing.T) {
+	cfg := testConfig()
+	cfg.VerifyProof = true
+	c, api, _ := newTestClient(cfg)
+	result, err := c.Run(context.Background(), "prompt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.ProofVerified {
+		t.Error("proof not reported as verified")
+	}
+
+	c.Blobs = rejectingBlobAPI{api}
+	if _, err := c.Run(context.Background(), "prompt"); !errors.Is(err, ErrVerify) || !errors.Is(err, ErrNotIncluded) {
+		t.Errorf("rejected proof: error = %v, want a verification error", err)
+	}
+}
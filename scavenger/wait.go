@@ -0,0 +1,53 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// waitPolicy is the backoff between polls for a submitted blob. Its
+// attempts are bounded by Config.Wait instead.
+var waitPolicy = RetryPolicy{BaseDelay: 500 * time.Millisecond, MaxDelay: 5 * time.Second}
+
+// IsBlobNotFound reports whether err is the node telling us there is no
+// such blob. The error arrives over RPC as a plain string, so we can't use
+// errors.Is.
+func IsBlobNotFound(err error) bool {
+	return strings.Contains(err.Error(), blob.ErrBlobNotFound.Error())
+}
+
+// waitForBlob polls the node until the blob with commitment at height can
+// be fetched, or the configured wait passes. Only not found errors are
+// retried, anything else is returned right away.
+func (c *Client) waitForBlob(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) error {
+	ctx, cancel := context.WithTimeout(ctx, c.Config.Wait)
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+		_, err := c.Blobs.Get(ctx, height, ns, commitment)
+		if err == nil {
+			if attempt > 1 {
+				c.logger().Info("Blob available", "height", height, "polls", attempt)
+			}
+			return nil
+		}
+		if !IsBlobNotFound(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("blob still not available after %d polls in %s: %w", attempt, c.Config.Wait, err)
+			}
+			return ctx.Err()
+		case <-time.After(waitPolicy.delay(attempt)):
+		}
+	}
+}
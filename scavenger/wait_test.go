@@ -0,0 +1,94 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+)
+
+// lateBlobAPI is a FakeBlobAPI whose blobs can't be fetched for the first
+// misses calls to Get, like a node that hasn't caught up yet.
+type lateBlobAPI struct {
+	*FakeBlobAPI
+
+	mu     sync.Mutex
+	misses int
+	gets   int
+}
+
+func (a *lateBlobAPI) Get(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (*blob.Blob, error) {
+	a.mu.Lock()
+	a.gets++
+	late := a.gets <= a.misses
+	a.mu.Unlock()
+	if late {
+		// Over RPC, the error is only a string.
+		return nil, errors.New(blob.ErrBlobNotFound.Error())
+	}
+	return a.FakeBlobAPI.Get(ctx, height, ns, commitment)
+}
+
+// fastWait makes waitForBlob poll without waiting until the test ends.
+func fastWait(t *testing.T) {
+	t.Helper()
+	old := waitPolicy
+	waitPolicy = RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	t.Cleanup(func() { waitPolicy = old })
+}
+
+func TestRunWaitsForBlob(t *testing.T) {
+	fastWait(t)
+	cfg := testConfig()
+	cfg.Wait = time.Minute
+	api := &lateBlobAPI{FakeBlobAPI: &FakeBlobAPI{}, misses: 3}
+	c := &Client{Config: cfg, Blobs: api, Completer: &fakeCompleter{}}
+	result, err := c.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FetchedPayload != "hi" {
+		t.Errorf("fetched %q, want the prompt", result.FetchedPayload)
+	}
+	if api.gets < 4 {
+		t.Errorf("polled %d times, want the misses and a hit", api.gets)
+	}
+}
+
+func TestWaitForBlobTimeout(t *testing.T) {
+	fastWait(t)
+	cfg := testConfig()
+	cfg.Wait = 20 * time.Millisecond
+	api := &lateBlobAPI{FakeBlobAPI: &FakeBlobAPI{}, misses: 1 << 30}
+	c := &Client{Config: cfg, Blobs: api}
+	err := c.waitForBlob(context.Background(), 1, testNS(t, testNamespace), []byte("commitment"))
+	if err == nil || !strings.Contains(err.Error(), "blob still not available after") || !IsBlobNotFound(err) {
+		t.Errorf("error = %v, want the blob still not found after the wait", err)
+	}
+}
+
+func TestWaitForBlobOtherError(t *testing.T) {
+	fastWait(t)
+	cfg := testConfig()
+	cfg.Wait = time.Minute
+	broken := errors.New("node is syncing")
+	c := &Client{Config: cfg, Blobs: &FakeBlobAPI{GetErr: broken}}
+	if err := c.waitForBlob(context.Background(), 1, testNS(t, testNamespace), []byte("commitment")); !errors.Is(err, broken) {
+		t.Errorf("error = %v, want the node's right away", err)
+	}
+}
+
+func TestIsBlobNotFound(t *testing.T) {
+	if !IsBlobNotFound(fmt.Errorf("rpc: %s", blob.ErrBlobNotFound.Error())) {
+		t.Error("wrapped not found error isn't recognized")
+	}
+	if IsBlobNotFound(errors.New("connection refused")) {
+		t.Error("other error is taken for not found")
+	}
+}
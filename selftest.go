@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// selftestCommand checks that the node can submit and fetch blobs, for
+// health checks. It fails if any stage does.
+func selftestCommand(ctx context.Context, args []string) error {
+	cfg, err := loadConfig(args, os.Getenv)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("selftest", os.Stderr, cfg, nodeFlags, submitFlags, []string{"blob-version", "verify-proof"})
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: prompt-scavenger selftest [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	setupLogging(cfg)
+
+	ctx, cancel := withTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	// Connecting is a stage of its own, so it is timed like the others.
+	start := time.Now()
+	client, err := connect(ctx, cfg)
+	connectStage := scavenger.SelfTestStage{Name: "connect", Duration: time.Since(start)}
+	report := &scavenger.SelfTestReport{}
+	if err != nil {
+		connectStage.Error = err.Error()
+		report.Stages = []scavenger.SelfTestStage{connectStage}
+	} else {
+		defer client.Close()
+		report = client.SelfTest(ctx)
+		report.Stages = append([]scavenger.SelfTestStage{connectStage}, report.Stages...)
+	}
+
+	if cfg.Output == scavenger.OutputJSON {
+		if err := writeJSON(os.Stdout, report); err != nil {
+			return err
+		}
+	} else {
+		for _, s := range report.Stages {
+			status := "PASS"
+			if s.Error != "" {
+				status = "FAIL"
+			}
+			fmt.Printf("%s %-8s %s", status, s.Name, s.Duration.Round(time.Millisecond))
+			if s.Error != "" {
+				fmt.Printf(" %s", s.Error)
+			}
+			fmt.Println()
+		}
+	}
+
+	if failed := report.Stages[len(report.Stages)-1]; failed.Error != "" {
+		return fmt.Errorf("self test failed at stage %s", failed.Name)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSelftestCommandConnectFailure(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	err := selftestCommand(context.Background(), []string{"-node", "ws://127.0.0.1:1", "-timeout", "5s"})
+	if err == nil || err.Error() != "self test failed at stage connect" {
+		t.Fatalf("error = %v, want the connect stage to fail", err)
+	}
+	if code := exitCode(context.Background(), err); code == 0 {
+		t.Error("failed self test exits with 0")
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// receipt is everything needed to retrieve a submitted blob again. Its
+// fields map onto the flags of the fetch subcommand.
+type receipt struct {
+	// Version is the format of the receipt, receiptVersion when written.
+	Version    int    `json:"version,omitempty"`
+	Namespace  string `json:"namespace"`
+	Height     uint64 `json:"height"`
+	Commitment string `json:"commitment"`
+	TxHash     string `json:"tx_hash,omitempty"`
+	Memo       string `json:"memo,omitempty"`
+	// MirrorHeight is the height on the mirror node, if mirrored.
+	MirrorHeight uint64 `json:"mirror_height,omitempty"`
+	// Commitments lists every chunk's commitment for chunked prompts, in
+	// order. Commitment is then the first chunk's.
+	Commitments []string `json:"commitments,omitempty"`
+	// Namespaces has a receipt for every namespace, this one first, when
+	// the prompt was submitted to several.
+	Namespaces []*receipt `json:"namespaces,omitempty"`
+
+	// The model and parameters configured at submission, which replay
+	// asks with.
+	Provider     string   `json:"provider,omitempty"`
+	Model        string   `json:"model,omitempty"`
+	SystemPrompt string   `json:"system_prompt,omitempty"`
+	Temperature  *float32 `json:"temperature,omitempty"`
+	MaxTokens    *int     `json:"max_tokens,omitempty"`
+	TopP         *float32 `json:"top_p,omitempty"`
+	Seed         *int     `json:"seed,omitempty"`
+}
+
+// receiptVersion is the version of the receipts written by submit. Older
+// receipts had no version, nor the model.
+const receiptVersion = 1
+
+// fetchArgs formats the receipt as arguments for the fetch subcommand.
+func (r *receipt) fetchArgs() string {
+	commitment := r.Commitment
+	if len(r.Commitments) > 0 {
+		commitment = strings.Join(r.Commitments, ",")
+	}
+	return fmt.Sprintf("-height %d -namespace %s -commitment %s", r.Height, r.Namespace, commitment)
+}
+
+// submitCommand only submits the prompt as a blob and prints a receipt for
+// fetching it later. The model isn't asked.
+func submitCommand(ctx context.Context, args []string) error {
+	var receiptFile string
+	opts, err := parseFlags("prompt-scavenger submit", args, os.Stdin, os.Stderr, os.Getenv, [][]string{
+		nodeFlags, namespaceFlags, payloadFlags, promptFlags, submitFlags, providerFlags, samplingFlags,
+	}, func(fs *flag.FlagSet) {
+		fs.StringVar(&receiptFile, "receipt-file", "", "also write the receipt to this file, as JSON")
+	})
+	if err != nil {
+		return err
+	}
+	if opts.promptsFile != "" {
+		return fmt.Errorf("flag -prompts-file is not supported by submit")
+	}
+	if opts.askOnly {
+		return fmt.Errorf("flag -ask-only is not supported by submit")
+	}
+	cfg := opts.config
+	setupLogging(cfg)
+	warnHighGasPrice(cfg.GasPrice)
+
+	ctx, cancel := withTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	client, err := connect(ctx, cfg)
+	if err != nil {
+		return scavenger.StageError("connect", err)
+	}
+	defer client.Close()
+
+	if opts.randomNamespace {
+		cfg.Namespace, err = scavenger.RandomNamespaceID()
+		if err != nil {
+			return err
+		}
+	}
+	if opts.dryRun {
+		return runDry(ctx, client, opts, os.Stdout)
+	}
+	namespaces, err := cfg.NamespaceIDs()
+	if err != nil {
+		return scavenger.StageError("namespace", fmt.Errorf("Failed to decode namespace: %w", err))
+	}
+
+	var subs []*scavenger.Submission
+	if opts.file != "" {
+		var sub *scavenger.Submission
+		sub, err = submitFile(ctx, client, namespaces[0], opts.file, opts.contentType)
+		subs = []*scavenger.Submission{sub}
+	} else {
+		subs, err = client.SubmitPromptTo(ctx, namespaces, opts.prompt)
+	}
+	if err != nil {
+		return scavenger.StageError("submit", err)
+	}
+	if err := client.WaitConfirmations(ctx, subs[0].Height); err != nil {
+		return scavenger.StageError("confirmations", err)
+	}
+
+	r := newReceipt(subs[0])
+	r.Version = receiptVersion
+	r.Memo = cfg.Memo
+	r.Provider, r.Model, r.SystemPrompt = cfg.Provider, cfg.Model, cfg.SystemPrompt
+	r.Temperature, r.MaxTokens, r.TopP, r.Seed = cfg.Temperature, cfg.MaxTokens, cfg.TopP, cfg.Seed
+	if len(subs) > 1 {
+		for _, sub := range subs {
+			r.Namespaces = append(r.Namespaces, newReceipt(sub))
+		}
+	}
+	if receiptFile != "" {
+		if err := writeReceipt(receiptFile, r); err != nil {
+			return err
+		}
+		slog.Info("Receipt written", "file", receiptFile)
+	}
+
+	if cfg.Output == scavenger.OutputJSON {
+		return writeJSON(os.Stdout, r)
+	}
+	if len(r.Namespaces) == 0 {
+		fmt.Println(r.fetchArgs())
+		return nil
+	}
+	for _, nr := range r.Namespaces {
+		fmt.Println(nr.fetchArgs())
+	}
+	return nil
+}
+
+// newReceipt returns the receipt for sub.
+func newReceipt(sub *scavenger.Submission) *receipt {
+	r := &receipt{
+		Namespace:  scavenger.NamespaceHex(sub.Namespace),
+		Height:     sub.Height,
+		Commitment: hex.EncodeToString(sub.Blobs[0].Commitment),
+		TxHash:     sub.TxHash,
+		// The mirror has the blobs under the same commitments.
+		MirrorHeight: sub.MirrorHeight,
+	}
+	if len(sub.Blobs) > 1 {
+		r.Commitments = scavenger.CommitmentsHex(sub.Blobs)
+	}
+	return r
+}
+
+// submitFile submits the file at path. Without a content type, it is
+// detected from the file's contents.
+func submitFile(ctx context.Context, client *scavenger.Client, ns share.Namespace, path, contentType string) (*scavenger.Submission, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return client.SubmitFile(ctx, ns, contentType, data)
+}
+
+// readReceipt reads a receipt written by writeReceipt from the file at
+// path. Receipts of an unknown version are rejected.
+func readReceipt(path string) (*receipt, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading receipt: %w", err)
+	}
+	var r receipt
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("error decoding receipt: %w", err)
+	}
+	// Receipts without a version are from before versions were recorded,
+	// and still hold where the blob is.
+	if r.Version != 0 && r.Version != receiptVersion {
+		return nil, fmt.Errorf("unknown receipt version %d, expected %d", r.Version, receiptVersion)
+	}
+	return &r, nil
+}
+
+// writeReceipt writes r to the file at path as JSON.
+func writeReceipt(path string, r *receipt) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding receipt: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("error writing receipt: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReceiptRoundTrip(t *testing.T) {
+	temperature := float32(0.5)
+	want := &receipt{
+		Version:     receiptVersion,
+		Namespace:   testNamespace,
+		Height:      42,
+		Commitment:  "abcd",
+		TxHash:      "ff00",
+		Model:       "gpt-4o",
+		Temperature: &temperature,
+	}
+	path := filepath.Join(t.TempDir(), "receipt.json")
+	if err := writeReceipt(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readReceipt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Height != want.Height || got.Commitment != want.Commitment || got.TxHash != want.TxHash || got.Model != want.Model {
+		t.Errorf("read %+v, want %+v", got, want)
+	}
+	if got.Temperature == nil || *got.Temperature != temperature {
+		t.Errorf("temperature = %v, want %v", got.Temperature, temperature)
+	}
+}
+
+func TestReadReceiptErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"not JSON", "height: 1", "error decoding receipt"},
+		{"unknown version", `{"version": 99, "height": 1}`, "unknown receipt version 99"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readReceipt(writeFile(t, "receipt.json", tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+	if _, err := readReceipt(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("reading a missing receipt succeeded")
+	}
+}
+
+func TestNewReceipt(t *testing.T) {
+	client, _, _ := newTestClient(t)
+	sub, err := client.SubmitPrompt(context.Background(), testNS(t), "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newReceipt(sub)
+	if r.Height != sub.Height || r.Commitment != hex.EncodeToString(sub.Blobs[0].Commitment) {
+		t.Errorf("receipt = %+v, want the submission's height and commitment", r)
+	}
+	if !strings.HasSuffix(r.Namespace, testNamespace) {
+		t.Errorf("namespace = %s, want %s", r.Namespace, testNamespace)
+	}
+	if len(r.Commitments) != 0 {
+		t.Errorf("commitments = %v, want none for a single blob", r.Commitments)
+	}
+	want := "-height 1 -namespace " + r.Namespace + " -commitment " + r.Commitment
+	if got := r.fetchArgs(); got != want {
+		t.Errorf("fetch args = %q, want %q", got, want)
+	}
+}
+
+func TestSubmitFile(t *testing.T) {
+	client, _, _ := newTestClient(t)
+	path := writeFile(t, "notes.txt", "plain text")
+	sub, err := submitFile(context.Background(), client, testNS(t), path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched, err := client.FetchPrompt(context.Background(), sub.Height, sub.Namespace, sub.Commitments())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fetched.Payload) != "plain text" || !strings.HasPrefix(fetched.ContentType, "text/plain") {
+		t.Errorf("fetched %q as %q, want the file with its detected content type", fetched.Payload, fetched.ContentType)
+	}
+	if _, err := submitFile(context.Background(), client, testNS(t), filepath.Join(t.TempDir(), "missing"), ""); err == nil {
+		t.Error("submitting a missing file succeeded")
+	}
+}
+
+func TestNewReceiptChunked(t *testing.T) {
+	client, _, _ := newTestClient(t)
+	client.Config.Raw = true
+	client.Config.ChunkSize = 4
+	sub, err := client.SubmitPrompt(context.Background(), testNS(t), "a prompt of several chunks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newReceipt(sub)
+	if len(r.Commitments) != len(sub.Blobs) || len(r.Commitments) < 2 {
+		t.Fatalf("commitments = %v, want one for each of the %d chunks", r.Commitments, len(sub.Blobs))
+	}
+	if r.Commitment != r.Commitments[0] {
+		t.Errorf("commitment = %s, want the first chunk's", r.Commitment)
+	}
+	if !strings.HasSuffix(r.fetchArgs(), "-commitment "+strings.Join(r.Commitments, ",")) {
+		t.Errorf("fetch args = %q, want every chunk's commitment", r.fetchArgs())
+	}
+}
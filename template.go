@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// renderTemplate renders the prompt template in path with vars. Variables
+// the template uses but vars lacks are an error, so typos don't silently
+// render as nothing.
+func renderTemplate(path string, vars map[string]string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading template file: %w", err)
+	}
+	tmpl, err := template.New(path).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+	var prompt strings.Builder
+	if err := tmpl.Execute(&prompt, vars); err != nil {
+		return "", fmt.Errorf("error rendering template: %w", err)
+	}
+	rendered := strings.TrimSuffix(prompt.String(), "\n")
+	if rendered == "" {
+		return "", fmt.Errorf("template rendered an empty prompt")
+	}
+	return rendered, nil
+}
+
+// varsFlag returns the setter of the -var flag, which can be repeated and
+// collects the template variables in vars.
+func varsFlag(vars map[string]string) func(string) error {
+	return func(s string) error {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("must be key=value")
+		}
+		vars[key] = value
+		return nil
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	path := writeFile(t, "prompt.tmpl", "Summarize {{.topic}} for {{.audience}}.\n")
+	got, err := renderTemplate(path, map[string]string{"topic": "blobs", "audience": "kids", "unused": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Summarize blobs for kids." {
+		t.Errorf("rendered %q, want the variables substituted without the final newline", got)
+	}
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name, template string
+		vars           map[string]string
+		want           string
+	}{
+		{"missing variable", "About {{.topic}}", map[string]string{"topik": "typo"}, "error rendering template"},
+		{"syntax", "About {{.topic", nil, "error parsing template"},
+		{"empty", "{{.nothing}}\n", map[string]string{"nothing": ""}, "empty prompt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := renderTemplate(writeFile(t, "prompt.tmpl", tt.template), tt.vars)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFlagsTemplate(t *testing.T) {
+	path := writeFile(t, "prompt.tmpl", "{{.greeting}}, {{.name}}!")
+	opts, err := parse(t, []string{"-namespace", testNamespace, "-template-file", path, "-var", "greeting=Hello", "-var", "name=a=b"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.prompt != "Hello, a=b!" {
+		t.Errorf("prompt = %q, want the rendered template", opts.prompt)
+	}
+
+	for _, args := range [][]string{
+		{"-namespace", testNamespace, "-template-file", path, "-var", "greeting=Hi"},
+		{"-namespace", testNamespace, "-template-file", path, "-var", "novalue"},
+		{"-namespace", testNamespace, "-template-file", path, "-var", "greeting=Hi", "-var", "name=x", "-prompt", "too"},
+		{"-namespace", testNamespace, "-var", "greeting=Hi", "-prompt", "hi"},
+	} {
+		if _, err := parse(t, args, nil, nil); err == nil {
+			t.Errorf("flags %q were accepted", args)
+		}
+	}
+}
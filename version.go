@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"text/tabwriter"
+)
+
+// Build information, injected with -ldflags, e.g.
+//
+//	go build -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse HEAD) -X main.date=$(date -u +%FT%TZ)"
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
+)
+
+// versionDeps are the dependencies whose versions are worth reporting.
+var versionDeps = []string{
+	"github.com/celestiaorg/celestia-openrpc",
+	"github.com/sashabaranov/go-openai",
+}
+
+// versionInfo is what the version subcommand prints.
+type versionInfo struct {
+	Version      string            `json:"version"`
+	Commit       string            `json:"commit,omitempty"`
+	Date         string            `json:"date,omitempty"`
+	GoVersion    string            `json:"go_version"`
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+}
+
+// buildVersionInfo collects the injected build information. Whatever
+// wasn't injected is filled in from the build info the Go toolchain
+// embeds, if there is any.
+func buildVersionInfo() versionInfo {
+	info := versionInfo{Version: version, Commit: commit, Date: date, GoVersion: runtime.Version()}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, s := range build.Settings {
+		switch {
+		case s.Key == "vcs.revision" && info.Commit == "":
+			info.Commit = s.Value
+		case s.Key == "vcs.time" && info.Date == "":
+			info.Date = s.Value
+		}
+	}
+	for _, dep := range build.Deps {
+		for _, path := range versionDeps {
+			if dep.Path == path {
+				if info.Dependencies == nil {
+					info.Dependencies = make(map[string]string)
+				}
+				info.Dependencies[path] = dep.Version
+			}
+		}
+	}
+	return info
+}
+
+// versionCommand prints the version of the program and its main
+// dependencies, for bug reports.
+func versionCommand(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	asJSON := fs.Bool("json", false, "print the version information as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	info := buildVersionInfo()
+	if *asJSON {
+		return writeJSON(os.Stdout, info)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "prompt-scavenger\t%s\n", info.Version)
+	if info.Commit != "" {
+		fmt.Fprintf(w, "commit\t%s\n", info.Commit)
+	}
+	if info.Date != "" {
+		fmt.Fprintf(w, "built\t%s\n", info.Date)
+	}
+	fmt.Fprintf(w, "go\t%s\n", info.GoVersion)
+	for _, path := range versionDeps {
+		if v, ok := info.Dependencies[path]; ok {
+			fmt.Fprintf(w, "%s\t%s\n", path, v)
+		}
+	}
+	return w.Flush()
+}
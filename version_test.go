@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what fn writes to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = old }()
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+	fn()
+	w.Close()
+	return <-out
+}
+
+// injectVersion sets the build information as -ldflags would, until the
+// test ends.
+func injectVersion(t *testing.T) {
+	t.Helper()
+	oldVersion, oldCommit, oldDate := version, commit, date
+	version, commit, date = "v1.2.0", "abc123", "2024-05-01T12:00:00Z"
+	t.Cleanup(func() { version, commit, date = oldVersion, oldCommit, oldDate })
+}
+
+func TestVersionCommand(t *testing.T) {
+	injectVersion(t)
+	var err error
+	out := captureStdout(t, func() { err = versionCommand(context.Background(), nil) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			got[fields[0]] = fields[1]
+		}
+	}
+	want := map[string]string{"prompt-scavenger": "v1.2.0", "commit": "abc123", "built": "2024-05-01T12:00:00Z"}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %q, want %q in output %q", key, got[key], value, out)
+		}
+	}
+	if !strings.HasPrefix(got["go"], "go") {
+		t.Errorf("go = %q, want the Go version", got["go"])
+	}
+}
+
+func TestVersionCommandJSON(t *testing.T) {
+	injectVersion(t)
+	var err error
+	out := captureStdout(t, func() { err = versionCommand(context.Background(), []string{"-json"}) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info versionInfo
+	if err := json.Unmarshal([]byte(out), &info); err != nil {
+		t.Fatalf("output %q isn't JSON: %v", out, err)
+	}
+	if info.Version != "v1.2.0" || info.Commit != "abc123" || info.Date != "2024-05-01T12:00:00Z" || info.GoVersion == "" {
+		t.Errorf("info = %+v, want the injected build information", info)
+	}
+}
+
+func TestVersionCommandArgs(t *testing.T) {
+	if err := versionCommand(context.Background(), []string{"extra"}); err == nil || !strings.Contains(err.Error(), "unexpected arguments: extra") {
+		t.Errorf("error = %v, want the unexpected argument reported", err)
+	}
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/header"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// watchCommand runs as a daemon, answering every prompt that is submitted
+// to the namespace until it is interrupted.
+func watchCommand(ctx context.Context, args []string) error {
+	cfg, err := loadConfig(args, os.Getenv)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("watch", os.Stderr, cfg, nodeFlags, namespaceFlags, providerFlags, samplingFlags, askFlags, []string{"verify-sig"})
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: prompt-scavenger watch -namespace <hex> [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	metricsAddr := fs.String("metrics-addr", "", "serve Prometheus metrics at /metrics on this address, e.g. :9090")
+	stateFile := fs.String("state-file", "", "record the last processed height in this file, and resume from it after a restart")
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if cfg.Namespace == "" {
+		return fmt.Errorf("missing required flag -namespace")
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	setupLogging(cfg)
+	warnUnknownModel(cfg)
+	warnOpenAIKeyFile(cfg)
+	warnSeed(cfg)
+
+	// We keep watching until we're interrupted, which cancels ctx.
+	client, err := connect(ctx, cfg)
+	if err != nil {
+		return scavenger.StageError("connect", err)
+	}
+	defer client.Close()
+
+	namespaceID, err := scavenger.CreateNamespaceID(cfg.Namespace, cfg.NamespaceVersion, cfg.PadNamespace)
+	if err != nil {
+		return scavenger.StageError("namespace", fmt.Errorf("Failed to decode namespace: %w", err))
+	}
+	client.Completer, err = scavenger.NewCompleter(cfg, client.StreamOutput)
+	if err != nil {
+		return err
+	}
+
+	// With metrics enabled, we count the calls to the node and the model.
+	if *metricsAddr != "" {
+		m := newMetrics()
+		if err := serveMetrics(ctx, *metricsAddr, m); err != nil {
+			return err
+		}
+		client.Blobs = m.instrumentBlobs(client.Blobs)
+		client.Completer = m.instrumentCompleter(client.Completer)
+	}
+
+	w := newWatcher(client.Blobs.GetAll, namespaceID, watchHandler(client, namespaceID, os.Stdout))
+	if *stateFile != "" {
+		w.statePath = *stateFile
+		w.last, err = loadWatchState(*stateFile)
+		if err != nil {
+			return err
+		}
+		if w.last > 0 {
+			slog.Info("Resuming after the last processed height", "height", w.last, "state_file", *stateFile)
+		}
+	}
+
+	slog.Info("Watching for new blobs", "namespace", scavenger.NamespaceHex(namespaceID))
+	for {
+		headers, err := client.CurrentNode().Header.Subscribe(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			// A lost connection is dialed again before subscribing anew.
+			reconnected, rerr := client.Reconnect(ctx, err)
+			if rerr != nil {
+				return fmt.Errorf("Failed to subscribe to headers: %w", errors.Join(err, rerr))
+			}
+			if reconnected {
+				continue
+			}
+			return fmt.Errorf("Failed to subscribe to headers: %w", err)
+		}
+		// Heights missed while we weren't subscribed are caught up on
+		// right away, rather than with the next header.
+		if w.last > 0 {
+			head, err := client.CurrentNode().Header.LocalHead(ctx)
+			if err != nil {
+				slog.Warn("Failed to get the head to catch up to, catching up with the next header", "error", err)
+			} else {
+				w.processUpTo(ctx, head.Height())
+			}
+		}
+		w.run(ctx, headers)
+
+		// The subscription ends either because we're shutting down or
+		// because the node closed it, in which case we subscribe again.
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopped watching")
+			return nil
+		case <-time.After(time.Second):
+			slog.Warn("Header subscription closed, resubscribing")
+		}
+	}
+}
+
+// watchHandler returns the handler answering the prompts of the blobs
+// watch finds in namespaceID with client, printing the answers to out.
+func watchHandler(client *scavenger.Client, namespaceID share.Namespace, out io.Writer) blobHandler {
+	cfg := client.Config
+	return func(ctx context.Context, height uint64, b *blob.Blob, parts map[int]bool) error {
+		// Chunks don't say which prompt they belong to, so we can't tell
+		// the chunks of several prompts at a height apart.
+		if scavenger.IsChunk(b.Data) {
+			slog.Info("Skipping chunk of a chunked prompt, fetch it by all its commitments", "height", height, "commitment", hex.EncodeToString(b.Commitment))
+			return nil
+		}
+		// Failed blobs are tried again, so blobs that can't ever be
+		// answered are skipped instead.
+		payload, err := scavenger.DecodePayload(cfg, b.Data)
+		if err != nil {
+			slog.Warn("Skipping undecodable blob", "height", height, "commitment", hex.EncodeToString(b.Commitment), "error", err)
+			return nil
+		}
+		if scavenger.IsFilePayload(payload) {
+			slog.Info("Skipping blob holding a file", "height", height, "commitment", hex.EncodeToString(b.Commitment))
+			return nil
+		}
+		prompts, isPack, err := scavenger.DecodePack(payload)
+		if err != nil {
+			slog.Warn("Skipping undecodable blob", "height", height, "commitment", hex.EncodeToString(b.Commitment), "error", err)
+			return nil
+		}
+		// Packs aren't signed, so we can only answer them without
+		// -verify-sig.
+		if isPack && cfg.VerifySignature {
+			slog.Warn("Skipping blob without a valid signature", "height", height, "commitment", hex.EncodeToString(b.Commitment), "error", scavenger.ErrUnsigned)
+			return nil
+		}
+		// The prompts of a pack answered before aren't asked again when
+		// the pack is retried for the others.
+		if isPack {
+			var errs []error
+			for i, prompt := range prompts {
+				if parts[i] {
+					continue
+				}
+				answer, _, err := client.Ask(ctx, scavenger.WrapPrompt(client.Config, prompt))
+				if err != nil {
+					errs = append(errs, fmt.Errorf("prompt %d: %w", i, err))
+					continue
+				}
+				slog.Info("Answered prompt of pack",
+					"height", height,
+					"namespace", scavenger.NamespaceHex(namespaceID),
+					"commitment", hex.EncodeToString(b.Commitment),
+					"prompt", i,
+					"model", cfg.Model)
+				printWatchAnswer(out, cfg, answer)
+				parts[i] = true
+			}
+			return errors.Join(errs...)
+		}
+		if cfg.VerifySignature {
+			signer, err := scavenger.VerifyPrompt(payload)
+			if err != nil {
+				slog.Warn("Skipping blob without a valid signature", "height", height, "commitment", hex.EncodeToString(b.Commitment), "error", err)
+				return nil
+			}
+			slog.Info("Prompt signature verified", "height", height, "signer", hex.EncodeToString(signer))
+		}
+		prompt, _, err := scavenger.DecodePrompt(payload)
+		if err != nil {
+			slog.Warn("Skipping undecodable blob", "height", height, "commitment", hex.EncodeToString(b.Commitment), "error", err)
+			return nil
+		}
+		answer, _, err := client.Ask(ctx, scavenger.WrapPrompt(client.Config, string(prompt)))
+		if err != nil {
+			return err
+		}
+		slog.Info("Answered blob",
+			"height", height,
+			"namespace", scavenger.NamespaceHex(namespaceID),
+			"commitment", hex.EncodeToString(b.Commitment),
+			"model", cfg.Model)
+		printWatchAnswer(out, cfg, answer)
+		return nil
+	}
+}
+
+// printWatchAnswer prints an answer to out on its own line. A streamed
+// answer has already been printed as it arrived, so only the line is
+// ended.
+func printWatchAnswer(out io.Writer, cfg *scavenger.Config, answer string) {
+	if cfg.Stream {
+		fmt.Fprintln(out)
+		return
+	}
+	fmt.Fprintln(out, answer)
+}
+
+// getAllFunc fetches all blobs in the given namespaces at a height, as
+// scavenger.BlobAPI.GetAll does.
+type getAllFunc func(context.Context, uint64, []share.Namespace) ([]*blob.Blob, error)
+
+// blobHandler processes a single blob found at height. A blob of several
+// parts, like a pack of prompts, may fail halfway: parts holds the indexes
+// of the parts that earlier attempts processed, and the handler adds to it
+// so that a retry skips them.
+type blobHandler func(ctx context.Context, height uint64, b *blob.Blob, parts map[int]bool) error
+
+// watcher hands every new blob in a namespace to a handler, once.
+type watcher struct {
+	getAll getAllFunc
+	ns     share.Namespace
+	handle blobHandler
+
+	// seen holds the status of the blobs tried so far by height and hex
+	// commitment, so that blobs aren't processed twice when heights are
+	// tried again. Heights up to last are never processed again, so they
+	// are dropped.
+	seen map[uint64]map[string]*blobStatus
+	// last is the height up to which every height was processed, zero
+	// before the first. Heights after it that a subscription skipped, as
+	// when the connection dropped, are caught up on. With statePath set,
+	// it is saved there after every height.
+	last      uint64
+	statePath string
+	// first is the first height processed, which is where processing
+	// starts again if it failed before last was set.
+	first uint64
+}
+
+// maxBlobAttempts is how many times a blob is handled before it is given
+// up on. Some failures, like a response the content filter withholds,
+// happen every time, and the heights after the blob's are only done with
+// once it is.
+const maxBlobAttempts = 3
+
+// blobStatus is how far a watcher got with a blob.
+type blobStatus struct {
+	// done is set once the blob was handled, or given up on.
+	done     bool
+	failures int
+	// parts are the parts of the blob handled, for the handler.
+	parts map[int]bool
+}
+
+// newWatcher creates a watcher for the blobs in ns.
+func newWatcher(getAll getAllFunc, ns share.Namespace, handle blobHandler) *watcher {
+	return &watcher{
+		getAll: getAll,
+		ns:     ns,
+		handle: handle,
+		seen:   make(map[uint64]map[string]*blobStatus),
+	}
+}
+
+// run processes the blobs at every height received from headers, until
+// the channel is closed or ctx is done.
+func (w *watcher) run(ctx context.Context, headers <-chan *header.ExtendedHeader) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case h, ok := <-headers:
+			if !ok {
+				return
+			}
+			w.processUpTo(ctx, h.Height())
+		}
+	}
+}
+
+// processUpTo processes every height after the last processed one up to
+// height, so none is missed between subscriptions. The last processed
+// height only advances over heights that were processed in full, so a
+// height that failed is tried again with the next one. Blobs already
+// handled are skipped then.
+func (w *watcher) processUpTo(ctx context.Context, height uint64) {
+	from := height
+	switch {
+	case w.last > 0:
+		from = w.last + 1
+	case w.first > 0:
+		from = w.first
+	default:
+		w.first = height
+	}
+	if height > from {
+		slog.Info("Catching up on missed heights", "from", from, "to", height-1)
+	}
+	advance := true
+	for h := from; h <= height && ctx.Err() == nil; h++ {
+		if !w.processHeight(ctx, h) {
+			advance = false
+		}
+		if advance {
+			w.last = h
+			delete(w.seen, h)
+			w.saveState()
+		}
+	}
+}
+
+// saveState saves the last processed height to statePath, if set.
+// Failing to is only logged, it just means catching up on more heights
+// after a restart.
+func (w *watcher) saveState() {
+	if w.statePath == "" {
+		return
+	}
+	if err := saveWatchState(w.statePath, w.last); err != nil {
+		slog.Warn("Failed to save watch state", "state_file", w.statePath, "error", err)
+	}
+}
+
+// processHeight handles all blobs in the namespace at height that haven't
+// been handled yet. Failures are logged rather than returned, so a single
+// bad blob doesn't stop the watcher, and the blob is tried again with the
+// height, up to maxBlobAttempts times in all. Once ctx is done, the blob
+// being handled is still finished, but no further ones are started. It
+// reports whether every blob at height was handled or given up on.
+func (w *watcher) processHeight(ctx context.Context, height uint64) bool {
+	blobs, err := w.getAll(ctx, height, []share.Namespace{w.ns})
+	if err != nil {
+		if scavenger.IsBlobNotFound(err) {
+			return true
+		}
+		if ctx.Err() == nil {
+			slog.Error("Failed to get blobs", "height", height, "namespace", scavenger.NamespaceHex(w.ns), "error", err)
+		}
+		return false
+	}
+
+	handled := true
+	for _, b := range blobs {
+		if ctx.Err() != nil {
+			return false
+		}
+		key := hex.EncodeToString(b.Commitment)
+		status := w.status(height, key)
+		if status.done {
+			continue
+		}
+		if err := w.handle(context.WithoutCancel(ctx), height, b, status.parts); err != nil {
+			status.failures++
+			if status.failures >= maxBlobAttempts {
+				slog.Warn("Failed to process blob, giving up on it", "height", height, "namespace", scavenger.NamespaceHex(w.ns), "commitment", key, "attempts", status.failures, "error", err)
+				status.done = true
+				continue
+			}
+			slog.Error("Failed to process blob, trying again with the next height", "height", height, "namespace", scavenger.NamespaceHex(w.ns), "commitment", key, "error", err)
+			handled = false
+			continue
+		}
+		status.done = true
+	}
+	return handled
+}
+
+// status returns the status of the blob with the hex commitment key at
+// height, creating it on the first try.
+func (w *watcher) status(height uint64, key string) *blobStatus {
+	if w.seen[height] == nil {
+		w.seen[height] = make(map[string]*blobStatus)
+	}
+	status := w.seen[height][key]
+	if status == nil {
+		status = &blobStatus{parts: make(map[int]bool)}
+		w.seen[height][key] = status
+	}
+	return status
+}
+
+// watchState is what the state file of watch holds.
+type watchState struct {
+	// Height is the last height whose blobs were all processed.
+	Height uint64 `json:"height"`
+}
+
+// loadWatchState returns the last processed height recorded in the state
+// file at path, zero if there is none yet.
+func loadWatchState(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error reading watch state: %w", err)
+	}
+	var state watchState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return 0, fmt.Errorf("error decoding watch state %s: %w", path, err)
+	}
+	return state.Height, nil
+}
+
+// saveWatchState records height as the last processed one in the state
+// file at path. The file is replaced in one step, so a crash leaves either
+// the old or the new height.
+func saveWatchState(path string, height uint64) error {
+	data, err := json.Marshal(watchState{Height: height})
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
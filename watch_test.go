@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/celestiaorg/celestia-openrpc/types/blob"
+	"github.com/celestiaorg/celestia-openrpc/types/core"
+	"github.com/celestiaorg/celestia-openrpc/types/header"
+	"github.com/celestiaorg/celestia-openrpc/types/share"
+
+	"github.com/distractedm1nd/prompt-scavenger/scavenger"
+)
+
+// submitBlobs submits a block with a blob in ns for each of payloads to
+// api, and returns its height.
+func submitBlobs(t *testing.T, api interface {
+	Submit(context.Context, []*blob.Blob, float64) (uint64, error)
+}, ns share.Namespace, payloads ...string) uint64 {
+	t.Helper()
+	blobs := make([]*blob.Blob, len(payloads))
+	for i, payload := range payloads {
+		b, err := blob.NewBlobV0(ns, []byte(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		blobs[i] = b
+	}
+	height, err := api.Submit(context.Background(), blobs, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return height
+}
+
+// headerAt returns a header at height.
+func headerAt(height uint64) *header.ExtendedHeader {
+	return &header.ExtendedHeader{Commit: &core.Commit{Height: int64(height)}}
+}
+
+// recordingHandler returns a blobHandler recording the payloads it gets.
+func recordingHandler(handled *[]string) blobHandler {
+	return func(_ context.Context, _ uint64, b *blob.Blob, _ map[int]bool) error {
+		*handled = append(*handled, string(b.Data))
+		return nil
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	_, api, _ := newTestClient(t)
+	ns := testNS(t)
+	submitBlobs(t, api, ns, "one")
+	submitBlobs(t, api, ns, "two", "three")
+	submitBlobs(t, api, ns, "four")
+
+	var handled []string
+	w := newWatcher(api.GetAll, ns, recordingHandler(&handled))
+	headers := make(chan *header.ExtendedHeader, 2)
+	// Height 2 is skipped by the subscription, and caught up on.
+	headers <- headerAt(1)
+	headers <- headerAt(3)
+	close(headers)
+	w.run(context.Background(), headers)
+
+	if want := []string{"one", "two", "three", "four"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled %q, want %q", handled, want)
+	}
+	if w.last != 3 {
+		t.Errorf("last processed height = %d, want 3", w.last)
+	}
+}
+
+func TestWatcherOtherNamespace(t *testing.T) {
+	_, api, _ := newTestClient(t)
+	other, err := share.NewBlobNamespaceV0([]byte("other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	height := submitBlobs(t, api, other, "not ours")
+
+	var handled []string
+	w := newWatcher(api.GetAll, testNS(t), recordingHandler(&handled))
+	w.processUpTo(context.Background(), height)
+	if len(handled) != 0 {
+		t.Errorf("handled %q of another namespace", handled)
+	}
+	// Heights without blobs are done with.
+	if w.last != height {
+		t.Errorf("last processed height = %d, want %d", w.last, height)
+	}
+}
+
+func TestWatcherRetriesFailedBlobs(t *testing.T) {
+	_, api, _ := newTestClient(t)
+	ns := testNS(t)
+	submitBlobs(t, api, ns, "ok", "flaky")
+	submitBlobs(t, api, ns, "next")
+
+	var handled []string
+	fail := true
+	w := newWatcher(api.GetAll, ns, func(ctx context.Context, height uint64, b *blob.Blob, _ map[int]bool) error {
+		if string(b.Data) == "flaky" && fail {
+			fail = false
+			return errors.New("model unavailable")
+		}
+		handled = append(handled, string(b.Data))
+		return nil
+	})
+	w.processUpTo(context.Background(), 1)
+	if w.last != 0 {
+		t.Fatalf("last processed height = %d after a failure, want 0", w.last)
+	}
+
+	// The failed blob is tried again with the next height, the handled
+	// one isn't.
+	w.processUpTo(context.Background(), 2)
+	if want := []string{"ok", "flaky", "next"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled %q, want %q", handled, want)
+	}
+	if w.last != 2 {
+		t.Errorf("last processed height = %d, want 2", w.last)
+	}
+	if len(w.seen) != 0 {
+		t.Errorf("seen still holds %d processed heights", len(w.seen))
+	}
+}
+
+func TestWatcherRetriesGetAll(t *testing.T) {
+	_, api, _ := newTestClient(t)
+	ns := testNS(t)
+	submitBlobs(t, api, ns, "one")
+
+	var handled []string
+	w := newWatcher(api.GetAll, ns, recordingHandler(&handled))
+	api.GetErr = errors.New("node unavailable")
+	w.processUpTo(context.Background(), 1)
+	api.GetErr = nil
+	w.processUpTo(context.Background(), 1)
+	if want := []string{"one"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled %q, want %q", handled, want)
+	}
+}
+
+func TestWatchHandler(t *testing.T) {
+	client, api, completer := newTestClient(t)
+	ns := testNS(t)
+	height := submitBlobs(t, api, ns, "what is 2+2?")
+
+	var out bytes.Buffer
+	w := newWatcher(api.GetAll, ns, watchHandler(client, ns, &out))
+	w.processUpTo(context.Background(), height)
+	if want := []string{"what is 2+2?"}; !reflect.DeepEqual(completer.asked(), want) {
+		t.Errorf("asked %q, want %q", completer.asked(), want)
+	}
+	if want := "answer to what is 2+2?\n"; out.String() != want {
+		t.Errorf("printed %q, want %q", out.String(), want)
+	}
+}
+
+func TestPrintWatchAnswerStreamed(t *testing.T) {
+	client, _, _ := newTestClient(t)
+	client.Config.Stream = true
+	var out bytes.Buffer
+	printWatchAnswer(&out, client.Config, "already streamed")
+	if out.String() != "\n" {
+		t.Errorf("printed %q, want only the end of the line", out.String())
+	}
+}
+
+func TestWatchState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	height, err := loadWatchState(path)
+	if err != nil || height != 0 {
+		t.Fatalf("missing state = %d, %v, want 0", height, err)
+	}
+
+	_, api, _ := newTestClient(t)
+	ns := testNS(t)
+	submitBlobs(t, api, ns, "one")
+	submitBlobs(t, api, ns, "two")
+	var handled []string
+	w := newWatcher(api.GetAll, ns, recordingHandler(&handled))
+	w.statePath = path
+	w.processUpTo(context.Background(), 1)
+	w.processUpTo(context.Background(), 2)
+	if height, err = loadWatchState(path); err != nil || height != 2 {
+		t.Fatalf("saved state = %d, %v, want 2", height, err)
+	}
+
+	// A watcher resuming from the state starts after it.
+	w = newWatcher(api.GetAll, ns, recordingHandler(&handled))
+	w.last = height
+	submitBlobs(t, api, ns, "three")
+	w.processUpTo(context.Background(), 3)
+	if want := []string{"one", "two", "three"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled %q, want %q", handled, want)
+	}
+}
+
+func TestWatcherResubscribe(t *testing.T) {
+	_, api, _ := newTestClient(t)
+	ns := testNS(t)
+	submitBlobs(t, api, ns, "one")
+
+	var handled []string
+	w := newWatcher(api.GetAll, ns, func(_ context.Context, height uint64, b *blob.Blob, _ map[int]bool) error {
+		handled = append(handled, fmt.Sprintf("%d:%s", height, b.Data))
+		return nil
+	})
+	headers := make(chan *header.ExtendedHeader, 1)
+	headers <- headerAt(1)
+	close(headers)
+	w.run(context.Background(), headers)
+
+	// While the connection is down, blocks keep coming. The next
+	// subscription starts at height 4, and delivers height 3 again.
+	submitBlobs(t, api, ns, "two")
+	submitBlobs(t, api, ns, "three")
+	submitBlobs(t, api, ns, "four")
+	headers = make(chan *header.ExtendedHeader, 2)
+	headers <- headerAt(4)
+	headers <- headerAt(3)
+	close(headers)
+	w.run(context.Background(), headers)
+
+	if want := []string{"1:one", "2:two", "3:three", "4:four"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled %q, want every height once, in order", handled)
+	}
+	if w.last != 4 {
+		t.Errorf("last processed height = %d, want 4", w.last)
+	}
+}
+
+func TestWatcherRetriesFirstHeight(t *testing.T) {
+	_, api, _ := newTestClient(t)
+	ns := testNS(t)
+	for _, payload := range []string{"old", "first", "second"} {
+		submitBlobs(t, api, ns, payload)
+	}
+
+	var handled []string
+	fail := true
+	w := newWatcher(api.GetAll, ns, func(_ context.Context, _ uint64, b *blob.Blob, _ map[int]bool) error {
+		if string(b.Data) == "first" && fail {
+			fail = false
+			return errors.New("model unavailable")
+		}
+		handled = append(handled, string(b.Data))
+		return nil
+	})
+	// Watching starts at height 2, before which nothing is processed.
+	w.processUpTo(context.Background(), 2)
+	w.processUpTo(context.Background(), 3)
+	if want := []string{"first", "second"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled %q, want %q", handled, want)
+	}
+	if w.last != 3 {
+		t.Errorf("last processed height = %d, want 3", w.last)
+	}
+}
+
+func TestWatchStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := saveWatchState(path, 42); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"height":42}` {
+		t.Errorf("state file holds %s, want the height as JSON", data)
+	}
+	if _, err := os.Stat(path + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temporary state file was left behind: %v", err)
+	}
+
+	corrupt := writeFile(t, "corrupt.json", "42")
+	if _, err := loadWatchState(corrupt); err == nil || !strings.Contains(err.Error(), "error decoding watch state") {
+		t.Errorf("error = %v, want a decoding error", err)
+	}
+}
+
+func TestWatcherSaveStateFails(t *testing.T) {
+	_, api, _ := newTestClient(t)
+	ns := testNS(t)
+	submitBlobs(t, api, ns, "one")
+
+	var handled []string
+	w := newWatcher(api.GetAll, ns, recordingHandler(&handled))
+	w.statePath = filepath.Join(t.TempDir(), "missing", "state.json")
+	logs := captureLogs(t)
+	w.processUpTo(context.Background(), 1)
+	if w.last != 1 || len(handled) != 1 {
+		t.Errorf("handled %q up to height %d, want the height processed anyway", handled, w.last)
+	}
+	if !strings.Contains(logs.String(), "Failed to save watch state") {
+		t.Errorf("logged %q, want the failure to save the state", logs.String())
+	}
+}
+
+func TestWatcherGivesUpOnBlob(t *testing.T) {
+	_, api, _ := newTestClient(t)
+	ns := testNS(t)
+	submitBlobs(t, api, ns, "filtered", "fine")
+
+	attempts := 0
+	var handled []string
+	w := newWatcher(api.GetAll, ns, func(_ context.Context, height uint64, b *blob.Blob, _ map[int]bool) error {
+		if string(b.Data) == "filtered" {
+			attempts++
+			return errors.New("model returned an empty response")
+		}
+		handled = append(handled, fmt.Sprintf("%d:%s", height, b.Data))
+		return nil
+	})
+	logs := captureLogs(t)
+	for height := uint64(1); height <= maxBlobAttempts+2; height++ {
+		if height > 1 {
+			submitBlobs(t, api, ns, fmt.Sprint("next ", height))
+		}
+		w.processUpTo(context.Background(), height)
+	}
+	if attempts != maxBlobAttempts {
+		t.Errorf("the failing blob was tried %d times, want %d", attempts, maxBlobAttempts)
+	}
+	if w.last != maxBlobAttempts+2 {
+		t.Errorf("last processed height = %d, want it past the blob given up on", w.last)
+	}
+	if len(handled) != maxBlobAttempts+2 || handled[0] != "1:fine" {
+		t.Errorf("handled %q, want every other blob once", handled)
+	}
+	if !strings.Contains(logs.String(), "giving up on it") {
+		t.Errorf("logged %q, want a warning about the blob given up on", logs.String())
+	}
+}
+
+// flakyCompleter is a fakeCompleter failing the first time it is asked
+// about fail.
+type flakyCompleter struct {
+	*fakeCompleter
+	fail   string
+	failed bool
+}
+
+func (f *flakyCompleter) Complete(ctx context.Context, messages []scavenger.Message) (string, scavenger.Usage, error) {
+	if messages[len(messages)-1].Content == f.fail && !f.failed {
+		f.failed = true
+		f.fakeCompleter.Complete(ctx, messages)
+		return "", scavenger.Usage{}, errors.New("rate limited")
+	}
+	return f.fakeCompleter.Complete(ctx, messages)
+}
+
+func TestWatchHandlerPackRetry(t *testing.T) {
+	client, _, completer := newTestClient(t)
+	client.Completer = &flakyCompleter{fakeCompleter: completer, fail: "two"}
+	ns := testNS(t)
+	sub, err := client.SubmitPack(context.Background(), ns, []string{"one", "two", "three"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	w := newWatcher(client.Blobs.GetAll, ns, watchHandler(client, ns, &out))
+	w.processUpTo(context.Background(), sub.Height)
+	if w.last != 0 {
+		t.Fatalf("last processed height = %d after a prompt failed, want 0", w.last)
+	}
+	w.processUpTo(context.Background(), sub.Height)
+	if want := []string{"one", "two", "three", "two"}; !reflect.DeepEqual(completer.asked(), want) {
+		t.Errorf("asked %q, want only the failed prompt asked again", completer.asked())
+	}
+	if want := "answer to one\nanswer to three\nanswer to two\n"; out.String() != want {
+		t.Errorf("printed %q, want every answer once", out.String())
+	}
+	if w.last != sub.Height {
+		t.Errorf("last processed height = %d, want %d", w.last, sub.Height)
+	}
+}